@@ -0,0 +1,57 @@
+package saga
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Resumer 能够从持久化实例继续执行的saga，*Saga[T] 实现该接口
+type Resumer interface {
+	Name() string
+	Resume(ctx context.Context, inst *Instance) error
+}
+
+// Recovery 继续执行进程崩溃或补偿失败后遗留的saga实例
+type Recovery struct {
+	repo  Repository
+	sagas map[string]Resumer
+}
+
+// NewRecovery 创建saga恢复器，sagas 为可以恢复的saga定义
+func NewRecovery(repo Repository, sagas ...Resumer) *Recovery {
+	r := &Recovery{repo: repo, sagas: make(map[string]Resumer, len(sagas))}
+	for _, s := range sagas {
+		r.sagas[s.Name()] = s
+	}
+	return r
+}
+
+// RecoverStale 继续执行更新时间早于 updatedBefore 仍未结束的实例，返回本轮结束的实例数
+// 只处理一段时间未更新的实例，避免与仍在执行中的请求并发处理同一实例；
+// 单个实例恢复失败只记录日志，下一轮会再次处理
+func (r *Recovery) RecoverStale(ctx context.Context, updatedBefore time.Time, limit int) (int, error) {
+	insts, err := r.repo.FindUnfinished(ctx, updatedBefore, limit)
+	if err != nil {
+		return 0, fmt.Errorf("查询未结束的saga实例失败: %w", err)
+	}
+
+	finished := 0
+	for _, inst := range insts {
+		s, ok := r.sagas[inst.Name]
+		if !ok {
+			slog.Warn("saga定义未注册，跳过恢复", "saga", inst.Name, "instance", inst.ID)
+			continue
+		}
+
+		// 恢复后正向执行失败时会补偿，实例以补偿完成结束
+		err := s.Resume(ctx, inst)
+		if inst.IsFinished() {
+			finished++
+			continue
+		}
+		slog.Error("恢复saga实例失败", "saga", inst.Name, "instance", inst.ID, "error", err)
+	}
+	return finished, ctx.Err()
+}
@@ -0,0 +1,14 @@
+package saga
+
+import (
+	"context"
+	"time"
+)
+
+// saga实例仓储接口
+type Repository interface {
+	Create(ctx context.Context, inst *Instance) error
+	Save(ctx context.Context, inst *Instance) error
+	// FindUnfinished 查询更新时间早于 updatedBefore 仍未结束的实例，按更新时间升序
+	FindUnfinished(ctx context.Context, updatedBefore time.Time, limit int) ([]*Instance, error)
+}
@@ -0,0 +1,185 @@
+package saga
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Status saga实例状态
+type Status string
+
+const (
+	StatusRunning      Status = "running"      // 正向执行中
+	StatusCompensating Status = "compensating" // 某个步骤失败，逆序补偿中
+	StatusCompleted    Status = "completed"    // 全部步骤执行成功
+	StatusCompensated  Status = "compensated"  // 已执行的步骤全部补偿完成
+)
+
+// TableName 指定模型对应的数据库表名
+func (Instance) TableName() string {
+	return "t_saga"
+}
+
+// Instance saga执行实例，每个步骤执行或补偿后持久化一次，进程崩溃后由 Recovery 从记录的位置继续
+type Instance struct {
+	ID        string    `json:"id" gorm:"column:id;primaryKey"`
+	Name      string    `json:"name" gorm:"column:name"`     // saga名称，恢复时据此找到步骤定义
+	BizID     string    `json:"biz_id" gorm:"column:biz_id"` // 业务ID，如订单ID，便于排查
+	Status    Status    `json:"status" gorm:"column:status"`
+	Step      int       `json:"step" gorm:"column:step"` // 已执行成功且尚未补偿的步骤数
+	Data      string    `json:"data" gorm:"column:data"` // 步骤间共享的业务数据(json)
+	LastError string    `json:"last_error" gorm:"column:last_error"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"column:updated_at"`
+}
+
+// IsFinished 实例是否已结束，结束的实例不再恢复
+func (i *Instance) IsFinished() bool {
+	return i.Status == StatusCompleted || i.Status == StatusCompensated
+}
+
+// Step saga步骤
+// Action 失败时视为没有产生效果，只补偿之前已成功的步骤；崩溃恢复时可能被重复执行，需要幂等
+// Compensate 撤销 Action 的效果，失败后会被重试，需要幂等；没有需要撤销的效果时为 nil
+type Step[T any] struct {
+	Name       string
+	Action     func(ctx context.Context, data *T) error
+	Compensate func(ctx context.Context, data *T) error
+}
+
+// Saga 由多个步骤组成的长事务，T 为步骤间共享的业务数据，需要能够json序列化
+type Saga[T any] struct {
+	name  string
+	steps []Step[T]
+	repo  Repository
+	now   func() time.Time
+}
+
+// New 创建saga定义，name 在所有saga中唯一
+func New[T any](repo Repository, name string, steps ...Step[T]) *Saga[T] {
+	return &Saga[T]{name: name, steps: steps, repo: repo, now: time.Now}
+}
+
+// Name saga名称
+func (s *Saga[T]) Name() string {
+	return s.name
+}
+
+// Run 创建saga实例并依次执行各步骤
+// 某个步骤失败时逆序补偿已成功的步骤，并返回该步骤的错误；补偿失败时实例保持补偿中，由 Recovery 重试
+// 执行进度持久化失败只记录日志，崩溃后从上次记录的位置重新执行
+func (s *Saga[T]) Run(ctx context.Context, bizID string, data *T) error {
+	now := s.now()
+	inst := &Instance{
+		ID:        uuid.New().String(),
+		Name:      s.name,
+		BizID:     bizID,
+		Status:    StatusRunning,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := encode(inst, data); err != nil {
+		return err
+	}
+	if err := s.repo.Create(ctx, inst); err != nil {
+		return fmt.Errorf("创建saga[%s]实例失败: %w", s.name, err)
+	}
+	return s.execute(ctx, inst, data)
+}
+
+// Resume 从持久化的位置继续执行未结束的实例，返回值与 Run 一致
+func (s *Saga[T]) Resume(ctx context.Context, inst *Instance) error {
+	if inst.Name != s.name {
+		return fmt.Errorf("saga实例[%s]属于[%s]，不能由[%s]恢复", inst.ID, inst.Name, s.name)
+	}
+	if inst.Step < 0 || inst.Step > len(s.steps) {
+		return fmt.Errorf("saga实例[%s]步骤位置[%d]无效", inst.ID, inst.Step)
+	}
+	data := new(T)
+	if err := json.Unmarshal([]byte(inst.Data), data); err != nil {
+		return fmt.Errorf("解析saga实例[%s]数据失败: %w", inst.ID, err)
+	}
+
+	switch inst.Status {
+	case StatusRunning:
+		return s.execute(ctx, inst, data)
+	case StatusCompensating:
+		return s.compensate(ctx, inst, data)
+	default:
+		return nil
+	}
+}
+
+// execute 从 inst.Step 开始正向执行剩余步骤
+func (s *Saga[T]) execute(ctx context.Context, inst *Instance, data *T) error {
+	for inst.Step < len(s.steps) {
+		step := s.steps[inst.Step]
+		if err := step.Action(ctx, data); err != nil {
+			inst.Status = StatusCompensating
+			inst.LastError = fmt.Sprintf("%s: %v", step.Name, err)
+			s.save(ctx, inst, data)
+			if compErr := s.compensate(ctx, inst, data); compErr != nil {
+				slog.Error("saga补偿失败，等待恢复任务重试", "saga", s.name, "instance", inst.ID, "error", compErr)
+			}
+			return err
+		}
+
+		inst.Step++
+		if inst.Step < len(s.steps) {
+			s.save(ctx, inst, data)
+		}
+	}
+
+	inst.Status = StatusCompleted
+	s.save(ctx, inst, data)
+	return nil
+}
+
+// compensate 从 inst.Step 开始逆序补偿已成功的步骤
+func (s *Saga[T]) compensate(ctx context.Context, inst *Instance, data *T) error {
+	for inst.Step > 0 {
+		step := s.steps[inst.Step-1]
+		if step.Compensate != nil {
+			if err := step.Compensate(ctx, data); err != nil {
+				inst.LastError = fmt.Sprintf("补偿%s: %v", step.Name, err)
+				s.save(ctx, inst, data)
+				return fmt.Errorf("补偿步骤[%s]失败: %w", step.Name, err)
+			}
+		}
+
+		inst.Step--
+		if inst.Step > 0 {
+			s.save(ctx, inst, data)
+		}
+	}
+
+	inst.Status = StatusCompensated
+	s.save(ctx, inst, data)
+	return nil
+}
+
+// save 持久化执行进度，步骤可能修改了共享数据，每次保存都重新序列化
+func (s *Saga[T]) save(ctx context.Context, inst *Instance, data *T) {
+	inst.UpdatedAt = s.now()
+	if err := encode(inst, data); err != nil {
+		slog.Error("saga序列化数据失败", "saga", s.name, "instance", inst.ID, "error", err)
+	}
+	if err := s.repo.Save(ctx, inst); err != nil {
+		slog.Error("saga保存进度失败", "saga", s.name, "instance", inst.ID, "error", err)
+	}
+}
+
+// encode 序列化共享数据到实例
+func encode[T any](inst *Instance, data *T) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("序列化saga[%s]数据失败: %w", inst.Name, err)
+	}
+	inst.Data = string(body)
+	return nil
+}
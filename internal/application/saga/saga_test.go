@@ -0,0 +1,234 @@
+package saga_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vaynedu/ddd_order_example/internal/application/saga"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+// testData 步骤间共享的测试数据
+type testData struct {
+	Done []string `json:"done"`
+}
+
+// testSteps 记录步骤和补偿的执行顺序，failAction/failCompensate 指定失败的步骤
+type testSteps struct {
+	calls          []string
+	failAction     map[string]error
+	failCompensate map[string]error
+}
+
+func (ts *testSteps) step(name string) saga.Step[testData] {
+	return saga.Step[testData]{
+		Name: name,
+		Action: func(ctx context.Context, data *testData) error {
+			ts.calls = append(ts.calls, name)
+			if err := ts.failAction[name]; err != nil {
+				return err
+			}
+			data.Done = append(data.Done, name)
+			return nil
+		},
+		Compensate: func(ctx context.Context, data *testData) error {
+			ts.calls = append(ts.calls, "undo_"+name)
+			return ts.failCompensate[name]
+		},
+	}
+}
+
+// recordingRepository 记录每次保存时实例的快照
+func recordingRepository(ctrl *gomock.Controller, saved *[]saga.Instance) *mocks.MockSagaRepository {
+	repo := mocks.NewMockSagaRepository(ctrl)
+	record := func(ctx context.Context, inst *saga.Instance) error {
+		*saved = append(*saved, *inst)
+		return nil
+	}
+	repo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(record).AnyTimes()
+	repo.EXPECT().Save(gomock.Any(), gomock.Any()).DoAndReturn(record).AnyTimes()
+	return repo
+}
+
+// TestSaga_Run_Completed 全部步骤成功，每个步骤完成后持久化进度和共享数据
+func TestSaga_Run_Completed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var saved []saga.Instance
+	ts := &testSteps{}
+	s := saga.New(recordingRepository(ctrl, &saved), "test", ts.step("a"), ts.step("b"), ts.step("c"))
+
+	data := &testData{}
+	assert.NoError(t, s.Run(context.Background(), "biz_1", data))
+
+	assert.Equal(t, []string{"a", "b", "c"}, ts.calls)
+	assert.Len(t, saved, 4)
+	assert.Equal(t, saga.StatusRunning, saved[0].Status)
+	assert.Equal(t, 0, saved[0].Step)
+	assert.Equal(t, 2, saved[2].Step)
+	assert.JSONEq(t, `{"done":["a","b"]}`, saved[2].Data)
+
+	last := saved[len(saved)-1]
+	assert.Equal(t, saga.StatusCompleted, last.Status)
+	assert.Equal(t, 3, last.Step)
+	assert.Equal(t, "biz_1", last.BizID)
+	assert.Equal(t, saved[0].ID, last.ID)
+}
+
+// TestSaga_Run_FailAtEachStep 任一步骤失败时逆序补偿之前成功的步骤，返回该步骤的错误
+func TestSaga_Run_FailAtEachStep(t *testing.T) {
+	errStep := errors.New("step failed")
+	tests := []struct {
+		fail  string
+		calls []string
+	}{
+		{"a", []string{"a"}},
+		{"b", []string{"a", "b", "undo_a"}},
+		{"c", []string{"a", "b", "c", "undo_b", "undo_a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fail, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			var saved []saga.Instance
+			ts := &testSteps{failAction: map[string]error{tt.fail: errStep}}
+			s := saga.New(recordingRepository(ctrl, &saved), "test", ts.step("a"), ts.step("b"), ts.step("c"))
+
+			err := s.Run(context.Background(), "biz_1", &testData{})
+
+			assert.ErrorIs(t, err, errStep)
+			assert.Equal(t, tt.calls, ts.calls)
+			last := saved[len(saved)-1]
+			assert.Equal(t, saga.StatusCompensated, last.Status)
+			assert.Equal(t, 0, last.Step)
+			assert.Equal(t, fmt.Sprintf("%s: %v", tt.fail, errStep), last.LastError)
+		})
+	}
+}
+
+// TestSaga_CompensationFailed 补偿失败时实例保持补偿中，恢复时从失败的补偿继续
+func TestSaga_CompensationFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var saved []saga.Instance
+	ts := &testSteps{
+		failAction:     map[string]error{"c": errors.New("step failed")},
+		failCompensate: map[string]error{"a": errors.New("undo failed")},
+	}
+	s := saga.New(recordingRepository(ctrl, &saved), "test", ts.step("a"), ts.step("b"), ts.step("c"))
+
+	assert.Error(t, s.Run(context.Background(), "biz_1", &testData{}))
+	assert.Equal(t, []string{"a", "b", "c", "undo_b", "undo_a"}, ts.calls)
+
+	inst := saved[len(saved)-1]
+	assert.Equal(t, saga.StatusCompensating, inst.Status)
+	assert.Equal(t, 1, inst.Step)
+	assert.Contains(t, inst.LastError, "undo failed")
+
+	// 补偿恢复正常后只重试未完成的补偿
+	ts.calls = nil
+	ts.failCompensate = nil
+	assert.NoError(t, s.Resume(context.Background(), &inst))
+	assert.Equal(t, []string{"undo_a"}, ts.calls)
+	assert.Equal(t, saga.StatusCompensated, inst.Status)
+	assert.Equal(t, 0, inst.Step)
+}
+
+// TestSaga_Resume_Running 崩溃时正在执行的实例从记录的步骤继续，共享数据从持久化内容恢复
+func TestSaga_Resume_Running(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var saved []saga.Instance
+	ts := &testSteps{}
+	s := saga.New(recordingRepository(ctrl, &saved), "test", ts.step("a"), ts.step("b"), ts.step("c"))
+
+	inst := &saga.Instance{ID: "saga_1", Name: "test", Status: saga.StatusRunning, Step: 1, Data: `{"done":["a"]}`}
+	assert.NoError(t, s.Resume(context.Background(), inst))
+
+	assert.Equal(t, []string{"b", "c"}, ts.calls)
+	assert.Equal(t, saga.StatusCompleted, inst.Status)
+	assert.JSONEq(t, `{"done":["a","b","c"]}`, inst.Data)
+}
+
+// TestSaga_Resume_Invalid 实例与saga定义不匹配时不执行任何步骤
+func TestSaga_Resume_Invalid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ts := &testSteps{}
+	s := saga.New(mocks.NewMockSagaRepository(ctrl), "test", ts.step("a"))
+
+	assert.Error(t, s.Resume(context.Background(), &saga.Instance{Name: "other", Status: saga.StatusRunning, Data: `{}`}))
+	assert.Error(t, s.Resume(context.Background(), &saga.Instance{Name: "test", Status: saga.StatusRunning, Step: 2, Data: `{}`}))
+	assert.Error(t, s.Resume(context.Background(), &saga.Instance{Name: "test", Status: saga.StatusRunning, Data: `{`}))
+	assert.Empty(t, ts.calls)
+}
+
+// TestSaga_Run_SaveProgressFailed 进度保存失败不中断执行，崩溃后从上次记录的位置重新执行
+func TestSaga_Run_SaveProgressFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockSagaRepository(ctrl)
+	repo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+	repo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(errors.New("db down")).Times(2)
+
+	ts := &testSteps{}
+	s := saga.New(repo, "test", ts.step("a"), ts.step("b"))
+
+	assert.NoError(t, s.Run(context.Background(), "biz_1", &testData{}))
+	assert.Equal(t, []string{"a", "b"}, ts.calls)
+}
+
+// TestSaga_Run_CreateFailed 实例创建失败时不执行任何步骤
+func TestSaga_Run_CreateFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockSagaRepository(ctrl)
+	repo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(errors.New("db down"))
+
+	ts := &testSteps{}
+	s := saga.New(repo, "test", ts.step("a"))
+
+	assert.Error(t, s.Run(context.Background(), "biz_1", &testData{}))
+	assert.Empty(t, ts.calls)
+}
+
+// TestRecovery_RecoverStale 按名称找到saga定义恢复实例，未注册的定义跳过
+func TestRecovery_RecoverStale(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockSagaRepository(ctrl)
+	repo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	ts := &testSteps{failCompensate: map[string]error{"b": errors.New("undo failed")}}
+	s := saga.New(repo, "test", ts.step("a"), ts.step("b"))
+	recovery := saga.NewRecovery(repo, s)
+
+	before := time.Now()
+	data, _ := json.Marshal(testData{Done: []string{"a"}})
+	repo.EXPECT().FindUnfinished(gomock.Any(), before, 10).Return([]*saga.Instance{
+		{ID: "saga_1", Name: "test", Status: saga.StatusRunning, Step: 1, Data: string(data)},
+		{ID: "saga_2", Name: "test", Status: saga.StatusCompensating, Step: 2, Data: string(data)},
+		{ID: "saga_3", Name: "unknown", Status: saga.StatusRunning, Data: `{}`},
+	}, nil)
+
+	finished, err := recovery.RecoverStale(context.Background(), before, 10)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, finished)
+	assert.Equal(t, []string{"b", "undo_b"}, ts.calls)
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_order_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_promotion_core"
+	"github.com/vaynedu/ddd_order_example/internal/shared/event"
+)
+
+// CouponReleaseHandler 订单取消后释放占用的优惠券，归还客户的使用次数
+// 事件可能重复投递，释放本身是幂等的
+type CouponReleaseHandler struct {
+	promotionService *domain_promotion_core.PromotionDomainService
+}
+
+func NewCouponReleaseHandler(promotionService *domain_promotion_core.PromotionDomainService) *CouponReleaseHandler {
+	return &CouponReleaseHandler{promotionService: promotionService}
+}
+
+// Handle 处理订单已取消事件，实现 event.Handler
+func (h *CouponReleaseHandler) Handle(ctx context.Context, evt event.Event) error {
+	cancelled, ok := evt.(*domain_order_core.OrderCancelledEvent)
+	if !ok {
+		return nil
+	}
+	if err := h.promotionService.Release(ctx, cancelled.OrderID); err != nil {
+		slog.Error("订单取消后释放优惠券失败", "order_id", cancelled.OrderID, "error", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_order_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_promotion_core"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+// TestCouponReleaseHandler_Handle 订单取消事件释放该订单占用的优惠券，其他事件忽略
+func TestCouponReleaseHandler_Handle(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUsageRepo := mocks.NewMockCouponUsageRepository(ctrl)
+	handler := NewCouponReleaseHandler(domain_promotion_core.NewPromotionDomainService(mocks.NewMockCouponRepository(ctrl), mockUsageRepo))
+
+	mockUsageRepo.EXPECT().ReleaseByOrderID(gomock.Any(), "order_1").Return(nil)
+
+	cancelled := &domain_order_core.OrderCancelledEvent{OrderEvent: domain_order_core.OrderEvent{OrderID: "order_1"}}
+	assert.NoError(t, handler.Handle(context.Background(), cancelled))
+
+	created := &domain_order_core.OrderCreatedEvent{OrderEvent: domain_order_core.OrderEvent{OrderID: "order_2"}}
+	assert.NoError(t, handler.Handle(context.Background(), created))
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_idempotency_core"
+)
+
+// IdempotentFunc 需要幂等保护的业务逻辑，返回业务单号
+// 业务需在保存业务数据的工作单元中调用 completeIdempotency，幂等结果与业务数据一起提交
+type IdempotentFunc func(ctx context.Context) (orderID string, err error)
+
+// ResponseFunc 根据业务单号生成需要回放的响应体
+type ResponseFunc func(orderID string) ([]byte, error)
+
+type IdempotencyService struct {
+	repo domain_idempotency_core.Repository
+}
+
+func NewIdempotencyService(repo domain_idempotency_core.Repository) *IdempotencyService {
+	return &IdempotencyService{repo: repo}
+}
+
+// idempotencyKey ctx 中保存当前请求幂等结果的键
+type idempotencyKey struct{}
+
+// idempotencyCompletion 当前请求的幂等结果，业务保存数据时写入
+type idempotencyCompletion struct {
+	complete func(ctx context.Context, orderID string) error
+	done     bool
+}
+
+// completeIdempotency 在保存业务数据的工作单元中记录幂等结果，租约已被接管时返回 ErrIdempotencyLeaseLost 使业务回滚
+// ctx 不在幂等保护下(如saga恢复任务)时不做任何事
+func completeIdempotency(ctx context.Context, orderID string) error {
+	c, ok := ctx.Value(idempotencyKey{}).(*idempotencyCompletion)
+	if !ok || c.done {
+		return nil
+	}
+	if err := c.complete(ctx, orderID); err != nil {
+		return err
+	}
+	c.done = true
+	return nil
+}
+
+// Execute 以幂等键保护业务逻辑的执行
+// 1. 首次请求：占用幂等键 -> 执行业务，业务在保存数据的事务中记录幂等结果，二者一起提交
+// 2. 重放请求：请求摘要一致则返回首次结果(replayed=true)，不一致返回 ErrIdempotencyKeyConflict
+// 3. 并发重复请求：只有一个能占用幂等键，其余返回 ErrIdempotencyKeyInProgress
+// 4. 占用者崩溃：处理中的记录超过租约后由相同请求接管，崩溃前未提交的业务数据已随事务回滚
+// 远程调用等耗时步骤不在事务中执行，事务只包含业务数据和幂等结果的写入
+func (s *IdempotencyService) Execute(ctx context.Context, key, requestHash string, fn IdempotentFunc, respond ResponseFunc) (record *domain_idempotency_core.IdempotencyRecordDO, replayed bool, err error) {
+	// 租约开始时间与数据库的毫秒精度一致，条件更新时才能准确比较
+	now := time.Now().Truncate(time.Millisecond)
+	record = &domain_idempotency_core.IdempotencyRecordDO{
+		Key:         key,
+		RequestHash: requestHash,
+		Status:      domain_idempotency_core.IdempotencyStatusProcessing,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if err := s.repo.Create(ctx, record); err != nil {
+		if !errors.Is(err, domain_idempotency_core.ErrIdempotencyKeyExists) {
+			return nil, false, fmt.Errorf("占用幂等键失败: %w", err)
+		}
+		if record, replayed, err = s.resume(ctx, key, requestHash, now); err != nil || replayed {
+			return record, replayed, err
+		}
+	}
+
+	leasedAt := record.UpdatedAt
+	completion := &idempotencyCompletion{
+		complete: func(ctx context.Context, orderID string) error {
+			response, err := respond(orderID)
+			if err != nil {
+				return err
+			}
+			record.Complete(orderID, response)
+			if err := s.repo.Complete(ctx, record, leasedAt); err != nil {
+				return fmt.Errorf("保存幂等结果失败: %w", err)
+			}
+			return nil
+		},
+	}
+	orderID, err := fn(context.WithValue(ctx, idempotencyKey{}, completion))
+	if err == nil && !completion.done {
+		// 业务没有在保存数据时记录结果，补记；此时业务数据已提交，失败不回滚业务
+		err = completion.complete(ctx, orderID)
+	}
+	if err != nil {
+		// 业务失败释放幂等键，允许客户端使用同一个键重试；已记录结果或租约已被接管时不会释放
+		if relErr := s.repo.Release(ctx, key, leasedAt); relErr != nil {
+			return nil, false, fmt.Errorf("%w (释放幂等键失败: %v)", err, relErr)
+		}
+		return nil, false, err
+	}
+	return record, false, nil
+}
+
+// resume 处理幂等键已存在的请求：已完成的返回首次结果，处理中且超过租约的由本次请求接管
+func (s *IdempotencyService) resume(ctx context.Context, key, requestHash string, now time.Time) (*domain_idempotency_core.IdempotencyRecordDO, bool, error) {
+	existing, err := s.repo.FindByKey(ctx, key)
+	if err != nil {
+		// 占用者业务失败刚释放了幂等键，让客户端重试即可
+		if errors.Is(err, domain_idempotency_core.ErrIdempotencyKeyNotFound) {
+			return nil, false, domain_idempotency_core.ErrIdempotencyKeyInProgress
+		}
+		return nil, false, fmt.Errorf("查询幂等记录失败: %w", err)
+	}
+
+	if !existing.MatchRequest(requestHash) {
+		return nil, false, domain_idempotency_core.ErrIdempotencyKeyConflict
+	}
+	if existing.IsCompleted() {
+		return existing, true, nil
+	}
+	if !existing.LeaseExpired(now) {
+		return nil, false, domain_idempotency_core.ErrIdempotencyKeyInProgress
+	}
+
+	if err := s.repo.TakeOver(ctx, key, now.Add(-domain_idempotency_core.ProcessingLease), now); err != nil {
+		return nil, false, err
+	}
+	existing.UpdatedAt = now
+	return existing, false, nil
+}
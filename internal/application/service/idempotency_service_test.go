@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_idempotency_core"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+// TestIdempotencyService_Execute_FirstRequest 首次请求执行业务并记录结果
+func TestIdempotencyService_Execute_FirstRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockIdempotencyRepository(ctrl)
+	service := NewIdempotencyService(mockRepo)
+
+	mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+	mockRepo.EXPECT().Complete(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, record *domain_idempotency_core.IdempotencyRecordDO, leasedAt time.Time) error {
+			// 幂等结果在业务保存数据的事务中记录
+			assert.True(t, inTestTx(ctx))
+			assert.Equal(t, domain_idempotency_core.IdempotencyStatusCompleted, record.Status)
+			return nil
+		})
+
+	uow := newTestUnitOfWork(ctrl)
+	calls := 0
+	record, replayed, err := service.Execute(context.Background(), "key_1", "hash_1", func(ctx context.Context) (string, error) {
+		calls++
+		// 远程调用等步骤不在事务中执行
+		assert.False(t, inTestTx(ctx))
+		return "order_1", uow.Do(ctx, func(ctx context.Context) error {
+			return completeIdempotency(ctx, "order_1")
+		})
+	}, testOrderResponse)
+
+	assert.NoError(t, err)
+	assert.False(t, replayed)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, "order_1", record.OrderID)
+	assert.Equal(t, `{"order_id":"order_1"}`, record.Response)
+}
+
+// TestIdempotencyService_Execute_CompleteAfterBusiness 业务未在事务中记录结果时，执行成功后补记
+func TestIdempotencyService_Execute_CompleteAfterBusiness(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockIdempotencyRepository(ctrl)
+	service := NewIdempotencyService(mockRepo)
+
+	mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+	mockRepo.EXPECT().Complete(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+	record, _, err := service.Execute(context.Background(), "key_1", "hash_1", func(ctx context.Context) (string, error) {
+		return "order_1", nil
+	}, testOrderResponse)
+
+	assert.NoError(t, err)
+	assert.Equal(t, `{"order_id":"order_1"}`, record.Response)
+}
+
+// testOrderResponse 测试用的创建订单响应体
+func testOrderResponse(orderID string) ([]byte, error) {
+	return []byte(`{"order_id":"` + orderID + `"}`), nil
+}
+
+// TestIdempotencyService_Execute_Replay 相同请求重放时返回首次结果，不再执行业务
+func TestIdempotencyService_Execute_Replay(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockIdempotencyRepository(ctrl)
+	service := NewIdempotencyService(mockRepo)
+
+	mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(domain_idempotency_core.ErrIdempotencyKeyExists)
+	mockRepo.EXPECT().FindByKey(gomock.Any(), "key_1").Return(&domain_idempotency_core.IdempotencyRecordDO{
+		Key:         "key_1",
+		RequestHash: "hash_1",
+		OrderID:     "order_1",
+		Response:    `{"order_id":"order_1"}`,
+		Status:      domain_idempotency_core.IdempotencyStatusCompleted,
+	}, nil)
+
+	record, replayed, err := service.Execute(context.Background(), "key_1", "hash_1", func(ctx context.Context) (string, error) {
+		t.Fatal("重放请求不应再次执行业务")
+		return "", nil
+	}, testOrderResponse)
+
+	assert.NoError(t, err)
+	assert.True(t, replayed)
+	assert.Equal(t, `{"order_id":"order_1"}`, record.Response)
+}
+
+// TestIdempotencyService_Execute_Conflict 同一个键携带不同请求体时拒绝
+func TestIdempotencyService_Execute_Conflict(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockIdempotencyRepository(ctrl)
+	service := NewIdempotencyService(mockRepo)
+
+	mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(domain_idempotency_core.ErrIdempotencyKeyExists)
+	mockRepo.EXPECT().FindByKey(gomock.Any(), "key_1").Return(&domain_idempotency_core.IdempotencyRecordDO{
+		Key:         "key_1",
+		RequestHash: "hash_1",
+		Status:      domain_idempotency_core.IdempotencyStatusCompleted,
+	}, nil)
+
+	_, _, err := service.Execute(context.Background(), "key_1", "hash_2", nil, nil)
+
+	assert.ErrorIs(t, err, domain_idempotency_core.ErrIdempotencyKeyConflict)
+}
+
+// TestIdempotencyService_Execute_InProgress 并发的重复请求在首个请求完成前返回处理中
+func TestIdempotencyService_Execute_InProgress(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockIdempotencyRepository(ctrl)
+	service := NewIdempotencyService(mockRepo)
+
+	mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(domain_idempotency_core.ErrIdempotencyKeyExists)
+	mockRepo.EXPECT().FindByKey(gomock.Any(), "key_1").Return(&domain_idempotency_core.IdempotencyRecordDO{
+		Key:         "key_1",
+		RequestHash: "hash_1",
+		Status:      domain_idempotency_core.IdempotencyStatusProcessing,
+		UpdatedAt:   time.Now(),
+	}, nil)
+
+	_, _, err := service.Execute(context.Background(), "key_1", "hash_1", nil, nil)
+
+	assert.ErrorIs(t, err, domain_idempotency_core.ErrIdempotencyKeyInProgress)
+}
+
+// TestIdempotencyService_Execute_TakeOver 占用者崩溃后超过租约的处理中记录由相同请求接管
+func TestIdempotencyService_Execute_TakeOver(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockIdempotencyRepository(ctrl)
+	service := NewIdempotencyService(mockRepo)
+
+	mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(domain_idempotency_core.ErrIdempotencyKeyExists)
+	mockRepo.EXPECT().FindByKey(gomock.Any(), "key_1").Return(&domain_idempotency_core.IdempotencyRecordDO{
+		Key:         "key_1",
+		RequestHash: "hash_1",
+		Status:      domain_idempotency_core.IdempotencyStatusProcessing,
+		UpdatedAt:   time.Now().Add(-2 * domain_idempotency_core.ProcessingLease),
+	}, nil)
+	var takenAt time.Time
+	mockRepo.EXPECT().TakeOver(gomock.Any(), "key_1", gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, key string, staleBefore, now time.Time) error {
+			takenAt = now
+			return nil
+		})
+	mockRepo.EXPECT().Complete(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, record *domain_idempotency_core.IdempotencyRecordDO, leasedAt time.Time) error {
+			// 以接管时写入的租约开始时间作为条件
+			assert.Equal(t, takenAt, leasedAt)
+			return nil
+		})
+
+	record, replayed, err := service.Execute(context.Background(), "key_1", "hash_1", func(ctx context.Context) (string, error) {
+		return "order_1", completeIdempotency(ctx, "order_1")
+	}, testOrderResponse)
+
+	assert.NoError(t, err)
+	assert.False(t, replayed)
+	assert.Equal(t, "order_1", record.OrderID)
+}
+
+// TestIdempotencyService_Execute_LeaseLost 执行期间租约被接管时回滚业务，不释放接管者的记录
+func TestIdempotencyService_Execute_LeaseLost(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockIdempotencyRepository(ctrl)
+	service := NewIdempotencyService(mockRepo)
+
+	mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+	mockRepo.EXPECT().Complete(gomock.Any(), gomock.Any(), gomock.Any()).Return(domain_idempotency_core.ErrIdempotencyLeaseLost)
+	mockRepo.EXPECT().Release(gomock.Any(), "key_1", gomock.Any()).Return(nil)
+
+	// 记录结果失败时业务事务回滚
+	_, _, err := service.Execute(context.Background(), "key_1", "hash_1", func(ctx context.Context) (string, error) {
+		return "", completeIdempotency(ctx, "order_1")
+	}, testOrderResponse)
+
+	assert.ErrorIs(t, err, domain_idempotency_core.ErrIdempotencyLeaseLost)
+}
+
+// TestIdempotencyService_Execute_BusinessFailed 业务失败时释放幂等键
+func TestIdempotencyService_Execute_BusinessFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockIdempotencyRepository(ctrl)
+	service := NewIdempotencyService(mockRepo)
+
+	bizErr := errors.New("product is invalid")
+	mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+	mockRepo.EXPECT().Release(gomock.Any(), "key_1", gomock.Any()).Return(nil)
+
+	_, _, err := service.Execute(context.Background(), "key_1", "hash_1", func(ctx context.Context) (string, error) {
+		return "", bizErr
+	}, testOrderResponse)
+
+	assert.ErrorIs(t, err, bizErr)
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_inventory_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_order_core"
+	"github.com/vaynedu/ddd_order_example/internal/shared/event"
+)
+
+// InventoryReservationHandler 根据订单状态变更处理库存预占：支付成功后确认，订单取消(含超时取消)后释放
+// 事件可能重复投递，库存服务的确认、释放本身是幂等的
+type InventoryReservationHandler struct {
+	orderDomainService domain_order_core.OrderDomainService // 依赖订单领域服务
+	inventoryService   domain_inventory_core.InventoryService
+}
+
+func NewInventoryReservationHandler(orderDomainService domain_order_core.OrderDomainService, inventoryService domain_inventory_core.InventoryService) *InventoryReservationHandler {
+	return &InventoryReservationHandler{
+		orderDomainService: orderDomainService,
+		inventoryService:   inventoryService,
+	}
+}
+
+// Handle 处理订单已支付、已取消事件，实现 event.Handler
+func (h *InventoryReservationHandler) Handle(ctx context.Context, evt event.Event) error {
+	var (
+		orderID string
+		apply   func(ctx context.Context, reservationID string) error
+		action  string
+	)
+	switch e := evt.(type) {
+	case *domain_order_core.OrderPaidEvent:
+		orderID, apply, action = e.OrderID, h.inventoryService.ConfirmReservation, "确认"
+	case *domain_order_core.OrderCancelledEvent:
+		orderID, apply, action = e.OrderID, h.inventoryService.ReleaseReservation, "释放"
+	default:
+		return nil
+	}
+
+	orderDO, err := h.orderDomainService.GetOrderByID(ctx, orderID)
+	if err != nil {
+		slog.Error("处理订单库存预占失败", "order_id", orderID, "action", action, "error", err)
+		return err
+	}
+
+	// 单个预占失败不影响其他预占
+	var errs []error
+	for _, id := range orderDO.ReservationIDs() {
+		if err := apply(ctx, id); err != nil {
+			slog.Error("处理订单库存预占失败", "order_id", orderID, "action", action, "reservation_id", id, "error", err)
+			errs = append(errs, fmt.Errorf("预占[%s]: %w", id, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_inventory_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_order_core"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func newReservedOrder() *domain_order_core.OrderDO {
+	return &domain_order_core.OrderDO{
+		ID: "order_1",
+		Items: []domain_order_core.OrderItemDO{
+			{ProductID: "prod_1", ReservationID: "rsv_1"},
+			{ProductID: "prod_2", ReservationID: "rsv_2"},
+			{ProductID: "prod_3"}, // 历史订单没有预占记录
+		},
+	}
+}
+
+// TestInventoryReservationHandler_Paid 订单支付成功后确认全部预占
+func TestInventoryReservationHandler_Paid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	mockInventory := mocks.NewMockInventoryService(ctrl)
+	handler := NewInventoryReservationHandler(domain_order_core.NewOrderDomainService(mockOrderRepo), mockInventory)
+
+	mockOrderRepo.EXPECT().FindByID(gomock.Any(), "order_1").Return(newReservedOrder(), nil)
+	mockInventory.EXPECT().ConfirmReservation(gomock.Any(), "rsv_1").Return(nil)
+	mockInventory.EXPECT().ConfirmReservation(gomock.Any(), "rsv_2").Return(nil)
+
+	paid := &domain_order_core.OrderPaidEvent{OrderEvent: domain_order_core.OrderEvent{OrderID: "order_1"}}
+	assert.NoError(t, handler.Handle(context.Background(), paid))
+}
+
+// TestInventoryReservationHandler_Cancelled 订单取消后释放预占，单个释放失败不影响其他预占
+func TestInventoryReservationHandler_Cancelled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	mockInventory := mocks.NewMockInventoryService(ctrl)
+	handler := NewInventoryReservationHandler(domain_order_core.NewOrderDomainService(mockOrderRepo), mockInventory)
+
+	mockOrderRepo.EXPECT().FindByID(gomock.Any(), "order_1").Return(newReservedOrder(), nil)
+	mockInventory.EXPECT().ReleaseReservation(gomock.Any(), "rsv_1").Return(domain_inventory_core.ErrReservationNotFound)
+	mockInventory.EXPECT().ReleaseReservation(gomock.Any(), "rsv_2").Return(nil)
+
+	cancelled := &domain_order_core.OrderCancelledEvent{OrderEvent: domain_order_core.OrderEvent{OrderID: "order_1"}}
+	err := handler.Handle(context.Background(), cancelled)
+
+	assert.ErrorIs(t, err, domain_inventory_core.ErrReservationNotFound)
+}
+
+// TestInventoryReservationHandler_IgnoreOtherEvents 其他订单事件不处理
+func TestInventoryReservationHandler_IgnoreOtherEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler := NewInventoryReservationHandler(domain_order_core.NewOrderDomainService(mocks.NewMockOrderRepository(ctrl)), mocks.NewMockInventoryService(ctrl))
+
+	created := &domain_order_core.OrderCreatedEvent{OrderEvent: domain_order_core.OrderEvent{OrderID: "order_1"}}
+	assert.NoError(t, handler.Handle(context.Background(), created))
+}
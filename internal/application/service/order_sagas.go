@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/vaynedu/ddd_order_example/internal/application/saga"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_inventory_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_order_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_promotion_core"
+	"github.com/vaynedu/ddd_order_example/pkg/dmoney"
+	"gorm.io/gorm"
+)
+
+const (
+	sagaNameCreateOrder = "create_order" // 下单：预占库存 -> 占用优惠券 -> 保存订单
+	sagaNamePayOrder    = "pay_order"    // 发起支付：创建支付单并向渠道下单 -> 支付单和订单置为待支付
+)
+
+// createOrderSagaData 下单saga的共享数据，订单已完成商品校验和优惠分摊
+type createOrderSagaData struct {
+	Order   *domain_order_core.OrderDO           `json:"order"`
+	Pricing *domain_promotion_core.PricingResult `json:"pricing"`
+}
+
+// payOrderSagaData 发起支付saga的共享数据
+type payOrderSagaData struct {
+	OrderID        string `json:"order_id"`
+	Amount         int64  `json:"amount"`   // 订单应付金额
+	Currency       string `json:"currency"` // 订单币种
+	PaymentID      string `json:"payment_id"`
+	PaymentCreated bool   `json:"payment_created"` // 支付单由本次saga创建或重新打开，失败时需要关闭
+}
+
+// newCreateOrderSaga 下单saga，保存订单是最后一步，此前任一步骤失败都会释放已占用的资源
+func (s *OrderService) newCreateOrderSaga(repo saga.Repository) *saga.Saga[createOrderSagaData] {
+	return saga.New(repo, sagaNameCreateOrder,
+		saga.Step[createOrderSagaData]{Name: "reserve_stock", Action: s.reserveStock, Compensate: s.releaseStock},
+		saga.Step[createOrderSagaData]{Name: "redeem_coupons", Action: s.redeemCoupons, Compensate: s.releaseCoupons},
+		saga.Step[createOrderSagaData]{Name: "save_order", Action: s.saveNewOrder},
+	)
+}
+
+// newPayOrderSaga 发起支付saga，支付单和订单状态保存失败时关闭本次创建的支付单
+func (s *OrderService) newPayOrderSaga(repo saga.Repository) *saga.Saga[payOrderSagaData] {
+	return saga.New(repo, sagaNamePayOrder,
+		saga.Step[payOrderSagaData]{Name: "create_payment", Action: s.preparePayment, Compensate: s.closePayment},
+		saga.Step[payOrderSagaData]{Name: "mark_pending", Action: s.markOrderPending},
+	)
+}
+
+// reserveStock 按订单行预占库存，预占ID记录在订单行上；同一订单重复预占返回原预占
+func (s *OrderService) reserveStock(ctx context.Context, data *createOrderSagaData) error {
+	order := data.Order
+	stockItems := make([]domain_inventory_core.StockItem, len(order.Items))
+	for i, item := range order.Items {
+		stockItems[i] = domain_inventory_core.StockItem{ProductID: item.ProductID, Quantity: item.Quantity}
+	}
+	reservations, err := s.inventoryService.ReserveStock(ctx, order.ID, stockItems)
+	if err != nil {
+		return fmt.Errorf("预占库存失败: %w", err)
+	}
+
+	reservationIDs := make([]string, len(reservations))
+	for i, reservation := range reservations {
+		reservationIDs[i] = reservation.ID
+	}
+	if err := order.AssignReservations(reservationIDs); err != nil {
+		// 步骤失败不会执行本步骤的补偿，在这里释放已预占的库存
+		return errors.Join(err, releaseReservations(ctx, s.inventoryService, reservationIDs))
+	}
+	return nil
+}
+
+// releaseStock 释放订单行上的库存预占
+func (s *OrderService) releaseStock(ctx context.Context, data *createOrderSagaData) error {
+	return releaseReservations(ctx, s.inventoryService, data.Order.ReservationIDs())
+}
+
+// redeemCoupons 占用优惠券使用次数，并发下单时由占用保证不超过使用次数；同一订单重复占用幂等
+func (s *OrderService) redeemCoupons(ctx context.Context, data *createOrderSagaData) error {
+	return s.promotionService.Redeem(ctx, data.Order.ID, data.Order.CustomerID, data.Pricing)
+}
+
+// releaseCoupons 释放订单占用的优惠券
+func (s *OrderService) releaseCoupons(ctx context.Context, data *createOrderSagaData) error {
+	if len(data.Pricing.Coupons) == 0 {
+		return nil
+	}
+	return s.promotionService.Release(ctx, data.Order.ID)
+}
+
+// saveNewOrder 保存订单，提交后分发订单创建事件
+// 幂等结果与订单在同一个事务内提交；事件在提交后分发，事务回滚时订阅者不会收到不存在的订单的事件
+func (s *OrderService) saveNewOrder(ctx context.Context, data *createOrderSagaData) error {
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		if err := s.orderDomainService.CreateOrder(ctx, data.Order); err != nil {
+			return err
+		}
+		return completeIdempotency(ctx, data.Order.ID)
+	})
+	if err != nil {
+		// 恢复执行时订单可能已在崩溃前保存成功
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			if _, findErr := s.orderDomainService.GetOrderByID(ctx, data.Order.ID); findErr == nil {
+				return nil
+			}
+		}
+		return err
+	}
+
+	dispatchOrderEvents(ctx, s.dispatcher, data.Order)
+	return nil
+}
+
+// preparePayment 复用订单未完成的支付单，不存在时创建新支付单
+// 订单仍可支付而支付单已失败或关闭(如渠道下单失败、上次saga补偿关闭)时重新打开支付单，否则订单将无法再支付
+func (s *OrderService) preparePayment(ctx context.Context, data *payOrderSagaData) error {
+	existingPayment, err := s.paymentService.GetPaymentByOrderID(ctx, data.OrderID)
+	if err != nil && !errors.Is(err, domain_payment_core.ErrPaymentNotFound) {
+		// 仅当支付单不存在时才继续创建，其他错误正常返回
+		return err
+	}
+
+	if existingPayment != nil {
+		switch {
+		case existingPayment.IsSucceeded():
+			return domain_payment_core.ErrPaymentPaid
+		case existingPayment.Status == domain_payment_core.PaymentStatusPending, existingPayment.Status == domain_payment_core.PaymentStatusCreated:
+			// 恢复执行时可能复用到本次saga崩溃前创建的支付单，此时不再关闭，由超时关单兜底
+			data.PaymentID = existingPayment.ID
+			return nil
+		default:
+			if err := s.paymentService.RetryPayment(ctx, existingPayment.ID); err != nil {
+				return fmt.Errorf("重新发起支付失败，支付单状态: %s: %w", domain_payment_core.GetPaymentStatusDetail(existingPayment.Status), err)
+			}
+			data.PaymentID = existingPayment.ID
+			data.PaymentCreated = true
+			return nil
+		}
+	}
+
+	amount := dmoney.New(data.Amount, dmoney.Currency(data.Currency))
+	paymentID, err := s.paymentService.InitiatePayment(ctx, data.OrderID, amount, int(domain_payment_core.PaymentChannelAlipay))
+	if err != nil {
+		return fmt.Errorf("创建支付单失败: %w", err)
+	}
+	data.PaymentID = paymentID
+	data.PaymentCreated = true
+	return nil
+}
+
+// closePayment 关闭本次创建或重新打开的支付单，订单再次发起支付时重新打开；支付单已支付时以支付结果为准，由支付通知和对账推进订单
+func (s *OrderService) closePayment(ctx context.Context, data *payOrderSagaData) error {
+	if !data.PaymentCreated {
+		return nil
+	}
+	if err := s.paymentService.ExpirePayment(ctx, data.PaymentID); err != nil && !errors.Is(err, domain_payment_core.ErrPaymentPaid) {
+		return err
+	}
+	return nil
+}
+
+// markOrderPending 在同一个事务中把支付单和订单置为待支付，提交后分发事件
+// 重新查询订单保证恢复执行时幂等
+func (s *OrderService) markOrderPending(ctx context.Context, data *payOrderSagaData) error {
+	var orderDO *domain_order_core.OrderDO
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		if orderDO, err = s.orderDomainService.GetOrderByID(ctx, data.OrderID); err != nil {
+			return err
+		}
+		if orderDO.Status == domain_order_core.OrderStatusPending {
+			return nil
+		}
+
+		// 渠道下单成功只代表支付已发起，支付结果以渠道异步通知为准
+		if err := s.paymentService.MarkPaymentPending(ctx, data.PaymentID); err != nil {
+			return fmt.Errorf("更新支付单为待支付失败: %w", err)
+		}
+		if err := orderDO.MarkAsPendingPayment(); err != nil {
+			return fmt.Errorf("更新订单为待支付状态失败: %w", err)
+		}
+		if err := s.orderDomainService.UpdateOrder(ctx, orderDO); err != nil {
+			return fmt.Errorf("保存订单状态失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	dispatchOrderEvents(ctx, s.dispatcher, orderDO)
+	return nil
+}
@@ -0,0 +1,368 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vaynedu/ddd_order_example/internal/application/saga"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_inventory_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_order_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_promotion_core"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/memory"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/mocks"
+	"github.com/vaynedu/ddd_order_example/internal/shared/event"
+	"github.com/vaynedu/ddd_order_example/pkg/dmoney"
+	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
+)
+
+// recordSagaInstances saga实例仓储，记录每次保存时实例的快照
+func recordSagaInstances(ctrl *gomock.Controller, saved *[]saga.Instance) *mocks.MockSagaRepository {
+	repo := mocks.NewMockSagaRepository(ctrl)
+	record := func(ctx context.Context, inst *saga.Instance) error {
+		*saved = append(*saved, *inst)
+		return nil
+	}
+	repo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(record).AnyTimes()
+	repo.EXPECT().Save(gomock.Any(), gomock.Any()).DoAndReturn(record).AnyTimes()
+	return repo
+}
+
+// TestOrderService_CreateOrderSaga_FailAtEachStep 下单saga任一步骤失败时逆序释放之前占用的资源
+func TestOrderService_CreateOrderSaga_FailAtEachStep(t *testing.T) {
+	errInjected := errors.New("injected failure")
+	tests := []struct {
+		step   string
+		expect func(inventory *mocks.MockInventoryService, usageRepo *mocks.MockCouponUsageRepository, orderRepo *mocks.MockOrderRepository)
+	}{
+		{
+			step: "reserve_stock",
+			expect: func(inventory *mocks.MockInventoryService, usageRepo *mocks.MockCouponUsageRepository, orderRepo *mocks.MockOrderRepository) {
+				inventory.EXPECT().ReserveStock(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errInjected)
+			},
+		},
+		{
+			step: "redeem_coupons",
+			expect: func(inventory *mocks.MockInventoryService, usageRepo *mocks.MockCouponUsageRepository, orderRepo *mocks.MockOrderRepository) {
+				reserve := inventory.EXPECT().ReserveStock(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(reserveAll)
+				usageRepo.EXPECT().Redeem(gomock.Any(), gomock.Any(), gomock.Any()).After(reserve).Return(errInjected)
+				inventory.EXPECT().ReleaseReservation(gomock.Any(), "rsv_prod_1").Return(nil)
+			},
+		},
+		{
+			step: "save_order",
+			expect: func(inventory *mocks.MockInventoryService, usageRepo *mocks.MockCouponUsageRepository, orderRepo *mocks.MockOrderRepository) {
+				inventory.EXPECT().ReserveStock(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(reserveAll)
+				usageRepo.EXPECT().Redeem(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+				orderRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(errInjected)
+				release := usageRepo.EXPECT().ReleaseByOrderID(gomock.Any(), gomock.Any()).Return(nil)
+				inventory.EXPECT().ReleaseReservation(gomock.Any(), "rsv_prod_1").After(release).Return(nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.step, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+			mockProductService := mocks.NewMockProductService(ctrl)
+			mockInventory := mocks.NewMockInventoryService(ctrl)
+			promotionService, mockUsageRepo := newCouponPromotionService(ctrl)
+			var saved []saga.Instance
+			service := NewOrderService(domain_order_core.NewOrderDomainService(mockOrderRepo), nil, mockProductService, promotionService, mockInventory, newTestUnitOfWork(ctrl), recordSagaInstances(ctrl, &saved), event.NewEventBus())
+
+			mockProductService.EXPECT().ValidateProducts(gomock.Any(), gomock.Any()).DoAndReturn(validProductResults)
+			tt.expect(mockInventory, mockUsageRepo, mockOrderRepo)
+
+			_, err := service.CreateOrder(context.Background(), "cust_1", []*domain_order_core.OrderItemDO{
+				{ProductID: "prod_1", Quantity: 4, UnitPrice: 3000},
+			}, []string{"SAVE10"})
+
+			assert.ErrorIs(t, err, errInjected)
+			last := saved[len(saved)-1]
+			assert.Equal(t, sagaNameCreateOrder, last.Name)
+			assert.Equal(t, saga.StatusCompensated, last.Status)
+			assert.True(t, strings.HasPrefix(last.LastError, tt.step+": "), last.LastError)
+		})
+	}
+}
+
+// TestOrderService_CreateOrderSaga_CompensationRetried 释放库存失败时saga保持补偿中，恢复任务重试未完成的补偿
+func TestOrderService_CreateOrderSaga_CompensationRetried(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	mockProductService := mocks.NewMockProductService(ctrl)
+	mockInventory := newTestInventoryService(ctrl)
+	promotionService, mockUsageRepo := newCouponPromotionService(ctrl)
+	var saved []saga.Instance
+	sagaRepo := recordSagaInstances(ctrl, &saved)
+	service := NewOrderService(domain_order_core.NewOrderDomainService(mockOrderRepo), nil, mockProductService, promotionService, mockInventory, newTestUnitOfWork(ctrl), sagaRepo, event.NewEventBus())
+
+	mockProductService.EXPECT().ValidateProducts(gomock.Any(), gomock.Any()).DoAndReturn(validProductResults)
+	mockUsageRepo.EXPECT().Redeem(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	mockOrderRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(gorm.ErrInvalidTransaction)
+	mockUsageRepo.EXPECT().ReleaseByOrderID(gomock.Any(), gomock.Any()).Return(nil)
+	mockInventory.EXPECT().ReleaseReservation(gomock.Any(), "rsv_prod_1").Return(domain_inventory_core.ErrReservationNotFound)
+
+	_, err := service.CreateOrder(context.Background(), "cust_1", []*domain_order_core.OrderItemDO{
+		{ProductID: "prod_1", Quantity: 4, UnitPrice: 3000},
+	}, []string{"SAVE10"})
+	assert.ErrorIs(t, err, gorm.ErrInvalidTransaction)
+
+	// 优惠券已释放，只剩库存预占待释放
+	inst := saved[len(saved)-1]
+	assert.Equal(t, saga.StatusCompensating, inst.Status)
+	assert.Equal(t, 1, inst.Step)
+
+	mockInventory.EXPECT().ReleaseReservation(gomock.Any(), "rsv_prod_1").Return(nil)
+	before := time.Now()
+	sagaRepo.EXPECT().FindUnfinished(gomock.Any(), before, 10).Return([]*saga.Instance{&inst}, nil)
+
+	finished, err := saga.NewRecovery(sagaRepo, service.Sagas()...).RecoverStale(context.Background(), before, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, finished)
+	assert.Equal(t, saga.StatusCompensated, inst.Status)
+}
+
+// newCreateOrderSagaInstance 崩溃时停留在指定步骤的下单saga实例
+func newCreateOrderSagaInstance(t *testing.T, step int) *saga.Instance {
+	order := &domain_order_core.OrderDO{ID: "order_1", CustomerID: "cust_1", Status: domain_order_core.OrderStatusCreated}
+	assert.NoError(t, order.AddItem("prod_1", 1, 100))
+	assert.NoError(t, order.AssignReservations([]string{"rsv_prod_1"}))
+	data, err := json.Marshal(&createOrderSagaData{
+		Order:   order,
+		Pricing: &domain_promotion_core.PricingResult{Currency: "CNY", LineDiscounts: []int64{0}},
+	})
+	assert.NoError(t, err)
+	return &saga.Instance{ID: "saga_1", Name: sagaNameCreateOrder, BizID: order.ID, Status: saga.StatusRunning, Step: step, Data: string(data)}
+}
+
+// TestOrderService_CreateOrderSaga_Resume 崩溃前已预占库存、占用优惠券，恢复后只保存订单并分发事件
+func TestOrderService_CreateOrderSaga_Resume(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	mockHandler := mocks.NewMockHandler(ctrl)
+	bus := event.NewEventBus()
+	bus.RegisterHandler(domain_order_core.EventNameOrderCreated, mockHandler)
+	service := NewOrderService(domain_order_core.NewOrderDomainService(mockOrderRepo), nil, nil, newTestPromotionService(), mocks.NewMockInventoryService(ctrl), newTestUnitOfWork(ctrl), newTestSagaRepository(ctrl), bus)
+
+	var saved *domain_order_core.OrderDO
+	mockOrderRepo.EXPECT().Save(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, order *domain_order_core.OrderDO) error {
+			saved = order
+			return nil
+		})
+	mockHandler.EXPECT().Handle(gomock.Any(), gomock.Any()).Return(nil)
+
+	inst := newCreateOrderSagaInstance(t, 2)
+	assert.NoError(t, service.createSaga.Resume(context.Background(), inst))
+
+	assert.Equal(t, saga.StatusCompleted, inst.Status)
+	assert.Equal(t, "order_1", saved.ID)
+	assert.Equal(t, []string{"rsv_prod_1"}, saved.ReservationIDs())
+}
+
+// TestOrderService_CreateOrderSaga_ResumeAlreadySaved 订单在崩溃前已保存，恢复时主键冲突视为保存成功，不释放资源
+func TestOrderService_CreateOrderSaga_ResumeAlreadySaved(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	service := NewOrderService(domain_order_core.NewOrderDomainService(mockOrderRepo), nil, nil, newTestPromotionService(), mocks.NewMockInventoryService(ctrl), newTestUnitOfWork(ctrl), newTestSagaRepository(ctrl), event.NewEventBus())
+
+	mockOrderRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(gorm.ErrDuplicatedKey)
+	mockOrderRepo.EXPECT().FindByID(gomock.Any(), "order_1").Return(&domain_order_core.OrderDO{ID: "order_1"}, nil)
+
+	inst := newCreateOrderSagaInstance(t, 2)
+	assert.NoError(t, service.createSaga.Resume(context.Background(), inst))
+	assert.Equal(t, saga.StatusCompleted, inst.Status)
+}
+
+// expectPayableOrder 每次查询都返回指定状态的新订单 order_1，金额1000分
+func expectPayableOrder(t *testing.T, orderRepo *mocks.MockOrderRepository, status domain_order_core.OrderStatus) {
+	orderRepo.EXPECT().FindByID(gomock.Any(), "order_1").DoAndReturn(
+		func(ctx context.Context, id string) (*domain_order_core.OrderDO, error) {
+			order := &domain_order_core.OrderDO{ID: id, CustomerID: "cust_1"}
+			assert.NoError(t, order.AddItem("prod_1", 2, 500))
+			order.Status = status
+			return order, nil
+		}).AnyTimes()
+}
+
+// TestOrderService_PayOrder_Success 创建支付单后，支付单和订单在同一个工作单元中置为待支付
+func TestOrderService_PayOrder_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	mockProxy := mocks.NewMockPaymentProxy(ctrl)
+	paymentRepo := memory.NewPaymentRepository()
+	mockPaymentRepo := mocks.NewMockRepository(ctrl)
+	mockPaymentRepo.EXPECT().FindByID(gomock.Any(), gomock.Any()).DoAndReturn(paymentRepo.FindByID).AnyTimes()
+	mockPaymentRepo.EXPECT().FindByOrderID(gomock.Any(), gomock.Any()).DoAndReturn(paymentRepo.FindByOrderID).AnyTimes()
+	mockPaymentRepo.EXPECT().Save(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, p *domain_payment_core.PaymentDO) error {
+			if p.Status == domain_payment_core.PaymentStatusPending {
+				assert.True(t, inTestTx(ctx))
+			}
+			return paymentRepo.Save(ctx, p)
+		}).AnyTimes()
+	var sagas []saga.Instance
+	paymentService := NewPaymentService(domain_payment_core.NewPaymentDomainService(mockPaymentRepo, nil), mockProxy)
+	service := NewOrderService(domain_order_core.NewOrderDomainService(mockOrderRepo), paymentService, nil, newTestPromotionService(), nil, newTestUnitOfWork(ctrl), recordSagaInstances(ctrl, &sagas), event.NewEventBus())
+
+	expectPayableOrder(t, mockOrderRepo, domain_order_core.OrderStatusCreated)
+	mockProxy.EXPECT().CreatePayment(gomock.Any(), "order_1", gomock.Any()).Return("txn_1", nil)
+	mockOrderRepo.EXPECT().Save(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, order *domain_order_core.OrderDO) error {
+			assert.Equal(t, domain_order_core.OrderStatusPending, order.Status)
+			assert.True(t, inTestTx(ctx))
+			return nil
+		})
+
+	assert.NoError(t, service.PayOrder(context.Background(), "order_1"))
+
+	payment, err := paymentRepo.FindByOrderID(context.Background(), "order_1")
+	assert.NoError(t, err)
+	assert.Equal(t, domain_payment_core.PaymentStatusPending, payment.Status)
+	assert.Equal(t, int64(1000), payment.Amount)
+	last := sagas[len(sagas)-1]
+	assert.Equal(t, sagaNamePayOrder, last.Name)
+	assert.Equal(t, saga.StatusCompleted, last.Status)
+}
+
+// TestOrderService_PayOrderSaga_FailAtEachStep 发起支付saga任一步骤失败时不留下待支付的孤立支付单，订单再次发起支付时重新打开支付单
+func TestOrderService_PayOrderSaga_FailAtEachStep(t *testing.T) {
+	errInjected := errors.New("injected failure")
+	tests := []struct {
+		step          string
+		expect        func(proxy *mocks.MockPaymentProxy, orderRepo *mocks.MockOrderRepository)
+		paymentStatus domain_payment_core.PaymentStatus
+	}{
+		{
+			// 渠道下单失败，支付单由支付服务标记为失败
+			step: "create_payment",
+			expect: func(proxy *mocks.MockPaymentProxy, orderRepo *mocks.MockOrderRepository) {
+				proxy.EXPECT().CreatePayment(gomock.Any(), "order_1", gomock.Any()).Return("", errInjected)
+			},
+			paymentStatus: domain_payment_core.PaymentStatusFailed,
+		},
+		{
+			// 订单保存失败，关闭本次创建的支付单
+			step: "mark_pending",
+			expect: func(proxy *mocks.MockPaymentProxy, orderRepo *mocks.MockOrderRepository) {
+				proxy.EXPECT().CreatePayment(gomock.Any(), "order_1", gomock.Any()).Return("txn_1", nil)
+				orderRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(errInjected)
+			},
+			paymentStatus: domain_payment_core.PaymentStatusExpired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.step, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+			mockProxy := mocks.NewMockPaymentProxy(ctrl)
+			paymentRepo := memory.NewPaymentRepository()
+			var sagas []saga.Instance
+			paymentService := NewPaymentService(domain_payment_core.NewPaymentDomainService(paymentRepo, nil), mockProxy)
+			service := NewOrderService(domain_order_core.NewOrderDomainService(mockOrderRepo), paymentService, nil, newTestPromotionService(), nil, newTestUnitOfWork(ctrl), recordSagaInstances(ctrl, &sagas), event.NewEventBus())
+			expectPayableOrder(t, mockOrderRepo, domain_order_core.OrderStatusCreated)
+			tt.expect(mockProxy, mockOrderRepo)
+
+			err := service.PayOrder(context.Background(), "order_1")
+
+			assert.ErrorIs(t, err, errInjected)
+			payment, findErr := paymentRepo.FindByOrderID(context.Background(), "order_1")
+			assert.NoError(t, findErr)
+			assert.Equal(t, tt.paymentStatus, payment.Status)
+			last := sagas[len(sagas)-1]
+			assert.Equal(t, saga.StatusCompensated, last.Status)
+			assert.True(t, strings.HasPrefix(last.LastError, tt.step+": "), last.LastError)
+
+			// 订单仍为已创建，再次发起支付时重新打开支付单并向渠道下单
+			mockProxy.EXPECT().CreatePayment(gomock.Any(), "order_1", dmoney.New(1000, dmoney.CNY)).Return("txn_2", nil)
+			mockOrderRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil)
+
+			assert.NoError(t, service.PayOrder(context.Background(), "order_1"))
+			payment, findErr = paymentRepo.FindByOrderID(context.Background(), "order_1")
+			assert.NoError(t, findErr)
+			assert.Equal(t, domain_payment_core.PaymentStatusPending, payment.Status)
+			assert.Equal(t, saga.StatusCompleted, sagas[len(sagas)-1].Status)
+		})
+	}
+}
+
+// TestOrderService_PayOrder_ReuseUnfinishedPayment 复用未完成的支付单，订单保存失败时不关闭非本次创建的支付单
+func TestOrderService_PayOrder_ReuseUnfinishedPayment(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	paymentRepo := memory.NewPaymentRepository()
+	paymentService := NewPaymentService(domain_payment_core.NewPaymentDomainService(paymentRepo, nil), mocks.NewMockPaymentProxy(ctrl))
+	service := NewOrderService(domain_order_core.NewOrderDomainService(mockOrderRepo), paymentService, nil, newTestPromotionService(), nil, newTestUnitOfWork(ctrl), newTestSagaRepository(ctrl), event.NewEventBus())
+
+	ctx := context.Background()
+	expectPayableOrder(t, mockOrderRepo, domain_order_core.OrderStatusCreated)
+	require.NoError(t, paymentRepo.Save(ctx, &domain_payment_core.PaymentDO{ID: "order_1", OrderID: "order_1", Amount: 1000, Status: domain_payment_core.PaymentStatusPending}))
+	mockOrderRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(gorm.ErrInvalidTransaction)
+
+	err := service.PayOrder(ctx, "order_1")
+
+	assert.ErrorIs(t, err, gorm.ErrInvalidTransaction)
+	payment, findErr := paymentRepo.FindByID(ctx, "order_1")
+	assert.NoError(t, findErr)
+	assert.Equal(t, domain_payment_core.PaymentStatusPending, payment.Status)
+}
+
+// TestOrderService_PayOrder_AlreadyPaid 支付单已支付时不再修改订单
+func TestOrderService_PayOrder_AlreadyPaid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	paymentRepo := memory.NewPaymentRepository()
+	paymentService := NewPaymentService(domain_payment_core.NewPaymentDomainService(paymentRepo, nil), mocks.NewMockPaymentProxy(ctrl))
+	service := NewOrderService(domain_order_core.NewOrderDomainService(mockOrderRepo), paymentService, nil, newTestPromotionService(), nil, newTestUnitOfWork(ctrl), newTestSagaRepository(ctrl), event.NewEventBus())
+
+	ctx := context.Background()
+	expectPayableOrder(t, mockOrderRepo, domain_order_core.OrderStatusCreated)
+	require.NoError(t, paymentRepo.Save(ctx, &domain_payment_core.PaymentDO{ID: "order_1", OrderID: "order_1", Amount: 1000, Status: domain_payment_core.PaymentStatusCompleted}))
+
+	err := service.PayOrder(ctx, "order_1")
+
+	assert.ErrorIs(t, err, domain_payment_core.ErrPaymentPaid)
+}
+
+// TestOrderService_PayOrderSaga_ResumeAlreadyPending 订单在崩溃前已置为待支付，恢复时直接完成
+func TestOrderService_PayOrderSaga_ResumeAlreadyPending(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	paymentService := NewPaymentService(domain_payment_core.NewPaymentDomainService(memory.NewPaymentRepository(), nil), mocks.NewMockPaymentProxy(ctrl))
+	service := NewOrderService(domain_order_core.NewOrderDomainService(mockOrderRepo), paymentService, nil, newTestPromotionService(), nil, newTestUnitOfWork(ctrl), newTestSagaRepository(ctrl), event.NewEventBus())
+	expectPayableOrder(t, mockOrderRepo, domain_order_core.OrderStatusPending)
+
+	data, _ := json.Marshal(&payOrderSagaData{OrderID: "order_1", Amount: 1000, Currency: "CNY", PaymentID: "order_1", PaymentCreated: true})
+	inst := &saga.Instance{ID: "saga_1", Name: sagaNamePayOrder, Status: saga.StatusRunning, Step: 1, Data: string(data)}
+
+	assert.NoError(t, service.paySaga.Resume(context.Background(), inst))
+	assert.Equal(t, saga.StatusCompleted, inst.Status)
+}
@@ -4,70 +4,205 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"math"
 
 	"github.com/google/uuid"
+	"github.com/vaynedu/ddd_order_example/internal/application/saga"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_inventory_core"
 	"github.com/vaynedu/ddd_order_example/internal/domain/domain_order_core"
-	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
 	"github.com/vaynedu/ddd_order_example/internal/domain/domain_product_core"
-	"gorm.io/gorm"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_promotion_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_uow_core"
+	"github.com/vaynedu/ddd_order_example/internal/shared/event"
+	"github.com/vaynedu/ddd_order_example/pkg/dmoney"
 )
 
 type OrderService struct {
-	productService     domain_product_core.ProductService   // 依赖商品领域接口
-	orderDomainService domain_order_core.OrderDomainService // 依赖订单领域服务
-	paymentService     *PaymentService                      // 注入依赖支付服务
+	productService     domain_product_core.ProductService            // 依赖商品领域接口
+	orderDomainService domain_order_core.OrderDomainService          // 依赖订单领域服务
+	paymentService     *PaymentService                               // 注入依赖支付服务
+	promotionService   *domain_promotion_core.PromotionDomainService // 依赖优惠领域服务
+	inventoryService   domain_inventory_core.InventoryService        // 依赖库存服务
+	uow                domain_uow_core.UnitOfWork                    // 跨仓储的事务
+	dispatcher         event.Dispatcher                              // 依赖领域事件分发
+
+	createSaga *saga.Saga[createOrderSagaData] // 下单saga
+	paySaga    *saga.Saga[payOrderSagaData]    // 发起支付saga
 }
 
-func NewOrderService(orderDomainService domain_order_core.OrderDomainService, paymentService *PaymentService, productService domain_product_core.ProductService) *OrderService {
-	return &OrderService{
+func NewOrderService(orderDomainService domain_order_core.OrderDomainService, paymentService *PaymentService, productService domain_product_core.ProductService, promotionService *domain_promotion_core.PromotionDomainService, inventoryService domain_inventory_core.InventoryService, uow domain_uow_core.UnitOfWork, sagaRepo saga.Repository, dispatcher event.Dispatcher) *OrderService {
+	s := &OrderService{
 		orderDomainService: orderDomainService,
 		paymentService:     paymentService,
 		productService:     productService,
+		promotionService:   promotionService,
+		inventoryService:   inventoryService,
+		uow:                uow,
+		dispatcher:         dispatcher,
 	}
+	s.createSaga = s.newCreateOrderSaga(sagaRepo)
+	s.paySaga = s.newPayOrderSaga(sagaRepo)
+	return s
+}
+
+// Sagas 订单服务编排的saga，注册到 saga.Recovery 后可恢复崩溃遗留的实例
+func (s *OrderService) Sagas() []saga.Resumer {
+	return []saga.Resumer{s.createSaga, s.paySaga}
 }
 
-func (s *OrderService) CreateOrder(ctx context.Context, customerID string, items []*domain_order_core.OrderItemDO) (string, error) {
-	// todo 考虑分布式锁、业务幂等， 防止重复创建单子
-	// todo 假装这里只有一个商品
-	// todo 这块逻辑要重新封装处理
-	// 验证商品状态, 并获取商品信息
-	req := &domain_product_core.ValidateProductRequest{
-		ProductID: items[0].ProductID,
-		Name:      "",
-		Price:     items[0].UnitPrice,
-		Quantity:  items[0].Quantity,
-	}
-	resp, err := s.productService.ValidateProduct(ctx, req)
+// CreateOrder 创建订单，支持多商品行
+// 每个商品都会经过商品服务校验，小计和总金额以校验后的商品单价在服务端重新计算
+// 所有商品行必须使用同一币种，订单以该币种计价，商品服务返回的价格币种与订单币种不一致时拒绝下单
+// couponCodes 为客户使用的优惠券券码，优惠金额按适用商品分摊到订单行
+// 订单保存前依次预占库存、占用优惠券，后续步骤失败时释放已预占的库存和优惠券，进程崩溃后由saga恢复任务继续
+func (s *OrderService) CreateOrder(ctx context.Context, customerID string, items []*domain_order_core.OrderItemDO, couponCodes []string) (string, error) {
+	// 业务幂等由接口层通过 Idempotency-Key 调用 IdempotencyService 保证， 防止重复创建单子
+	lines, currency, err := mergeOrderItems(items)
 	if err != nil {
 		return "", err
 	}
-	if resp.IsValid == false {
-		return "", errors.New(resp.Messages)
-	}
-	if resp.Product.Status != domain_product_core.StatusValid {
-		return "", errors.New("product is not available")
-	}
 
-	p := resp.Product
-
-	// 创建订单
 	newOrder := &domain_order_core.OrderDO{
 		ID:         uuid.New().String(),
 		CustomerID: customerID,
 		Status:     domain_order_core.OrderStatusCreated,
-		Items: []domain_order_core.OrderItemDO{
-			{
-				ProductID: p.ID,
-				Quantity:  items[0].Quantity,
-				UnitPrice: items[0].UnitPrice,
-				Subtotal:  items[0].Subtotal,
-			},
-		},
-		TotalAmount: items[0].Subtotal,
-	}
-
-	// 委托领域服务处理业务逻辑
-	return newOrder.ID, s.orderDomainService.CreateOrder(ctx, newOrder)
+		Currency:   string(currency),
+	}
+
+	// 批量验证商品状态, 并获取商品信息
+	reqs := make([]*domain_product_core.ValidateProductRequest, len(lines))
+	for i, line := range lines {
+		reqs[i] = &domain_product_core.ValidateProductRequest{
+			ProductID: line.ProductID,
+			Name:      "",
+			Price:     line.UnitPrice,
+			Currency:  string(currency),
+			Quantity:  line.Quantity,
+		}
+	}
+	results, err := s.productService.ValidateProducts(ctx, reqs)
+	if err != nil {
+		return "", fmt.Errorf("商品校验失败: %w", err)
+	}
+	if len(results) != len(lines) {
+		return "", fmt.Errorf("商品校验结果数量不匹配: 期望%d, 实际%d", len(lines), len(results))
+	}
+
+	for i, line := range lines {
+		result := results[i]
+		if result.Err != nil {
+			return "", fmt.Errorf("商品[%s]校验失败: %w", line.ProductID, result.Err)
+		}
+		resp := result.Response
+		if resp == nil {
+			return "", fmt.Errorf("商品[%s]校验结果为空", line.ProductID)
+		}
+		if !resp.IsValid {
+			return "", fmt.Errorf("商品[%s]校验失败: %s", line.ProductID, resp.Messages)
+		}
+		if resp.Product == nil || resp.Product.Status != domain_product_core.StatusValid {
+			return "", fmt.Errorf("商品[%s]不可售", line.ProductID)
+		}
+		if resp.Product.Currency != newOrder.Currency {
+			return "", fmt.Errorf("%w: 商品[%s]价格币种为%s，订单币种为%s", domain_order_core.ErrMixedCurrency, line.ProductID, resp.Product.Currency, newOrder.Currency)
+		}
+
+		// 以商品服务返回的单价为准，不信任客户端传入的小计
+		if err := newOrder.AddItem(line.ProductID, line.Quantity, resp.Product.Price); err != nil {
+			return "", err
+		}
+	}
+
+	// 计算优惠并分摊到订单行
+	pricing, err := s.promotionService.Price(ctx, customerID, currency, pricingLines(newOrder.Items), couponCodes)
+	if err != nil {
+		return "", fmt.Errorf("优惠计算失败: %w", err)
+	}
+	if err := newOrder.ApplyDiscounts(pricing.LineDiscounts, pricing.CouponIDs()); err != nil {
+		return "", err
+	}
+
+	// 预占库存、占用优惠券、保存订单由saga编排，失败时逆序释放已占用的资源
+	data := &createOrderSagaData{Order: newOrder, Pricing: pricing}
+	if err := s.createSaga.Run(ctx, newOrder.ID, data); err != nil {
+		return "", err
+	}
+	return newOrder.ID, nil
+}
+
+// releaseReservations 释放库存预占，释放幂等，单个预占释放失败不影响其他预占
+func releaseReservations(ctx context.Context, inventoryService domain_inventory_core.InventoryService, reservationIDs []string) error {
+	var errs []error
+	for _, id := range reservationIDs {
+		if err := inventoryService.ReleaseReservation(ctx, id); err != nil {
+			errs = append(errs, fmt.Errorf("释放库存预占[%s]失败: %w", id, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// pricingLines 把订单行转换为优惠计算的输入，顺序与订单行一致
+func pricingLines(items []domain_order_core.OrderItemDO) []domain_promotion_core.PricingLine {
+	lines := make([]domain_promotion_core.PricingLine, len(items))
+	for i, item := range items {
+		lines[i] = domain_promotion_core.PricingLine{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+			Subtotal:  item.Subtotal,
+		}
+	}
+	return lines
+}
+
+// mergeOrderItems 合并请求中重复的商品行，保持首次出现的顺序，并返回订单币种
+// 同一商品的订单行单价必须一致、所有订单行币种必须一致，否则视为非法请求
+func mergeOrderItems(items []*domain_order_core.OrderItemDO) ([]*domain_order_core.OrderItemDO, dmoney.Currency, error) {
+	if len(items) == 0 {
+		return nil, "", errors.New("订单商品不能为空")
+	}
+
+	var currency dmoney.Currency
+	merged := make([]*domain_order_core.OrderItemDO, 0, len(items))
+	index := make(map[string]*domain_order_core.OrderItemDO, len(items))
+	for _, item := range items {
+		if item == nil || item.ProductID == "" {
+			return nil, "", errors.New("商品ID不能为空")
+		}
+		if item.Quantity <= 0 {
+			return nil, "", fmt.Errorf("商品[%s]数量必须大于0", item.ProductID)
+		}
+		if currency == "" {
+			currency = item.ItemCurrency()
+		} else if item.ItemCurrency() != currency {
+			return nil, "", fmt.Errorf("%w: 商品[%s]币种为%s，其他商品为%s", domain_order_core.ErrMixedCurrency, item.ProductID, item.ItemCurrency(), currency)
+		}
+
+		if existing, ok := index[item.ProductID]; ok {
+			if existing.UnitPrice != item.UnitPrice {
+				return nil, "", fmt.Errorf("商品[%s]存在多个不同单价的订单行", item.ProductID)
+			}
+			if existing.Quantity > math.MaxInt64-item.Quantity {
+				return nil, "", fmt.Errorf("商品[%s]数量溢出", item.ProductID)
+			}
+			existing.Quantity += item.Quantity
+			continue
+		}
+
+		line := &domain_order_core.OrderItemDO{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			Currency:  string(currency),
+			UnitPrice: item.UnitPrice,
+		}
+		index[item.ProductID] = line
+		merged = append(merged, line)
+	}
+	if !currency.IsValid() {
+		return nil, "", fmt.Errorf("订单%w: %s", dmoney.ErrUnknownCurrency, currency)
+	}
+	return merged, currency, nil
 }
 
 // GetOrder 获取订单
@@ -75,6 +210,11 @@ func (s *OrderService) GetOrder(ctx context.Context, orderID string) (*domain_or
 	return s.orderDomainService.GetOrderByID(ctx, orderID)
 }
 
+// ListOrders 按条件分页查询订单
+func (s *OrderService) ListOrders(ctx context.Context, query domain_order_core.OrderQuery) (*domain_order_core.OrderPage, error) {
+	return s.orderDomainService.FindOrders(ctx, query)
+}
+
 // CancelOrder 取消订单
 func (s *OrderService) CancelOrder(ctx context.Context, orderID string) error {
 	order, err := s.orderDomainService.GetOrderByID(ctx, orderID)
@@ -88,7 +228,12 @@ func (s *OrderService) CancelOrder(ctx context.Context, orderID string) error {
 	}
 
 	// 持久化更新
-	return s.orderDomainService.UpdateOrder(ctx, order)
+	if err := s.orderDomainService.UpdateOrder(ctx, order); err != nil {
+		return err
+	}
+
+	dispatchOrderEvents(ctx, s.dispatcher, order)
+	return nil
 }
 
 // PayOrder 支付订单
@@ -99,69 +244,47 @@ func (s *OrderService) PayOrder(ctx context.Context, orderID string) error {
 		return err
 	}
 
-	// 2. 业务规则检查：订单必须是已创建的状态
-	if orderDO.Status != domain_order_core.OrderStatusCreated {
+	// 2. 业务规则检查：订单必须可以流转到待支付状态
+	if !orderDO.CanTransitionTo(domain_order_core.OrderStatusPending) {
 		return fmt.Errorf("订单状态异常，当前状态: %s,无法发起支付", domain_order_core.GetOrderStatusDetail(orderDO.Status))
 	}
 
-	// 3. 检查是否已存在支付单
-	existingPayment, err := s.paymentService.GetPaymentByOrderID(ctx, orderDO.ID)
-	if err != nil {
-		// 仅当支付单不存在时才返回错误，其他错误正常返回
-		if !errors.Is(err, domain_payment_core.ErrPaymentNotFound) {
-			return err
-		}
-	}
-
-	// 4. 处理支付单
-	var paymentID string
-	if existingPayment != nil {
-		switch existingPayment.Status {
-		case domain_payment_core.PaymentStatusPaid:
-			return errors.New("订单已支付，无需重复操作")
-		case domain_payment_core.PaymentStatusPending, domain_payment_core.PaymentStatusCreated:
-			paymentID = existingPayment.ID
-		default:
-			return fmt.Errorf("支付单状态异常: %s", domain_payment_core.GetPaymentStatusDetail(existingPayment.Status))
-		}
-	} else {
-		// 创建新支付单
-		newPaymentID, err := s.paymentService.CreatePayment(ctx, orderDO.ID, orderDO.TotalAmount, "CNY", 1)
-		if err != nil {
-			return fmt.Errorf("创建支付单失败: %w", err)
-		}
-		paymentID = newPaymentID
-	}
-
-	// 5. 更新订单状态为待支付
-	if err := orderDO.MarkAsPendingPayment(); err != nil {
-		return fmt.Errorf("更新订单为待支付状态失败: %w", err)
-	}
-
-	// 6. 持久化订单状态变更
-	if err := s.orderDomainService.UpdateOrder(ctx, orderDO); err != nil {
-		return fmt.Errorf("保存订单状态失败: %w", err)
+	// 3. 创建或复用支付单，再在同一个事务中把支付单和订单置为待支付，失败时关闭本次创建的支付单
+	data := &payOrderSagaData{
+		OrderID:  orderDO.ID,
+		Amount:   orderDO.Total().Amount(),
+		Currency: string(orderDO.OrderCurrency()),
 	}
-
-	// 7. 这里应该调用支付网关获取支付链接或发起支付处理
-	// 实际项目中这里会有支付网关的交互逻辑
-
-	if paymentID != "" {
-		// 这里占位， 打印下paymentID， 这里还是重新修改
-		fmt.Println("支付链接或支付处理信息:", paymentID)
+	if err := s.paySaga.Run(ctx, orderDO.ID, data); err != nil {
+		return err
 	}
 
+	slog.Debug("订单已发起支付", "order_id", orderDO.ID, "payment_id", data.PaymentID)
 	return nil
 }
 
 // UpdateOrder 更新订单
 func (s *OrderService) UpdateOrder(ctx context.Context, orderDO *domain_order_core.OrderDO) error {
 	if err := s.orderDomainService.UpdateOrder(ctx, orderDO); err != nil {
-		// 检查是否为乐观锁冲突错误 (处理乐观锁冲突（v2 特定写法）)
-		if errors.Is(err, gorm.ErrDuplicatedKey) {
-			return fmt.Errorf("订单已被其他操作更新，请刷新后重试: %w", err)
+		// 乐观锁冲突原样返回，由调用方决定重新读取后重试还是提示客户端刷新
+		if errors.Is(err, domain_order_core.ErrConcurrentModification) {
+			return err
 		}
 		return fmt.Errorf("更新订单失败: %w", err)
 	}
+
+	dispatchOrderEvents(ctx, s.dispatcher, orderDO)
 	return nil
 }
+
+// dispatchOrderEvents 订单保存成功后分发聚合记录的领域事件
+// 事件已随订单写入发件箱，这里分发失败由发件箱投递器补偿，不影响业务结果
+func dispatchOrderEvents(ctx context.Context, dispatcher event.Dispatcher, orderDO *domain_order_core.OrderDO) {
+	events := orderDO.PullEvents()
+	if len(events) == 0 {
+		return
+	}
+	if err := dispatcher.Dispatch(ctx, events...); err != nil {
+		slog.Error("分发订单领域事件失败，等待发件箱重试", "order_id", orderDO.ID, "error", err)
+	}
+}
@@ -2,13 +2,19 @@ package service
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_inventory_core"
 	"github.com/vaynedu/ddd_order_example/internal/domain/domain_order_core"
 	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
 	"github.com/vaynedu/ddd_order_example/internal/domain/domain_product_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_promotion_core"
 	"github.com/vaynedu/ddd_order_example/internal/infrastructure/mocks"
+	"github.com/vaynedu/ddd_order_example/internal/shared/event"
+	"github.com/vaynedu/ddd_order_example/pkg/dmoney"
 	"go.uber.org/mock/gomock"
 	"gorm.io/gorm"
 )
@@ -25,10 +31,10 @@ func TestOrderService_CreateOrder_Success(t *testing.T) {
 	mockPaymentProxy := mocks.NewMockPaymentProxy(ctrl)
 
 	// 初始化领域服务和应用服务
-	paymentDomainService := domain_payment_core.NewPaymentDomainService(mockPaymentRepo)
+	paymentDomainService := domain_payment_core.NewPaymentDomainService(mockPaymentRepo, nil)
 	mockPaymentService := NewPaymentService(paymentDomainService, mockPaymentProxy)
 	orderDomainService := domain_order_core.NewOrderDomainService(mockOrderRepo)
-	service := NewOrderService(orderDomainService, mockPaymentService, mockProductService)
+	service := NewOrderService(orderDomainService, mockPaymentService, mockProductService, newTestPromotionService(), newTestInventoryService(ctrl), newTestUnitOfWork(ctrl), newTestSagaRepository(ctrl), event.NewEventBus())
 
 	// 准备测试数据
 	ctx := context.Background()
@@ -43,18 +49,24 @@ func TestOrderService_CreateOrder_Success(t *testing.T) {
 	}
 
 	// 设置mock预期
-	mockProductService.EXPECT().ValidateProduct(gomock.Any(), gomock.Any()).Return(&domain_product_core.ValidateProductResponse{
-		IsValid: true,
-		Product: &domain_product_core.Product{
-			ID:     "prod_123",
-			Status: domain_product_core.StatusValid,
+	mockProductService.EXPECT().ValidateProducts(gomock.Any(), gomock.Len(1)).Return([]*domain_product_core.ValidateProductResult{
+		{
+			Response: &domain_product_core.ValidateProductResponse{
+				IsValid: true,
+				Product: &domain_product_core.Product{
+					ID:       "prod_123",
+					Price:    100,
+					Currency: "CNY",
+					Status:   domain_product_core.StatusValid,
+				},
+			},
 		},
 	}, nil)
 
 	mockOrderRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil)
 
 	// 执行测试
-	orderID, err := service.CreateOrder(ctx, customerID, items)
+	orderID, err := service.CreateOrder(ctx, customerID, items, nil)
 
 	// 验证结果
 	assert.NoError(t, err)
@@ -62,6 +74,177 @@ func TestOrderService_CreateOrder_Success(t *testing.T) {
 	t.Logf("orderID: %s", orderID)
 }
 
+// TestOrderService_CreateOrder_MultiItems 多商品下单，重复商品行合并，金额以商品服务单价为准
+func TestOrderService_CreateOrder_MultiItems(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	mockProductService := mocks.NewMockProductService(ctrl)
+	orderDomainService := domain_order_core.NewOrderDomainService(mockOrderRepo)
+	service := NewOrderService(orderDomainService, nil, mockProductService, newTestPromotionService(), newTestInventoryService(ctrl), newTestUnitOfWork(ctrl), newTestSagaRepository(ctrl), event.NewEventBus())
+
+	ctx := context.Background()
+	items := []*domain_order_core.OrderItemDO{
+		{ProductID: "prod_1", Quantity: 2, UnitPrice: 100, Subtotal: 1},
+		{ProductID: "prod_2", Quantity: 1, UnitPrice: 250, Subtotal: 1},
+		{ProductID: "prod_1", Quantity: 3, UnitPrice: 100, Subtotal: 1},
+	}
+
+	// 每个不同的商品只校验一次，且只发起一次批量调用
+	mockProductService.EXPECT().ValidateProducts(gomock.Any(), gomock.Len(2)).DoAndReturn(validProductResults)
+
+	var saved *domain_order_core.OrderDO
+	mockOrderRepo.EXPECT().Save(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, order *domain_order_core.OrderDO) error {
+			saved = order
+			return nil
+		})
+
+	orderID, err := service.CreateOrder(ctx, "cust_123", items, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, orderID, saved.ID)
+	assert.Len(t, saved.Items, 2)
+	assert.Equal(t, int64(5), saved.Items[0].Quantity)
+	assert.Equal(t, int64(500), saved.Items[0].Subtotal)
+	assert.Equal(t, int64(250), saved.Items[1].Subtotal)
+	assert.Equal(t, int64(750), saved.TotalAmount)
+}
+
+// TestOrderService_CreateOrder_ConflictingUnitPrice 同一商品多行单价不一致时拒绝下单
+func TestOrderService_CreateOrder_ConflictingUnitPrice(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProductService := mocks.NewMockProductService(ctrl)
+	service := NewOrderService(domain_order_core.OrderDomainService{}, nil, mockProductService, newTestPromotionService(), newTestInventoryService(ctrl), newTestUnitOfWork(ctrl), newTestSagaRepository(ctrl), event.NewEventBus())
+
+	items := []*domain_order_core.OrderItemDO{
+		{ProductID: "prod_1", Quantity: 1, UnitPrice: 100},
+		{ProductID: "prod_1", Quantity: 1, UnitPrice: 90},
+	}
+
+	_, err := service.CreateOrder(context.Background(), "cust_123", items, nil)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "不同单价")
+}
+
+// TestOrderService_CreateOrder_MixedCurrency 商品行币种不一致时拒绝下单，不调用商品服务
+func TestOrderService_CreateOrder_MixedCurrency(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProductService := mocks.NewMockProductService(ctrl)
+	service := NewOrderService(domain_order_core.OrderDomainService{}, nil, mockProductService, newTestPromotionService(), newTestInventoryService(ctrl), newTestUnitOfWork(ctrl), newTestSagaRepository(ctrl), event.NewEventBus())
+
+	items := []*domain_order_core.OrderItemDO{
+		{ProductID: "prod_1", Quantity: 1, Currency: "USD", UnitPrice: 100},
+		{ProductID: "prod_2", Quantity: 1, Currency: "CNY", UnitPrice: 100},
+	}
+
+	_, err := service.CreateOrder(context.Background(), "cust_123", items, nil)
+
+	assert.True(t, errors.Is(err, domain_order_core.ErrMixedCurrency))
+}
+
+// TestOrderService_CreateOrder_Currency 订单以商品行的币种计价
+func TestOrderService_CreateOrder_Currency(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	mockProductService := mocks.NewMockProductService(ctrl)
+	service := NewOrderService(domain_order_core.NewOrderDomainService(mockOrderRepo), nil, mockProductService, newTestPromotionService(), newTestInventoryService(ctrl), newTestUnitOfWork(ctrl), newTestSagaRepository(ctrl), event.NewEventBus())
+
+	items := []*domain_order_core.OrderItemDO{
+		{ProductID: "prod_1", Quantity: 2, Currency: "USD", UnitPrice: 1999},
+	}
+
+	mockProductService.EXPECT().ValidateProducts(gomock.Any(), gomock.Len(1)).DoAndReturn(validProductResults)
+	var saved *domain_order_core.OrderDO
+	mockOrderRepo.EXPECT().Save(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, order *domain_order_core.OrderDO) error {
+			saved = order
+			return nil
+		})
+
+	_, err := service.CreateOrder(context.Background(), "cust_123", items, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "USD", saved.Currency)
+	assert.Equal(t, "USD", saved.Items[0].Currency)
+	assert.Equal(t, dmoney.New(3998, dmoney.USD), saved.Total())
+}
+
+// TestOrderService_CreateOrder_ProductCurrencyMismatch 商品价格币种与订单币种不一致时拒绝下单
+func TestOrderService_CreateOrder_ProductCurrencyMismatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProductService := mocks.NewMockProductService(ctrl)
+	service := NewOrderService(domain_order_core.OrderDomainService{}, nil, mockProductService, newTestPromotionService(), newTestInventoryService(ctrl), newTestUnitOfWork(ctrl), newTestSagaRepository(ctrl), event.NewEventBus())
+
+	items := []*domain_order_core.OrderItemDO{
+		{ProductID: "prod_1", Quantity: 2, Currency: "USD", UnitPrice: 1999},
+	}
+
+	// 商品服务按人民币分返回价格
+	mockProductService.EXPECT().ValidateProducts(gomock.Any(), gomock.Len(1)).DoAndReturn(
+		func(ctx context.Context, reqs []*domain_product_core.ValidateProductRequest) ([]*domain_product_core.ValidateProductResult, error) {
+			assert.Equal(t, "USD", reqs[0].Currency)
+			results, _ := validProductResults(ctx, reqs)
+			results[0].Response.Product.Currency = "CNY"
+			return results, nil
+		})
+
+	_, err := service.CreateOrder(context.Background(), "cust_123", items, nil)
+
+	assert.ErrorIs(t, err, domain_order_core.ErrMixedCurrency)
+}
+
+// TestOrderService_CreateOrder_InvalidProduct 任一商品校验失败则整单失败
+func TestOrderService_CreateOrder_InvalidProduct(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProductService := mocks.NewMockProductService(ctrl)
+	service := NewOrderService(domain_order_core.OrderDomainService{}, nil, mockProductService, newTestPromotionService(), newTestInventoryService(ctrl), newTestUnitOfWork(ctrl), newTestSagaRepository(ctrl), event.NewEventBus())
+
+	items := []*domain_order_core.OrderItemDO{
+		{ProductID: "prod_1", Quantity: 1, UnitPrice: 100},
+		{ProductID: "prod_2", Quantity: 1, UnitPrice: 100},
+	}
+
+	mockProductService.EXPECT().ValidateProducts(gomock.Any(), gomock.Len(2)).DoAndReturn(
+		func(ctx context.Context, reqs []*domain_product_core.ValidateProductRequest) ([]*domain_product_core.ValidateProductResult, error) {
+			results, _ := validProductResults(ctx, reqs)
+			results[1].Response = &domain_product_core.ValidateProductResponse{IsValid: false, Messages: "product is invalid"}
+			return results, nil
+		})
+
+	_, err := service.CreateOrder(context.Background(), "cust_123", items, nil)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "prod_2")
+}
+
+// validProductResults 按请求原样返回有效商品的批量校验结果
+func validProductResults(ctx context.Context, reqs []*domain_product_core.ValidateProductRequest) ([]*domain_product_core.ValidateProductResult, error) {
+	results := make([]*domain_product_core.ValidateProductResult, len(reqs))
+	for i, req := range reqs {
+		results[i] = &domain_product_core.ValidateProductResult{
+			Request: req,
+			Response: &domain_product_core.ValidateProductResponse{
+				IsValid: true,
+				Product: &domain_product_core.Product{ID: req.ProductID, Price: req.Price, Currency: req.Currency, Status: domain_product_core.StatusValid},
+			},
+		}
+	}
+	return results, nil
+}
+
 // TestOrderService_UpdateOrder_OptimisticLockConflict 更新订单乐观锁冲突场景
 func TestOrderService_UpdateOrder_OptimisticLockConflict(t *testing.T) {
 	ctrl := gomock.NewController(t)
@@ -70,7 +253,7 @@ func TestOrderService_UpdateOrder_OptimisticLockConflict(t *testing.T) {
 	// 创建mock依赖
 	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
 	orderDomainService := domain_order_core.NewOrderDomainService(mockOrderRepo)
-	service := NewOrderService(orderDomainService, nil, nil)
+	service := NewOrderService(orderDomainService, nil, nil, newTestPromotionService(), nil, newTestUnitOfWork(ctrl), newTestSagaRepository(ctrl), event.NewEventBus())
 
 	// 准备测试数据
 	ctx := context.Background()
@@ -80,14 +263,14 @@ func TestOrderService_UpdateOrder_OptimisticLockConflict(t *testing.T) {
 		Status:     domain_order_core.OrderStatusCreated,
 	}
 
-	// 设置mock预期 - 返回乐观锁冲突错误
-	mockOrderRepo.EXPECT().Save(gomock.Any(), orderDO).Return(gorm.ErrDuplicatedKey).AnyTimes()
+	// 设置mock预期 - 版本号不一致，仓储返回乐观锁冲突错误
+	mockOrderRepo.EXPECT().Save(gomock.Any(), orderDO).Return(domain_order_core.ErrConcurrentModification)
 
 	// 执行测试
 	err := service.UpdateOrder(ctx, orderDO)
 
 	// 验证结果
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, domain_order_core.ErrConcurrentModification)
 	assert.Contains(t, err.Error(), "订单已被其他操作更新，请刷新后重试")
 }
 
@@ -99,7 +282,7 @@ func TestOrderService_GetOrder_Success(t *testing.T) {
 	// 创建mock依赖
 	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
 	orderDomainService := domain_order_core.NewOrderDomainService(mockOrderRepo)
-	service := NewOrderService(orderDomainService, nil, nil)
+	service := NewOrderService(orderDomainService, nil, nil, newTestPromotionService(), nil, newTestUnitOfWork(ctrl), newTestSagaRepository(ctrl), event.NewEventBus())
 
 	// 准备测试数据
 	ctx := context.Background()
@@ -121,6 +304,29 @@ func TestOrderService_GetOrder_Success(t *testing.T) {
 	t.Logf("result: %v", result)
 }
 
+// TestOrderService_ListOrders 查询条件规范化后交给仓储，非法条件不查询仓储
+func TestOrderService_ListOrders(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	service := NewOrderService(domain_order_core.NewOrderDomainService(mockOrderRepo), nil, nil, newTestPromotionService(), nil, newTestUnitOfWork(ctrl), newTestSagaRepository(ctrl), event.NewEventBus())
+
+	ctx := context.Background()
+	expectedPage := &domain_order_core.OrderPage{Orders: []*domain_order_core.OrderDO{{ID: "order_123"}}}
+	mockOrderRepo.EXPECT().Find(ctx, domain_order_core.OrderQuery{
+		CustomerID: "cust_123",
+		Limit:      domain_order_core.DefaultOrderPageSize,
+	}).Return(expectedPage, nil)
+
+	page, err := service.ListOrders(ctx, domain_order_core.OrderQuery{CustomerID: "cust_123"})
+	assert.NoError(t, err)
+	assert.Equal(t, expectedPage, page)
+
+	_, err = service.ListOrders(ctx, domain_order_core.OrderQuery{Statuses: []domain_order_core.OrderStatus{"refunded"}})
+	assert.Error(t, err)
+}
+
 // MockProductService 模拟ProductService接口
 type MockProductService struct {
 	ctrl *gomock.Controller
@@ -134,6 +340,10 @@ func (m *MockProductService) ValidateProduct(ctx context.Context, req *domain_pr
 	panic("implement me")
 }
 
+func (m *MockProductService) ValidateProducts(ctx context.Context, reqs []*domain_product_core.ValidateProductRequest) ([]*domain_product_core.ValidateProductResult, error) {
+	panic("implement me")
+}
+
 // MockPaymentService 模拟PaymentService结构体
 type MockPaymentService struct {
 	ctrl *gomock.Controller
@@ -147,6 +357,293 @@ func (m *MockPaymentService) GetPaymentByOrderID(ctx context.Context, orderID st
 	return nil, domain_payment_core.ErrPaymentNotFound
 }
 
-func (m *MockPaymentService) CreatePayment(ctx context.Context, orderID string, amount int64, currency string, payType int) (string, error) {
+func (m *MockPaymentService) CreatePayment(ctx context.Context, orderID string, amount dmoney.Money, payType int) (string, error) {
 	return "pay_123", nil
 }
+
+// newTestPromotionService 不使用优惠券的场景不会访问优惠券仓储
+func newTestPromotionService() *domain_promotion_core.PromotionDomainService {
+	return domain_promotion_core.NewPromotionDomainService(nil, nil)
+}
+
+// newTestInventoryService 库存充足，按订单行返回预占记录
+func newTestInventoryService(ctrl *gomock.Controller) *mocks.MockInventoryService {
+	mockInventory := mocks.NewMockInventoryService(ctrl)
+	mockInventory.EXPECT().ReserveStock(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(reserveAll).AnyTimes()
+	return mockInventory
+}
+
+// testTxKey 测试工作单元在 ctx 中标记事务
+type testTxKey struct{}
+
+// inTestTx ctx 是否处于测试工作单元中
+func inTestTx(ctx context.Context) bool {
+	return ctx.Value(testTxKey{}) != nil
+}
+
+// newTestUnitOfWork 工作单元，在 ctx 中标记事务后执行 fn
+func newTestUnitOfWork(ctrl *gomock.Controller) *mocks.MockUnitOfWork {
+	uow := mocks.NewMockUnitOfWork(ctrl)
+	uow.EXPECT().Do(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, fn func(ctx context.Context) error) error {
+			return fn(context.WithValue(ctx, testTxKey{}, true))
+		}).AnyTimes()
+	return uow
+}
+
+// newTestSagaRepository saga实例仓储，接受任意执行进度
+func newTestSagaRepository(ctrl *gomock.Controller) *mocks.MockSagaRepository {
+	repo := mocks.NewMockSagaRepository(ctrl)
+	repo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	repo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	return repo
+}
+
+// newTestOrder 订单 order_123，金额1000分
+func newTestOrder(status domain_order_core.OrderStatus) *domain_order_core.OrderDO {
+	return &domain_order_core.OrderDO{ID: "order_123", Status: status, TotalAmount: 1000, Currency: "CNY"}
+}
+
+// newTestPayment 订单 order_123 的支付单 pay_123，金额1000分
+func newTestPayment(status domain_payment_core.PaymentStatus) *domain_payment_core.PaymentDO {
+	return &domain_payment_core.PaymentDO{ID: "pay_123", OrderID: "order_123", Amount: 1000, Currency: "CNY", Status: status}
+}
+
+// newTestChannelTrade 与 newTestPayment 金额一致的渠道交易
+func newTestChannelTrade(status domain_payment_core.PaymentStatus) *domain_payment_core.ChannelTrade {
+	return &domain_payment_core.ChannelTrade{Status: status, TransactionID: "trade_123", Amount: dmoney.New(1000, dmoney.CNY)}
+}
+
+func reserveAll(ctx context.Context, orderID string, items []domain_inventory_core.StockItem) ([]*domain_inventory_core.ReservationDO, error) {
+	reservations := make([]*domain_inventory_core.ReservationDO, len(items))
+	for i, item := range items {
+		reservations[i] = &domain_inventory_core.ReservationDO{
+			ID:        "rsv_" + item.ProductID,
+			OrderID:   orderID,
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			Status:    domain_inventory_core.ReservationStatusReserved,
+		}
+	}
+	return reservations, nil
+}
+
+// TestOrderService_CreateOrder_DispatchEvents 订单保存成功后分发订单已创建事件
+func TestOrderService_CreateOrder_DispatchEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	mockProductService := mocks.NewMockProductService(ctrl)
+	mockHandler := mocks.NewMockHandler(ctrl)
+	bus := event.NewEventBus()
+	bus.RegisterHandler(domain_order_core.EventNameOrderCreated, mockHandler)
+	service := NewOrderService(domain_order_core.NewOrderDomainService(mockOrderRepo), nil, mockProductService, newTestPromotionService(), newTestInventoryService(ctrl), newTestUnitOfWork(ctrl), newTestSagaRepository(ctrl), bus)
+
+	mockProductService.EXPECT().ValidateProducts(gomock.Any(), gomock.Any()).DoAndReturn(validProductResults)
+	mockOrderRepo.EXPECT().Save(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, order *domain_order_core.OrderDO) error {
+		// 仓储保存时事件尚未取出，需随订单写入发件箱
+		assert.Len(t, order.Events(), 1)
+		assert.True(t, inTestTx(ctx))
+		return nil
+	})
+
+	var received *domain_order_core.OrderCreatedEvent
+	mockHandler.EXPECT().Handle(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, evt event.Event) error {
+		// 事务提交后才分发事件
+		assert.False(t, inTestTx(ctx))
+		received = evt.(*domain_order_core.OrderCreatedEvent)
+		return nil
+	})
+
+	orderID, err := service.CreateOrder(context.Background(), "cust_1", []*domain_order_core.OrderItemDO{
+		{ProductID: "prod_1", Quantity: 2, UnitPrice: 100},
+	}, nil)
+
+	assert.NoError(t, err)
+	if assert.NotNil(t, received) {
+		assert.Equal(t, orderID, received.OrderID)
+		assert.Equal(t, domain_order_core.OrderStatusCreated, received.NewStatus)
+		assert.Equal(t, int64(200), received.Amount)
+		assert.Equal(t, "cust_1", received.CustomerID)
+	}
+}
+
+// TestOrderService_CreateOrder_SaveFailedNoEvents 保存失败时不分发事件，并释放已预占的库存
+func TestOrderService_CreateOrder_SaveFailedNoEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	mockProductService := mocks.NewMockProductService(ctrl)
+	mockHandler := mocks.NewMockHandler(ctrl)
+	bus := event.NewEventBus()
+	bus.RegisterHandler(domain_order_core.EventNameOrderCreated, mockHandler)
+	mockInventory := newTestInventoryService(ctrl)
+	service := NewOrderService(domain_order_core.NewOrderDomainService(mockOrderRepo), nil, mockProductService, newTestPromotionService(), mockInventory, newTestUnitOfWork(ctrl), newTestSagaRepository(ctrl), bus)
+
+	mockProductService.EXPECT().ValidateProducts(gomock.Any(), gomock.Any()).DoAndReturn(validProductResults)
+	mockOrderRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(gorm.ErrInvalidTransaction)
+	mockHandler.EXPECT().Handle(gomock.Any(), gomock.Any()).Times(0)
+	mockInventory.EXPECT().ReleaseReservation(gomock.Any(), "rsv_prod_1").Return(nil)
+
+	_, err := service.CreateOrder(context.Background(), "cust_1", []*domain_order_core.OrderItemDO{
+		{ProductID: "prod_1", Quantity: 1, UnitPrice: 100},
+	}, nil)
+
+	assert.Error(t, err)
+}
+
+// newCouponPromotionService 返回一张满100减10的优惠券，每人限用1次
+func newCouponPromotionService(ctrl *gomock.Controller) (*domain_promotion_core.PromotionDomainService, *mocks.MockCouponUsageRepository) {
+	mockCouponRepo := mocks.NewMockCouponRepository(ctrl)
+	mockUsageRepo := mocks.NewMockCouponUsageRepository(ctrl)
+	mockCouponRepo.EXPECT().FindByCodes(gomock.Any(), []string{"SAVE10"}).Return([]*domain_promotion_core.CouponDO{{
+		ID:               "coupon_1",
+		Code:             "SAVE10",
+		Type:             domain_promotion_core.CouponTypeThreshold,
+		Currency:         "CNY",
+		Threshold:        10000,
+		Amount:           1000,
+		PerCustomerLimit: 1,
+		StartAt:          time.Now().Add(-time.Hour),
+		Status:           domain_promotion_core.CouponStatusActive,
+	}}, nil)
+	mockUsageRepo.EXPECT().CountRedeemed(gomock.Any(), "coupon_1", "cust_1").Return(int64(0), nil)
+	return domain_promotion_core.NewPromotionDomainService(mockCouponRepo, mockUsageRepo), mockUsageRepo
+}
+
+// TestOrderService_CreateOrder_WithCoupon 使用优惠券下单，优惠分摊到订单行并占用优惠券
+func TestOrderService_CreateOrder_WithCoupon(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	mockProductService := mocks.NewMockProductService(ctrl)
+	promotionService, mockUsageRepo := newCouponPromotionService(ctrl)
+	service := NewOrderService(domain_order_core.NewOrderDomainService(mockOrderRepo), nil, mockProductService, promotionService, newTestInventoryService(ctrl), newTestUnitOfWork(ctrl), newTestSagaRepository(ctrl), event.NewEventBus())
+
+	mockProductService.EXPECT().ValidateProducts(gomock.Any(), gomock.Len(2)).DoAndReturn(validProductResults)
+	redeem := mockUsageRepo.EXPECT().Redeem(gomock.Any(), gomock.Len(1), map[string]int64{"coupon_1": 1}).Return(nil)
+	var saved *domain_order_core.OrderDO
+	mockOrderRepo.EXPECT().Save(gomock.Any(), gomock.Any()).After(redeem).DoAndReturn(
+		func(ctx context.Context, order *domain_order_core.OrderDO) error {
+			saved = order
+			return nil
+		})
+
+	_, err := service.CreateOrder(context.Background(), "cust_1", []*domain_order_core.OrderItemDO{
+		{ProductID: "prod_1", Quantity: 3, UnitPrice: 3000},
+		{ProductID: "prod_2", Quantity: 1, UnitPrice: 1000},
+	}, []string{"SAVE10"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"coupon_1"}, saved.CouponIDs)
+	assert.Equal(t, int64(900), saved.Items[0].Discount)
+	assert.Equal(t, int64(100), saved.Items[1].Discount)
+	assert.Equal(t, int64(1000), saved.DiscountAmount)
+	assert.Equal(t, int64(9000), saved.TotalAmount)
+}
+
+// TestOrderService_CreateOrder_CouponReleasedOnSaveFailure 订单保存失败时释放已占用的优惠券和库存
+func TestOrderService_CreateOrder_CouponReleasedOnSaveFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	mockProductService := mocks.NewMockProductService(ctrl)
+	promotionService, mockUsageRepo := newCouponPromotionService(ctrl)
+	mockInventory := newTestInventoryService(ctrl)
+	service := NewOrderService(domain_order_core.NewOrderDomainService(mockOrderRepo), nil, mockProductService, promotionService, mockInventory, newTestUnitOfWork(ctrl), newTestSagaRepository(ctrl), event.NewEventBus())
+
+	mockProductService.EXPECT().ValidateProducts(gomock.Any(), gomock.Any()).DoAndReturn(validProductResults)
+	mockUsageRepo.EXPECT().Redeem(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	var orderID string
+	mockOrderRepo.EXPECT().Save(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, order *domain_order_core.OrderDO) error {
+			orderID = order.ID
+			return gorm.ErrInvalidTransaction
+		})
+	mockUsageRepo.EXPECT().ReleaseByOrderID(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, id string) error {
+			assert.Equal(t, orderID, id)
+			return nil
+		})
+	mockInventory.EXPECT().ReleaseReservation(gomock.Any(), "rsv_prod_1").Return(nil)
+
+	_, err := service.CreateOrder(context.Background(), "cust_1", []*domain_order_core.OrderItemDO{
+		{ProductID: "prod_1", Quantity: 4, UnitPrice: 3000},
+	}, []string{"SAVE10"})
+
+	assert.True(t, errors.Is(err, gorm.ErrInvalidTransaction))
+}
+
+// TestOrderService_CreateOrder_CouponLimitExceeded 占用优惠券失败时不保存订单，并释放已预占的库存
+func TestOrderService_CreateOrder_CouponLimitExceeded(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProductService := mocks.NewMockProductService(ctrl)
+	promotionService, mockUsageRepo := newCouponPromotionService(ctrl)
+	mockInventory := newTestInventoryService(ctrl)
+	service := NewOrderService(domain_order_core.NewOrderDomainService(mocks.NewMockOrderRepository(ctrl)), nil, mockProductService, promotionService, mockInventory, newTestUnitOfWork(ctrl), newTestSagaRepository(ctrl), event.NewEventBus())
+
+	mockProductService.EXPECT().ValidateProducts(gomock.Any(), gomock.Any()).DoAndReturn(validProductResults)
+	mockUsageRepo.EXPECT().Redeem(gomock.Any(), gomock.Any(), gomock.Any()).Return(domain_promotion_core.ErrCouponUsageLimitExceeded)
+	mockInventory.EXPECT().ReleaseReservation(gomock.Any(), "rsv_prod_1").Return(nil)
+
+	_, err := service.CreateOrder(context.Background(), "cust_1", []*domain_order_core.OrderItemDO{
+		{ProductID: "prod_1", Quantity: 4, UnitPrice: 3000},
+	}, []string{"SAVE10"})
+
+	assert.True(t, errors.Is(err, domain_promotion_core.ErrCouponUsageLimitExceeded))
+}
+
+// TestOrderService_CreateOrder_InsufficientStock 库存不足时不占用优惠券、不保存订单
+func TestOrderService_CreateOrder_InsufficientStock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProductService := mocks.NewMockProductService(ctrl)
+	mockInventory := mocks.NewMockInventoryService(ctrl)
+	service := NewOrderService(domain_order_core.NewOrderDomainService(mocks.NewMockOrderRepository(ctrl)), nil, mockProductService, newTestPromotionService(), mockInventory, newTestUnitOfWork(ctrl), newTestSagaRepository(ctrl), event.NewEventBus())
+
+	mockProductService.EXPECT().ValidateProducts(gomock.Any(), gomock.Any()).DoAndReturn(validProductResults)
+	mockInventory.EXPECT().ReserveStock(gomock.Any(), gomock.Any(), []domain_inventory_core.StockItem{{ProductID: "prod_1", Quantity: 5}}).
+		Return(nil, domain_inventory_core.ErrInsufficientStock)
+
+	_, err := service.CreateOrder(context.Background(), "cust_1", []*domain_order_core.OrderItemDO{
+		{ProductID: "prod_1", Quantity: 2, UnitPrice: 100},
+		{ProductID: "prod_1", Quantity: 3, UnitPrice: 100},
+	}, nil)
+
+	assert.True(t, errors.Is(err, domain_inventory_core.ErrInsufficientStock))
+}
+
+// TestOrderService_CreateOrder_ReservationIDs 预占ID记录在对应的订单行上
+func TestOrderService_CreateOrder_ReservationIDs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	mockProductService := mocks.NewMockProductService(ctrl)
+	service := NewOrderService(domain_order_core.NewOrderDomainService(mockOrderRepo), nil, mockProductService, newTestPromotionService(), newTestInventoryService(ctrl), newTestUnitOfWork(ctrl), newTestSagaRepository(ctrl), event.NewEventBus())
+
+	mockProductService.EXPECT().ValidateProducts(gomock.Any(), gomock.Any()).DoAndReturn(validProductResults)
+	var saved *domain_order_core.OrderDO
+	mockOrderRepo.EXPECT().Save(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, order *domain_order_core.OrderDO) error {
+			saved = order
+			return nil
+		})
+
+	_, err := service.CreateOrder(context.Background(), "cust_1", []*domain_order_core.OrderItemDO{
+		{ProductID: "prod_1", Quantity: 1, UnitPrice: 100},
+		{ProductID: "prod_2", Quantity: 1, UnitPrice: 200},
+	}, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "rsv_prod_1", saved.Items[0].ReservationID)
+	assert.Equal(t, "rsv_prod_2", saved.Items[1].ReservationID)
+	assert.Equal(t, []string{"rsv_prod_1", "rsv_prod_2"}, saved.ReservationIDs())
+}
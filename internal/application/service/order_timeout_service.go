@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_order_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/payment"
+	"github.com/vaynedu/ddd_order_example/internal/shared/event"
+)
+
+// errPaidAtChannel 渠道侧已支付但本地尚未收到结果，订单不能取消
+var errPaidAtChannel = errors.New("渠道侧已支付，等待支付结果同步")
+
+type OrderTimeoutService struct {
+	orderDomainService domain_order_core.OrderDomainService // 依赖订单领域服务
+	paymentService     *PaymentService                      // 依赖支付应用服务
+	dispatcher         event.Dispatcher                     // 依赖领域事件分发
+}
+
+func NewOrderTimeoutService(
+	orderDomainService domain_order_core.OrderDomainService,
+	paymentService *PaymentService,
+	dispatcher event.Dispatcher,
+) *OrderTimeoutService {
+	return &OrderTimeoutService{
+		orderDomainService: orderDomainService,
+		paymentService:     paymentService,
+		dispatcher:         dispatcher,
+	}
+}
+
+// CancelOverdueOrders 取消创建时间早于 deadline 仍未支付的订单，按 batchSize 分页扫描，返回取消成功的数量
+// 单个订单处理失败只记录日志，不影响其他订单，下一轮扫描会再次处理
+func (s *OrderTimeoutService) CancelOverdueOrders(ctx context.Context, deadline time.Time, batchSize int) (int, error) {
+	cancelled := 0
+	var cursor *domain_order_core.OrderCursor
+	for {
+		page, err := s.orderDomainService.FindOverdueOrders(ctx, deadline, cursor, batchSize)
+		if err != nil {
+			return cancelled, fmt.Errorf("查询超时订单失败: %w", err)
+		}
+
+		for _, orderDO := range page.Orders {
+			if err := s.cancelOverdueOrder(ctx, orderDO); err != nil {
+				slog.Error("取消超时订单失败", "order_id", orderDO.ID, "error", err)
+				continue
+			}
+			cancelled++
+		}
+
+		if page.NextCursor == nil || ctx.Err() != nil {
+			return cancelled, ctx.Err()
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// cancelOverdueOrder 取消单个超时订单并关闭支付单
+// 支付结果未确定时先关闭渠道交易，关闭后用户不能再支付，渠道侧已支付的订单不取消；
+// 多实例并发处理同一订单时由订单乐观锁保证只有一个能取消成功
+func (s *OrderTimeoutService) cancelOverdueOrder(ctx context.Context, orderDO *domain_order_core.OrderDO) error {
+	// 1. 检查支付单
+	paymentDO, err := s.paymentService.GetPaymentByOrderID(ctx, orderDO.ID)
+	if err != nil && !errors.Is(err, domain_payment_core.ErrPaymentNotFound) {
+		return err
+	}
+	if paymentDO != nil {
+		if paymentDO.IsSucceeded() {
+			// 支付单已成功但订单未同步(如通知处理中断)，补齐订单状态而不是取消
+			if err := markOrderPaid(ctx, s.orderDomainService, s.dispatcher, orderDO.ID); err != nil {
+				return err
+			}
+			return domain_payment_core.ErrPaymentPaid
+		}
+		if !paymentDO.IsFinished() {
+			if err := s.closeChannelTrade(ctx, paymentDO); err != nil {
+				return err
+			}
+		}
+	}
+
+	// 2. 取消订单
+	if err := orderDO.Cancel(); err != nil {
+		return err
+	}
+	if err := s.orderDomainService.UpdateOrder(ctx, orderDO); err != nil {
+		return fmt.Errorf("保存订单状态失败: %w", err)
+	}
+	dispatchOrderEvents(ctx, s.dispatcher, orderDO)
+
+	// 3. 关闭支付单，订单已取消，失败时只记录日志
+	if paymentDO != nil && !paymentDO.IsFinished() {
+		if err := s.paymentService.ExpirePayment(ctx, paymentDO.ID); err != nil {
+			slog.Error("关闭订单支付单失败", "order_id", orderDO.ID, "error", err)
+		}
+	}
+	return nil
+}
+
+// closeChannelTrade 关闭支付单的渠道交易，渠道侧已支付时返回 errPaidAtChannel
+func (s *OrderTimeoutService) closeChannelTrade(ctx context.Context, paymentDO *domain_payment_core.PaymentDO) error {
+	err := s.paymentService.CloseChannelTrade(ctx, paymentDO)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, payment.ErrTradeNotExist):
+		// 订单已超过支付时限，渠道侧仍没有交易说明用户未扫码，可以取消
+		return nil
+	case errors.Is(err, payment.ErrTradeNotClosable):
+		// 交易已支付或已关闭，查询确认是否已支付
+		trade, err := s.paymentService.QueryChannelTrade(ctx, paymentDO)
+		if err != nil {
+			return fmt.Errorf("查询渠道支付状态失败: %w", err)
+		}
+		if trade.IsPaid() {
+			return errPaidAtChannel
+		}
+		return nil
+	default:
+		return fmt.Errorf("关闭渠道交易失败: %w", err)
+	}
+}
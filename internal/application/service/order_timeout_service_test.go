@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_order_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/mocks"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/payment"
+	"github.com/vaynedu/ddd_order_example/internal/shared/event"
+	"go.uber.org/mock/gomock"
+)
+
+// TestOrderTimeoutService_CancelOverdueOrders_QueryCondition 只查询创建时间早于截止时间的待支付订单
+func TestOrderTimeoutService_CancelOverdueOrders_QueryCondition(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	service := NewOrderTimeoutService(domain_order_core.NewOrderDomainService(mockOrderRepo), nil, event.NewEventBus())
+
+	deadline := time.Now().Add(-30 * time.Minute)
+	mockOrderRepo.EXPECT().Find(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, query domain_order_core.OrderQuery) (*domain_order_core.OrderPage, error) {
+		assert.ElementsMatch(t, []domain_order_core.OrderStatus{domain_order_core.OrderStatusCreated, domain_order_core.OrderStatusPending}, query.Statuses)
+		assert.Equal(t, deadline, *query.CreatedTo)
+		assert.Equal(t, 50, query.Limit)
+		return &domain_order_core.OrderPage{}, nil
+	})
+
+	cancelled, err := service.CancelOverdueOrders(context.Background(), deadline, 50)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, cancelled)
+}
+
+// TestOrderTimeoutService_CancelOverdueOrders_NoPayment 未发起支付的订单直接取消
+func TestOrderTimeoutService_CancelOverdueOrders_NoPayment(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	mockPaymentRepo := mocks.NewMockRepository(ctrl)
+	paymentService := NewPaymentService(domain_payment_core.NewPaymentDomainService(mockPaymentRepo, nil), mocks.NewMockPaymentProxy(ctrl))
+	service := NewOrderTimeoutService(domain_order_core.NewOrderDomainService(mockOrderRepo), paymentService, event.NewEventBus())
+
+	order := newTestOrder(domain_order_core.OrderStatusCreated)
+	mockOrderRepo.EXPECT().Find(gomock.Any(), gomock.Any()).Return(&domain_order_core.OrderPage{Orders: []*domain_order_core.OrderDO{order}}, nil)
+	mockPaymentRepo.EXPECT().FindByOrderID(gomock.Any(), "order_123").Return(nil, domain_payment_core.ErrPaymentNotFound)
+	mockOrderRepo.EXPECT().Save(gomock.Any(), order).Return(nil)
+
+	cancelled, err := service.CancelOverdueOrders(context.Background(), time.Now(), 100)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, cancelled)
+	assert.Equal(t, domain_order_core.OrderStatusCancelled, order.Status)
+}
+
+// TestOrderTimeoutService_CancelOverdueOrders_ExpirePayment 先关闭渠道交易，关闭成功、交易不存在或已关闭时取消订单并关闭支付单
+func TestOrderTimeoutService_CancelOverdueOrders_ExpirePayment(t *testing.T) {
+	cases := []struct {
+		name     string
+		closeErr error
+		trade    *domain_payment_core.ChannelTrade
+	}{
+		{name: "渠道交易关闭成功"},
+		{name: "渠道交易不存在", closeErr: payment.ErrTradeNotExist},
+		{name: "渠道交易已关闭", closeErr: payment.ErrTradeNotClosable, trade: newTestChannelTrade(domain_payment_core.PaymentStatusClosed)},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+			mockPaymentRepo := mocks.NewMockRepository(ctrl)
+			mockProxy := mocks.NewMockPaymentProxy(ctrl)
+			paymentService := NewPaymentService(domain_payment_core.NewPaymentDomainService(mockPaymentRepo, nil), mockProxy)
+			service := NewOrderTimeoutService(domain_order_core.NewOrderDomainService(mockOrderRepo), paymentService, event.NewEventBus())
+
+			order := newTestOrder(domain_order_core.OrderStatusPending)
+			paymentDO := newTestPayment(domain_payment_core.PaymentStatusPending)
+			mockOrderRepo.EXPECT().Find(gomock.Any(), gomock.Any()).Return(&domain_order_core.OrderPage{Orders: []*domain_order_core.OrderDO{order}}, nil)
+			mockPaymentRepo.EXPECT().FindByOrderID(gomock.Any(), "order_123").Return(paymentDO, nil)
+			closeTrade := mockProxy.EXPECT().CloseTrade(gomock.Any(), "order_123").Return(c.closeErr)
+			if c.trade != nil {
+				mockProxy.EXPECT().QueryPaymentStatus(gomock.Any(), "order_123").Return(c.trade, nil).After(closeTrade)
+			}
+			mockOrderRepo.EXPECT().Save(gomock.Any(), order).Return(nil).After(closeTrade)
+			mockPaymentRepo.EXPECT().FindByID(gomock.Any(), "pay_123").Return(paymentDO, nil)
+			mockPaymentRepo.EXPECT().Save(gomock.Any(), paymentDO).Return(nil)
+
+			cancelled, err := service.CancelOverdueOrders(context.Background(), time.Now(), 100)
+
+			assert.NoError(t, err)
+			assert.Equal(t, 1, cancelled)
+			assert.Equal(t, domain_order_core.OrderStatusCancelled, order.Status)
+			assert.Equal(t, domain_payment_core.PaymentStatusExpired, paymentDO.Status)
+		})
+	}
+}
+
+// TestOrderTimeoutService_CancelOverdueOrders_PaymentSucceeded 支付单已成功时补齐订单为已支付而不是取消
+func TestOrderTimeoutService_CancelOverdueOrders_PaymentSucceeded(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	mockPaymentRepo := mocks.NewMockRepository(ctrl)
+	paymentService := NewPaymentService(domain_payment_core.NewPaymentDomainService(mockPaymentRepo, nil), mocks.NewMockPaymentProxy(ctrl))
+	service := NewOrderTimeoutService(domain_order_core.NewOrderDomainService(mockOrderRepo), paymentService, event.NewEventBus())
+
+	order := newTestOrder(domain_order_core.OrderStatusPending)
+	mockOrderRepo.EXPECT().Find(gomock.Any(), gomock.Any()).Return(&domain_order_core.OrderPage{Orders: []*domain_order_core.OrderDO{order}}, nil)
+	mockPaymentRepo.EXPECT().FindByOrderID(gomock.Any(), "order_123").Return(newTestPayment(domain_payment_core.PaymentStatusCompleted), nil)
+	mockOrderRepo.EXPECT().FindByID(gomock.Any(), "order_123").Return(order, nil)
+	mockOrderRepo.EXPECT().Save(gomock.Any(), order).Return(nil)
+
+	cancelled, err := service.CancelOverdueOrders(context.Background(), time.Now(), 100)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, cancelled)
+	assert.Equal(t, domain_order_core.OrderStatusPaid, order.Status)
+}
+
+// TestOrderTimeoutService_CancelOverdueOrders_PaidAtChannel 渠道侧已支付或关闭、查询失败时不取消订单
+func TestOrderTimeoutService_CancelOverdueOrders_PaidAtChannel(t *testing.T) {
+	cases := []struct {
+		name     string
+		closeErr error
+		trade    *domain_payment_core.ChannelTrade
+		queryErr error
+	}{
+		{name: "渠道已支付", closeErr: payment.ErrTradeNotClosable, trade: newTestChannelTrade(domain_payment_core.PaymentStatusCompleted)},
+		{name: "渠道关闭失败", closeErr: errors.New("gateway timeout")},
+		{name: "渠道查询失败", closeErr: payment.ErrTradeNotClosable, queryErr: errors.New("gateway timeout")},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+			mockPaymentRepo := mocks.NewMockRepository(ctrl)
+			mockProxy := mocks.NewMockPaymentProxy(ctrl)
+			paymentService := NewPaymentService(domain_payment_core.NewPaymentDomainService(mockPaymentRepo, nil), mockProxy)
+			service := NewOrderTimeoutService(domain_order_core.NewOrderDomainService(mockOrderRepo), paymentService, event.NewEventBus())
+
+			order := newTestOrder(domain_order_core.OrderStatusPending)
+			paymentDO := newTestPayment(domain_payment_core.PaymentStatusPending)
+			mockOrderRepo.EXPECT().Find(gomock.Any(), gomock.Any()).Return(&domain_order_core.OrderPage{Orders: []*domain_order_core.OrderDO{order}}, nil)
+			mockPaymentRepo.EXPECT().FindByOrderID(gomock.Any(), "order_123").Return(paymentDO, nil)
+			mockProxy.EXPECT().CloseTrade(gomock.Any(), "order_123").Return(c.closeErr)
+			if errors.Is(c.closeErr, payment.ErrTradeNotClosable) {
+				mockProxy.EXPECT().QueryPaymentStatus(gomock.Any(), "order_123").Return(c.trade, c.queryErr)
+			}
+
+			cancelled, err := service.CancelOverdueOrders(context.Background(), time.Now(), 100)
+
+			assert.NoError(t, err)
+			assert.Equal(t, 0, cancelled)
+			assert.Equal(t, domain_order_core.OrderStatusPending, order.Status)
+			assert.Equal(t, domain_payment_core.PaymentStatusPending, paymentDO.Status)
+		})
+	}
+}
+
+// TestOrderTimeoutService_CancelOverdueOrders_Paginate 一页处理完后按游标继续扫描
+func TestOrderTimeoutService_CancelOverdueOrders_Paginate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	mockPaymentRepo := mocks.NewMockRepository(ctrl)
+	paymentService := NewPaymentService(domain_payment_core.NewPaymentDomainService(mockPaymentRepo, nil), mocks.NewMockPaymentProxy(ctrl))
+	service := NewOrderTimeoutService(domain_order_core.NewOrderDomainService(mockOrderRepo), paymentService, event.NewEventBus())
+
+	first := &domain_order_core.OrderDO{ID: "order_2", Status: domain_order_core.OrderStatusCreated, CreatedAt: time.Now()}
+	second := &domain_order_core.OrderDO{ID: "order_1", Status: domain_order_core.OrderStatusCreated, CreatedAt: time.Now()}
+	cursor := &domain_order_core.OrderCursor{CreatedAt: first.CreatedAt, ID: first.ID}
+
+	gomock.InOrder(
+		mockOrderRepo.EXPECT().Find(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, query domain_order_core.OrderQuery) (*domain_order_core.OrderPage, error) {
+			assert.Nil(t, query.Cursor)
+			return &domain_order_core.OrderPage{Orders: []*domain_order_core.OrderDO{first}, NextCursor: cursor}, nil
+		}),
+		mockOrderRepo.EXPECT().Find(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, query domain_order_core.OrderQuery) (*domain_order_core.OrderPage, error) {
+			assert.Equal(t, cursor, query.Cursor)
+			return &domain_order_core.OrderPage{Orders: []*domain_order_core.OrderDO{second}}, nil
+		}),
+	)
+	mockPaymentRepo.EXPECT().FindByOrderID(gomock.Any(), gomock.Any()).Return(nil, domain_payment_core.ErrPaymentNotFound).Times(2)
+	mockOrderRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+	cancelled, err := service.CancelOverdueOrders(context.Background(), time.Now(), 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, cancelled)
+}
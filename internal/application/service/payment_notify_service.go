@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_order_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_reconciliation_core"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/payment"
+	"github.com/vaynedu/ddd_order_example/internal/shared/event"
+)
+
+type PaymentNotifyService struct {
+	notifier           payment.PaymentNotifier                          // 依赖支付通知解析
+	paymentService     *PaymentService                                  // 依赖支付应用服务
+	orderDomainService domain_order_core.OrderDomainService             // 依赖订单领域服务
+	discrepancyRepo    domain_reconciliation_core.DiscrepancyRepository // 依赖对账差异仓储
+	dispatcher         event.Dispatcher                                 // 依赖领域事件分发
+}
+
+func NewPaymentNotifyService(
+	notifier payment.PaymentNotifier,
+	paymentService *PaymentService,
+	orderDomainService domain_order_core.OrderDomainService,
+	discrepancyRepo domain_reconciliation_core.DiscrepancyRepository,
+	dispatcher event.Dispatcher,
+) *PaymentNotifyService {
+	return &PaymentNotifyService{
+		notifier:           notifier,
+		paymentService:     paymentService,
+		orderDomainService: orderDomainService,
+		discrepancyRepo:    discrepancyRepo,
+		dispatcher:         dispatcher,
+	}
+}
+
+// HandleNotification 处理支付渠道异步通知
+// 渠道会重复投递同一通知直到收到成功应答，整个流程必须幂等：
+// 支付单已有结果时不再修改，订单已支付时不再流转；
+// 支付单已关闭或订单已取消后才收到支付成功时记录差异等待人工退款，并应答成功避免渠道无限重试
+func (s *PaymentNotifyService) HandleNotification(ctx context.Context, values url.Values) error {
+	// 1. 验签并解析通知
+	notification, err := s.notifier.ParseNotification(ctx, values)
+	if err != nil {
+		return err
+	}
+
+	// 2. 获取支付单，校验通知金额
+	paymentDO, err := s.paymentService.GetPaymentByOrderID(ctx, notification.OrderID)
+	if err != nil {
+		return err
+	}
+	if !notification.Amount.Equal(paymentDO.Money()) {
+		return fmt.Errorf("%w: 通知金额%s %s, 支付单金额%s %s", domain_payment_core.ErrPaymentAmountMismatch,
+			notification.Amount, notification.Amount.Currency(), paymentDO.Money(), paymentDO.Currency)
+	}
+
+	// 3. 更新支付单
+	switch notification.Status {
+	case domain_payment_core.PaymentStatusPending:
+		// 等待买家付款，无需处理
+		return nil
+	case domain_payment_core.PaymentStatusCompleted:
+		if paymentDO.IsFinished() && !paymentDO.IsSucceeded() {
+			if paymentDO.CanRefund() {
+				// 已发起退款，渠道退款后会再次通知交易状态，无需处理
+				return nil
+			}
+			// 支付单已过期、失败或取消(如超时关单后用户仍完成支付)，订单不再流转为已支付
+			return s.recordPaidConflict(ctx, paymentDO, notification,
+				fmt.Sprintf("渠道通知支付成功，但支付单状态为%s，需人工退款", domain_payment_core.GetPaymentStatusDetail(paymentDO.Status)))
+		}
+		if err := s.paymentService.ProcessPaymentResult(ctx, paymentDO.ID, notification.TransactionID, true); err != nil {
+			return fmt.Errorf("更新支付结果失败: %w", err)
+		}
+	default:
+		if err := s.paymentService.ProcessPaymentResult(ctx, paymentDO.ID, notification.TransactionID, false); err != nil {
+			return fmt.Errorf("更新支付结果失败: %w", err)
+		}
+		return nil
+	}
+
+	// 4. 支付成功，订单流转为已支付
+	err = markOrderPaid(ctx, s.orderDomainService, s.dispatcher, notification.OrderID)
+	var illegal *domain_order_core.ErrIllegalTransition
+	if errors.As(err, &illegal) {
+		return s.recordPaidConflict(ctx, paymentDO, notification,
+			fmt.Sprintf("渠道通知支付成功，但订单状态为%s，需人工退款", domain_order_core.GetOrderStatusDetail(illegal.From)))
+	}
+	return err
+}
+
+// recordPaidConflict 渠道已支付但本地无法确认支付时记录差异，差异ID固定，重复通知不会产生重复记录
+// 写入失败时返回错误，渠道稍后重试通知
+func (s *PaymentNotifyService) recordPaidConflict(ctx context.Context, paymentDO *domain_payment_core.PaymentDO, notification *payment.PaymentNotification, detail string) error {
+	trade := &domain_payment_core.ChannelTrade{
+		Status:        notification.Status,
+		TransactionID: notification.TransactionID,
+		Amount:        notification.Amount,
+	}
+	discrepancy := domain_reconciliation_core.NewNotificationDiscrepancy(domain_reconciliation_core.DiscrepancyTypeOrderConflict, paymentDO, trade, detail)
+	if err := s.discrepancyRepo.Append(ctx, []*domain_reconciliation_core.DiscrepancyDO{discrepancy}); err != nil {
+		return fmt.Errorf("记录支付通知差异失败: %w", err)
+	}
+	slog.Warn("支付通知与本地状态冲突，已记录差异", "order_id", paymentDO.OrderID, "payment_id", paymentDO.ID, "detail", detail)
+	return nil
+}
+
+// markOrderPaid 支付成功后订单流转为已支付，订单已处于支付后的状态时直接返回
+// 订单无法流转(如已取消)时返回 *domain_order_core.ErrIllegalTransition
+func markOrderPaid(ctx context.Context, orderDomainService domain_order_core.OrderDomainService, dispatcher event.Dispatcher, orderID string) error {
+	orderDO, err := orderDomainService.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	switch orderDO.Status {
+	case domain_order_core.OrderStatusPaid, domain_order_core.OrderStatusShipped, domain_order_core.OrderStatusCompleted:
+		// 重复处理，订单已处于支付后的状态
+		return nil
+	}
+	if err := orderDO.MarkAsPaid(); err != nil {
+		return fmt.Errorf("更新订单为已支付状态失败: %w", err)
+	}
+	if err := orderDomainService.UpdateOrder(ctx, orderDO); err != nil {
+		return fmt.Errorf("保存订单状态失败: %w", err)
+	}
+
+	dispatchOrderEvents(ctx, dispatcher, orderDO)
+	return nil
+}
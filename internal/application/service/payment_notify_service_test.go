@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_order_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_reconciliation_core"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/mocks"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/payment"
+	"github.com/vaynedu/ddd_order_example/internal/shared/event"
+	"github.com/vaynedu/ddd_order_example/pkg/dmoney"
+	"go.uber.org/mock/gomock"
+)
+
+// TestPaymentNotifyService_HandleNotification_Success 支付成功通知更新支付单并将订单标记为已支付
+func TestPaymentNotifyService_HandleNotification_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockNotifier := mocks.NewMockPaymentNotifier(ctrl)
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	mockPaymentRepo := mocks.NewMockRepository(ctrl)
+	paymentService := NewPaymentService(domain_payment_core.NewPaymentDomainService(mockPaymentRepo, nil), mocks.NewMockPaymentProxy(ctrl))
+	service := NewPaymentNotifyService(mockNotifier, paymentService, domain_order_core.NewOrderDomainService(mockOrderRepo), mocks.NewMockDiscrepancyRepository(ctrl), event.NewEventBus())
+
+	order := newTestOrder(domain_order_core.OrderStatusPending)
+	paymentDO := newTestPayment(domain_payment_core.PaymentStatusPending)
+	mockNotifier.EXPECT().ParseNotification(gomock.Any(), gomock.Any()).Return(&payment.PaymentNotification{
+		OrderID:       "order_123",
+		TransactionID: "trade_no_1",
+		Status:        domain_payment_core.PaymentStatusCompleted,
+		Amount:        dmoney.New(1000, dmoney.CNY),
+	}, nil)
+	mockPaymentRepo.EXPECT().FindByOrderID(gomock.Any(), "order_123").Return(paymentDO, nil)
+	mockPaymentRepo.EXPECT().FindByID(gomock.Any(), "pay_123").Return(paymentDO, nil)
+	mockPaymentRepo.EXPECT().Save(gomock.Any(), paymentDO).Return(nil)
+	mockOrderRepo.EXPECT().FindByID(gomock.Any(), "order_123").Return(order, nil)
+	mockOrderRepo.EXPECT().Save(gomock.Any(), order).Return(nil)
+
+	err := service.HandleNotification(context.Background(), url.Values{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain_payment_core.PaymentStatusCompleted, paymentDO.Status)
+	assert.Equal(t, "trade_no_1", paymentDO.TransactionID)
+	assert.Equal(t, domain_order_core.OrderStatusPaid, order.Status)
+}
+
+// TestPaymentNotifyService_HandleNotification_Duplicate 重复通知不再修改支付单和订单
+func TestPaymentNotifyService_HandleNotification_Duplicate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockNotifier := mocks.NewMockPaymentNotifier(ctrl)
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	mockPaymentRepo := mocks.NewMockRepository(ctrl)
+	paymentService := NewPaymentService(domain_payment_core.NewPaymentDomainService(mockPaymentRepo, nil), mocks.NewMockPaymentProxy(ctrl))
+	service := NewPaymentNotifyService(mockNotifier, paymentService, domain_order_core.NewOrderDomainService(mockOrderRepo), mocks.NewMockDiscrepancyRepository(ctrl), event.NewEventBus())
+
+	order := newTestOrder(domain_order_core.OrderStatusPending)
+	paymentDO := newTestPayment(domain_payment_core.PaymentStatusPending)
+	mockNotifier.EXPECT().ParseNotification(gomock.Any(), gomock.Any()).Return(&payment.PaymentNotification{
+		OrderID:       "order_123",
+		TransactionID: "trade_no_1",
+		Status:        domain_payment_core.PaymentStatusCompleted,
+		Amount:        dmoney.New(1000, dmoney.CNY),
+	}, nil).Times(2)
+	mockPaymentRepo.EXPECT().FindByOrderID(gomock.Any(), "order_123").Return(paymentDO, nil).AnyTimes()
+	mockPaymentRepo.EXPECT().FindByID(gomock.Any(), "pay_123").Return(paymentDO, nil).AnyTimes()
+	mockPaymentRepo.EXPECT().Save(gomock.Any(), paymentDO).Return(nil).Times(1)
+	mockOrderRepo.EXPECT().FindByID(gomock.Any(), "order_123").Return(order, nil).AnyTimes()
+	mockOrderRepo.EXPECT().Save(gomock.Any(), order).Return(nil).Times(1)
+
+	assert.NoError(t, service.HandleNotification(context.Background(), url.Values{}))
+	completedAt := paymentDO.CompletedAt
+
+	assert.NoError(t, service.HandleNotification(context.Background(), url.Values{}))
+	assert.Same(t, completedAt, paymentDO.CompletedAt)
+	assert.Equal(t, domain_order_core.OrderStatusPaid, order.Status)
+}
+
+// TestPaymentNotifyService_HandleNotification_AmountMismatch 通知金额与支付单不一致时拒绝
+func TestPaymentNotifyService_HandleNotification_AmountMismatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockNotifier := mocks.NewMockPaymentNotifier(ctrl)
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	mockPaymentRepo := mocks.NewMockRepository(ctrl)
+	paymentService := NewPaymentService(domain_payment_core.NewPaymentDomainService(mockPaymentRepo, nil), mocks.NewMockPaymentProxy(ctrl))
+	service := NewPaymentNotifyService(mockNotifier, paymentService, domain_order_core.NewOrderDomainService(mockOrderRepo), mocks.NewMockDiscrepancyRepository(ctrl), event.NewEventBus())
+
+	paymentDO := newTestPayment(domain_payment_core.PaymentStatusPending)
+	mockNotifier.EXPECT().ParseNotification(gomock.Any(), gomock.Any()).Return(&payment.PaymentNotification{
+		OrderID:       "order_123",
+		TransactionID: "trade_no_1",
+		Status:        domain_payment_core.PaymentStatusCompleted,
+		Amount:        dmoney.New(1, dmoney.CNY),
+	}, nil)
+	mockPaymentRepo.EXPECT().FindByOrderID(gomock.Any(), "order_123").Return(paymentDO, nil)
+
+	err := service.HandleNotification(context.Background(), url.Values{})
+
+	assert.ErrorIs(t, err, domain_payment_core.ErrPaymentAmountMismatch)
+	assert.Equal(t, domain_payment_core.PaymentStatusPending, paymentDO.Status)
+}
+
+// TestPaymentNotifyService_HandleNotification_Closed 交易关闭时支付单标记失败，订单保持待支付
+func TestPaymentNotifyService_HandleNotification_Closed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockNotifier := mocks.NewMockPaymentNotifier(ctrl)
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	mockPaymentRepo := mocks.NewMockRepository(ctrl)
+	paymentService := NewPaymentService(domain_payment_core.NewPaymentDomainService(mockPaymentRepo, nil), mocks.NewMockPaymentProxy(ctrl))
+	service := NewPaymentNotifyService(mockNotifier, paymentService, domain_order_core.NewOrderDomainService(mockOrderRepo), mocks.NewMockDiscrepancyRepository(ctrl), event.NewEventBus())
+
+	paymentDO := newTestPayment(domain_payment_core.PaymentStatusPending)
+	mockNotifier.EXPECT().ParseNotification(gomock.Any(), gomock.Any()).Return(&payment.PaymentNotification{
+		OrderID:       "order_123",
+		TransactionID: "trade_no_1",
+		Status:        domain_payment_core.PaymentStatusClosed,
+		Amount:        dmoney.New(1000, dmoney.CNY),
+	}, nil)
+	mockPaymentRepo.EXPECT().FindByOrderID(gomock.Any(), "order_123").Return(paymentDO, nil)
+	mockPaymentRepo.EXPECT().FindByID(gomock.Any(), "pay_123").Return(paymentDO, nil)
+	mockPaymentRepo.EXPECT().Save(gomock.Any(), paymentDO).Return(nil)
+
+	err := service.HandleNotification(context.Background(), url.Values{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain_payment_core.PaymentStatusFailed, paymentDO.Status)
+}
+
+// TestPaymentNotifyService_HandleNotification_PaidAfterClosed 支付单已关闭或订单已取消后收到支付成功，记录差异并应答成功
+func TestPaymentNotifyService_HandleNotification_PaidAfterClosed(t *testing.T) {
+	cases := []struct {
+		name          string
+		paymentStatus domain_payment_core.PaymentStatus
+		orderStatus   domain_order_core.OrderStatus
+		detail        string
+	}{
+		{name: "支付单已过期", paymentStatus: domain_payment_core.PaymentStatusExpired, orderStatus: domain_order_core.OrderStatusCreated, detail: "支付单状态为"},
+		{name: "订单已取消", paymentStatus: domain_payment_core.PaymentStatusPending, orderStatus: domain_order_core.OrderStatusCancelled, detail: "订单状态为"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockNotifier := mocks.NewMockPaymentNotifier(ctrl)
+			mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+			mockPaymentRepo := mocks.NewMockRepository(ctrl)
+			mockDiscrepancyRepo := mocks.NewMockDiscrepancyRepository(ctrl)
+			paymentService := NewPaymentService(domain_payment_core.NewPaymentDomainService(mockPaymentRepo, nil), mocks.NewMockPaymentProxy(ctrl))
+			service := NewPaymentNotifyService(mockNotifier, paymentService, domain_order_core.NewOrderDomainService(mockOrderRepo), mockDiscrepancyRepo, event.NewEventBus())
+
+			order := newTestOrder(c.orderStatus)
+			paymentDO := newTestPayment(c.paymentStatus)
+			mockNotifier.EXPECT().ParseNotification(gomock.Any(), gomock.Any()).Return(&payment.PaymentNotification{
+				OrderID:       "order_123",
+				TransactionID: "trade_no_1",
+				Status:        domain_payment_core.PaymentStatusCompleted,
+				Amount:        dmoney.New(1000, dmoney.CNY),
+			}, nil).Times(2)
+			mockPaymentRepo.EXPECT().FindByOrderID(gomock.Any(), "order_123").Return(paymentDO, nil).AnyTimes()
+			mockPaymentRepo.EXPECT().FindByID(gomock.Any(), "pay_123").Return(paymentDO, nil).AnyTimes()
+			mockPaymentRepo.EXPECT().Save(gomock.Any(), paymentDO).Return(nil).AnyTimes()
+			mockOrderRepo.EXPECT().FindByID(gomock.Any(), "order_123").Return(order, nil).AnyTimes()
+			var ids []string
+			mockDiscrepancyRepo.EXPECT().Append(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, discrepancies []*domain_reconciliation_core.DiscrepancyDO) error {
+				d := discrepancies[0]
+				assert.Equal(t, domain_reconciliation_core.DiscrepancySourceNotification, d.Source)
+				assert.Equal(t, domain_reconciliation_core.DiscrepancyTypeOrderConflict, d.Type)
+				assert.Equal(t, "trade_no_1", d.TransactionID)
+				assert.Contains(t, d.Detail, c.detail)
+				ids = append(ids, d.ID)
+				return nil
+			}).Times(2)
+
+			// 渠道重复通知时差异ID不变
+			assert.NoError(t, service.HandleNotification(context.Background(), url.Values{}))
+			assert.NoError(t, service.HandleNotification(context.Background(), url.Values{}))
+			assert.Equal(t, ids[0], ids[1])
+			assert.Equal(t, c.orderStatus, order.Status)
+		})
+	}
+}
+
+// TestPaymentNotifyService_HandleNotification_RecordDiscrepancyFailed 差异写入失败时返回错误，由渠道重试通知
+func TestPaymentNotifyService_HandleNotification_RecordDiscrepancyFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockNotifier := mocks.NewMockPaymentNotifier(ctrl)
+	mockPaymentRepo := mocks.NewMockRepository(ctrl)
+	mockDiscrepancyRepo := mocks.NewMockDiscrepancyRepository(ctrl)
+	paymentService := NewPaymentService(domain_payment_core.NewPaymentDomainService(mockPaymentRepo, nil), mocks.NewMockPaymentProxy(ctrl))
+	service := NewPaymentNotifyService(mockNotifier, paymentService, domain_order_core.NewOrderDomainService(mocks.NewMockOrderRepository(ctrl)), mockDiscrepancyRepo, event.NewEventBus())
+
+	mockNotifier.EXPECT().ParseNotification(gomock.Any(), gomock.Any()).Return(&payment.PaymentNotification{
+		OrderID:       "order_123",
+		TransactionID: "trade_no_1",
+		Status:        domain_payment_core.PaymentStatusCompleted,
+		Amount:        dmoney.New(1000, dmoney.CNY),
+	}, nil)
+	mockPaymentRepo.EXPECT().FindByOrderID(gomock.Any(), "order_123").Return(newTestPayment(domain_payment_core.PaymentStatusExpired), nil)
+	mockDiscrepancyRepo.EXPECT().Append(gomock.Any(), gomock.Any()).Return(errors.New("db down"))
+
+	err := service.HandleNotification(context.Background(), url.Values{})
+
+	assert.ErrorContains(t, err, "记录支付通知差异失败")
+}
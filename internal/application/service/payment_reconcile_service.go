@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_order_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_reconciliation_core"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/payment"
+	"github.com/vaynedu/ddd_order_example/internal/shared/event"
+)
+
+// ReconcileResult 一次支付状态对账的处理结果
+type ReconcileResult struct {
+	Checked       int // 查询渠道的支付单数量
+	Paid          int // 按渠道结果更新为支付成功的数量
+	Failed        int // 按渠道结果更新为支付失败的数量
+	Discrepancies int // 记录的差异数量
+}
+
+type PaymentReconcileService struct {
+	paymentService     *PaymentService                                  // 依赖支付应用服务
+	orderDomainService domain_order_core.OrderDomainService             // 依赖订单领域服务
+	discrepancyRepo    domain_reconciliation_core.DiscrepancyRepository // 依赖对账差异仓储
+	dispatcher         event.Dispatcher                                 // 依赖领域事件分发
+}
+
+func NewPaymentReconcileService(
+	paymentService *PaymentService,
+	orderDomainService domain_order_core.OrderDomainService,
+	discrepancyRepo domain_reconciliation_core.DiscrepancyRepository,
+	dispatcher event.Dispatcher,
+) *PaymentReconcileService {
+	return &PaymentReconcileService{
+		paymentService:     paymentService,
+		orderDomainService: orderDomainService,
+		discrepancyRepo:    discrepancyRepo,
+		dispatcher:         dispatcher,
+	}
+}
+
+// ReconcilePayments 对更新时间早于 updatedBefore 仍未确定结果的支付单逐一查询渠道，按渠道结果更新支付单和订单
+// 单笔支付单处理失败只记录日志，不影响其他支付单，下一轮对账会再次处理
+func (s *PaymentReconcileService) ReconcilePayments(ctx context.Context, updatedBefore time.Time, batchSize int) (*ReconcileResult, error) {
+	result := &ReconcileResult{}
+	var cursor *domain_payment_core.PaymentCursor
+	for {
+		page, err := s.paymentService.FindUnsettledPayments(ctx, updatedBefore, cursor, batchSize)
+		if err != nil {
+			return result, fmt.Errorf("查询待对账支付单失败: %w", err)
+		}
+
+		for _, paymentDO := range page.Payments {
+			if err := s.reconcilePayment(ctx, paymentDO, result); err != nil {
+				slog.Error("支付单对账失败", "payment_id", paymentDO.ID, "error", err)
+			}
+		}
+
+		if page.NextCursor == nil || ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// reconcilePayment 查询单笔支付单的渠道交易并应用
+func (s *PaymentReconcileService) reconcilePayment(ctx context.Context, paymentDO *domain_payment_core.PaymentDO, result *ReconcileResult) error {
+	// 1. 查询渠道交易
+	trade, err := s.paymentService.QueryChannelTrade(ctx, paymentDO)
+	if errors.Is(err, payment.ErrTradeNotExist) {
+		// 用户尚未扫码，是否过期由订单超时任务按支付时限处理
+		result.Checked++
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("查询渠道支付状态失败: %w", err)
+	}
+	result.Checked++
+
+	switch trade.Status {
+	case domain_payment_core.PaymentStatusPaid, domain_payment_core.PaymentStatusCompleted:
+		// 2. 渠道交易金额与支付单不一致时不更新支付结果，记录差异等待人工核查
+		if !trade.Amount.Equal(paymentDO.Money()) {
+			s.recordDiscrepancy(ctx, domain_reconciliation_core.DiscrepancyTypeAmountMismatch, paymentDO, trade,
+				fmt.Sprintf("渠道已支付，但交易金额%s %s与支付单金额%s %s不一致", trade.Amount, trade.Amount.Currency(), paymentDO.Money(), paymentDO.Currency), result)
+			return nil
+		}
+
+		// 3. 渠道已支付但本地未收到通知，按渠道结果修正并记录差异，渠道交易号取自查询结果
+		s.recordDiscrepancy(ctx, domain_reconciliation_core.DiscrepancyTypeStatusMismatch, paymentDO, trade,
+			"渠道已支付，本地未收到支付结果，已按渠道结果更新", result)
+		if err := s.paymentService.ProcessPaymentResult(ctx, paymentDO.ID, trade.TransactionID, true); err != nil {
+			return fmt.Errorf("更新支付结果失败: %w", err)
+		}
+		result.Paid++
+
+		err := markOrderPaid(ctx, s.orderDomainService, s.dispatcher, paymentDO.OrderID)
+		var illegal *domain_order_core.ErrIllegalTransition
+		if errors.As(err, &illegal) {
+			s.recordDiscrepancy(ctx, domain_reconciliation_core.DiscrepancyTypeOrderConflict, paymentDO, trade,
+				fmt.Sprintf("渠道已支付，但订单状态为%s，需人工退款", domain_order_core.GetOrderStatusDetail(illegal.From)), result)
+		}
+		return err
+	case domain_payment_core.PaymentStatusFailed, domain_payment_core.PaymentStatusClosed,
+		domain_payment_core.PaymentStatusExpired, domain_payment_core.PaymentStatusCanceled:
+		// 4. 渠道支付失败或交易关闭，支付单标记失败，订单取消
+		if err := s.paymentService.ProcessPaymentResult(ctx, paymentDO.ID, trade.TransactionID, false); err != nil {
+			return fmt.Errorf("更新支付结果失败: %w", err)
+		}
+		result.Failed++
+		return s.cancelUnpaidOrder(ctx, paymentDO.OrderID)
+	default:
+		// 渠道侧仍在等待支付，下一轮再查
+		return nil
+	}
+}
+
+// cancelUnpaidOrder 支付失败后取消仍未支付的订单，订单已处于其他状态时不处理
+func (s *PaymentReconcileService) cancelUnpaidOrder(ctx context.Context, orderID string) error {
+	orderDO, err := s.orderDomainService.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	if orderDO.Status != domain_order_core.OrderStatusCreated && orderDO.Status != domain_order_core.OrderStatusPending {
+		return nil
+	}
+
+	if err := orderDO.Cancel(); err != nil {
+		return err
+	}
+	if err := s.orderDomainService.UpdateOrder(ctx, orderDO); err != nil {
+		return fmt.Errorf("保存订单状态失败: %w", err)
+	}
+	dispatchOrderEvents(ctx, s.dispatcher, orderDO)
+	return nil
+}
+
+// recordDiscrepancy 记录对账差异，写入失败只记录日志，不影响对账结果
+func (s *PaymentReconcileService) recordDiscrepancy(ctx context.Context, typ domain_reconciliation_core.DiscrepancyType, paymentDO *domain_payment_core.PaymentDO, trade *domain_payment_core.ChannelTrade, detail string, result *ReconcileResult) {
+	discrepancy := domain_reconciliation_core.NewPaymentDiscrepancy(domain_reconciliation_core.DiscrepancySourceGatewayQuery, typ, paymentDO, trade, detail)
+	if err := s.discrepancyRepo.Save(ctx, discrepancy); err != nil {
+		slog.Error("记录支付单对账差异失败", "payment_id", paymentDO.ID, "error", err)
+		return
+	}
+	result.Discrepancies++
+}
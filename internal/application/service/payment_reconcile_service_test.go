@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_order_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_reconciliation_core"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/mocks"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/payment"
+	"github.com/vaynedu/ddd_order_example/internal/shared/event"
+	"github.com/vaynedu/ddd_order_example/pkg/dmoney"
+	"go.uber.org/mock/gomock"
+)
+
+// TestPaymentReconcileService_ReconcilePayments_Paid 渠道已支付时更新支付单和订单，并记录差异
+func TestPaymentReconcileService_ReconcilePayments_Paid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	mockPaymentRepo := mocks.NewMockRepository(ctrl)
+	mockProxy := mocks.NewMockPaymentProxy(ctrl)
+	mockDiscrepancyRepo := mocks.NewMockDiscrepancyRepository(ctrl)
+	paymentService := NewPaymentService(domain_payment_core.NewPaymentDomainService(mockPaymentRepo, nil), mockProxy)
+	service := NewPaymentReconcileService(paymentService, domain_order_core.NewOrderDomainService(mockOrderRepo), mockDiscrepancyRepo, event.NewEventBus())
+
+	order := newTestOrder(domain_order_core.OrderStatusPending)
+	paymentDO := newTestPayment(domain_payment_core.PaymentStatusPending)
+	mockPaymentRepo.EXPECT().FindByStatuses(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), 100).
+		Return(&domain_payment_core.PaymentPage{Payments: []*domain_payment_core.PaymentDO{paymentDO}}, nil)
+	mockProxy.EXPECT().QueryPaymentStatus(gomock.Any(), "order_123").Return(newTestChannelTrade(domain_payment_core.PaymentStatusCompleted), nil)
+	var discrepancy *domain_reconciliation_core.DiscrepancyDO
+	mockDiscrepancyRepo.EXPECT().Save(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, d *domain_reconciliation_core.DiscrepancyDO) error {
+		discrepancy = d
+		return nil
+	})
+	mockPaymentRepo.EXPECT().FindByID(gomock.Any(), "pay_123").Return(paymentDO, nil)
+	mockPaymentRepo.EXPECT().Save(gomock.Any(), paymentDO).Return(nil)
+	mockOrderRepo.EXPECT().FindByID(gomock.Any(), "order_123").Return(order, nil)
+	mockOrderRepo.EXPECT().Save(gomock.Any(), order).Return(nil)
+
+	result, err := service.ReconcilePayments(context.Background(), time.Now(), 100)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &ReconcileResult{Checked: 1, Paid: 1, Discrepancies: 1}, result)
+	assert.Equal(t, domain_payment_core.PaymentStatusCompleted, paymentDO.Status)
+	assert.Equal(t, domain_order_core.OrderStatusPaid, order.Status)
+	assert.Equal(t, domain_reconciliation_core.DiscrepancyTypeStatusMismatch, discrepancy.Type)
+	assert.Equal(t, "待支付", discrepancy.LocalStatus)
+	// 未收到通知的支付单本地没有渠道交易号，取自渠道查询结果
+	assert.Equal(t, "trade_123", paymentDO.TransactionID)
+	assert.Equal(t, "trade_123", discrepancy.TransactionID)
+	assert.Equal(t, int64(1000), discrepancy.RemoteAmount)
+}
+
+// TestPaymentReconcileService_ReconcilePayments_AmountMismatch 渠道交易金额与支付单不一致时只记录差异，不更新支付结果
+func TestPaymentReconcileService_ReconcilePayments_AmountMismatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPaymentRepo := mocks.NewMockRepository(ctrl)
+	mockProxy := mocks.NewMockPaymentProxy(ctrl)
+	mockDiscrepancyRepo := mocks.NewMockDiscrepancyRepository(ctrl)
+	paymentService := NewPaymentService(domain_payment_core.NewPaymentDomainService(mockPaymentRepo, nil), mockProxy)
+	service := NewPaymentReconcileService(paymentService, domain_order_core.NewOrderDomainService(mocks.NewMockOrderRepository(ctrl)), mockDiscrepancyRepo, event.NewEventBus())
+
+	paymentDO := newTestPayment(domain_payment_core.PaymentStatusPending)
+	trade := newTestChannelTrade(domain_payment_core.PaymentStatusCompleted)
+	trade.Amount = dmoney.New(900, dmoney.CNY)
+	mockPaymentRepo.EXPECT().FindByStatuses(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), 100).
+		Return(&domain_payment_core.PaymentPage{Payments: []*domain_payment_core.PaymentDO{paymentDO}}, nil)
+	mockProxy.EXPECT().QueryPaymentStatus(gomock.Any(), "order_123").Return(trade, nil)
+	var discrepancy *domain_reconciliation_core.DiscrepancyDO
+	mockDiscrepancyRepo.EXPECT().Save(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, d *domain_reconciliation_core.DiscrepancyDO) error {
+		discrepancy = d
+		return nil
+	})
+
+	result, err := service.ReconcilePayments(context.Background(), time.Now(), 100)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &ReconcileResult{Checked: 1, Discrepancies: 1}, result)
+	assert.Equal(t, domain_payment_core.PaymentStatusPending, paymentDO.Status)
+	assert.Equal(t, domain_reconciliation_core.DiscrepancyTypeAmountMismatch, discrepancy.Type)
+	assert.Equal(t, int64(1000), discrepancy.LocalAmount)
+	assert.Equal(t, int64(900), discrepancy.RemoteAmount)
+	assert.Equal(t, "trade_123", discrepancy.TransactionID)
+}
+
+// TestPaymentReconcileService_ReconcilePayments_OrderConflict 渠道已支付但订单已取消，记录需人工退款的差异
+func TestPaymentReconcileService_ReconcilePayments_OrderConflict(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	mockPaymentRepo := mocks.NewMockRepository(ctrl)
+	mockProxy := mocks.NewMockPaymentProxy(ctrl)
+	mockDiscrepancyRepo := mocks.NewMockDiscrepancyRepository(ctrl)
+	paymentService := NewPaymentService(domain_payment_core.NewPaymentDomainService(mockPaymentRepo, nil), mockProxy)
+	service := NewPaymentReconcileService(paymentService, domain_order_core.NewOrderDomainService(mockOrderRepo), mockDiscrepancyRepo, event.NewEventBus())
+
+	order := newTestOrder(domain_order_core.OrderStatusCancelled)
+	paymentDO := newTestPayment(domain_payment_core.PaymentStatusPending)
+	mockPaymentRepo.EXPECT().FindByStatuses(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), 100).
+		Return(&domain_payment_core.PaymentPage{Payments: []*domain_payment_core.PaymentDO{paymentDO}}, nil)
+	mockProxy.EXPECT().QueryPaymentStatus(gomock.Any(), "order_123").Return(newTestChannelTrade(domain_payment_core.PaymentStatusCompleted), nil)
+	var discrepancies []*domain_reconciliation_core.DiscrepancyDO
+	mockDiscrepancyRepo.EXPECT().Save(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, d *domain_reconciliation_core.DiscrepancyDO) error {
+		discrepancies = append(discrepancies, d)
+		return nil
+	}).Times(2)
+	mockPaymentRepo.EXPECT().FindByID(gomock.Any(), "pay_123").Return(paymentDO, nil)
+	mockPaymentRepo.EXPECT().Save(gomock.Any(), paymentDO).Return(nil)
+	mockOrderRepo.EXPECT().FindByID(gomock.Any(), "order_123").Return(order, nil)
+
+	result, err := service.ReconcilePayments(context.Background(), time.Now(), 100)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Discrepancies)
+	assert.Equal(t, domain_order_core.OrderStatusCancelled, order.Status)
+	assert.Equal(t, domain_reconciliation_core.DiscrepancyTypeOrderConflict, discrepancies[1].Type)
+}
+
+// TestPaymentReconcileService_ReconcilePayments_Closed 渠道交易关闭时支付单标记失败并取消订单
+func TestPaymentReconcileService_ReconcilePayments_Closed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	mockPaymentRepo := mocks.NewMockRepository(ctrl)
+	mockProxy := mocks.NewMockPaymentProxy(ctrl)
+	paymentService := NewPaymentService(domain_payment_core.NewPaymentDomainService(mockPaymentRepo, nil), mockProxy)
+	service := NewPaymentReconcileService(paymentService, domain_order_core.NewOrderDomainService(mockOrderRepo), mocks.NewMockDiscrepancyRepository(ctrl), event.NewEventBus())
+
+	order := newTestOrder(domain_order_core.OrderStatusPending)
+	paymentDO := newTestPayment(domain_payment_core.PaymentStatusPending)
+	mockPaymentRepo.EXPECT().FindByStatuses(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), 100).
+		Return(&domain_payment_core.PaymentPage{Payments: []*domain_payment_core.PaymentDO{paymentDO}}, nil)
+	mockProxy.EXPECT().QueryPaymentStatus(gomock.Any(), "order_123").Return(newTestChannelTrade(domain_payment_core.PaymentStatusClosed), nil)
+	mockPaymentRepo.EXPECT().FindByID(gomock.Any(), "pay_123").Return(paymentDO, nil)
+	mockPaymentRepo.EXPECT().Save(gomock.Any(), paymentDO).Return(nil)
+	mockOrderRepo.EXPECT().FindByID(gomock.Any(), "order_123").Return(order, nil)
+	mockOrderRepo.EXPECT().Save(gomock.Any(), order).Return(nil)
+
+	result, err := service.ReconcilePayments(context.Background(), time.Now(), 100)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &ReconcileResult{Checked: 1, Failed: 1}, result)
+	assert.Equal(t, domain_payment_core.PaymentStatusFailed, paymentDO.Status)
+	assert.Equal(t, domain_order_core.OrderStatusCancelled, order.Status)
+}
+
+// TestPaymentReconcileService_ReconcilePayments_Unchanged 渠道仍待支付、交易不存在或查询失败时不做修改
+func TestPaymentReconcileService_ReconcilePayments_Unchanged(t *testing.T) {
+	cases := []struct {
+		name    string
+		trade   *domain_payment_core.ChannelTrade
+		err     error
+		checked int
+	}{
+		{name: "渠道待支付", trade: newTestChannelTrade(domain_payment_core.PaymentStatusPending), checked: 1},
+		{name: "渠道交易不存在", err: payment.ErrTradeNotExist, checked: 1},
+		{name: "渠道查询失败", err: errors.New("gateway timeout")},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockPaymentRepo := mocks.NewMockRepository(ctrl)
+			mockProxy := mocks.NewMockPaymentProxy(ctrl)
+			paymentService := NewPaymentService(domain_payment_core.NewPaymentDomainService(mockPaymentRepo, nil), mockProxy)
+			service := NewPaymentReconcileService(paymentService, domain_order_core.NewOrderDomainService(mocks.NewMockOrderRepository(ctrl)), mocks.NewMockDiscrepancyRepository(ctrl), event.NewEventBus())
+
+			paymentDO := newTestPayment(domain_payment_core.PaymentStatusPending)
+			mockPaymentRepo.EXPECT().FindByStatuses(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), 100).
+				Return(&domain_payment_core.PaymentPage{Payments: []*domain_payment_core.PaymentDO{paymentDO}}, nil)
+			mockProxy.EXPECT().QueryPaymentStatus(gomock.Any(), "order_123").Return(c.trade, c.err)
+
+			result, err := service.ReconcilePayments(context.Background(), time.Now(), 100)
+
+			assert.NoError(t, err)
+			assert.Equal(t, &ReconcileResult{Checked: c.checked}, result)
+			assert.Equal(t, domain_payment_core.PaymentStatusPending, paymentDO.Status)
+		})
+	}
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
 	"github.com/vaynedu/ddd_order_example/internal/infrastructure/payment"
+	"github.com/vaynedu/ddd_order_example/pkg/dmoney"
 	"gorm.io/gorm"
 )
 
@@ -23,23 +25,87 @@ func NewPaymentService(domainService *domain_payment_core.PaymentDomainService,
 }
 
 // 创建支付请求 
-func (s *PaymentService) CreatePayment(ctx context.Context, orderID string, amount int64, currency string, channel int) (string, error) {
+// orderAmount 为订单币种的金额，渠道实际收取的是换算后的支付金额
+func (s *PaymentService) CreatePayment(ctx context.Context, orderID string, orderAmount dmoney.Money, channel int) (string, error) {
+	paymentID, err := s.InitiatePayment(ctx, orderID, orderAmount, channel)
+	if err != nil {
+		return "", err
+	}
+
+	// 渠道下单成功只代表支付已发起，支付结果以渠道异步通知为准
+	if err := s.MarkPaymentPending(ctx, paymentID); err != nil {
+		return "", fmt.Errorf("更新支付单为待支付失败: %w", err)
+	}
+
+	return paymentID, nil
+}
+
+// InitiatePayment 创建支付记录并向渠道下单，支付单保持已创建状态
+// 调用方可以在自己的工作单元中调用 MarkPaymentPending，与其他业务数据一起提交
+func (s *PaymentService) InitiatePayment(ctx context.Context, orderID string, orderAmount dmoney.Money, channel int) (string, error) {
 	// 1. 创建支付记录
-	paymentDO, err := s.domainService.CreatePayment(ctx, orderID, amount, currency, channel)
+	paymentDO, err := s.domainService.CreatePayment(ctx, orderID, orderAmount, channel)
 	if err != nil {
 		return "", err
 	}
 
 	// 2. 调用外部支付系统
-	transactionID, err := s.paymentProxy.CreatePayment(ctx, orderID, amount)
-	if err != nil {
+	if _, err := s.paymentProxy.CreatePayment(ctx, orderID, paymentDO.Money()); err != nil {
 		// 支付失败，更新支付状态
 		_ = s.domainService.ProcessPaymentResult(ctx, paymentDO.ID, "", false)
 		return "", err
 	}
 
-	// 3. 更新支付记录
-	return paymentDO.ID, s.domainService.ProcessPaymentResult(ctx, paymentDO.ID, transactionID, true)
+	return paymentDO.ID, nil
+}
+
+// RetryPayment 重新打开支付失败或已关闭的支付单并再次向渠道下单，支付单保持已创建状态
+func (s *PaymentService) RetryPayment(ctx context.Context, paymentID string) error {
+	paymentDO, err := s.domainService.ReopenPayment(ctx, paymentID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.paymentProxy.CreatePayment(ctx, paymentDO.OrderID, paymentDO.Money()); err != nil {
+		_ = s.domainService.ProcessPaymentResult(ctx, paymentDO.ID, "", false)
+		return err
+	}
+	return nil
+}
+
+// MarkPaymentPending 渠道下单成功，支付单进入待支付，重复调用幂等
+func (s *PaymentService) MarkPaymentPending(ctx context.Context, paymentID string) error {
+	return s.domainService.MarkPaymentPending(ctx, paymentID)
+}
+
+// ProcessPaymentResult 处理渠道支付结果，重复通知幂等
+func (s *PaymentService) ProcessPaymentResult(ctx context.Context, paymentID, transactionID string, success bool) error {
+	return s.domainService.ProcessPaymentResult(ctx, paymentID, transactionID, success)
+}
+
+// ExpirePayment 关闭超时未支付的支付单
+func (s *PaymentService) ExpirePayment(ctx context.Context, paymentID string) error {
+	return s.domainService.ExpirePayment(ctx, paymentID)
+}
+
+// QueryChannelTrade 向支付渠道查询支付单的实际交易，渠道侧以订单ID作为商户订单号
+func (s *PaymentService) QueryChannelTrade(ctx context.Context, paymentDO *domain_payment_core.PaymentDO) (*domain_payment_core.ChannelTrade, error) {
+	return s.paymentProxy.QueryPaymentStatus(ctx, paymentDO.OrderID)
+}
+
+// CloseChannelTrade 关闭支付单在支付渠道的交易，关闭后用户不能再支付
+func (s *PaymentService) CloseChannelTrade(ctx context.Context, paymentDO *domain_payment_core.PaymentDO) error {
+	return s.paymentProxy.CloseTrade(ctx, paymentDO.OrderID)
+}
+
+// FindUnsettledPayments 分页查询长时间未确定支付结果的支付单
+func (s *PaymentService) FindUnsettledPayments(ctx context.Context, updatedBefore time.Time, cursor *domain_payment_core.PaymentCursor, limit int) (*domain_payment_core.PaymentPage, error) {
+	return s.domainService.FindUnsettledPayments(ctx, updatedBefore, cursor, limit)
+}
+
+// FindCompletedPayments 查询指定渠道在 [from, to) 内支付完成的支付单
+func (s *PaymentService) FindCompletedPayments(ctx context.Context, channel domain_payment_core.PaymentChannel, from, to time.Time) ([]*domain_payment_core.PaymentDO, error) {
+	return s.domainService.FindCompletedPayments(ctx, channel, from, to)
 }
 
 // GetPaymentByOrderID 根据订单ID查询支付单
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_order_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/payment"
+	"github.com/vaynedu/ddd_order_example/internal/shared/event"
+	"github.com/vaynedu/ddd_order_example/pkg/dmoney"
+)
+
+// ErrRefundResultUnknown 调用渠道退款的结果未知，退款单保持退款中
+var ErrRefundResultUnknown = errors.New("退款结果未知，退款单保持退款中")
+
+type RefundService struct {
+	orderDomainService  domain_order_core.OrderDomainService     // 依赖订单领域服务
+	paymentService      *PaymentService                          // 依赖支付应用服务
+	refundDomainService *domain_payment_core.RefundDomainService // 依赖退款领域服务
+	paymentProxy        payment.PaymentProxy                     // 依赖支付代理
+	dispatcher          event.Dispatcher                         // 依赖领域事件分发
+}
+
+func NewRefundService(
+	orderDomainService domain_order_core.OrderDomainService,
+	paymentService *PaymentService,
+	refundDomainService *domain_payment_core.RefundDomainService,
+	paymentProxy payment.PaymentProxy,
+	dispatcher event.Dispatcher,
+) *RefundService {
+	return &RefundService{
+		orderDomainService:  orderDomainService,
+		paymentService:      paymentService,
+		refundDomainService: refundDomainService,
+		paymentProxy:        paymentProxy,
+		dispatcher:          dispatcher,
+	}
+}
+
+// RefundOrder 订单退款，amount 为0表示退回剩余全部可退金额
+// 全额退款且订单尚未发货时取消订单，部分退款不改变订单状态
+func (s *RefundService) RefundOrder(ctx context.Context, orderID string, amount int64, reason string) (*domain_payment_core.RefundDO, error) {
+	// 1. 获取订单
+	orderDO, err := s.orderDomainService.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. 业务规则检查：只有已支付的订单可以退款
+	switch orderDO.Status {
+	case domain_order_core.OrderStatusPaid, domain_order_core.OrderStatusShipped, domain_order_core.OrderStatusCompleted:
+	default:
+		return nil, fmt.Errorf("订单状态异常，当前状态: %s,无法退款", domain_order_core.GetOrderStatusDetail(orderDO.Status))
+	}
+
+	// 3. 获取支付单
+	paymentDO, err := s.paymentService.GetPaymentByOrderID(ctx, orderDO.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 4. 创建退款单，校验累计退款金额
+	refund, err := s.refundDomainService.CreateRefund(ctx, paymentDO.ID, amount, reason)
+	if err != nil {
+		return nil, fmt.Errorf("创建退款单失败: %w", err)
+	}
+
+	// 5. 调用外部支付系统退款
+	refundTransactionID, err := s.paymentProxy.Refund(ctx, orderDO.ID, refund.ID, dmoney.New(refund.Amount, paymentDO.Money().Currency()))
+	if err != nil {
+		if !errors.Is(err, payment.ErrRefundRejected) && !errors.Is(err, payment.ErrUnsupportedCurrency) {
+			// 网络超时等错误时渠道可能已受理退款，退款单保持退款中并继续占用可退金额，避免重复退款；
+			// 结果由对账单对账核对，渠道已退款时记录差异
+			return nil, fmt.Errorf("%w: %w", ErrRefundResultUnknown, err)
+		}
+		if _, _, procErr := s.refundDomainService.ProcessRefundResult(ctx, refund.ID, "", false); procErr != nil {
+			return nil, fmt.Errorf("发起退款失败: %w (更新退款单失败: %v)", err, procErr)
+		}
+		return nil, fmt.Errorf("发起退款失败: %w", err)
+	}
+
+	// 6. 更新退款单和支付单
+	refund, paymentDO, err = s.refundDomainService.ProcessRefundResult(ctx, refund.ID, refundTransactionID, true)
+	if err != nil {
+		return nil, fmt.Errorf("更新退款结果失败: %w", err)
+	}
+
+	// 7. 全额退款的已支付订单流转为已取消
+	if paymentDO.IsFullyRefunded() && orderDO.Status == domain_order_core.OrderStatusPaid {
+		if err := orderDO.Cancel(); err != nil {
+			return nil, err
+		}
+		if err := s.orderDomainService.UpdateOrder(ctx, orderDO); err != nil {
+			return nil, fmt.Errorf("保存订单状态失败: %w", err)
+		}
+		dispatchOrderEvents(ctx, s.dispatcher, orderDO)
+	}
+
+	return refund, nil
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_order_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/memory"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/mocks"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/payment"
+	"github.com/vaynedu/ddd_order_example/internal/shared/event"
+	"github.com/vaynedu/ddd_order_example/pkg/dmoney"
+	"go.uber.org/mock/gomock"
+)
+
+// TestRefundService_RefundOrder_Partial 部分退款不改变订单状态
+func TestRefundService_RefundOrder_Partial(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// 退款累计金额依赖已保存的退款单，支付和退款仓储使用内存实现
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	mockProxy := mocks.NewMockPaymentProxy(ctrl)
+	paymentRepo := memory.NewPaymentRepository()
+	refundDomainService := domain_payment_core.NewRefundDomainService(paymentRepo, memory.NewRefundRepository(paymentRepo), memory.NewUnitOfWork())
+	paymentService := NewPaymentService(domain_payment_core.NewPaymentDomainService(paymentRepo, nil), mockProxy)
+	service := NewRefundService(domain_order_core.NewOrderDomainService(mockOrderRepo), paymentService, refundDomainService, mockProxy, event.NewEventBus())
+
+	ctx := context.Background()
+	order := newTestOrder(domain_order_core.OrderStatusPaid)
+	require.NoError(t, paymentRepo.Save(ctx, newTestPayment(domain_payment_core.PaymentStatusCompleted)))
+
+	mockOrderRepo.EXPECT().FindByID(gomock.Any(), "order_123").Return(order, nil)
+	mockProxy.EXPECT().Refund(gomock.Any(), "order_123", gomock.Any(), dmoney.New(400, dmoney.CNY)).Return("refund_tx_1", nil)
+
+	refund, err := service.RefundOrder(ctx, "order_123", 400, "少发一件")
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain_payment_core.RefundStatusSucceeded, refund.Status)
+	assert.Equal(t, "refund_tx_1", refund.RefundTransactionID)
+	payment, _ := paymentRepo.FindByID(ctx, "pay_123")
+	assert.Equal(t, domain_payment_core.PaymentStatusRefunded, payment.Status)
+	assert.Equal(t, domain_order_core.OrderStatusPaid, order.Status)
+}
+
+// TestRefundService_RefundOrder_FullCancelsOrder 多次部分退款累计到全额后取消订单
+func TestRefundService_RefundOrder_FullCancelsOrder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	mockProxy := mocks.NewMockPaymentProxy(ctrl)
+	paymentRepo := memory.NewPaymentRepository()
+	refundDomainService := domain_payment_core.NewRefundDomainService(paymentRepo, memory.NewRefundRepository(paymentRepo), memory.NewUnitOfWork())
+	paymentService := NewPaymentService(domain_payment_core.NewPaymentDomainService(paymentRepo, nil), mockProxy)
+	service := NewRefundService(domain_order_core.NewOrderDomainService(mockOrderRepo), paymentService, refundDomainService, mockProxy, event.NewEventBus())
+
+	ctx := context.Background()
+	order := newTestOrder(domain_order_core.OrderStatusPaid)
+	require.NoError(t, paymentRepo.Save(ctx, newTestPayment(domain_payment_core.PaymentStatusCompleted)))
+
+	mockOrderRepo.EXPECT().FindByID(gomock.Any(), "order_123").Return(order, nil).Times(3)
+	mockProxy.EXPECT().Refund(gomock.Any(), "order_123", gomock.Any(), gomock.Any()).Return("refund_tx", nil).Times(2)
+	mockOrderRepo.EXPECT().Save(gomock.Any(), order).Return(nil)
+
+	_, err := service.RefundOrder(ctx, "order_123", 400, "")
+	assert.NoError(t, err)
+
+	// 金额为0时退回剩余全部可退金额
+	refund, err := service.RefundOrder(ctx, "order_123", 0, "")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(600), refund.Amount)
+	payment, _ := paymentRepo.FindByID(ctx, "pay_123")
+	assert.Equal(t, domain_payment_core.PaymentStatusRefundedSuccess, payment.Status)
+	assert.Equal(t, domain_order_core.OrderStatusCancelled, order.Status)
+
+	// 订单已取消，不能继续退款
+	_, err = service.RefundOrder(ctx, "order_123", 1, "")
+	assert.Error(t, err)
+}
+
+// TestRefundService_RefundOrder_GatewayRejected 渠道拒绝退款时退款单标记失败，不占用可退金额
+func TestRefundService_RefundOrder_GatewayRejected(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	mockProxy := mocks.NewMockPaymentProxy(ctrl)
+	paymentRepo := memory.NewPaymentRepository()
+	refundRepo := memory.NewRefundRepository(paymentRepo)
+	refundDomainService := domain_payment_core.NewRefundDomainService(paymentRepo, refundRepo, memory.NewUnitOfWork())
+	paymentService := NewPaymentService(domain_payment_core.NewPaymentDomainService(paymentRepo, nil), mockProxy)
+	service := NewRefundService(domain_order_core.NewOrderDomainService(mockOrderRepo), paymentService, refundDomainService, mockProxy, event.NewEventBus())
+
+	ctx := context.Background()
+	order := newTestOrder(domain_order_core.OrderStatusPaid)
+	require.NoError(t, paymentRepo.Save(ctx, newTestPayment(domain_payment_core.PaymentStatusCompleted)))
+
+	mockOrderRepo.EXPECT().FindByID(gomock.Any(), "order_123").Return(order, nil)
+	mockProxy.EXPECT().Refund(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return("", fmt.Errorf("%w: 交易状态不合法", payment.ErrRefundRejected))
+
+	_, err := service.RefundOrder(ctx, "order_123", 1000, "")
+
+	assert.ErrorIs(t, err, payment.ErrRefundRejected)
+	refunds, _ := refundRepo.FindByPaymentID(ctx, "pay_123")
+	require.Len(t, refunds, 1)
+	assert.Equal(t, domain_payment_core.RefundStatusFailed, refunds[0].Status)
+	assert.False(t, refunds[0].OccupiesAmount())
+	payment, _ := paymentRepo.FindByID(ctx, "pay_123")
+	assert.Equal(t, domain_payment_core.PaymentStatusRefundFailed, payment.Status)
+	assert.Equal(t, domain_order_core.OrderStatusPaid, order.Status)
+}
+
+// TestRefundService_RefundOrder_GatewayUnknown 渠道退款结果未知时退款单保持退款中，继续占用可退金额
+func TestRefundService_RefundOrder_GatewayUnknown(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrderRepo := mocks.NewMockOrderRepository(ctrl)
+	mockProxy := mocks.NewMockPaymentProxy(ctrl)
+	paymentRepo := memory.NewPaymentRepository()
+	refundRepo := memory.NewRefundRepository(paymentRepo)
+	refundDomainService := domain_payment_core.NewRefundDomainService(paymentRepo, refundRepo, memory.NewUnitOfWork())
+	paymentService := NewPaymentService(domain_payment_core.NewPaymentDomainService(paymentRepo, nil), mockProxy)
+	service := NewRefundService(domain_order_core.NewOrderDomainService(mockOrderRepo), paymentService, refundDomainService, mockProxy, event.NewEventBus())
+
+	ctx := context.Background()
+	order := newTestOrder(domain_order_core.OrderStatusPaid)
+	require.NoError(t, paymentRepo.Save(ctx, newTestPayment(domain_payment_core.PaymentStatusCompleted)))
+
+	mockOrderRepo.EXPECT().FindByID(gomock.Any(), "order_123").Return(order, nil).Times(2)
+	mockProxy.EXPECT().Refund(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return("", errors.New("gateway timeout"))
+
+	_, err := service.RefundOrder(ctx, "order_123", 1000, "")
+
+	assert.ErrorIs(t, err, ErrRefundResultUnknown)
+	refunds, _ := refundRepo.FindByPaymentID(ctx, "pay_123")
+	require.Len(t, refunds, 1)
+	assert.Equal(t, domain_payment_core.RefundStatusProcessing, refunds[0].Status)
+	payment, _ := paymentRepo.FindByID(ctx, "pay_123")
+	assert.Equal(t, domain_payment_core.PaymentStatusRefunding, payment.Status)
+	assert.Equal(t, domain_order_core.OrderStatusPaid, order.Status)
+
+	// 退款中的金额仍被占用，不能重复退款
+	_, err = service.RefundOrder(ctx, "order_123", 1, "")
+	assert.ErrorIs(t, err, domain_payment_core.ErrRefundAmountExceeded)
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_reconciliation_core"
+)
+
+// ErrStatementParserNotFound 支付渠道未注册对账单解析器
+var ErrStatementParserNotFound = fmt.Errorf("支付渠道未注册对账单解析器")
+
+type StatementReconcileService struct {
+	parsers             map[domain_payment_core.PaymentChannel]domain_reconciliation_core.StatementParser // 按渠道注册的对账单解析器
+	paymentService      *PaymentService                                                                   // 依赖支付应用服务
+	refundDomainService *domain_payment_core.RefundDomainService                                          // 依赖退款领域服务
+	discrepancyRepo     domain_reconciliation_core.DiscrepancyRepository                                  // 依赖对账差异仓储
+}
+
+func NewStatementReconcileService(
+	parsers []domain_reconciliation_core.StatementParser,
+	paymentService *PaymentService,
+	refundDomainService *domain_payment_core.RefundDomainService,
+	discrepancyRepo domain_reconciliation_core.DiscrepancyRepository,
+) *StatementReconcileService {
+	s := &StatementReconcileService{
+		parsers:             make(map[domain_payment_core.PaymentChannel]domain_reconciliation_core.StatementParser, len(parsers)),
+		paymentService:      paymentService,
+		refundDomainService: refundDomainService,
+		discrepancyRepo:     discrepancyRepo,
+	}
+	for _, parser := range parsers {
+		s.parsers[parser.Channel()] = parser
+	}
+	return s
+}
+
+// ReconcileStatement 解析渠道日对账单，与本地账单日当天完成的支付和退款核对，差异落库后返回汇总
+// 差异ID由账单内容确定，同一账单重复对账不会产生重复记录
+func (s *StatementReconcileService) ReconcileStatement(ctx context.Context, channel domain_payment_core.PaymentChannel, billDate time.Time, r io.Reader) (*domain_reconciliation_core.StatementReport, error) {
+	parser, ok := s.parsers[channel]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrStatementParserNotFound, domain_payment_core.GetPaymentChannelDetail(channel))
+	}
+
+	// 1. 解析对账单
+	records, err := parser.Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("解析对账单失败: %w", err)
+	}
+
+	// 2. 查询账单日内本地完成的支付和退款
+	from := time.Date(billDate.Year(), billDate.Month(), billDate.Day(), 0, 0, 0, 0, billDate.Location())
+	to := from.AddDate(0, 0, 1)
+	payments, err := s.paymentService.FindCompletedPayments(ctx, channel, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("查询账单日支付单失败: %w", err)
+	}
+	refunds, err := s.refundDomainService.FindCompletedRefunds(ctx, channel, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("查询账单日退款单失败: %w", err)
+	}
+
+	// 3. 逐条核对并保存差异
+	report := domain_reconciliation_core.ReconcileStatement(channel, from, records, payments, refunds)
+	if err := s.discrepancyRepo.Append(ctx, report.Discrepancies); err != nil {
+		return nil, fmt.Errorf("保存对账差异失败: %w", err)
+	}
+	return report, nil
+}
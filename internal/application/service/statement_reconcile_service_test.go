@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_reconciliation_core"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+// stubStatementParser 返回固定明细的对账单解析器
+type stubStatementParser struct {
+	records []*domain_reconciliation_core.StatementRecord
+}
+
+func (p *stubStatementParser) Channel() domain_payment_core.PaymentChannel {
+	return domain_payment_core.PaymentChannelAlipay
+}
+
+func (p *stubStatementParser) Parse(r io.Reader) ([]*domain_reconciliation_core.StatementRecord, error) {
+	return p.records, nil
+}
+
+// TestStatementReconcileService_ReconcileStatement 按账单日查询本地记录，核对后批量保存差异
+func TestStatementReconcileService_ReconcileStatement(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPaymentRepo := mocks.NewMockRepository(ctrl)
+	mockRefundRepo := mocks.NewMockRefundRepository(ctrl)
+	mockDiscrepancyRepo := mocks.NewMockDiscrepancyRepository(ctrl)
+	parser := &stubStatementParser{records: []*domain_reconciliation_core.StatementRecord{
+		{Type: domain_reconciliation_core.StatementRecordPayment, TransactionID: "trade_1", OrderID: "order_1", Amount: 1000},
+		{Type: domain_reconciliation_core.StatementRecordPayment, TransactionID: "trade_2", OrderID: "order_2", Amount: 2000},
+	}}
+	service := NewStatementReconcileService(
+		[]domain_reconciliation_core.StatementParser{parser},
+		NewPaymentService(domain_payment_core.NewPaymentDomainService(mockPaymentRepo, nil), mocks.NewMockPaymentProxy(ctrl)),
+		domain_payment_core.NewRefundDomainService(mockPaymentRepo, mockRefundRepo, newTestUnitOfWork(ctrl)),
+		mockDiscrepancyRepo,
+	)
+
+	from := time.Date(2024, 6, 1, 0, 0, 0, 0, time.Local)
+	to := from.AddDate(0, 0, 1)
+	mockPaymentRepo.EXPECT().FindCompletedBetween(gomock.Any(), domain_payment_core.PaymentChannelAlipay, from, to).
+		Return([]*domain_payment_core.PaymentDO{{ID: "pay_1", OrderID: "order_1", TransactionID: "trade_1", Amount: 1000}}, nil)
+	mockRefundRepo.EXPECT().FindCompletedBetween(gomock.Any(), domain_payment_core.PaymentChannelAlipay, from, to).Return(nil, nil)
+	mockDiscrepancyRepo.EXPECT().Append(gomock.Any(), gomock.Len(1)).Return(nil)
+
+	report, err := service.ReconcileStatement(context.Background(), domain_payment_core.PaymentChannelAlipay, from.Add(15*time.Hour), strings.NewReader(""))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Matched)
+	assert.Equal(t, 1, report.MissingLocal)
+}
+
+// TestStatementReconcileService_ReconcileStatement_ParserNotFound 渠道未注册解析器时直接返回错误
+func TestStatementReconcileService_ReconcileStatement_ParserNotFound(t *testing.T) {
+	service := NewStatementReconcileService(nil, nil, nil, nil)
+
+	_, err := service.ReconcileStatement(context.Background(), domain_payment_core.PaymentChannelWechat, time.Now(), strings.NewReader(""))
+
+	assert.True(t, errors.Is(err, ErrStatementParserNotFound))
+}
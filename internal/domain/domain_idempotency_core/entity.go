@@ -0,0 +1,53 @@
+package domain_idempotency_core
+
+import "time"
+
+// TableName 指定模型对应的数据库表名
+func (IdempotencyRecordDO) TableName() string {
+	return "t_idempotency_key"
+}
+
+// IdempotencyRecordDO 幂等记录，记录幂等键与请求摘要、业务结果的对应关系
+type IdempotencyRecordDO struct {
+	Key         string            `json:"key" gorm:"column:idempotency_key;primaryKey"`
+	RequestHash string            `json:"request_hash" gorm:"column:request_hash"`
+	OrderID     string            `json:"order_id" gorm:"column:order_id"`
+	Response    string            `json:"response" gorm:"column:response"`
+	Status      IdempotencyStatus `json:"status" gorm:"column:status"`
+	CreatedAt   time.Time         `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt   time.Time         `json:"updated_at" gorm:"column:updated_at"`
+}
+
+// ProcessingLease 处理中记录的租约时长，持有者崩溃后超过租约的记录可以被相同请求接管
+const ProcessingLease = 30 * time.Second
+
+// IdempotencyStatus 幂等记录状态
+type IdempotencyStatus string
+
+const (
+	IdempotencyStatusProcessing IdempotencyStatus = "processing" // 处理中，已占用幂等键
+	IdempotencyStatusCompleted  IdempotencyStatus = "completed"  // 已完成，可直接回放响应
+)
+
+// MatchRequest 判断重放请求与首次请求是否一致
+func (r *IdempotencyRecordDO) MatchRequest(requestHash string) bool {
+	return r.RequestHash == requestHash
+}
+
+// IsCompleted 是否已完成
+func (r *IdempotencyRecordDO) IsCompleted() bool {
+	return r.Status == IdempotencyStatusCompleted
+}
+
+// LeaseExpired 处理中的记录是否已超过租约，更新时间即租约开始时间
+func (r *IdempotencyRecordDO) LeaseExpired(now time.Time) bool {
+	return !r.IsCompleted() && r.UpdatedAt.Before(now.Add(-ProcessingLease))
+}
+
+// Complete 记录业务结果
+func (r *IdempotencyRecordDO) Complete(orderID string, response []byte) {
+	r.OrderID = orderID
+	r.Response = string(response)
+	r.Status = IdempotencyStatusCompleted
+	r.UpdatedAt = time.Now()
+}
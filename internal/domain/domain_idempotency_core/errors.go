@@ -0,0 +1,12 @@
+package domain_idempotency_core
+
+import "errors"
+
+// 幂等领域错误定义
+var (
+	ErrIdempotencyKeyExists     = errors.New("idempotency key already exists")
+	ErrIdempotencyKeyNotFound   = errors.New("idempotency key not found")
+	ErrIdempotencyKeyConflict   = errors.New("幂等键已被不同的请求使用")
+	ErrIdempotencyKeyInProgress = errors.New("相同幂等键的请求正在处理中，请稍后重试")
+	ErrIdempotencyLeaseLost     = errors.New("幂等键的处理租约已被其他请求接管")
+)
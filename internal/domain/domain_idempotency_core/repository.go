@@ -0,0 +1,22 @@
+package domain_idempotency_core
+
+import (
+	"context"
+	"time"
+)
+
+// Repository 幂等记录仓储接口
+// 处理中记录的更新时间即租约开始时间，Complete 和 Release 只在租约未被接管时生效
+type Repository interface {
+	// Create 占用幂等键，键已存在时返回 ErrIdempotencyKeyExists
+	Create(ctx context.Context, record *IdempotencyRecordDO) error
+	// FindByKey 查询幂等记录，不存在时返回 ErrIdempotencyKeyNotFound
+	FindByKey(ctx context.Context, key string) (*IdempotencyRecordDO, error)
+	// TakeOver 接管更新时间早于 staleBefore 的处理中记录，把租约开始时间改为 now；
+	// 记录已完成或已被其他请求接管时返回 ErrIdempotencyKeyInProgress
+	TakeOver(ctx context.Context, key string, staleBefore, now time.Time) error
+	// Complete 保存业务结果，租约已被接管时返回 ErrIdempotencyLeaseLost
+	Complete(ctx context.Context, record *IdempotencyRecordDO, leasedAt time.Time) error
+	// Release 释放仍由本次租约持有的幂等键，已被接管时不做修改
+	Release(ctx context.Context, key string, leasedAt time.Time) error
+}
@@ -0,0 +1,10 @@
+package domain_inventory_core
+
+import "errors"
+
+// 库存领域错误定义
+var (
+	ErrInsufficientStock   = errors.New("库存不足")
+	ErrReservationNotFound = errors.New("库存预占记录不存在")
+	ErrReservationReleased = errors.New("库存预占已释放")
+)
@@ -0,0 +1,20 @@
+package domain_inventory_core
+
+import "context"
+
+// StockItem 需要预占库存的商品及数量
+type StockItem struct {
+	ProductID string
+	Quantity  int64
+}
+
+// InventoryService 库存服务抽象接口，所有操作都是幂等的，调用方失败后可以直接重试
+type InventoryService interface {
+	// ReserveStock 为订单预占库存，返回的预占记录与 items 按下标一一对应
+	// 任一商品库存不足时整体失败并返回 ErrInsufficientStock；同一订单重复预占返回已有的预占记录
+	ReserveStock(ctx context.Context, orderID string, items []StockItem) ([]*ReservationDO, error)
+	// ConfirmReservation 支付成功后确认预占，预占的库存正式扣减；已确认时直接返回
+	ConfirmReservation(ctx context.Context, reservationID string) error
+	// ReleaseReservation 订单取消后释放预占，库存归还可售；已释放时直接返回
+	ReleaseReservation(ctx context.Context, reservationID string) error
+}
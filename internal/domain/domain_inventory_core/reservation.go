@@ -0,0 +1,41 @@
+package domain_inventory_core
+
+import "time"
+
+// TableName 指定模型对应的数据库表名
+func (ReservationDO) TableName() string {
+	return "t_inventory_reservation"
+}
+
+// ReservationDO 库存预占记录，一个订单对同一商品只有一条预占
+type ReservationDO struct {
+	ID        string            `json:"id" gorm:"column:id;primaryKey"`
+	OrderID   string            `json:"order_id" gorm:"column:order_id"`
+	ProductID string            `json:"product_id" gorm:"column:product_id"`
+	Quantity  int64             `json:"quantity" gorm:"column:quantity"`
+	Status    ReservationStatus `json:"status" gorm:"column:status"`
+	CreatedAt time.Time         `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt time.Time         `json:"updated_at" gorm:"column:updated_at"`
+}
+
+// ReservationStatus 库存预占状态
+type ReservationStatus string
+
+const (
+	ReservationStatusReserved  ReservationStatus = "reserved"  // 已预占，库存从可售转为预占
+	ReservationStatusConfirmed ReservationStatus = "confirmed" // 支付成功后确认，预占库存扣减
+	ReservationStatusReleased  ReservationStatus = "released"  // 订单取消后释放，库存归还可售
+)
+
+func GetReservationStatusDetail(status ReservationStatus) string {
+	switch status {
+	case ReservationStatusReserved:
+		return "已预占"
+	case ReservationStatusConfirmed:
+		return "已确认"
+	case ReservationStatusReleased:
+		return "已释放"
+	default:
+		return "未知"
+	}
+}
@@ -2,8 +2,12 @@ package domain_order_core
 
 import (
 	"errors"
+	"fmt"
+	"math"
 	"time"
 
+	"github.com/vaynedu/ddd_order_example/internal/shared/event"
+	"github.com/vaynedu/ddd_order_example/pkg/dmoney"
 	"gorm.io/plugin/optimisticlock"
 )
 
@@ -20,11 +24,18 @@ type OrderDO struct {
 	CustomerID  string        `json:"customer_id" gorm:"column:customer_id"`
 	Items       []OrderItemDO `json:"items" gorm:"foreignKey:OrderID"`
 	Status      OrderStatus   `json:"status" gorm:"column:status"`
-	TotalAmount int64         `json:"total_amount" gorm:"column:total_amount"`
-	CreatedAt   time.Time     `json:"created_at" gorm:"column:created_at"`
-	UpdatedAt   time.Time     `json:"updated_at" gorm:"column:updated_at"`
+	Currency    string        `json:"currency" gorm:"column:currency"`         // 计价币种，订单项必须与订单币种一致
+	TotalAmount int64         `json:"total_amount" gorm:"column:total_amount"` // 应付金额，即商品小计之和减去优惠金额
+	// 优惠金额及使用的优惠券，各订单行分摊的优惠记录在 OrderItemDO.Discount
+	DiscountAmount int64     `json:"discount_amount" gorm:"column:discount_amount"`
+	CouponIDs      []string  `json:"coupon_ids" gorm:"column:coupon_ids;serializer:json"`
+	CreatedAt      time.Time `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt      time.Time `json:"updated_at" gorm:"column:updated_at"`
 	// Version     int64         `json:"version" gorm:"column:version;optimistic_lock"` // 乐观锁版本号
 	Version optimisticlock.Version `json:"version" gorm:"column:version;optimistic_lock"` // 乐观锁版本号
+
+	events   []event.Event  // 已记录尚未分发的领域事件，不持久化
+	snapshot *OrderSnapshot // 最近一次加载或保存时的持久化状态，不持久化
 }
 
 // OrderItemDOs 订单项集合
@@ -39,11 +50,95 @@ func (OrderItemDO) TableName() string {
 }
 
 type OrderItemDO struct {
+	ID        int64  `json:"-" gorm:"column:id;primaryKey"` // 订单行ID，未保存的订单行为0
 	OrderID   string `json:"order_id" gorm:"column:order_id"`
 	ProductID string `json:"product_id" gorm:"column:product_id"`
 	Quantity  int64  `json:"quantity" gorm:"column:quantity"`
+	Currency  string `json:"currency" gorm:"column:currency"`
 	UnitPrice int64  `json:"unit_price" gorm:"column:unit_price"`
 	Subtotal  int64  `json:"subtotal" gorm:"column:subtotal"`
+	Discount  int64  `json:"discount" gorm:"column:discount"` // 分摊到该订单行的优惠金额，部分退款时按比例计算可退金额
+	// 库存预占ID，支付成功后确认、订单取消后释放
+	ReservationID string `json:"reservation_id" gorm:"column:reservation_id"`
+}
+
+var (
+	// ErrMixedCurrency 订单项币种与订单币种不一致
+	ErrMixedCurrency = errors.New("订单商品币种不一致")
+	// ErrDiscountedItems 已使用优惠券的订单不能再修改商品，否则分摊到订单行的优惠会失效
+	ErrDiscountedItems = errors.New("订单已使用优惠券，不能修改商品")
+	// ErrReservedItems 已预占库存的订单不能再替换商品，否则订单行与库存预占对不上，取消时无法释放
+	ErrReservedItems = errors.New("订单已预占库存，不能修改商品")
+	// ErrOrderNotFound 订单不存在
+	ErrOrderNotFound = errors.New("订单不存在")
+	// ErrConcurrentModification 订单已被其他操作更新，保存时的版本号与数据库中的不一致
+	ErrConcurrentModification = errors.New("订单已被其他操作更新，请刷新后重试")
+)
+
+// CurrentVersion 订单当前版本号，未保存过的订单为0
+func (o *OrderDO) CurrentVersion() int64 {
+	return o.Version.Int64
+}
+
+// OrderCurrency 订单计价币种，金额字段均为该币种的最小单位；未指定时为默认币种
+func (o *OrderDO) OrderCurrency() dmoney.Currency {
+	return currencyOrDefault(o.Currency)
+}
+
+// Total 订单应付金额
+func (o *OrderDO) Total() dmoney.Money {
+	return dmoney.New(o.TotalAmount, o.OrderCurrency())
+}
+
+// Discount 订单优惠金额
+func (o *OrderDO) Discount() dmoney.Money {
+	return dmoney.New(o.DiscountAmount, o.OrderCurrency())
+}
+
+// ItemCurrency 订单项计价币种，未指定时为默认币种
+func (i OrderItemDO) ItemCurrency() dmoney.Currency {
+	return currencyOrDefault(i.Currency)
+}
+
+// UnitPriceMoney 商品单价
+func (i OrderItemDO) UnitPriceMoney() dmoney.Money {
+	return dmoney.New(i.UnitPrice, i.ItemCurrency())
+}
+
+// SubtotalMoney 商品小计
+func (i OrderItemDO) SubtotalMoney() dmoney.Money {
+	return dmoney.New(i.Subtotal, i.ItemCurrency())
+}
+
+// DiscountMoney 分摊到订单行的优惠金额
+func (i OrderItemDO) DiscountMoney() dmoney.Money {
+	return dmoney.New(i.Discount, i.ItemCurrency())
+}
+
+// PayableMoney 订单行应付金额，即小计减去分摊的优惠
+func (i OrderItemDO) PayableMoney() dmoney.Money {
+	return dmoney.New(i.Subtotal-i.Discount, i.ItemCurrency())
+}
+
+func currencyOrDefault(currency string) dmoney.Currency {
+	if currency == "" {
+		return dmoney.DefaultCurrency
+	}
+	return dmoney.Currency(currency)
+}
+
+// validateCurrency 校验订单币种受支持，且所有订单项与订单币种一致
+func (o *OrderDO) validateCurrency() error {
+	currency := o.OrderCurrency()
+	if !currency.IsValid() {
+		return fmt.Errorf("订单%w: %s", dmoney.ErrUnknownCurrency, currency)
+	}
+	for _, item := range o.Items {
+		if item.ItemCurrency() != currency {
+			return fmt.Errorf("%w: 商品[%s]币种为%s，订单币种为%s", ErrMixedCurrency, item.ProductID, item.ItemCurrency(), currency)
+		}
+	}
+	return nil
 }
 
 // OrderStatus 订单状态
@@ -88,7 +183,11 @@ func (o *OrderDO) Validate() error {
 		return errors.New("订单商品不能为空")
 	}
 
-	var calculatedTotal int64
+	if err := o.validateCurrency(); err != nil {
+		return err
+	}
+
+	calculatedTotal := dmoney.Zero(o.OrderCurrency())
 	for _, item := range o.Items {
 		if item.ProductID == "" {
 			return errors.New("商品ID不能为空")
@@ -102,23 +201,232 @@ func (o *OrderDO) Validate() error {
 			return errors.New("商品单价不能为负数")
 		}
 
-		calculatedTotal += item.Subtotal
+		subtotal, err := calculateSubtotal(item.UnitPriceMoney(), item.Quantity)
+		if err != nil {
+			return err
+		}
+		if item.Subtotal != subtotal {
+			return errors.New("商品小计与单价乘以数量不匹配")
+		}
+
+		if err := item.validateDiscount(); err != nil {
+			return err
+		}
+		if calculatedTotal, err = calculatedTotal.Add(item.PayableMoney()); err != nil {
+			return fmt.Errorf("订单总金额%w", err)
+		}
+	}
+
+	if !o.Total().Equal(calculatedTotal) {
+		return errors.New("订单总金额与商品应付金额之和不匹配")
+	}
+
+	return o.validateDiscountAmount()
+}
+
+// validateDiscount 订单行优惠不能为负数，也不能超过小计
+func (i OrderItemDO) validateDiscount() error {
+	if i.Discount < 0 || i.Discount > i.Subtotal {
+		return fmt.Errorf("商品[%s]优惠金额必须在0到小计之间", i.ProductID)
+	}
+	return nil
+}
+
+// validateDiscountAmount 订单优惠金额必须等于各订单行分摊的优惠之和
+func (o *OrderDO) validateDiscountAmount() error {
+	discount := dmoney.Zero(o.OrderCurrency())
+	for _, item := range o.Items {
+		var err error
+		if discount, err = discount.Add(item.DiscountMoney()); err != nil {
+			return fmt.Errorf("订单优惠金额%w", err)
+		}
+	}
+	if !o.Discount().Equal(discount) {
+		return errors.New("订单优惠金额与商品分摊优惠之和不匹配")
+	}
+	return nil
+}
+
+// AddItem 向订单添加商品行，单价为订单币种的最小单位，小计和总金额由服务端根据单价重新计算
+// 同一商品重复添加时合并数量，单价不一致则拒绝；已使用优惠券的订单不能再添加商品
+func (o *OrderDO) AddItem(productID string, quantity, unitPrice int64) error {
+	if o.hasDiscount() {
+		return ErrDiscountedItems
+	}
+	if productID == "" {
+		return errors.New("商品ID不能为空")
+	}
+	if quantity <= 0 {
+		return errors.New("商品数量必须大于0")
+	}
+	if unitPrice < 0 {
+		return errors.New("商品单价不能为负数")
+	}
+
+	for i := range o.Items {
+		item := &o.Items[i]
+		if item.ProductID != productID {
+			continue
+		}
+		if item.UnitPrice != unitPrice {
+			return fmt.Errorf("商品[%s]存在多个不同单价的订单行", productID)
+		}
+		if item.Quantity > math.MaxInt64-quantity {
+			return fmt.Errorf("商品[%s]数量溢出", productID)
+		}
+		subtotal, err := calculateSubtotal(item.UnitPriceMoney(), item.Quantity+quantity)
+		if err != nil {
+			return err
+		}
+		item.Quantity += quantity
+		item.Subtotal = subtotal
+		return o.CalculateTotalAmount()
+	}
+
+	currency := o.OrderCurrency()
+	subtotal, err := calculateSubtotal(dmoney.New(unitPrice, currency), quantity)
+	if err != nil {
+		return err
+	}
+	o.Items = append(o.Items, OrderItemDO{
+		OrderID:   o.ID,
+		ProductID: productID,
+		Quantity:  quantity,
+		Currency:  string(currency),
+		UnitPrice: unitPrice,
+		Subtotal:  subtotal,
+	})
+	return o.CalculateTotalAmount()
+}
+
+// ReplaceItems 整体替换订单商品并重新计算金额，已使用优惠券或已预占库存的订单不能修改商品
+// 同一商品沿用原订单行，保存时只更新变化的列
+func (o *OrderDO) ReplaceItems(items []OrderItemDO) error {
+	if o.hasDiscount() {
+		return ErrDiscountedItems
+	}
+	if o.hasReservation() {
+		return ErrReservedItems
+	}
+	used := make(map[int64]bool, len(o.Items))
+	for i := range items {
+		items[i].ID = 0
+		for _, old := range o.Items {
+			if old.ID != 0 && !used[old.ID] && old.ProductID == items[i].ProductID {
+				items[i].ID = old.ID
+				used[old.ID] = true
+				break
+			}
+		}
+	}
+	o.Items = items
+	return o.CalculateTotalAmount()
+}
+
+// ApplyDiscounts 记录优惠计算结果，discounts 与订单行一一对应，并重新计算应付金额
+func (o *OrderDO) ApplyDiscounts(discounts []int64, couponIDs []string) error {
+	if len(discounts) != len(o.Items) {
+		return fmt.Errorf("优惠分摊数量与订单行数量不匹配: 期望%d, 实际%d", len(o.Items), len(discounts))
+	}
+	// 先整体校验再写入，避免部分订单行的优惠生效
+	for i := range o.Items {
+		item := o.Items[i]
+		item.Discount = discounts[i]
+		if err := item.validateDiscount(); err != nil {
+			return err
+		}
+	}
+	for i := range o.Items {
+		o.Items[i].Discount = discounts[i]
 	}
+	o.CouponIDs = couponIDs
+	return o.CalculateTotalAmount()
+}
 
-	if o.TotalAmount != calculatedTotal {
-		return errors.New("订单总金额与商品小计之和不匹配")
+// ProratedRefundAmount 退回订单行部分商品时的可退金额，按数量比例分摊该行的应付金额
+// 按比例分摊时不能整除的部分计入本次退款，全部退回时等于该行应付金额
+func (o *OrderDO) ProratedRefundAmount(productID string, quantity int64) (dmoney.Money, error) {
+	for _, item := range o.Items {
+		if item.ProductID != productID {
+			continue
+		}
+		if quantity <= 0 || quantity > item.Quantity {
+			return dmoney.Money{}, fmt.Errorf("商品[%s]退货数量必须在1到%d之间", productID, item.Quantity)
+		}
+		shares, err := item.PayableMoney().Allocate(quantity, item.Quantity-quantity)
+		if err != nil {
+			return dmoney.Money{}, err
+		}
+		return shares[0], nil
 	}
+	return dmoney.Money{}, fmt.Errorf("订单中不存在商品[%s]", productID)
+}
 
+// AssignReservations 记录各订单行的库存预占ID，ids 与订单行一一对应
+func (o *OrderDO) AssignReservations(ids []string) error {
+	if len(ids) != len(o.Items) {
+		return fmt.Errorf("库存预占数量与订单行数量不匹配: 期望%d, 实际%d", len(o.Items), len(ids))
+	}
+	for i := range o.Items {
+		o.Items[i].ReservationID = ids[i]
+	}
 	return nil
 }
 
+// ReservationIDs 订单已预占库存的预占ID
+func (o *OrderDO) ReservationIDs() []string {
+	var ids []string
+	for _, item := range o.Items {
+		if item.ReservationID != "" {
+			ids = append(ids, item.ReservationID)
+		}
+	}
+	return ids
+}
+
+// hasDiscount 订单是否已使用优惠
+func (o *OrderDO) hasDiscount() bool {
+	if len(o.CouponIDs) > 0 || o.DiscountAmount != 0 {
+		return true
+	}
+	for _, item := range o.Items {
+		if item.Discount != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// hasReservation 是否有订单行已预占库存
+func (o *OrderDO) hasReservation() bool {
+	for _, item := range o.Items {
+		if item.ReservationID != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// calculateSubtotal 计算商品小计，防止乘法溢出
+func calculateSubtotal(unitPrice dmoney.Money, quantity int64) (int64, error) {
+	subtotal, err := unitPrice.Mul(quantity)
+	if err != nil {
+		return 0, fmt.Errorf("商品小计%w", err)
+	}
+	return subtotal.Amount(), nil
+}
+
 // ValidateUpdate 更新订单
 func (o *OrderDO) ValidateUpdate() error {
 	if o.ID == "" {
 		return errors.New("订单ID不能为空")
 	}
 
-	var calculatedTotal int64
+	if err := o.validateCurrency(); err != nil {
+		return err
+	}
+
+	calculatedTotal := dmoney.Zero(o.OrderCurrency())
 	for _, item := range o.Items {
 		if item.ProductID == "" {
 			return errors.New("商品ID不能为空")
@@ -132,68 +440,75 @@ func (o *OrderDO) ValidateUpdate() error {
 			return errors.New("商品单价不能为负数")
 		}
 
-		calculatedTotal += item.Subtotal
+		if err := item.validateDiscount(); err != nil {
+			return err
+		}
+		var err error
+		if calculatedTotal, err = calculatedTotal.Add(item.PayableMoney()); err != nil {
+			return fmt.Errorf("订单总金额%w", err)
+		}
 	}
 
-	if o.TotalAmount != calculatedTotal {
-		return errors.New("订单总金额与商品小计之和不匹配")
+	if !o.Total().Equal(calculatedTotal) {
+		return errors.New("订单总金额与商品应付金额之和不匹配")
 	}
 
-	return nil
+	return o.validateDiscountAmount()
 }
 
 // CanBeCancelled 检查订单是否可以被取消
 func (o *OrderDO) CanBeCancelled() bool {
-	return o.Status == OrderStatusCreated || o.Status == OrderStatusPaid
+	return o.CanTransitionTo(OrderStatusCancelled)
 }
 
 // Cancel 取消订单的行为方法
 func (o *OrderDO) Cancel() error {
-	if !o.CanBeCancelled() {
-		return errors.New("当前订单状态不允许取消")
-	}
-
-	o.Status = OrderStatusCancelled
-	o.UpdatedAt = time.Now()
-	return nil
+	return o.TransitionTo(OrderStatusCancelled)
 }
 
-// 标记订单为待支付状态
+// MarkAsPendingPayment 标记订单为待支付状态
 func (o *OrderDO) MarkAsPendingPayment() error {
-	// 状态验证：只能从CREATED状态转为PENDING_PAYMENT
-	if o.Status != OrderStatusCreated {
-		return errors.New("只有已创建的订单可以标记为待支付")
-	}
-
-	// 更新订单状态和支付ID
-	o.Status = OrderStatusPending
-	o.UpdatedAt = time.Now()
-
-	return nil
+	// 订单表没必要关联支付ID， 因为一个订单可能有多次支付
+	return o.TransitionTo(OrderStatusPending)
 }
 
-// MarkAsPaid 标记订单为待支付状态
+// MarkAsPaid 标记订单为已支付状态
 func (o *OrderDO) MarkAsPaid() error {
-	// 状态验证：只能从PENDING_PAYMENT状态转为PAID
-	if o.Status != OrderStatusPending {
-		return errors.New("只有待支付的订单可以标记为已支付")
-	}
+	return o.TransitionTo(OrderStatusPaid)
+}
 
-	// 验证是否有关联的支付ID
-	// 订单表没必要关联支付ID， 因为一个订单可能有多次支付
+// MarkAsShipped 标记订单为已发货状态
+func (o *OrderDO) MarkAsShipped() error {
+	return o.TransitionTo(OrderStatusShipped)
+}
 
-	o.Status = OrderStatusPaid
-	o.UpdatedAt = time.Now()
-	return nil
+// MarkAsCompleted 标记订单为已完成状态
+func (o *OrderDO) MarkAsCompleted() error {
+	return o.TransitionTo(OrderStatusCompleted)
 }
 
-// CalculateTotalAmount 计算订单总金额
+// CalculateTotalAmount 计算订单优惠金额和应付金额
 func (o *OrderDO) CalculateTotalAmount() error {
-	var totalAmount int64
+	if err := o.validateCurrency(); err != nil {
+		return err
+	}
+
+	total := dmoney.Zero(o.OrderCurrency())
+	discount := dmoney.Zero(o.OrderCurrency())
 	for _, item := range o.Items {
-		totalAmount += item.Subtotal
+		if err := item.validateDiscount(); err != nil {
+			return err
+		}
+		var err error
+		if total, err = total.Add(item.PayableMoney()); err != nil {
+			return fmt.Errorf("订单总金额%w", err)
+		}
+		if discount, err = discount.Add(item.DiscountMoney()); err != nil {
+			return fmt.Errorf("订单优惠金额%w", err)
+		}
 	}
 
-	o.TotalAmount = totalAmount
+	o.TotalAmount = total.Amount()
+	o.DiscountAmount = discount.Amount()
 	return nil
 }
@@ -0,0 +1,171 @@
+package domain_order_core
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vaynedu/ddd_order_example/pkg/dmoney"
+)
+
+// TestOrderDO_AddItem_Currency 订单项继承订单币种，金额按订单币种计算
+func TestOrderDO_AddItem_Currency(t *testing.T) {
+	order := &OrderDO{ID: "order_123", CustomerID: "cust_123", Currency: "USD"}
+
+	assert.NoError(t, order.AddItem("prod_1", 2, 1999))
+	assert.NoError(t, order.AddItem("prod_2", 1, 1))
+
+	assert.Equal(t, "USD", order.Items[0].Currency)
+	assert.Equal(t, dmoney.New(3999, dmoney.USD), order.Total())
+	assert.NoError(t, order.Validate())
+}
+
+// TestOrderDO_DefaultCurrency 未指定币种的历史订单按默认币种处理
+func TestOrderDO_DefaultCurrency(t *testing.T) {
+	order := newPayableOrder(OrderStatusCreated)
+
+	assert.Equal(t, dmoney.DefaultCurrency, order.OrderCurrency())
+	assert.Equal(t, dmoney.New(100, dmoney.DefaultCurrency), order.Total())
+	assert.NoError(t, order.Validate())
+}
+
+// TestOrderDO_Validate_MixedCurrency 订单项币种与订单不一致时拒绝
+func TestOrderDO_Validate_MixedCurrency(t *testing.T) {
+	order := &OrderDO{
+		ID:         "order_123",
+		CustomerID: "cust_123",
+		Currency:   "CNY",
+		Items: []OrderItemDO{
+			{ProductID: "prod_1", Quantity: 1, Currency: "CNY", UnitPrice: 100, Subtotal: 100},
+			{ProductID: "prod_2", Quantity: 1, Currency: "USD", UnitPrice: 100, Subtotal: 100},
+		},
+		TotalAmount: 200,
+	}
+
+	assert.True(t, errors.Is(order.Validate(), ErrMixedCurrency))
+	assert.True(t, errors.Is(order.ValidateUpdate(), ErrMixedCurrency))
+	assert.True(t, errors.Is(order.CalculateTotalAmount(), ErrMixedCurrency))
+	assert.Equal(t, int64(200), order.TotalAmount)
+}
+
+// TestOrderDO_Validate_UnknownCurrency 不支持的币种
+func TestOrderDO_Validate_UnknownCurrency(t *testing.T) {
+	order := &OrderDO{ID: "order_123", CustomerID: "cust_123", Currency: "XXX"}
+
+	assert.True(t, errors.Is(order.AddItem("prod_1", 1, 100), dmoney.ErrUnknownCurrency))
+	assert.True(t, errors.Is(order.Validate(), dmoney.ErrUnknownCurrency))
+}
+
+// newDiscountedOrder 商品A 3件*10元优惠6元，商品B 1件*20元优惠4元
+func newDiscountedOrder(t *testing.T) *OrderDO {
+	order := &OrderDO{ID: "order_123", CustomerID: "cust_123", Status: OrderStatusCreated}
+	assert.NoError(t, order.AddItem("prod_a", 3, 1000))
+	assert.NoError(t, order.AddItem("prod_b", 1, 2000))
+	assert.NoError(t, order.ApplyDiscounts([]int64{600, 400}, []string{"coupon_1"}))
+	return order
+}
+
+// TestOrderDO_ApplyDiscounts 应付金额为小计之和减去各订单行分摊的优惠
+func TestOrderDO_ApplyDiscounts(t *testing.T) {
+	order := newDiscountedOrder(t)
+
+	assert.Equal(t, dmoney.New(4000, dmoney.CNY), order.Total())
+	assert.Equal(t, dmoney.New(1000, dmoney.CNY), order.Discount())
+	assert.Equal(t, dmoney.New(2400, dmoney.CNY), order.Items[0].PayableMoney())
+	assert.NoError(t, order.Validate())
+	assert.NoError(t, order.ValidateUpdate())
+
+	// 已使用优惠券的订单不能再修改商品
+	assert.True(t, errors.Is(order.AddItem("prod_c", 1, 100), ErrDiscountedItems))
+	assert.True(t, errors.Is(order.ReplaceItems(nil), ErrDiscountedItems))
+}
+
+// TestOrderDO_ReplaceItems_KeepItemID 同一商品沿用原订单行ID，新商品的订单行ID为0
+func TestOrderDO_ReplaceItems_KeepItemID(t *testing.T) {
+	order := &OrderDO{ID: "order_123", CustomerID: "cust_123", Items: []OrderItemDO{
+		{ID: 1, ProductID: "prod_a", Quantity: 1, UnitPrice: 1000, Subtotal: 1000},
+		{ID: 2, ProductID: "prod_b", Quantity: 1, UnitPrice: 500, Subtotal: 500},
+	}}
+
+	assert.NoError(t, order.ReplaceItems([]OrderItemDO{
+		{ID: 9, ProductID: "prod_b", Quantity: 2, UnitPrice: 500, Subtotal: 1000},
+		{ProductID: "prod_c", Quantity: 1, UnitPrice: 300, Subtotal: 300},
+	}))
+
+	assert.Equal(t, int64(2), order.Items[0].ID)
+	assert.Zero(t, order.Items[1].ID)
+	assert.Equal(t, int64(1300), order.TotalAmount)
+}
+
+// TestOrderDO_ReplaceItems_Reserved 已预占库存的订单不能修改商品，预占记录保持不变
+func TestOrderDO_ReplaceItems_Reserved(t *testing.T) {
+	order := &OrderDO{ID: "order_123", CustomerID: "cust_123", Items: []OrderItemDO{
+		{ID: 1, ProductID: "prod_a", Quantity: 1, UnitPrice: 1000, Subtotal: 1000, ReservationID: "rsv_a"},
+	}, TotalAmount: 1000}
+
+	err := order.ReplaceItems([]OrderItemDO{
+		{ProductID: "prod_a", Quantity: 2, UnitPrice: 1000, Subtotal: 2000},
+	})
+
+	assert.True(t, errors.Is(err, ErrReservedItems))
+	assert.Equal(t, "rsv_a", order.Items[0].ReservationID)
+	assert.Equal(t, int64(1), order.Items[0].Quantity)
+	assert.Equal(t, int64(1000), order.TotalAmount)
+}
+
+// TestOrderDO_ApplyDiscounts_Invalid 优惠分摊与订单行不匹配或超过小计时不修改订单
+func TestOrderDO_ApplyDiscounts_Invalid(t *testing.T) {
+	order := &OrderDO{ID: "order_123", CustomerID: "cust_123"}
+	assert.NoError(t, order.AddItem("prod_a", 1, 1000))
+	assert.NoError(t, order.AddItem("prod_b", 1, 500))
+
+	assert.Error(t, order.ApplyDiscounts([]int64{100}, nil))
+	assert.Error(t, order.ApplyDiscounts([]int64{100, 600}, nil))
+	assert.Error(t, order.ApplyDiscounts([]int64{-1, 0}, nil))
+	assert.Zero(t, order.Items[0].Discount)
+	assert.Equal(t, int64(1500), order.TotalAmount)
+}
+
+// TestOrderDO_Validate_DiscountMismatch 订单优惠金额与订单行分摊之和不一致
+func TestOrderDO_Validate_DiscountMismatch(t *testing.T) {
+	order := newDiscountedOrder(t)
+	order.DiscountAmount = 900
+	order.TotalAmount = 4100
+
+	assert.Error(t, order.Validate())
+}
+
+// TestOrderDO_ProratedRefundAmount 部分退货按数量比例退回该行应付金额
+func TestOrderDO_ProratedRefundAmount(t *testing.T) {
+	order := &OrderDO{ID: "order_123", CustomerID: "cust_123"}
+	assert.NoError(t, order.AddItem("prod_a", 3, 1000))
+	assert.NoError(t, order.ApplyDiscounts([]int64{1001}, []string{"coupon_1"}))
+
+	// 应付 19.99 元，退 1 件按 1:2 分摊，不能整除的 1 分计入本次退款
+	amount, err := order.ProratedRefundAmount("prod_a", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, dmoney.New(667, dmoney.CNY), amount)
+
+	amount, err = order.ProratedRefundAmount("prod_a", 3)
+	assert.NoError(t, err)
+	assert.Equal(t, dmoney.New(1999, dmoney.CNY), amount)
+
+	_, err = order.ProratedRefundAmount("prod_a", 4)
+	assert.Error(t, err)
+	_, err = order.ProratedRefundAmount("prod_x", 1)
+	assert.Error(t, err)
+}
+
+// TestOrderDO_AssignReservations 预占ID按顺序记录到订单行，数量不一致时不修改订单
+func TestOrderDO_AssignReservations(t *testing.T) {
+	order := &OrderDO{ID: "order_123", CustomerID: "cust_123"}
+	assert.NoError(t, order.AddItem("prod_a", 1, 1000))
+	assert.NoError(t, order.AddItem("prod_b", 1, 500))
+
+	assert.Error(t, order.AssignReservations([]string{"rsv_a"}))
+	assert.Empty(t, order.ReservationIDs())
+
+	assert.NoError(t, order.AssignReservations([]string{"rsv_a", "rsv_b"}))
+	assert.Equal(t, "rsv_b", order.Items[1].ReservationID)
+	assert.Equal(t, []string{"rsv_a", "rsv_b"}, order.ReservationIDs())
+}
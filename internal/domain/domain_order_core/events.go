@@ -0,0 +1,189 @@
+package domain_order_core
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vaynedu/ddd_order_example/internal/shared/event"
+)
+
+// 订单领域事件名称
+const (
+	EventNameOrderCreated        = "order.created"
+	EventNameOrderPendingPayment = "order.pending_payment"
+	EventNameOrderPaid           = "order.paid"
+	EventNameOrderShipped        = "order.shipped"
+	EventNameOrderCompleted      = "order.completed"
+	EventNameOrderCancelled      = "order.cancelled"
+)
+
+// OrderEvent 订单领域事件公共字段，EventID 供下游消费去重
+type OrderEvent struct {
+	EventID    string      `json:"event_id"`
+	OrderID    string      `json:"order_id"`
+	OldStatus  OrderStatus `json:"old_status"`
+	NewStatus  OrderStatus `json:"new_status"`
+	Amount     int64       `json:"amount"`
+	Currency   string      `json:"currency"`
+	OccurredAt time.Time   `json:"occurred_at"`
+}
+
+func newOrderEvent(o *OrderDO, oldStatus OrderStatus) OrderEvent {
+	return OrderEvent{
+		EventID:    uuid.New().String(),
+		OrderID:    o.ID,
+		OldStatus:  oldStatus,
+		NewStatus:  o.Status,
+		Amount:     o.TotalAmount,
+		Currency:   string(o.OrderCurrency()),
+		OccurredAt: time.Now(),
+	}
+}
+
+// GetEventID 事件唯一ID
+func (e OrderEvent) GetEventID() string {
+	return e.EventID
+}
+
+// AggregateID 事件所属的订单ID
+func (e OrderEvent) AggregateID() string {
+	return e.OrderID
+}
+
+// OrderCreatedEvent 订单已创建
+type OrderCreatedEvent struct {
+	OrderEvent
+	CustomerID string `json:"customer_id"`
+}
+
+func (e *OrderCreatedEvent) Name() string {
+	return EventNameOrderCreated
+}
+
+// OrderPendingPaymentEvent 订单已发起支付，等待支付结果
+type OrderPendingPaymentEvent struct {
+	OrderEvent
+}
+
+func (e *OrderPendingPaymentEvent) Name() string {
+	return EventNameOrderPendingPayment
+}
+
+// OrderPaidEvent 订单已支付
+type OrderPaidEvent struct {
+	OrderEvent
+}
+
+func (e *OrderPaidEvent) Name() string {
+	return EventNameOrderPaid
+}
+
+// OrderShippedEvent 订单已发货
+type OrderShippedEvent struct {
+	OrderEvent
+}
+
+func (e *OrderShippedEvent) Name() string {
+	return EventNameOrderShipped
+}
+
+// OrderCompletedEvent 订单已完成
+type OrderCompletedEvent struct {
+	OrderEvent
+}
+
+func (e *OrderCompletedEvent) Name() string {
+	return EventNameOrderCompleted
+}
+
+// OrderCancelledEvent 订单已取消
+type OrderCancelledEvent struct {
+	OrderEvent
+}
+
+func (e *OrderCancelledEvent) Name() string {
+	return EventNameOrderCancelled
+}
+
+// NewOrderCreatedEvent 创建订单已创建事件
+func NewOrderCreatedEvent(o *OrderDO) *OrderCreatedEvent {
+	return &OrderCreatedEvent{OrderEvent: newOrderEvent(o, ""), CustomerID: o.CustomerID}
+}
+
+// newStatusChangedEvent 根据流转后的状态创建对应的领域事件
+func newStatusChangedEvent(o *OrderDO, oldStatus OrderStatus) event.Event {
+	base := newOrderEvent(o, oldStatus)
+	switch o.Status {
+	case OrderStatusPending:
+		return &OrderPendingPaymentEvent{OrderEvent: base}
+	case OrderStatusPaid:
+		return &OrderPaidEvent{OrderEvent: base}
+	case OrderStatusShipped:
+		return &OrderShippedEvent{OrderEvent: base}
+	case OrderStatusCompleted:
+		return &OrderCompletedEvent{OrderEvent: base}
+	case OrderStatusCancelled:
+		return &OrderCancelledEvent{OrderEvent: base}
+	default:
+		return nil
+	}
+}
+
+// EventNames 全部订单领域事件名称
+func EventNames() []string {
+	return []string{
+		EventNameOrderCreated,
+		EventNameOrderPendingPayment,
+		EventNameOrderPaid,
+		EventNameOrderShipped,
+		EventNameOrderCompleted,
+		EventNameOrderCancelled,
+	}
+}
+
+// DecodeEvent 按事件名称将序列化的事件还原为具体类型
+func DecodeEvent(name string, payload []byte) (event.Event, error) {
+	var evt event.Event
+	switch name {
+	case EventNameOrderCreated:
+		evt = &OrderCreatedEvent{}
+	case EventNameOrderPendingPayment:
+		evt = &OrderPendingPaymentEvent{}
+	case EventNameOrderPaid:
+		evt = &OrderPaidEvent{}
+	case EventNameOrderShipped:
+		evt = &OrderShippedEvent{}
+	case EventNameOrderCompleted:
+		evt = &OrderCompletedEvent{}
+	case EventNameOrderCancelled:
+		evt = &OrderCancelledEvent{}
+	default:
+		return nil, fmt.Errorf("未知的订单事件: %s", name)
+	}
+
+	if err := json.Unmarshal(payload, evt); err != nil {
+		return nil, fmt.Errorf("解析订单事件失败: %w", err)
+	}
+	return evt, nil
+}
+
+// recordEvent 聚合内记录领域事件，保存成功后由应用服务取出分发
+func (o *OrderDO) recordEvent(evt event.Event) {
+	if evt != nil {
+		o.events = append(o.events, evt)
+	}
+}
+
+// Events 查看尚未取出的领域事件，仓储据此写入发件箱
+func (o *OrderDO) Events() []event.Event {
+	return o.events
+}
+
+// PullEvents 取出并清空已记录的领域事件
+func (o *OrderDO) PullEvents() []event.Event {
+	events := o.events
+	o.events = nil
+	return events
+}
@@ -0,0 +1,58 @@
+package domain_order_core
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOrderDO_RecordEvents 每次合法的状态流转记录一个领域事件，取出后清空
+func TestOrderDO_RecordEvents(t *testing.T) {
+	order := newPayableOrder(OrderStatusCreated)
+
+	require.NoError(t, order.MarkAsPendingPayment())
+	require.NoError(t, order.MarkAsPaid())
+	assert.Error(t, order.MarkAsCompleted())
+
+	events := order.PullEvents()
+	require.Len(t, events, 2)
+	assert.Empty(t, order.PullEvents())
+
+	pending, ok := events[0].(*OrderPendingPaymentEvent)
+	require.True(t, ok)
+	assert.Equal(t, OrderStatusCreated, pending.OldStatus)
+	assert.Equal(t, OrderStatusPending, pending.NewStatus)
+
+	paid, ok := events[1].(*OrderPaidEvent)
+	require.True(t, ok)
+	assert.Equal(t, "order_123", paid.OrderID)
+	assert.Equal(t, OrderStatusPending, paid.OldStatus)
+	assert.Equal(t, OrderStatusPaid, paid.NewStatus)
+	assert.Equal(t, int64(100), paid.Amount)
+	assert.False(t, paid.OccurredAt.IsZero())
+	assert.NotEqual(t, pending.EventID, paid.EventID)
+}
+
+// TestDecodeEvent 序列化后的事件可以按名称还原为具体类型
+func TestDecodeEvent(t *testing.T) {
+	order := newPayableOrder(OrderStatusPaid)
+	require.NoError(t, order.Cancel())
+	cancelled := order.PullEvents()[0].(*OrderCancelledEvent)
+
+	payload, err := json.Marshal(cancelled)
+	require.NoError(t, err)
+
+	decoded, err := DecodeEvent(cancelled.Name(), payload)
+
+	require.NoError(t, err)
+	result, ok := decoded.(*OrderCancelledEvent)
+	require.True(t, ok)
+	assert.Equal(t, cancelled.EventID, result.EventID)
+	assert.Equal(t, cancelled.NewStatus, result.NewStatus)
+	assert.True(t, cancelled.OccurredAt.Equal(result.OccurredAt))
+
+	_, err = DecodeEvent("order.unknown", payload)
+	assert.Error(t, err)
+}
@@ -0,0 +1,93 @@
+package domain_order_core
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vaynedu/ddd_order_example/pkg/dmoney"
+)
+
+// 订单列表分页大小
+const (
+	DefaultOrderPageSize = 20
+	MaxOrderPageSize     = 100
+)
+
+// OrderQuery 订单列表查询条件，零值字段不参与过滤
+// 结果按 created_at,id 倒序排列，使用游标分页
+type OrderQuery struct {
+	CustomerID  string        // 客户ID
+	Statuses    []OrderStatus // 订单状态，命中任意一个即可
+	CreatedFrom *time.Time    // 创建时间下限(含)
+	CreatedTo   *time.Time    // 创建时间上限(不含)
+	Currency    string        // 订单币种，按金额过滤且未指定时为默认币种
+	MinAmount   *int64        // 订单金额下限(含)，Currency 币种的最小单位
+	MaxAmount   *int64        // 订单金额上限(含)，Currency 币种的最小单位
+	Cursor      *OrderCursor  // 上一页返回的游标，为空表示第一页
+	Limit       int           // 每页数量，为0时使用默认值
+}
+
+// OrderCursor 分页游标，记录上一页最后一条订单的排序键
+type OrderCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// OrderPage 订单分页结果，NextCursor 为空表示没有更多数据
+type OrderPage struct {
+	Orders     []*OrderDO
+	NextCursor *OrderCursor
+}
+
+// Normalize 校验查询条件并填充默认分页大小
+func (q *OrderQuery) Normalize() error {
+	for _, status := range q.Statuses {
+		if _, err := ParseOrderStatus(string(status)); err != nil {
+			return err
+		}
+	}
+	if q.CreatedFrom != nil && q.CreatedTo != nil && !q.CreatedFrom.Before(*q.CreatedTo) {
+		return errors.New("创建时间范围无效")
+	}
+	// 不同币种的金额不可比较，按金额过滤时只查询同一币种的订单
+	if (q.MinAmount != nil || q.MaxAmount != nil) && q.Currency == "" {
+		q.Currency = string(dmoney.DefaultCurrency)
+	}
+	if q.Currency != "" && !dmoney.Currency(q.Currency).IsValid() {
+		return fmt.Errorf("订单%w: %s", dmoney.ErrUnknownCurrency, q.Currency)
+	}
+	if q.MinAmount != nil && *q.MinAmount < 0 || q.MaxAmount != nil && *q.MaxAmount < 0 {
+		return errors.New("订单金额不能为负数")
+	}
+	if q.MinAmount != nil && q.MaxAmount != nil && *q.MinAmount > *q.MaxAmount {
+		return errors.New("订单金额范围无效")
+	}
+	if q.Cursor != nil && q.Cursor.ID == "" {
+		return errors.New("分页游标无效")
+	}
+
+	switch {
+	case q.Limit < 0:
+		return errors.New("每页数量不能为负数")
+	case q.Limit == 0:
+		q.Limit = DefaultOrderPageSize
+	case q.Limit > MaxOrderPageSize:
+		q.Limit = MaxOrderPageSize
+	}
+	return nil
+}
+
+// NewOrderPage 根据多查询一条的结果构造分页，orders 长度超过 limit 说明还有下一页
+func NewOrderPage(orders []*OrderDO, limit int) *OrderPage {
+	if len(orders) <= limit {
+		return &OrderPage{Orders: orders}
+	}
+
+	orders = orders[:limit]
+	last := orders[limit-1]
+	return &OrderPage{
+		Orders:     orders,
+		NextCursor: &OrderCursor{CreatedAt: last.CreatedAt, ID: last.ID},
+	}
+}
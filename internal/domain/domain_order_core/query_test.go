@@ -0,0 +1,80 @@
+package domain_order_core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestOrderQuery_Normalize 查询条件校验与分页大小默认值
+func TestOrderQuery_Normalize(t *testing.T) {
+	now := time.Now()
+	earlier := now.Add(-time.Hour)
+	amount := func(v int64) *int64 { return &v }
+
+	cases := []struct {
+		name      string
+		query     OrderQuery
+		wantErr   bool
+		wantLimit int
+	}{
+		{name: "默认分页大小", query: OrderQuery{}, wantLimit: DefaultOrderPageSize},
+		{name: "超过最大分页大小", query: OrderQuery{Limit: 1000}, wantLimit: MaxOrderPageSize},
+		{name: "合法条件", query: OrderQuery{Statuses: []OrderStatus{OrderStatusPaid}, CreatedFrom: &earlier, CreatedTo: &now, MinAmount: amount(1), MaxAmount: amount(1), Limit: 5}, wantLimit: 5},
+		{name: "未知状态", query: OrderQuery{Statuses: []OrderStatus{"refunded"}}, wantErr: true},
+		{name: "时间范围颠倒", query: OrderQuery{CreatedFrom: &now, CreatedTo: &earlier}, wantErr: true},
+		{name: "未知币种", query: OrderQuery{Currency: "XXX"}, wantErr: true},
+		{name: "金额为负", query: OrderQuery{MinAmount: amount(-1)}, wantErr: true},
+		{name: "金额范围颠倒", query: OrderQuery{MinAmount: amount(2), MaxAmount: amount(1)}, wantErr: true},
+		{name: "游标缺少ID", query: OrderQuery{Cursor: &OrderCursor{CreatedAt: now}}, wantErr: true},
+		{name: "分页大小为负", query: OrderQuery{Limit: -1}, wantErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.query.Normalize()
+			if c.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.wantLimit, c.query.Limit)
+		})
+	}
+}
+
+// TestNewOrderPage 多查出的一条用于判断下一页，游标指向本页最后一条
+func TestNewOrderPage(t *testing.T) {
+	now := time.Now()
+	orders := []*OrderDO{
+		{ID: "order_3", CreatedAt: now},
+		{ID: "order_2", CreatedAt: now.Add(-time.Minute)},
+		{ID: "order_1", CreatedAt: now.Add(-2 * time.Minute)},
+	}
+
+	page := NewOrderPage(orders, 2)
+	assert.Len(t, page.Orders, 2)
+	assert.Equal(t, &OrderCursor{CreatedAt: orders[1].CreatedAt, ID: "order_2"}, page.NextCursor)
+
+	page = NewOrderPage(orders, 3)
+	assert.Len(t, page.Orders, 3)
+	assert.Nil(t, page.NextCursor)
+}
+
+// TestOrderQuery_Normalize_AmountCurrency 按金额过滤且未指定币种时使用默认币种
+func TestOrderQuery_Normalize_AmountCurrency(t *testing.T) {
+	amount := int64(100)
+
+	query := OrderQuery{MinAmount: &amount}
+	assert.NoError(t, query.Normalize())
+	assert.Equal(t, "CNY", query.Currency)
+
+	query = OrderQuery{Currency: "USD", MaxAmount: &amount}
+	assert.NoError(t, query.Normalize())
+	assert.Equal(t, "USD", query.Currency)
+
+	query = OrderQuery{}
+	assert.NoError(t, query.Normalize())
+	assert.Empty(t, query.Currency)
+}
@@ -3,7 +3,12 @@ package domain_order_core
 import "context"
 
 // OrderRepository 订单仓储接口
+// 实现需要保证：保存和查询都复制订单，调用方修改返回的订单不影响已保存的数据；
+// 新订单(版本号为空)重复保存返回 gorm.ErrDuplicatedKey，已有订单版本号不一致返回 ErrConcurrentModification
 type OrderRepository interface {
 	Save(ctx context.Context, order *OrderDO) error
+	// FindByID 查询订单，不存在时返回 ErrOrderNotFound
 	FindByID(ctx context.Context, id string) (*OrderDO, error)
+	// Find 按条件分页查询订单，调用方需先执行 OrderQuery.Normalize
+	Find(ctx context.Context, query OrderQuery) (*OrderPage, error)
 }
@@ -2,7 +2,6 @@ package domain_order_core
 
 import (
 	"context"
-	"errors"
 	"time"
 )
 
@@ -27,6 +26,7 @@ func (s *OrderDomainService) CreateOrder(ctx context.Context, order *OrderDO) er
 	order.Status = OrderStatusCreated
 	order.CreatedAt = time.Now()
 	order.UpdatedAt = order.CreatedAt
+	order.recordEvent(NewOrderCreatedEvent(order))
 
 	// 持久化订单
 	return s.orderRepo.Save(ctx, order)
@@ -39,13 +39,11 @@ func (s *OrderDomainService) PayOrder(ctx context.Context, orderID string) error
 		return err
 	}
 
-	if order.Status != OrderStatusCreated {
-		return errors.New("只有已创建的订单可以支付")
+	// 支付成功只能从待支付状态流转，由状态机统一校验
+	if err := order.MarkAsPaid(); err != nil {
+		return err
 	}
 
-	order.Status = OrderStatusPaid
-	order.UpdatedAt = time.Now()
-
 	return s.orderRepo.Save(ctx, order)
 }
 
@@ -54,6 +52,24 @@ func (s *OrderDomainService) GetOrderByID(ctx context.Context, orderID string) (
 	return s.orderRepo.FindByID(ctx, orderID)
 }
 
+// FindOrders 按条件分页查询订单
+func (s *OrderDomainService) FindOrders(ctx context.Context, query OrderQuery) (*OrderPage, error) {
+	if err := query.Normalize(); err != nil {
+		return nil, err
+	}
+	return s.orderRepo.Find(ctx, query)
+}
+
+// FindOverdueOrders 分页查询创建时间早于 deadline 仍未支付的订单
+func (s *OrderDomainService) FindOverdueOrders(ctx context.Context, deadline time.Time, cursor *OrderCursor, limit int) (*OrderPage, error) {
+	return s.FindOrders(ctx, OrderQuery{
+		Statuses:  []OrderStatus{OrderStatusCreated, OrderStatusPending},
+		CreatedTo: &deadline,
+		Cursor:    cursor,
+		Limit:     limit,
+	})
+}
+
 // UpdateOrder 更新订单
 func (s *OrderDomainService) UpdateOrder(ctx context.Context, order *OrderDO) error {
 
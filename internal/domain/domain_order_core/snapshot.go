@@ -0,0 +1,32 @@
+package domain_order_core
+
+import "slices"
+
+// OrderSnapshot 订单最近一次从仓储加载或保存成功时的持久化状态
+// 仓储据此只写入变化的列和订单行，业务代码不需要关心
+type OrderSnapshot struct {
+	Order OrderDO               // 订单主表字段，不含订单项
+	Items map[int64]OrderItemDO // 已保存的订单行，按订单行ID索引
+}
+
+// TakeSnapshot 记录当前状态为持久化快照，由仓储在加载或保存成功后调用
+func (o *OrderDO) TakeSnapshot() {
+	order := *o
+	order.Items = nil
+	order.CouponIDs = slices.Clone(o.CouponIDs)
+	order.events = nil
+	order.snapshot = nil
+
+	items := make(map[int64]OrderItemDO, len(o.Items))
+	for _, item := range o.Items {
+		if item.ID != 0 {
+			items[item.ID] = item
+		}
+	}
+	o.snapshot = &OrderSnapshot{Order: order, Items: items}
+}
+
+// Snapshot 持久化快照，未从仓储加载过的订单返回nil
+func (o *OrderDO) Snapshot() *OrderSnapshot {
+	return o.snapshot
+}
@@ -0,0 +1,106 @@
+package domain_order_core
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrIllegalTransition 非法的订单状态流转
+type ErrIllegalTransition struct {
+	From   OrderStatus
+	To     OrderStatus
+	Reason string
+}
+
+func (e *ErrIllegalTransition) Error() string {
+	msg := fmt.Sprintf("订单状态不允许从[%s]流转到[%s]", GetOrderStatusDetail(e.From), GetOrderStatusDetail(e.To))
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	return msg
+}
+
+// transitionGuard 状态流转守卫，返回非nil表示不满足流转条件
+type transitionGuard func(o *OrderDO) error
+
+// orderTransitions 订单状态流转表: 当前状态 -> 目标状态 -> 守卫
+// 表中不存在的流转一律视为非法，终态(已完成、已取消)没有任何出边
+var orderTransitions = map[OrderStatus]map[OrderStatus]transitionGuard{
+	OrderStatusCreated: {
+		OrderStatusPending:   guardPayable,
+		OrderStatusCancelled: nil,
+	},
+	OrderStatusPending: {
+		OrderStatusPaid:      nil,
+		OrderStatusCancelled: nil,
+	},
+	OrderStatusPaid: {
+		OrderStatusShipped:   nil,
+		OrderStatusCancelled: nil,
+	},
+	OrderStatusShipped: {
+		OrderStatusCompleted: nil,
+	},
+	OrderStatusCompleted: {},
+	OrderStatusCancelled: {},
+}
+
+// guardPayable 发起支付前订单必须有商品且金额大于0
+func guardPayable(o *OrderDO) error {
+	if len(o.Items) == 0 {
+		return errors.New("订单商品不能为空")
+	}
+	if o.TotalAmount <= 0 {
+		return errors.New("订单金额必须大于0")
+	}
+	return nil
+}
+
+// ParseOrderStatus 将外部传入的字符串解析为订单状态
+func ParseOrderStatus(s string) (OrderStatus, error) {
+	status := OrderStatus(s)
+	if _, ok := orderTransitions[status]; !ok {
+		return OrderStatusUnknown, fmt.Errorf("未知的订单状态: %s", s)
+	}
+	return status, nil
+}
+
+// CanTransition 判断状态表中是否存在 from -> to 的流转(不执行守卫)
+func CanTransition(from, to OrderStatus) bool {
+	_, ok := orderTransitions[from][to]
+	return ok
+}
+
+// CanTransitionTo 判断订单当前是否可以流转到目标状态(包含守卫校验)
+func (o *OrderDO) CanTransitionTo(to OrderStatus) bool {
+	return o.checkTransition(to) == nil
+}
+
+// TransitionTo 订单状态变更的唯一入口，所有状态修改都必须经过状态机
+// 流转成功后记录对应的领域事件
+func (o *OrderDO) TransitionTo(to OrderStatus) error {
+	if err := o.checkTransition(to); err != nil {
+		return err
+	}
+
+	from := o.Status
+	o.Status = to
+	o.UpdatedAt = time.Now()
+	o.recordEvent(newStatusChangedEvent(o, from))
+	return nil
+}
+
+func (o *OrderDO) checkTransition(to OrderStatus) error {
+	guard, ok := orderTransitions[o.Status][to]
+	if !ok {
+		return &ErrIllegalTransition{From: o.Status, To: to}
+	}
+
+	if guard != nil {
+		if err := guard(o); err != nil {
+			return &ErrIllegalTransition{From: o.Status, To: to, Reason: err.Error()}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package domain_order_core
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newPayableOrder(status OrderStatus) *OrderDO {
+	return &OrderDO{
+		ID:          "order_123",
+		CustomerID:  "cust_123",
+		Status:      status,
+		Items:       []OrderItemDO{{ProductID: "prod_123", Quantity: 1, UnitPrice: 100, Subtotal: 100}},
+		TotalAmount: 100,
+	}
+}
+
+// TestOrderDO_TransitionTo_HappyPath 订单完整生命周期流转
+func TestOrderDO_TransitionTo_HappyPath(t *testing.T) {
+	order := newPayableOrder(OrderStatusCreated)
+
+	assert.NoError(t, order.MarkAsPendingPayment())
+	assert.NoError(t, order.MarkAsPaid())
+	assert.NoError(t, order.MarkAsShipped())
+	assert.NoError(t, order.MarkAsCompleted())
+	assert.Equal(t, OrderStatusCompleted, order.Status)
+	assert.False(t, order.UpdatedAt.IsZero())
+}
+
+// TestOrderDO_TransitionTo_Illegal 非法流转返回 ErrIllegalTransition 且状态不变
+func TestOrderDO_TransitionTo_Illegal(t *testing.T) {
+	cases := []struct {
+		from OrderStatus
+		to   OrderStatus
+	}{
+		{OrderStatusCreated, OrderStatusPaid},
+		{OrderStatusCreated, OrderStatusShipped},
+		{OrderStatusPending, OrderStatusCreated},
+		{OrderStatusShipped, OrderStatusCancelled},
+		{OrderStatusCompleted, OrderStatusCancelled},
+		{OrderStatusCancelled, OrderStatusCreated},
+		{OrderStatusUnknown, OrderStatusPaid},
+	}
+
+	for _, c := range cases {
+		order := newPayableOrder(c.from)
+		err := order.TransitionTo(c.to)
+
+		var illegal *ErrIllegalTransition
+		assert.True(t, errors.As(err, &illegal), "%s -> %s", c.from, c.to)
+		assert.Equal(t, c.from, illegal.From)
+		assert.Equal(t, c.to, illegal.To)
+		assert.Equal(t, c.from, order.Status)
+	}
+}
+
+// TestOrderDO_TransitionTo_Guard 守卫不满足时拒绝流转
+func TestOrderDO_TransitionTo_Guard(t *testing.T) {
+	order := newPayableOrder(OrderStatusCreated)
+	order.Items = nil
+	order.TotalAmount = 0
+
+	err := order.MarkAsPendingPayment()
+
+	var illegal *ErrIllegalTransition
+	assert.True(t, errors.As(err, &illegal))
+	assert.NotEmpty(t, illegal.Reason)
+	assert.False(t, order.CanTransitionTo(OrderStatusPending))
+	assert.True(t, CanTransition(OrderStatusCreated, OrderStatusPending))
+}
+
+// TestOrderDO_Cancel 只有未发货的订单可以取消
+func TestOrderDO_Cancel(t *testing.T) {
+	for _, status := range []OrderStatus{OrderStatusCreated, OrderStatusPending, OrderStatusPaid} {
+		order := newPayableOrder(status)
+		assert.True(t, order.CanBeCancelled())
+		assert.NoError(t, order.Cancel())
+		assert.Equal(t, OrderStatusCancelled, order.Status)
+	}
+
+	for _, status := range []OrderStatus{OrderStatusShipped, OrderStatusCompleted, OrderStatusCancelled} {
+		order := newPayableOrder(status)
+		assert.False(t, order.CanBeCancelled())
+		assert.Error(t, order.Cancel())
+	}
+}
+
+// TestParseOrderStatus 解析外部传入的订单状态
+func TestParseOrderStatus(t *testing.T) {
+	status, err := ParseOrderStatus("paid")
+	assert.NoError(t, err)
+	assert.Equal(t, OrderStatusPaid, status)
+
+	_, err = ParseOrderStatus("refunded")
+	assert.Error(t, err)
+
+	_, err = ParseOrderStatus("unknown")
+	assert.Error(t, err)
+}
@@ -2,6 +2,8 @@ package domain_payment_core
 
 import (
 	"time"
+
+	"github.com/vaynedu/ddd_order_example/pkg/dmoney"
 )
 
 // PaymentDO 支付领域对象
@@ -17,6 +19,31 @@ type PaymentDO struct {
 	CreatedAt           time.Time
 	UpdatedAt           time.Time
 	CompletedAt         *time.Time
+
+	// 支付币种与订单币种不同时记录下单时使用的汇率快照，币种相同时均为空
+	OrderAmount   int64      // 订单金额，订单币种的最小单位
+	OrderCurrency string     // 订单币种
+	ExchangeRate  string     // 1 单位订单币种可兑换的支付币种数量
+	RateSource    string     // 汇率来源
+	RateQuotedAt  *time.Time // 汇率生效时间
+}
+
+// Money 支付金额
+func (p *PaymentDO) Money() dmoney.Money {
+	return dmoney.New(p.Amount, dmoney.Currency(p.Currency))
+}
+
+// HasExchangeRate 支付时是否发生了币种换算
+func (p *PaymentDO) HasExchangeRate() bool {
+	return p.ExchangeRate != ""
+}
+
+// OrderMoney 订单金额，未发生币种换算时与支付金额相同
+func (p *PaymentDO) OrderMoney() dmoney.Money {
+	if !p.HasExchangeRate() {
+		return p.Money()
+	}
+	return dmoney.New(p.OrderAmount, dmoney.Currency(p.OrderCurrency))
 }
 
 // 支付状态
@@ -69,17 +96,24 @@ func GetPaymentStatusDetail(status PaymentStatus) string {
 	}
 }
 
-// 支付渠道枚举
+// 支付渠道枚举，取值与 t_payment.channel 一致
 type PaymentChannel int
 
 const (
-	PaymentChannelAlipay   PaymentChannel = iota // 支付宝
+	PaymentChannelUnknown  PaymentChannel = iota // 未知
+	PaymentChannelAlipay                         // 支付宝
 	PaymentChannelWechat                         // 微信
 	PaymentChannelUnionPay                       // 银联
 	PaymentChannelApplePay                       // ApplePay
 	PaymentChannelJDPay                          // 京东支付
 )
 
+// SettlementCurrency 渠道收款币种，订单币种与之不同时按汇率换算后发起支付
+// 目前接入的均为境内渠道，统一以人民币收款
+func (c PaymentChannel) SettlementCurrency() dmoney.Currency {
+	return dmoney.CNY
+}
+
 func GetPaymentChannelDetail(channel PaymentChannel) string {
 	switch channel {
 	case PaymentChannelAlipay:
@@ -95,4 +129,47 @@ func GetPaymentChannelDetail(channel PaymentChannel) string {
 	default:
 		return "未知"
 	}
-}
\ No newline at end of file
+}
+
+// ChannelTrade 向支付渠道查询到的交易，渠道以订单ID作为商户订单号
+type ChannelTrade struct {
+	Status        PaymentStatus // 渠道交易状态映射后的支付状态
+	TransactionID string        // 渠道交易号，未支付的支付单本地尚未记录
+	Amount        dmoney.Money  // 渠道交易金额
+}
+
+// IsPaid 渠道侧是否已支付
+func (t *ChannelTrade) IsPaid() bool {
+	return t.Status == PaymentStatusPaid || t.Status == PaymentStatusCompleted
+}
+
+// IsFinished 支付结果是否已确定，支付完成后的退款状态同样视为已确定
+func (p *PaymentDO) IsFinished() bool {
+	switch p.Status {
+	case PaymentStatusUnknown, PaymentStatusCreated, PaymentStatusPending:
+		return false
+	default:
+		return true
+	}
+}
+
+// IsSucceeded 是否已支付成功
+func (p *PaymentDO) IsSucceeded() bool {
+	return p.Status == PaymentStatusPaid || p.Status == PaymentStatusCompleted
+}
+
+// CanRefund 已完成支付(含部分退款、退款失败)的支付单才允许发起退款
+func (p *PaymentDO) CanRefund() bool {
+	switch p.Status {
+	case PaymentStatusPaid, PaymentStatusCompleted, PaymentStatusRefunding,
+		PaymentStatusRefunded, PaymentStatusRefundFailed:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsFullyRefunded 支付金额是否已全部退回
+func (p *PaymentDO) IsFullyRefunded() bool {
+	return p.Status == PaymentStatusRefundedSuccess
+}
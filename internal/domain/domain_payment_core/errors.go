@@ -10,3 +10,11 @@ var (
 	ErrPaymentAmountMismatch = errors.New("payment amount mismatch")
 	ErrPaymentPaid           = errors.New("订单已支付，无需重复操作")
 )
+
+// 退款领域错误定义
+var (
+	ErrRefundNotFound       = errors.New("refund not found")
+	ErrInvalidRefundAmount  = errors.New("退款金额必须大于0")
+	ErrRefundAmountExceeded = errors.New("累计退款金额超过支付金额")
+	ErrPaymentNotRefundable = errors.New("当前支付状态不允许退款")
+)
@@ -0,0 +1,25 @@
+package domain_payment_core
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/vaynedu/ddd_order_example/pkg/dmoney"
+)
+
+// ErrExchangeRateNotFound 没有可用的汇率
+var ErrExchangeRateNotFound = errors.New("汇率不存在")
+
+// ExchangeRateQuote 汇率报价，支付币种与订单币种不同时快照到支付单上
+type ExchangeRateQuote struct {
+	Rate     dmoney.ExchangeRate // 1 单位订单币种可兑换的支付币种数量
+	Source   string              // 汇率来源，如本地汇率文件名
+	QuotedAt time.Time           // 汇率生效时间
+}
+
+// ExchangeRateProvider 汇率查询接口，不同的汇率来源(本地文件、银行接口等)各自实现
+type ExchangeRateProvider interface {
+	// GetRate 查询 from 兑换 to 的汇率，没有该币种对时返回 ErrExchangeRateNotFound
+	GetRate(ctx context.Context, from, to dmoney.Currency) (*ExchangeRateQuote, error)
+}
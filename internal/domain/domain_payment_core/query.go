@@ -0,0 +1,29 @@
+package domain_payment_core
+
+import "time"
+
+// PaymentCursor 支付单扫描游标，记录上一页最后一条支付单的排序键
+type PaymentCursor struct {
+	UpdatedAt time.Time
+	ID        string
+}
+
+// PaymentPage 支付单分页结果，NextCursor 为空表示没有更多数据
+type PaymentPage struct {
+	Payments   []*PaymentDO
+	NextCursor *PaymentCursor
+}
+
+// NewPaymentPage 根据多查询一条的结果构造分页，payments 长度超过 limit 说明还有下一页
+func NewPaymentPage(payments []*PaymentDO, limit int) *PaymentPage {
+	if len(payments) <= limit {
+		return &PaymentPage{Payments: payments}
+	}
+
+	payments = payments[:limit]
+	last := payments[limit-1]
+	return &PaymentPage{
+		Payments:   payments,
+		NextCursor: &PaymentCursor{UpdatedAt: last.UpdatedAt, ID: last.ID},
+	}
+}
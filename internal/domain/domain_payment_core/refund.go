@@ -0,0 +1,52 @@
+package domain_payment_core
+
+import (
+	"time"
+)
+
+// RefundDO 退款领域对象，一笔支付可以有多次部分退款
+type RefundDO struct {
+	ID                  string
+	PaymentID           string
+	OrderID             string
+	Amount              int64
+	Status              RefundStatus
+	Reason              string
+	RefundTransactionID string
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	CompletedAt         *time.Time
+}
+
+// 退款状态
+type RefundStatus int
+
+const (
+	RefundStatusUnknown    RefundStatus = iota // 未知
+	RefundStatusProcessing                     // 退款中
+	RefundStatusSucceeded                      // 退款成功
+	RefundStatusFailed                         // 退款失败
+)
+
+func GetRefundStatusDetail(status RefundStatus) string {
+	switch status {
+	case RefundStatusProcessing:
+		return "退款中"
+	case RefundStatusSucceeded:
+		return "退款成功"
+	case RefundStatusFailed:
+		return "退款失败"
+	default:
+		return "未知"
+	}
+}
+
+// OccupiesAmount 退款中和退款成功的退款单都占用支付单的可退金额
+func (r *RefundDO) OccupiesAmount() bool {
+	return r.Status == RefundStatusProcessing || r.Status == RefundStatusSucceeded
+}
+
+// IsFinished 退款单是否已有最终结果
+func (r *RefundDO) IsFinished() bool {
+	return r.Status == RefundStatusSucceeded || r.Status == RefundStatusFailed
+}
@@ -0,0 +1,202 @@
+package domain_payment_core
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_uow_core"
+	"github.com/vaynedu/ddd_order_example/pkg/dmoney"
+)
+
+// RefundDomainService 退款领域服务
+// 支付单状态含义: 退款中(有退款单处理中)、已退款(部分退回)、退款成功(全部退回)、退款失败(最近一次退款失败且无成功退款)
+type RefundDomainService struct {
+	paymentRepo Repository
+	refundRepo  RefundRepository
+	uow         domain_uow_core.UnitOfWork
+}
+
+func NewRefundDomainService(paymentRepo Repository, refundRepo RefundRepository, uow domain_uow_core.UnitOfWork) *RefundDomainService {
+	return &RefundDomainService{
+		paymentRepo: paymentRepo,
+		refundRepo:  refundRepo,
+		uow:         uow,
+	}
+}
+
+// CreateRefund 创建退款单，amount 为0表示退回剩余全部可退金额
+// 退款中和退款成功的退款单累计金额不能超过支付金额；
+// 在工作单元中锁定支付单后再统计已占用金额，同一支付单的并发退款串行执行
+func (s *RefundDomainService) CreateRefund(ctx context.Context, paymentID string, amount int64, reason string) (*RefundDO, error) {
+	if amount < 0 {
+		return nil, ErrInvalidRefundAmount
+	}
+
+	var refund *RefundDO
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		payment, err := s.paymentRepo.FindByIDForUpdate(ctx, paymentID)
+		if err != nil {
+			return err
+		}
+		if !payment.CanRefund() {
+			return ErrPaymentNotRefundable
+		}
+
+		refunds, err := s.refundRepo.FindByPaymentID(ctx, paymentID)
+		if err != nil {
+			return err
+		}
+
+		occupied, err := occupiedAmount(payment, refunds)
+		if err != nil {
+			return err
+		}
+		refundable, err := payment.Money().Sub(occupied)
+		if err != nil {
+			return err
+		}
+		refundAmount := amount
+		if refundAmount == 0 {
+			refundAmount = refundable.Amount()
+		}
+		if refundAmount <= 0 {
+			return ErrInvalidRefundAmount
+		}
+		if refundAmount > refundable.Amount() {
+			return ErrRefundAmountExceeded
+		}
+
+		now := time.Now()
+		refund = &RefundDO{
+			ID:        uuid.New().String(),
+			PaymentID: payment.ID,
+			OrderID:   payment.OrderID,
+			Amount:    refundAmount,
+			Status:    RefundStatusProcessing,
+			Reason:    reason,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if err := s.refundRepo.Save(ctx, refund); err != nil {
+			return err
+		}
+
+		payment.Status = PaymentStatusRefunding
+		payment.UpdatedAt = now
+		return s.paymentRepo.Save(ctx, payment)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+// ProcessRefundResult 处理退款结果，重复通知同一结果时直接返回
+// 返回更新后的退款单和支付单，调用方据此判断是否已全额退款；
+// 在工作单元中锁定支付单后再读取退款单，同一支付单的退款结果串行处理，汇总支付单状态时不会覆盖其他退款的结果
+func (s *RefundDomainService) ProcessRefundResult(ctx context.Context, refundID, refundTransactionID string, success bool) (*RefundDO, *PaymentDO, error) {
+	refund, err := s.refundRepo.FindByID(ctx, refundID)
+	if err != nil {
+		return nil, nil, err
+	}
+	paymentID := refund.PaymentID
+
+	var payment *PaymentDO
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		if payment, err = s.paymentRepo.FindByIDForUpdate(ctx, paymentID); err != nil {
+			return err
+		}
+		if refund, err = s.refundRepo.FindByID(ctx, refundID); err != nil {
+			return err
+		}
+		if refund.IsFinished() {
+			return nil
+		}
+
+		now := time.Now()
+		refund.UpdatedAt = now
+		if success {
+			refund.Status = RefundStatusSucceeded
+			refund.RefundTransactionID = refundTransactionID
+			refund.CompletedAt = &now
+		} else {
+			refund.Status = RefundStatusFailed
+		}
+		if err := s.refundRepo.Save(ctx, refund); err != nil {
+			return err
+		}
+
+		refunds, err := s.refundRepo.FindByPaymentID(ctx, payment.ID)
+		if err != nil {
+			return err
+		}
+
+		payment.Status = aggregateRefundStatus(payment, refunds)
+		if success {
+			payment.RefundTransactionID = refundTransactionID
+		}
+		payment.UpdatedAt = now
+		return s.paymentRepo.Save(ctx, payment)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return refund, payment, nil
+}
+
+// GetRefundsByPaymentID 查询支付单的全部退款单
+func (s *RefundDomainService) GetRefundsByPaymentID(ctx context.Context, paymentID string) ([]*RefundDO, error) {
+	return s.refundRepo.FindByPaymentID(ctx, paymentID)
+}
+
+// FindCompletedRefunds 查询指定渠道在 [from, to) 内退款成功的退款单
+func (s *RefundDomainService) FindCompletedRefunds(ctx context.Context, channel PaymentChannel, from, to time.Time) ([]*RefundDO, error) {
+	return s.refundRepo.FindCompletedBetween(ctx, channel, from, to)
+}
+
+// occupiedAmount 计算已占用的退款金额，退款金额与支付单币种相同
+func occupiedAmount(payment *PaymentDO, refunds []*RefundDO) (dmoney.Money, error) {
+	currency := payment.Money().Currency()
+	total := dmoney.Zero(currency)
+	for _, r := range refunds {
+		if !r.OccupiesAmount() {
+			continue
+		}
+		var err error
+		if total, err = total.Add(dmoney.New(r.Amount, currency)); err != nil {
+			return dmoney.Money{}, err
+		}
+	}
+	return total, nil
+}
+
+// aggregateRefundStatus 根据全部退款单推导支付单状态
+func aggregateRefundStatus(payment *PaymentDO, refunds []*RefundDO) PaymentStatus {
+	var succeeded int64
+	var processing, failed bool
+	for _, r := range refunds {
+		switch r.Status {
+		case RefundStatusSucceeded:
+			succeeded += r.Amount
+		case RefundStatusProcessing:
+			processing = true
+		case RefundStatusFailed:
+			failed = true
+		}
+	}
+
+	switch {
+	case processing:
+		return PaymentStatusRefunding
+	case succeeded >= payment.Amount:
+		return PaymentStatusRefundedSuccess
+	case succeeded > 0:
+		return PaymentStatusRefunded
+	case failed:
+		return PaymentStatusRefundFailed
+	default:
+		return payment.Status
+	}
+}
@@ -0,0 +1,242 @@
+package domain_payment_core_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+// newTestUnitOfWork 直接执行 fn 的工作单元
+func newTestUnitOfWork(ctrl *gomock.Controller) *mocks.MockUnitOfWork {
+	uow := mocks.NewMockUnitOfWork(ctrl)
+	uow.EXPECT().Do(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, fn func(ctx context.Context) error) error {
+			return fn(ctx)
+		}).AnyTimes()
+	return uow
+}
+
+func newCompletedPayment() *domain_payment_core.PaymentDO {
+	return &domain_payment_core.PaymentDO{
+		ID:      "pay_123",
+		OrderID: "order_123",
+		Amount:  1000,
+		Status:  domain_payment_core.PaymentStatusCompleted,
+	}
+}
+
+// TestRefundDomainService_CreateRefund_Partial 部分退款，累计金额未超过支付金额
+func TestRefundDomainService_CreateRefund_Partial(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPaymentRepo := mocks.NewMockRepository(ctrl)
+	mockRefundRepo := mocks.NewMockRefundRepository(ctrl)
+	service := domain_payment_core.NewRefundDomainService(mockPaymentRepo, mockRefundRepo, newTestUnitOfWork(ctrl))
+
+	payment := newCompletedPayment()
+	payment.Status = domain_payment_core.PaymentStatusRefunded
+	mockPaymentRepo.EXPECT().FindByIDForUpdate(gomock.Any(), "pay_123").Return(payment, nil)
+	mockRefundRepo.EXPECT().FindByPaymentID(gomock.Any(), "pay_123").Return([]*domain_payment_core.RefundDO{
+		{ID: "r1", Amount: 300, Status: domain_payment_core.RefundStatusSucceeded},
+		{ID: "r2", Amount: 500, Status: domain_payment_core.RefundStatusFailed},
+	}, nil)
+	mockRefundRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil)
+	mockPaymentRepo.EXPECT().Save(gomock.Any(), payment).Return(nil)
+
+	refund, err := service.CreateRefund(context.Background(), "pay_123", 700, "七天无理由")
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(700), refund.Amount)
+	assert.Equal(t, domain_payment_core.RefundStatusProcessing, refund.Status)
+	assert.Equal(t, "order_123", refund.OrderID)
+	assert.Equal(t, domain_payment_core.PaymentStatusRefunding, payment.Status)
+}
+
+// TestRefundDomainService_CreateRefund_Exceeded 累计退款金额超过支付金额时拒绝
+func TestRefundDomainService_CreateRefund_Exceeded(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPaymentRepo := mocks.NewMockRepository(ctrl)
+	mockRefundRepo := mocks.NewMockRefundRepository(ctrl)
+	service := domain_payment_core.NewRefundDomainService(mockPaymentRepo, mockRefundRepo, newTestUnitOfWork(ctrl))
+
+	mockPaymentRepo.EXPECT().FindByIDForUpdate(gomock.Any(), "pay_123").Return(newCompletedPayment(), nil)
+	mockRefundRepo.EXPECT().FindByPaymentID(gomock.Any(), "pay_123").Return([]*domain_payment_core.RefundDO{
+		{ID: "r1", Amount: 300, Status: domain_payment_core.RefundStatusSucceeded},
+		{ID: "r2", Amount: 500, Status: domain_payment_core.RefundStatusProcessing},
+	}, nil)
+
+	_, err := service.CreateRefund(context.Background(), "pay_123", 201, "")
+
+	assert.ErrorIs(t, err, domain_payment_core.ErrRefundAmountExceeded)
+}
+
+// TestRefundDomainService_CreateRefund_FullRemaining 金额为0时退回剩余全部金额
+func TestRefundDomainService_CreateRefund_FullRemaining(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPaymentRepo := mocks.NewMockRepository(ctrl)
+	mockRefundRepo := mocks.NewMockRefundRepository(ctrl)
+	service := domain_payment_core.NewRefundDomainService(mockPaymentRepo, mockRefundRepo, newTestUnitOfWork(ctrl))
+
+	mockPaymentRepo.EXPECT().FindByIDForUpdate(gomock.Any(), "pay_123").Return(newCompletedPayment(), nil)
+	mockRefundRepo.EXPECT().FindByPaymentID(gomock.Any(), "pay_123").Return([]*domain_payment_core.RefundDO{
+		{ID: "r1", Amount: 300, Status: domain_payment_core.RefundStatusSucceeded},
+	}, nil)
+	mockRefundRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil)
+	mockPaymentRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil)
+
+	refund, err := service.CreateRefund(context.Background(), "pay_123", 0, "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(700), refund.Amount)
+}
+
+// TestRefundDomainService_CreateRefund_NotRefundable 未支付完成的支付单不允许退款
+func TestRefundDomainService_CreateRefund_NotRefundable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPaymentRepo := mocks.NewMockRepository(ctrl)
+	mockRefundRepo := mocks.NewMockRefundRepository(ctrl)
+	service := domain_payment_core.NewRefundDomainService(mockPaymentRepo, mockRefundRepo, newTestUnitOfWork(ctrl))
+
+	payment := newCompletedPayment()
+	payment.Status = domain_payment_core.PaymentStatusPending
+	mockPaymentRepo.EXPECT().FindByIDForUpdate(gomock.Any(), "pay_123").Return(payment, nil)
+
+	_, err := service.CreateRefund(context.Background(), "pay_123", 100, "")
+
+	assert.ErrorIs(t, err, domain_payment_core.ErrPaymentNotRefundable)
+}
+
+// TestRefundDomainService_ProcessRefundResult 退款成功后根据累计金额推导支付单状态
+func TestRefundDomainService_ProcessRefundResult(t *testing.T) {
+	cases := []struct {
+		name     string
+		refunds  []*domain_payment_core.RefundDO
+		expected domain_payment_core.PaymentStatus
+	}{
+		{
+			name: "部分退款",
+			refunds: []*domain_payment_core.RefundDO{
+				{ID: "r1", Amount: 400, Status: domain_payment_core.RefundStatusSucceeded},
+			},
+			expected: domain_payment_core.PaymentStatusRefunded,
+		},
+		{
+			name: "全额退款",
+			refunds: []*domain_payment_core.RefundDO{
+				{ID: "r0", Amount: 600, Status: domain_payment_core.RefundStatusSucceeded},
+				{ID: "r1", Amount: 400, Status: domain_payment_core.RefundStatusSucceeded},
+			},
+			expected: domain_payment_core.PaymentStatusRefundedSuccess,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockPaymentRepo := mocks.NewMockRepository(ctrl)
+			mockRefundRepo := mocks.NewMockRefundRepository(ctrl)
+			service := domain_payment_core.NewRefundDomainService(mockPaymentRepo, mockRefundRepo, newTestUnitOfWork(ctrl))
+
+			payment := newCompletedPayment()
+			payment.Status = domain_payment_core.PaymentStatusRefunding
+			refund := &domain_payment_core.RefundDO{ID: "r1", PaymentID: "pay_123", Amount: 400, Status: domain_payment_core.RefundStatusProcessing}
+
+			mockRefundRepo.EXPECT().FindByID(gomock.Any(), "r1").Return(refund, nil).Times(2)
+			mockPaymentRepo.EXPECT().FindByIDForUpdate(gomock.Any(), "pay_123").Return(payment, nil)
+			mockRefundRepo.EXPECT().Save(gomock.Any(), refund).Return(nil)
+			mockRefundRepo.EXPECT().FindByPaymentID(gomock.Any(), "pay_123").Return(c.refunds, nil)
+			mockPaymentRepo.EXPECT().Save(gomock.Any(), payment).Return(nil)
+
+			_, updated, err := service.ProcessRefundResult(context.Background(), "r1", "refund_tx_1", true)
+
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, updated.Status)
+			assert.Equal(t, "refund_tx_1", updated.RefundTransactionID)
+			assert.Equal(t, domain_payment_core.RefundStatusSucceeded, refund.Status)
+		})
+	}
+}
+
+// TestRefundDomainService_ProcessRefundResult_Idempotent 已完成的退款单重复处理时不做修改
+func TestRefundDomainService_ProcessRefundResult_Idempotent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPaymentRepo := mocks.NewMockRepository(ctrl)
+	mockRefundRepo := mocks.NewMockRefundRepository(ctrl)
+	service := domain_payment_core.NewRefundDomainService(mockPaymentRepo, mockRefundRepo, newTestUnitOfWork(ctrl))
+
+	refund := &domain_payment_core.RefundDO{ID: "r1", PaymentID: "pay_123", Amount: 400, Status: domain_payment_core.RefundStatusSucceeded}
+	mockRefundRepo.EXPECT().FindByID(gomock.Any(), "r1").Return(refund, nil).Times(2)
+	mockPaymentRepo.EXPECT().FindByIDForUpdate(gomock.Any(), "pay_123").Return(newCompletedPayment(), nil)
+
+	result, _, err := service.ProcessRefundResult(context.Background(), "r1", "refund_tx_2", false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain_payment_core.RefundStatusSucceeded, result.Status)
+}
+
+// TestRefundDomainService_ProcessRefundResult_LockPayment 在工作单元中锁定支付单后再读取和更新退款单
+func TestRefundDomainService_ProcessRefundResult_LockPayment(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	type txKey struct{}
+	inTx := func(ctx context.Context) bool { return ctx.Value(txKey{}) != nil }
+	uow := mocks.NewMockUnitOfWork(ctrl)
+	uow.EXPECT().Do(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, fn func(ctx context.Context) error) error {
+			return fn(context.WithValue(ctx, txKey{}, true))
+		})
+	mockPaymentRepo := mocks.NewMockRepository(ctrl)
+	mockRefundRepo := mocks.NewMockRefundRepository(ctrl)
+	service := domain_payment_core.NewRefundDomainService(mockPaymentRepo, mockRefundRepo, uow)
+
+	payment := newCompletedPayment()
+	payment.Status = domain_payment_core.PaymentStatusRefunding
+	refund := &domain_payment_core.RefundDO{ID: "r1", PaymentID: "pay_123", Amount: 400, Status: domain_payment_core.RefundStatusProcessing}
+	locked := mockPaymentRepo.EXPECT().FindByIDForUpdate(gomock.Any(), "pay_123").DoAndReturn(
+		func(ctx context.Context, id string) (*domain_payment_core.PaymentDO, error) {
+			assert.True(t, inTx(ctx))
+			return payment, nil
+		})
+	gomock.InOrder(
+		mockRefundRepo.EXPECT().FindByID(gomock.Any(), "r1").Return(refund, nil),
+		locked,
+		mockRefundRepo.EXPECT().FindByID(gomock.Any(), "r1").DoAndReturn(
+			func(ctx context.Context, id string) (*domain_payment_core.RefundDO, error) {
+				assert.True(t, inTx(ctx))
+				return refund, nil
+			}),
+	)
+	mockRefundRepo.EXPECT().Save(gomock.Any(), refund).DoAndReturn(
+		func(ctx context.Context, r *domain_payment_core.RefundDO) error {
+			assert.True(t, inTx(ctx))
+			return nil
+		})
+	mockRefundRepo.EXPECT().FindByPaymentID(gomock.Any(), "pay_123").Return([]*domain_payment_core.RefundDO{refund}, nil)
+	mockPaymentRepo.EXPECT().Save(gomock.Any(), payment).DoAndReturn(
+		func(ctx context.Context, p *domain_payment_core.PaymentDO) error {
+			assert.True(t, inTx(ctx))
+			return nil
+		})
+
+	_, updated, err := service.ProcessRefundResult(context.Background(), "r1", "", false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain_payment_core.RefundStatusFailed, refund.Status)
+	assert.Equal(t, domain_payment_core.PaymentStatusRefundFailed, updated.Status)
+}
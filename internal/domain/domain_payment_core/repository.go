@@ -1,10 +1,28 @@
 package domain_payment_core
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // 支付仓储接口
 type Repository interface {
 	Save(ctx context.Context, payment *PaymentDO) error
 	FindByID(ctx context.Context, id string) (*PaymentDO, error)
+	// FindByIDForUpdate 查询并锁定支付单，需在工作单元中调用，锁在事务结束时释放
+	FindByIDForUpdate(ctx context.Context, id string) (*PaymentDO, error)
 	FindByOrderID(ctx context.Context, orderID string) (*PaymentDO, error)
-}
\ No newline at end of file
+	// FindByStatuses 按更新时间升序分页查询指定状态且更新时间早于 updatedBefore 的支付单
+	FindByStatuses(ctx context.Context, statuses []PaymentStatus, updatedBefore time.Time, cursor *PaymentCursor, limit int) (*PaymentPage, error)
+	// FindCompletedBetween 查询指定渠道在 [from, to) 内支付完成的支付单
+	FindCompletedBetween(ctx context.Context, channel PaymentChannel, from, to time.Time) ([]*PaymentDO, error)
+}
+
+// 退款仓储接口
+type RefundRepository interface {
+	Save(ctx context.Context, refund *RefundDO) error
+	FindByID(ctx context.Context, id string) (*RefundDO, error)
+	FindByPaymentID(ctx context.Context, paymentID string) ([]*RefundDO, error)
+	// FindCompletedBetween 查询指定渠道在 [from, to) 内退款成功的退款单
+	FindCompletedBetween(ctx context.Context, channel PaymentChannel, from, to time.Time) ([]*RefundDO, error)
+}
@@ -2,41 +2,98 @@ package domain_payment_core
 
 import (
 	"context"
+	"fmt"
 	"time"
+
+	"github.com/vaynedu/ddd_order_example/pkg/dmoney"
 )
 
 type PaymentDomainService struct {
-	repo Repository
+	repo         Repository
+	rateProvider ExchangeRateProvider
 }
 
-func NewPaymentDomainService(repo Repository) *PaymentDomainService {
+func NewPaymentDomainService(repo Repository, rateProvider ExchangeRateProvider) *PaymentDomainService {
 	return &PaymentDomainService{
-		repo: repo,
+		repo:         repo,
+		rateProvider: rateProvider,
 	}
 }
 
-// CreatePayment 创建支付
-func (s *PaymentDomainService) CreatePayment(ctx context.Context, orderID string, amount int64, currency string, channel int) (*PaymentDO, error) {
+// CreatePayment 创建支付，orderAmount 为订单币种的订单金额
+// 渠道收款币种与订单币种不同时按当前汇率换算支付金额，并把汇率快照到支付单上，后续退款、对账均以支付金额为准
+func (s *PaymentDomainService) CreatePayment(ctx context.Context, orderID string, orderAmount dmoney.Money, channel int) (*PaymentDO, error) {
+	if !orderAmount.Currency().IsValid() {
+		return nil, fmt.Errorf("%w: %s", dmoney.ErrUnknownCurrency, orderAmount.Currency())
+	}
+
+	now := time.Now()
 	paymentDO := &PaymentDO{
 		ID:        orderID,
 		OrderID:   orderID,
-		Amount:    amount,
-		Currency:  currency,
 		Channel:   channel,
 		Status:    PaymentStatusCreated,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		CreatedAt: now,
+		UpdatedAt: now,
 	}
+
+	amount := orderAmount
+	if currency := PaymentChannel(channel).SettlementCurrency(); currency != orderAmount.Currency() {
+		quote, err := s.rateProvider.GetRate(ctx, orderAmount.Currency(), currency)
+		if err != nil {
+			return nil, fmt.Errorf("查询%s兑%s汇率失败: %w", orderAmount.Currency(), currency, err)
+		}
+		if quote.Rate.To() != currency {
+			return nil, fmt.Errorf("%w: 汇率目标币种为%s，渠道收款币种为%s", dmoney.ErrCurrencyMismatch, quote.Rate.To(), currency)
+		}
+		if amount, err = quote.Rate.Convert(orderAmount); err != nil {
+			return nil, fmt.Errorf("支付金额换算失败: %w", err)
+		}
+		quotedAt := quote.QuotedAt
+		paymentDO.OrderAmount = orderAmount.Amount()
+		paymentDO.OrderCurrency = string(orderAmount.Currency())
+		paymentDO.ExchangeRate = quote.Rate.String()
+		paymentDO.RateSource = quote.Source
+		paymentDO.RateQuotedAt = &quotedAt
+	}
+
+	paymentDO.Amount = amount.Amount()
+	paymentDO.Currency = string(amount.Currency())
 	return paymentDO, s.repo.Save(ctx, paymentDO)
 }
 
+// MarkPaymentPending 渠道下单成功，支付单进入待支付，等待渠道异步通知支付结果
+func (s *PaymentDomainService) MarkPaymentPending(ctx context.Context, paymentID string) error {
+	payment, err := s.repo.FindByID(ctx, paymentID)
+	if err != nil {
+		return err
+	}
+
+	if payment.Status == PaymentStatusPending {
+		return nil
+	}
+	if payment.Status != PaymentStatusCreated {
+		return ErrInvalidPaymentStatus
+	}
+
+	payment.Status = PaymentStatusPending
+	payment.UpdatedAt = time.Now()
+	return s.repo.Save(ctx, payment)
+}
+
 // 处理支付结果
+// 渠道通知可能重复投递，支付结果已确定的支付单直接忽略，保证幂等
 func (s *PaymentDomainService) ProcessPaymentResult(ctx context.Context, paymentID, transactionID string, success bool) error {
 	payment, err := s.repo.FindByID(ctx, paymentID)
 	if err != nil {
 		return err
 	}
 
+	if payment.IsFinished() {
+		return nil
+	}
+
+	payment.UpdatedAt = time.Now()
 	if success {
 		now := time.Now()
 		payment.Status = PaymentStatusCompleted
@@ -49,6 +106,61 @@ func (s *PaymentDomainService) ProcessPaymentResult(ctx context.Context, payment
 	return s.repo.Save(ctx, payment)
 }
 
+// ExpirePayment 订单超时未支付，关闭尚未确定支付结果的支付单
+// 已支付的支付单不允许关闭，其他已确定结果的支付单直接忽略
+func (s *PaymentDomainService) ExpirePayment(ctx context.Context, paymentID string) error {
+	payment, err := s.repo.FindByID(ctx, paymentID)
+	if err != nil {
+		return err
+	}
+
+	if payment.IsSucceeded() {
+		return ErrPaymentPaid
+	}
+	if payment.IsFinished() {
+		return nil
+	}
+
+	payment.Status = PaymentStatusExpired
+	payment.UpdatedAt = time.Now()
+	return s.repo.Save(ctx, payment)
+}
+
+// ReopenPayment 重新打开支付失败或已关闭的支付单，用于订单仍可支付时再次发起支付
+// 支付单ID与订单ID相同，每个订单只有一张支付单，重新支付时复用原支付单
+func (s *PaymentDomainService) ReopenPayment(ctx context.Context, paymentID string) (*PaymentDO, error) {
+	payment, err := s.repo.FindByID(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch payment.Status {
+	case PaymentStatusCreated, PaymentStatusPending:
+		return payment, nil
+	case PaymentStatusFailed, PaymentStatusExpired, PaymentStatusCanceled, PaymentStatusClosed:
+	default:
+		if payment.IsSucceeded() {
+			return nil, ErrPaymentPaid
+		}
+		return nil, ErrInvalidPaymentStatus
+	}
+
+	payment.Status = PaymentStatusCreated
+	payment.TransactionID = ""
+	payment.UpdatedAt = time.Now()
+	return payment, s.repo.Save(ctx, payment)
+}
+
+// FindUnsettledPayments 分页查询更新时间早于 updatedBefore 仍未确定支付结果的支付单
+func (s *PaymentDomainService) FindUnsettledPayments(ctx context.Context, updatedBefore time.Time, cursor *PaymentCursor, limit int) (*PaymentPage, error) {
+	statuses := []PaymentStatus{PaymentStatusCreated, PaymentStatusPending}
+	return s.repo.FindByStatuses(ctx, statuses, updatedBefore, cursor, limit)
+}
+
+// FindCompletedPayments 查询指定渠道在 [from, to) 内支付完成的支付单
+func (s *PaymentDomainService) FindCompletedPayments(ctx context.Context, channel PaymentChannel, from, to time.Time) ([]*PaymentDO, error) {
+	return s.repo.FindCompletedBetween(ctx, channel, from, to)
+}
 
 func (s *PaymentDomainService) GetPaymentByOrderID(ctx context.Context, orderID string) (*PaymentDO, error) {
 	return s.repo.FindByOrderID(ctx, orderID)
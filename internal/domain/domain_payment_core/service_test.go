@@ -0,0 +1,149 @@
+package domain_payment_core_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/mocks"
+	"github.com/vaynedu/ddd_order_example/pkg/dmoney"
+	"go.uber.org/mock/gomock"
+)
+
+// TestPaymentDomainService_CreatePayment_SameCurrency 订单币种与渠道收款币种相同时不查询汇率
+func TestPaymentDomainService_CreatePayment_SameCurrency(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPaymentRepo := mocks.NewMockRepository(ctrl)
+	mockRateProvider := mocks.NewMockExchangeRateProvider(ctrl)
+	service := domain_payment_core.NewPaymentDomainService(mockPaymentRepo, mockRateProvider)
+
+	mockPaymentRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil)
+
+	payment, err := service.CreatePayment(context.Background(), "order_123", dmoney.New(1999, dmoney.CNY), int(domain_payment_core.PaymentChannelAlipay))
+
+	require.NoError(t, err)
+	assert.Equal(t, dmoney.New(1999, dmoney.CNY), payment.Money())
+	assert.False(t, payment.HasExchangeRate())
+	assert.Equal(t, payment.Money(), payment.OrderMoney())
+	assert.Nil(t, payment.RateQuotedAt)
+}
+
+// TestPaymentDomainService_CreatePayment_Converted 币种不同时按汇率换算支付金额并快照汇率
+func TestPaymentDomainService_CreatePayment_Converted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPaymentRepo := mocks.NewMockRepository(ctrl)
+	mockRateProvider := mocks.NewMockExchangeRateProvider(ctrl)
+	service := domain_payment_core.NewPaymentDomainService(mockPaymentRepo, mockRateProvider)
+
+	quotedAt := time.Date(2024, 6, 1, 0, 0, 0, 0, time.Local)
+	rate, err := dmoney.ParseExchangeRate(dmoney.USD, dmoney.CNY, "7.1234")
+	require.NoError(t, err)
+	mockRateProvider.EXPECT().GetRate(gomock.Any(), dmoney.USD, dmoney.CNY).Return(&domain_payment_core.ExchangeRateQuote{
+		Rate:     rate,
+		Source:   "file:exchange_rates.json",
+		QuotedAt: quotedAt,
+	}, nil)
+
+	var saved *domain_payment_core.PaymentDO
+	mockPaymentRepo.EXPECT().Save(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, payment *domain_payment_core.PaymentDO) error {
+			saved = payment
+			return nil
+		})
+
+	payment, err := service.CreatePayment(context.Background(), "order_123", dmoney.New(1999, dmoney.USD), int(domain_payment_core.PaymentChannelAlipay))
+
+	require.NoError(t, err)
+	assert.Same(t, saved, payment)
+	// 19.99 * 7.1234 = 142.396766，四舍五入为 142.40
+	assert.Equal(t, dmoney.New(14240, dmoney.CNY), payment.Money())
+	assert.Equal(t, dmoney.New(1999, dmoney.USD), payment.OrderMoney())
+	assert.Equal(t, "7.1234", payment.ExchangeRate)
+	assert.Equal(t, "file:exchange_rates.json", payment.RateSource)
+	assert.Equal(t, quotedAt, *payment.RateQuotedAt)
+}
+
+// TestPaymentDomainService_CreatePayment_RateNotFound 没有可用汇率时不创建支付单
+func TestPaymentDomainService_CreatePayment_RateNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPaymentRepo := mocks.NewMockRepository(ctrl)
+	mockRateProvider := mocks.NewMockExchangeRateProvider(ctrl)
+	service := domain_payment_core.NewPaymentDomainService(mockPaymentRepo, mockRateProvider)
+
+	mockRateProvider.EXPECT().GetRate(gomock.Any(), dmoney.EUR, dmoney.CNY).Return(nil, domain_payment_core.ErrExchangeRateNotFound)
+
+	_, err := service.CreatePayment(context.Background(), "order_123", dmoney.New(100, dmoney.EUR), int(domain_payment_core.PaymentChannelAlipay))
+
+	assert.True(t, errors.Is(err, domain_payment_core.ErrExchangeRateNotFound))
+}
+
+// TestPaymentDomainService_CreatePayment_RateTargetMismatch 汇率目标币种与渠道收款币种不一致时拒绝
+func TestPaymentDomainService_CreatePayment_RateTargetMismatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPaymentRepo := mocks.NewMockRepository(ctrl)
+	mockRateProvider := mocks.NewMockExchangeRateProvider(ctrl)
+	service := domain_payment_core.NewPaymentDomainService(mockPaymentRepo, mockRateProvider)
+
+	rate, err := dmoney.ParseExchangeRate(dmoney.USD, dmoney.HKD, "7.8")
+	require.NoError(t, err)
+	mockRateProvider.EXPECT().GetRate(gomock.Any(), dmoney.USD, dmoney.CNY).Return(&domain_payment_core.ExchangeRateQuote{Rate: rate}, nil)
+
+	_, err = service.CreatePayment(context.Background(), "order_123", dmoney.New(100, dmoney.USD), int(domain_payment_core.PaymentChannelAlipay))
+
+	assert.True(t, errors.Is(err, dmoney.ErrCurrencyMismatch))
+}
+
+// TestPaymentDomainService_ReopenPayment 支付失败或已关闭的支付单重新打开为已创建，已支付的支付单不允许重新打开
+func TestPaymentDomainService_ReopenPayment(t *testing.T) {
+	tests := []struct {
+		status domain_payment_core.PaymentStatus
+		want   domain_payment_core.PaymentStatus
+		err    error
+	}{
+		{status: domain_payment_core.PaymentStatusFailed, want: domain_payment_core.PaymentStatusCreated},
+		{status: domain_payment_core.PaymentStatusExpired, want: domain_payment_core.PaymentStatusCreated},
+		{status: domain_payment_core.PaymentStatusPending, want: domain_payment_core.PaymentStatusPending},
+		{status: domain_payment_core.PaymentStatusCompleted, err: domain_payment_core.ErrPaymentPaid},
+		{status: domain_payment_core.PaymentStatusRefunded, err: domain_payment_core.ErrInvalidPaymentStatus},
+	}
+
+	for _, tt := range tests {
+		t.Run(domain_payment_core.GetPaymentStatusDetail(tt.status), func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockPaymentRepo := mocks.NewMockRepository(ctrl)
+			service := domain_payment_core.NewPaymentDomainService(mockPaymentRepo, nil)
+
+			payment := &domain_payment_core.PaymentDO{ID: "order_123", OrderID: "order_123", Status: tt.status, TransactionID: "trade_123"}
+			mockPaymentRepo.EXPECT().FindByID(gomock.Any(), "order_123").Return(payment, nil)
+			if tt.err == nil && tt.want != tt.status {
+				mockPaymentRepo.EXPECT().Save(gomock.Any(), payment).Return(nil)
+			}
+
+			reopened, err := service.ReopenPayment(context.Background(), "order_123")
+
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, reopened.Status)
+			if tt.want != tt.status {
+				assert.Empty(t, reopened.TransactionID)
+			}
+		})
+	}
+}
@@ -6,6 +6,8 @@ type Product struct {
 	Name   string
 	Status ProductStatus
 	Price  int64
+	// Currency 价格币种，Price 为该币种的最小单位
+	Currency string
 	// 其他领域属性...
 }
 
@@ -7,6 +7,7 @@ type ValidateProductRequest struct {
 	ProductID string
 	Name      string
 	Price     int64
+	Currency  string // 订单币种，商品价格不是该币种时校验失败
 	Quantity  int64
 }
 
@@ -19,8 +20,19 @@ type ValidateProductResponse struct {
 	// 后续考虑新增全局错误码
 }
 
+// ValidateProductResult 批量验证中单个商品的验证结果
+// Err 不为空表示该商品调用失败，此时 Response 为空
+type ValidateProductResult struct {
+	Request  *ValidateProductRequest
+	Response *ValidateProductResponse
+	Err      error
+}
+
 // ProductService 商品服务抽象接口
 type ProductService interface {
 	// ValidateProduct 验证商品是否合法可用
 	ValidateProduct(ctx context.Context, req *ValidateProductRequest) (*ValidateProductResponse, error)
+	// ValidateProducts 批量验证商品，返回结果与请求按下标一一对应
+	// 单个商品的失败记录在对应结果的 Err 中，只有整体调用失败(如ctx取消)才返回error
+	ValidateProducts(ctx context.Context, reqs []*ValidateProductRequest) ([]*ValidateProductResult, error)
 }
@@ -0,0 +1,159 @@
+package domain_promotion_core
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/vaynedu/ddd_order_example/pkg/dmoney"
+)
+
+// TableName 指定模型对应的数据库表名
+func (CouponDO) TableName() string {
+	return "t_coupon"
+}
+
+// CouponDO 优惠券定义，金额字段均为 Currency 币种的最小单位
+type CouponDO struct {
+	ID               string       `json:"id" gorm:"column:id;primaryKey"`
+	Code             string       `json:"code" gorm:"column:code"` // 券码，下单时客户端传入
+	Name             string       `json:"name" gorm:"column:name"`
+	Type             CouponType   `json:"type" gorm:"column:type"`
+	Currency         string       `json:"currency" gorm:"column:currency"`                         // 金额类优惠的币种，只能用于同币种订单
+	Amount           int64        `json:"amount" gorm:"column:amount"`                             // 立减、满减的优惠金额
+	PercentOff       int64        `json:"percent_off" gorm:"column:percent_off"`                   // 折扣比例，单位：万分之一，如 1500 表示减 15%(八五折)
+	MaxDiscount      int64        `json:"max_discount" gorm:"column:max_discount"`                 // 折扣券最高优惠金额，0 表示不限
+	Threshold        int64        `json:"threshold" gorm:"column:threshold"`                       // 满减门槛，适用商品金额达到门槛才可使用
+	BuyN             int64        `json:"buy_n" gorm:"column:buy_n"`                               // 买N送M：每购买 N 件
+	GetM             int64        `json:"get_m" gorm:"column:get_m"`                               // 买N送M：赠送 M 件
+	ProductIDs       []string     `json:"product_ids" gorm:"column:product_ids;serializer:json"`   // 适用商品，为空表示全部商品
+	CustomerIDs      []string     `json:"customer_ids" gorm:"column:customer_ids;serializer:json"` // 可用客户，为空表示全部客户
+	PerCustomerLimit int64        `json:"per_customer_limit" gorm:"column:per_customer_limit"`     // 每个客户可使用次数，0 表示不限
+	Exclusive        bool         `json:"exclusive" gorm:"column:exclusive"`                       // 独占券不能与其他优惠券叠加
+	StartAt          time.Time    `json:"start_at" gorm:"column:start_at"`                         // 生效时间(含)
+	EndAt            *time.Time   `json:"end_at" gorm:"column:end_at"`                             // 失效时间(不含)，为空表示长期有效
+	Status           CouponStatus `json:"status" gorm:"column:status"`
+	CreatedAt        time.Time    `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt        time.Time    `json:"updated_at" gorm:"column:updated_at"`
+}
+
+// CouponType 优惠券类型
+type CouponType string
+
+const (
+	CouponTypeFixed      CouponType = "fixed"       // 立减：适用商品减固定金额
+	CouponTypePercentage CouponType = "percentage"  // 折扣：适用商品按比例优惠，可设置最高优惠金额
+	CouponTypeThreshold  CouponType = "threshold"   // 满减：适用商品金额满门槛减固定金额
+	CouponTypeBuyNGetM   CouponType = "buy_n_get_m" // 买N送M：同一商品每 N+M 件免 M 件
+)
+
+// couponTypePriority 叠加使用时的计算顺序，后计算的优惠券以前面优惠后的金额为基数
+// 先按件赠送，再判断满减门槛，然后打折，最后立减
+var couponTypePriority = map[CouponType]int{
+	CouponTypeBuyNGetM:   0,
+	CouponTypeThreshold:  1,
+	CouponTypePercentage: 2,
+	CouponTypeFixed:      3,
+}
+
+// percentBase 折扣比例的基数，PercentOff 为万分比
+const percentBase = 10000
+
+func GetCouponTypeDetail(couponType CouponType) string {
+	switch couponType {
+	case CouponTypeFixed:
+		return "立减"
+	case CouponTypePercentage:
+		return "折扣"
+	case CouponTypeThreshold:
+		return "满减"
+	case CouponTypeBuyNGetM:
+		return "买N送M"
+	default:
+		return "未知"
+	}
+}
+
+// CouponStatus 优惠券状态
+type CouponStatus string
+
+const (
+	CouponStatusActive   CouponStatus = "active"   // 可用
+	CouponStatusDisabled CouponStatus = "disabled" // 已停用
+)
+
+// Validate 校验优惠券定义是否完整
+func (c *CouponDO) Validate() error {
+	if c.ID == "" || c.Code == "" {
+		return fmt.Errorf("%w: 优惠券ID和券码不能为空", ErrInvalidCoupon)
+	}
+	if c.Currency != "" && !dmoney.Currency(c.Currency).IsValid() {
+		return fmt.Errorf("%w: %w: %s", ErrInvalidCoupon, dmoney.ErrUnknownCurrency, c.Currency)
+	}
+	if c.EndAt != nil && !c.StartAt.Before(*c.EndAt) {
+		return fmt.Errorf("%w: 有效期无效", ErrInvalidCoupon)
+	}
+	if c.PerCustomerLimit < 0 {
+		return fmt.Errorf("%w: 使用次数限制不能为负数", ErrInvalidCoupon)
+	}
+
+	switch c.Type {
+	case CouponTypeFixed:
+		if c.Amount <= 0 || c.Currency == "" {
+			return fmt.Errorf("%w: 立减券需要指定币种和大于0的优惠金额", ErrInvalidCoupon)
+		}
+	case CouponTypeThreshold:
+		if c.Amount <= 0 || c.Threshold < c.Amount || c.Currency == "" {
+			return fmt.Errorf("%w: 满减券需要指定币种，优惠金额大于0且不超过门槛", ErrInvalidCoupon)
+		}
+	case CouponTypePercentage:
+		if c.PercentOff <= 0 || c.PercentOff > percentBase {
+			return fmt.Errorf("%w: 折扣比例必须在(0, %d]之间", ErrInvalidCoupon, percentBase)
+		}
+		if c.MaxDiscount < 0 || (c.MaxDiscount > 0 && c.Currency == "") {
+			return fmt.Errorf("%w: 折扣券的最高优惠金额需要指定币种", ErrInvalidCoupon)
+		}
+	case CouponTypeBuyNGetM:
+		if c.BuyN <= 0 || c.GetM <= 0 {
+			return fmt.Errorf("%w: 买N送M的N和M必须大于0", ErrInvalidCoupon)
+		}
+	default:
+		return fmt.Errorf("%w: 未知的优惠券类型%s", ErrInvalidCoupon, c.Type)
+	}
+	return nil
+}
+
+// IsActiveAt 优惠券在指定时间是否可用
+func (c *CouponDO) IsActiveAt(t time.Time) bool {
+	if c.Status != CouponStatusActive || t.Before(c.StartAt) {
+		return false
+	}
+	return c.EndAt == nil || t.Before(*c.EndAt)
+}
+
+// AppliesToProduct 商品是否在适用范围内
+func (c *CouponDO) AppliesToProduct(productID string) bool {
+	return len(c.ProductIDs) == 0 || slices.Contains(c.ProductIDs, productID)
+}
+
+// AvailableTo 客户是否可以使用
+func (c *CouponDO) AvailableTo(customerID string) bool {
+	return len(c.CustomerIDs) == 0 || slices.Contains(c.CustomerIDs, customerID)
+}
+
+// checkApplicable 校验优惠券对该客户、币种在指定时间是否可用，不校验使用次数
+func (c *CouponDO) checkApplicable(customerID string, currency dmoney.Currency, now time.Time) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	if !c.IsActiveAt(now) {
+		return fmt.Errorf("%w: 优惠券[%s]未生效或已失效", ErrCouponNotApplicable, c.Code)
+	}
+	if !c.AvailableTo(customerID) {
+		return fmt.Errorf("%w: 优惠券[%s]不适用于该客户", ErrCouponNotApplicable, c.Code)
+	}
+	if c.Currency != "" && dmoney.Currency(c.Currency) != currency {
+		return fmt.Errorf("%w: 优惠券[%s]币种为%s，订单币种为%s", ErrCouponNotApplicable, c.Code, c.Currency, currency)
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+package domain_promotion_core
+
+import "errors"
+
+// 优惠领域错误定义
+var (
+	ErrCouponNotFound           = errors.New("优惠券不存在")
+	ErrInvalidCoupon            = errors.New("优惠券定义无效")
+	ErrCouponNotApplicable      = errors.New("优惠券不满足使用条件")
+	ErrCouponNotStackable       = errors.New("优惠券不能叠加使用")
+	ErrCouponUsageLimitExceeded = errors.New("优惠券使用次数已达上限")
+)
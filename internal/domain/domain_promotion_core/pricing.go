@@ -0,0 +1,200 @@
+package domain_promotion_core
+
+import (
+	"fmt"
+	"math/big"
+	"slices"
+	"time"
+
+	"github.com/vaynedu/ddd_order_example/pkg/dmoney"
+)
+
+// PricingLine 参与优惠计算的订单行，金额为订单币种的最小单位
+type PricingLine struct {
+	ProductID string
+	Quantity  int64
+	UnitPrice int64
+	Subtotal  int64
+}
+
+// CouponDiscount 单张优惠券的优惠金额及其在各订单行上的分摊
+type CouponDiscount struct {
+	CouponID         string
+	Code             string
+	Type             CouponType
+	PerCustomerLimit int64 // 占用时按该上限校验使用次数
+	Amount           int64
+	Lines            []int64 // 分摊到各订单行的金额，与输入的订单行一一对应
+}
+
+// PricingResult 优惠计算结果，LineDiscounts 与输入的订单行一一对应
+type PricingResult struct {
+	Currency      dmoney.Currency
+	Coupons       []*CouponDiscount
+	LineDiscounts []int64
+	TotalDiscount int64
+}
+
+// CouponIDs 实际使用的优惠券ID，按计算顺序排列，未使用优惠券时为空
+func (r *PricingResult) CouponIDs() []string {
+	if len(r.Coupons) == 0 {
+		return nil
+	}
+	ids := make([]string, len(r.Coupons))
+	for i, c := range r.Coupons {
+		ids[i] = c.CouponID
+	}
+	return ids
+}
+
+// CalculateDiscounts 计算订单使用优惠券后的优惠金额，并按适用商品金额分摊到每个订单行
+//
+// 叠加规则：
+//   - 同一张优惠券只能使用一次，同一类型的优惠券最多使用一张
+//   - 独占券不能与其他优惠券叠加
+//   - 按 买N送M、满减、折扣、立减 的顺序计算，后计算的优惠券以前面优惠后的金额为基数
+//   - 每张优惠券只作用于其适用商品，优惠金额不超过适用商品的剩余金额，结果为0视为不满足使用条件
+//
+// 使用次数限制需要查询已使用记录，由 PromotionDomainService 校验
+func CalculateDiscounts(customerID string, currency dmoney.Currency, lines []PricingLine, coupons []*CouponDO, now time.Time) (*PricingResult, error) {
+	if err := checkStacking(coupons); err != nil {
+		return nil, err
+	}
+	for _, c := range coupons {
+		if err := c.checkApplicable(customerID, currency, now); err != nil {
+			return nil, err
+		}
+	}
+
+	ordered := slices.Clone(coupons)
+	slices.SortStableFunc(ordered, func(a, b *CouponDO) int {
+		return couponTypePriority[a.Type] - couponTypePriority[b.Type]
+	})
+
+	remaining := make([]int64, len(lines))
+	for i, line := range lines {
+		remaining[i] = line.Subtotal
+	}
+	result := &PricingResult{Currency: currency, LineDiscounts: make([]int64, len(lines))}
+	total := dmoney.Zero(currency)
+	for _, c := range ordered {
+		shares, err := couponShares(c, currency, lines, remaining)
+		if err != nil {
+			return nil, err
+		}
+
+		discount := &CouponDiscount{
+			CouponID:         c.ID,
+			Code:             c.Code,
+			Type:             c.Type,
+			PerCustomerLimit: c.PerCustomerLimit,
+			Lines:            shares,
+		}
+		for i, share := range shares {
+			remaining[i] -= share
+			result.LineDiscounts[i] += share
+			discount.Amount += share
+		}
+		if discount.Amount == 0 {
+			return nil, fmt.Errorf("%w: 优惠券[%s]未达到使用条件", ErrCouponNotApplicable, c.Code)
+		}
+		if total, err = total.Add(dmoney.New(discount.Amount, currency)); err != nil {
+			return nil, err
+		}
+		result.Coupons = append(result.Coupons, discount)
+	}
+	result.TotalDiscount = total.Amount()
+	return result, nil
+}
+
+// checkStacking 校验优惠券组合是否允许叠加
+func checkStacking(coupons []*CouponDO) error {
+	ids := make(map[string]bool, len(coupons))
+	types := make(map[CouponType]bool, len(coupons))
+	for _, c := range coupons {
+		if ids[c.ID] {
+			return fmt.Errorf("%w: 优惠券[%s]重复使用", ErrCouponNotStackable, c.Code)
+		}
+		if types[c.Type] {
+			return fmt.Errorf("%w: 同一订单最多使用一张%s券", ErrCouponNotStackable, GetCouponTypeDetail(c.Type))
+		}
+		if c.Exclusive && len(coupons) > 1 {
+			return fmt.Errorf("%w: 优惠券[%s]不能与其他优惠券同时使用", ErrCouponNotStackable, c.Code)
+		}
+		ids[c.ID] = true
+		types[c.Type] = true
+	}
+	return nil
+}
+
+// couponShares 计算单张优惠券在各订单行上的优惠金额，remaining 为各行已优惠后的剩余金额
+func couponShares(c *CouponDO, currency dmoney.Currency, lines []PricingLine, remaining []int64) ([]int64, error) {
+	shares := make([]int64, len(lines))
+
+	// 买N送M按行计算，每 N+M 件免 M 件，按单价计优惠
+	if c.Type == CouponTypeBuyNGetM {
+		for i, line := range lines {
+			if !c.AppliesToProduct(line.ProductID) {
+				continue
+			}
+			free := line.Quantity / (c.BuyN + c.GetM) * c.GetM
+			discount, err := dmoney.New(line.UnitPrice, currency).Mul(free)
+			if err != nil {
+				return nil, err
+			}
+			shares[i] = min(discount.Amount(), remaining[i])
+		}
+		return shares, nil
+	}
+
+	// 其余类型按适用商品的剩余金额合计计算，再按各行剩余金额比例分摊
+	var eligible []int
+	ratios := make([]int64, 0, len(lines))
+	base := dmoney.Zero(currency)
+	for i, line := range lines {
+		if !c.AppliesToProduct(line.ProductID) || remaining[i] <= 0 {
+			continue
+		}
+		var err error
+		if base, err = base.Add(dmoney.New(remaining[i], currency)); err != nil {
+			return nil, err
+		}
+		eligible = append(eligible, i)
+		ratios = append(ratios, remaining[i])
+	}
+	if len(eligible) == 0 {
+		return nil, fmt.Errorf("%w: 订单中没有优惠券[%s]适用的商品", ErrCouponNotApplicable, c.Code)
+	}
+
+	var discount int64
+	switch c.Type {
+	case CouponTypeFixed:
+		discount = c.Amount
+	case CouponTypeThreshold:
+		if base.Amount() < c.Threshold {
+			return nil, fmt.Errorf("%w: 优惠券[%s]适用商品金额未满%s", ErrCouponNotApplicable, c.Code,
+				dmoney.New(c.Threshold, currency))
+		}
+		discount = c.Amount
+	case CouponTypePercentage:
+		// 按比例计算的优惠向下取整，不足最小单位的部分不优惠
+		off := new(big.Int).Mul(big.NewInt(base.Amount()), big.NewInt(c.PercentOff))
+		discount = off.Quo(off, big.NewInt(percentBase)).Int64()
+		if c.MaxDiscount > 0 {
+			discount = min(discount, c.MaxDiscount)
+		}
+	}
+	discount = min(discount, base.Amount())
+	if discount == 0 {
+		return shares, nil
+	}
+
+	parts, err := dmoney.New(discount, currency).Allocate(ratios...)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range eligible {
+		shares[i] = parts[j].Amount()
+	}
+	return shares, nil
+}
@@ -0,0 +1,211 @@
+package domain_promotion_core
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vaynedu/ddd_order_example/pkg/dmoney"
+)
+
+var pricingNow = time.Date(2024, 6, 1, 12, 0, 0, 0, time.Local)
+
+// newCoupon 创建当前可用的人民币优惠券，modify 用于调整各类型的参数
+func newCoupon(id string, couponType CouponType, modify func(c *CouponDO)) *CouponDO {
+	c := &CouponDO{
+		ID:       id,
+		Code:     "CODE_" + id,
+		Type:     couponType,
+		Currency: "CNY",
+		StartAt:  pricingNow.Add(-time.Hour),
+		Status:   CouponStatusActive,
+	}
+	modify(c)
+	return c
+}
+
+// pricingLines 商品A 3件*10元，商品B 1件*20元，合计50元
+func pricingLines() []PricingLine {
+	return []PricingLine{
+		{ProductID: "prod_a", Quantity: 3, UnitPrice: 1000, Subtotal: 3000},
+		{ProductID: "prod_b", Quantity: 1, UnitPrice: 2000, Subtotal: 2000},
+	}
+}
+
+// TestCalculateDiscounts 各类型优惠券的优惠金额及按适用商品金额分摊
+func TestCalculateDiscounts(t *testing.T) {
+	cases := []struct {
+		name    string
+		coupon  *CouponDO
+		want    []int64
+		wantErr error
+	}{
+		{
+			name:   "立减按金额比例分摊",
+			coupon: newCoupon("c1", CouponTypeFixed, func(c *CouponDO) { c.Amount = 1000 }),
+			want:   []int64{600, 400},
+		},
+		{
+			name:   "立减分摊余数计入第一行",
+			coupon: newCoupon("c1", CouponTypeFixed, func(c *CouponDO) { c.Amount = 101 }),
+			want:   []int64{61, 40},
+		},
+		{
+			name: "立减不超过适用商品金额",
+			coupon: newCoupon("c1", CouponTypeFixed, func(c *CouponDO) {
+				c.Amount = 10000
+				c.ProductIDs = []string{"prod_b"}
+			}),
+			want: []int64{0, 2000},
+		},
+		{
+			name:   "折扣",
+			coupon: newCoupon("c1", CouponTypePercentage, func(c *CouponDO) { c.PercentOff = 1500 }),
+			want:   []int64{450, 300},
+		},
+		{
+			name: "折扣封顶",
+			coupon: newCoupon("c1", CouponTypePercentage, func(c *CouponDO) {
+				c.PercentOff = 1500
+				c.MaxDiscount = 500
+			}),
+			want: []int64{300, 200},
+		},
+		{
+			name: "满减只统计适用商品",
+			coupon: newCoupon("c1", CouponTypeThreshold, func(c *CouponDO) {
+				c.Threshold = 2000
+				c.Amount = 1000
+				c.ProductIDs = []string{"prod_b"}
+			}),
+			want: []int64{0, 1000},
+		},
+		{
+			name: "未达满减门槛",
+			coupon: newCoupon("c1", CouponTypeThreshold, func(c *CouponDO) {
+				c.Threshold = 6000
+				c.Amount = 1000
+			}),
+			wantErr: ErrCouponNotApplicable,
+		},
+		{
+			name: "买二送一",
+			coupon: newCoupon("c1", CouponTypeBuyNGetM, func(c *CouponDO) {
+				c.BuyN = 2
+				c.GetM = 1
+			}),
+			want: []int64{1000, 0},
+		},
+		{
+			name: "买N送M数量不足",
+			coupon: newCoupon("c1", CouponTypeBuyNGetM, func(c *CouponDO) {
+				c.BuyN = 1
+				c.GetM = 1
+				c.ProductIDs = []string{"prod_b"}
+			}),
+			wantErr: ErrCouponNotApplicable,
+		},
+		{
+			name:    "订单中没有适用商品",
+			coupon:  newCoupon("c1", CouponTypeFixed, func(c *CouponDO) { c.Amount = 100; c.ProductIDs = []string{"prod_x"} }),
+			wantErr: ErrCouponNotApplicable,
+		},
+		{
+			name:    "币种不一致",
+			coupon:  newCoupon("c1", CouponTypeFixed, func(c *CouponDO) { c.Amount = 100; c.Currency = "USD" }),
+			wantErr: ErrCouponNotApplicable,
+		},
+		{
+			name:    "不适用于该客户",
+			coupon:  newCoupon("c1", CouponTypeFixed, func(c *CouponDO) { c.Amount = 100; c.CustomerIDs = []string{"cust_2"} }),
+			wantErr: ErrCouponNotApplicable,
+		},
+		{
+			name: "已过期",
+			coupon: newCoupon("c1", CouponTypeFixed, func(c *CouponDO) {
+				end := pricingNow
+				c.Amount = 100
+				c.EndAt = &end
+			}),
+			wantErr: ErrCouponNotApplicable,
+		},
+		{
+			name:    "已停用",
+			coupon:  newCoupon("c1", CouponTypeFixed, func(c *CouponDO) { c.Amount = 100; c.Status = CouponStatusDisabled }),
+			wantErr: ErrCouponNotApplicable,
+		},
+		{
+			name:    "定义无效",
+			coupon:  newCoupon("c1", CouponTypePercentage, func(c *CouponDO) { c.PercentOff = 20000 }),
+			wantErr: ErrInvalidCoupon,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			result, err := CalculateDiscounts("cust_1", dmoney.CNY, pricingLines(), []*CouponDO{c.coupon}, pricingNow)
+			if c.wantErr != nil {
+				assert.True(t, errors.Is(err, c.wantErr), "err: %v", err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.want, result.LineDiscounts)
+			assert.Equal(t, c.want, result.Coupons[0].Lines)
+			assert.Equal(t, c.want[0]+c.want[1], result.TotalDiscount)
+			assert.Equal(t, []string{"c1"}, result.CouponIDs())
+		})
+	}
+}
+
+// TestCalculateDiscounts_Stacking 多张优惠券按类型顺序依次计算，后计算的以前面优惠后的金额为基数
+func TestCalculateDiscounts_Stacking(t *testing.T) {
+	coupons := []*CouponDO{
+		newCoupon("fixed", CouponTypeFixed, func(c *CouponDO) { c.Amount = 100 }),
+		newCoupon("percent", CouponTypePercentage, func(c *CouponDO) { c.PercentOff = 1000 }),
+		newCoupon("threshold", CouponTypeThreshold, func(c *CouponDO) { c.Threshold = 4000; c.Amount = 500 }),
+		newCoupon("bogo", CouponTypeBuyNGetM, func(c *CouponDO) { c.BuyN = 2; c.GetM = 1; c.ProductIDs = []string{"prod_a"} }),
+	}
+
+	result, err := CalculateDiscounts("cust_1", dmoney.CNY, pricingLines(), coupons, pricingNow)
+
+	assert.NoError(t, err)
+	// 买二送一后剩余 20+20 元，满40减5，再打九折减3.5元，最后立减1元
+	assert.Equal(t, []string{"bogo", "threshold", "percent", "fixed"}, result.CouponIDs())
+	assert.Equal(t, []int64{1000, 0}, result.Coupons[0].Lines)
+	assert.Equal(t, []int64{250, 250}, result.Coupons[1].Lines)
+	assert.Equal(t, []int64{175, 175}, result.Coupons[2].Lines)
+	assert.Equal(t, []int64{50, 50}, result.Coupons[3].Lines)
+	assert.Equal(t, []int64{1475, 475}, result.LineDiscounts)
+	assert.Equal(t, int64(1950), result.TotalDiscount)
+}
+
+// TestCalculateDiscounts_NotStackable 不允许的叠加组合
+func TestCalculateDiscounts_NotStackable(t *testing.T) {
+	fixed := newCoupon("fixed", CouponTypeFixed, func(c *CouponDO) { c.Amount = 100 })
+	cases := []struct {
+		name    string
+		coupons []*CouponDO
+	}{
+		{name: "同一张券重复使用", coupons: []*CouponDO{fixed, fixed}},
+		{name: "同类型券", coupons: []*CouponDO{fixed, newCoupon("fixed2", CouponTypeFixed, func(c *CouponDO) { c.Amount = 200 })}},
+		{name: "独占券", coupons: []*CouponDO{fixed, newCoupon("vip", CouponTypePercentage, func(c *CouponDO) { c.PercentOff = 1000; c.Exclusive = true })}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := CalculateDiscounts("cust_1", dmoney.CNY, pricingLines(), c.coupons, pricingNow)
+			assert.True(t, errors.Is(err, ErrCouponNotStackable), "err: %v", err)
+		})
+	}
+}
+
+// TestCalculateDiscounts_NoCoupons 不使用优惠券时没有优惠
+func TestCalculateDiscounts_NoCoupons(t *testing.T) {
+	result, err := CalculateDiscounts("cust_1", dmoney.CNY, pricingLines(), nil, pricingNow)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{0, 0}, result.LineDiscounts)
+	assert.Zero(t, result.TotalDiscount)
+	assert.Nil(t, result.CouponIDs())
+}
@@ -0,0 +1,21 @@
+package domain_promotion_core
+
+import "context"
+
+// 优惠券仓储接口
+type CouponRepository interface {
+	Save(ctx context.Context, coupon *CouponDO) error
+	// FindByCodes 按券码批量查询，不存在的券码不返回
+	FindByCodes(ctx context.Context, codes []string) ([]*CouponDO, error)
+}
+
+// 优惠券使用记录仓储接口
+type CouponUsageRepository interface {
+	// CountRedeemed 查询客户已占用的使用次数
+	CountRedeemed(ctx context.Context, couponID, customerID string) (int64, error)
+	// Redeem 原子地写入使用记录并占用次数，limits 为各优惠券的每客户使用上限(0 表示不限)
+	// 任一优惠券超过上限时整体失败并返回 ErrCouponUsageLimitExceeded；同一订单重复占用幂等
+	Redeem(ctx context.Context, usages []*CouponUsageDO, limits map[string]int64) error
+	// ReleaseByOrderID 释放订单占用的全部优惠券并归还使用次数，重复释放幂等
+	ReleaseByOrderID(ctx context.Context, orderID string) error
+}
@@ -0,0 +1,96 @@
+package domain_promotion_core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/vaynedu/ddd_order_example/pkg/dmoney"
+)
+
+type PromotionDomainService struct {
+	couponRepo CouponRepository
+	usageRepo  CouponUsageRepository
+	now        func() time.Time
+}
+
+func NewPromotionDomainService(couponRepo CouponRepository, usageRepo CouponUsageRepository) *PromotionDomainService {
+	return &PromotionDomainService{
+		couponRepo: couponRepo,
+		usageRepo:  usageRepo,
+		now:        time.Now,
+	}
+}
+
+// Price 计算订单使用优惠券后的优惠金额，不占用优惠券
+// 使用次数在这里做预检查，最终以 Redeem 时的原子占用为准
+func (s *PromotionDomainService) Price(ctx context.Context, customerID string, currency dmoney.Currency, lines []PricingLine, codes []string) (*PricingResult, error) {
+	if len(codes) == 0 {
+		return &PricingResult{Currency: currency, LineDiscounts: make([]int64, len(lines))}, nil
+	}
+
+	found, err := s.couponRepo.FindByCodes(ctx, codes)
+	if err != nil {
+		return nil, err
+	}
+	byCode := make(map[string]*CouponDO, len(found))
+	for _, c := range found {
+		byCode[c.Code] = c
+	}
+	coupons := make([]*CouponDO, 0, len(codes))
+	for _, code := range codes {
+		c, ok := byCode[code]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrCouponNotFound, code)
+		}
+		coupons = append(coupons, c)
+	}
+
+	result, err := CalculateDiscounts(customerID, currency, lines, coupons, s.now())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range coupons {
+		if c.PerCustomerLimit == 0 {
+			continue
+		}
+		used, err := s.usageRepo.CountRedeemed(ctx, c.ID, customerID)
+		if err != nil {
+			return nil, err
+		}
+		if used >= c.PerCustomerLimit {
+			return nil, fmt.Errorf("%w: 优惠券[%s]每人限用%d次", ErrCouponUsageLimitExceeded, c.Code, c.PerCustomerLimit)
+		}
+	}
+	return result, nil
+}
+
+// Redeem 按 Price 的计算结果为订单占用优惠券
+func (s *PromotionDomainService) Redeem(ctx context.Context, orderID, customerID string, result *PricingResult) error {
+	if len(result.Coupons) == 0 {
+		return nil
+	}
+	now := s.now()
+	limits := make(map[string]int64, len(result.Coupons))
+	usages := make([]*CouponUsageDO, 0, len(result.Coupons))
+	for _, d := range result.Coupons {
+		limits[d.CouponID] = d.PerCustomerLimit
+		usages = append(usages, &CouponUsageDO{
+			CouponID:   d.CouponID,
+			OrderID:    orderID,
+			CustomerID: customerID,
+			Currency:   string(result.Currency),
+			Discount:   d.Amount,
+			Status:     UsageStatusRedeemed,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		})
+	}
+	return s.usageRepo.Redeem(ctx, usages, limits)
+}
+
+// Release 释放订单占用的优惠券，订单取消或创建失败时调用
+func (s *PromotionDomainService) Release(ctx context.Context, orderID string) error {
+	return s.usageRepo.ReleaseByOrderID(ctx, orderID)
+}
@@ -0,0 +1,107 @@
+package domain_promotion_core_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_promotion_core"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/mocks"
+	"github.com/vaynedu/ddd_order_example/pkg/dmoney"
+	"go.uber.org/mock/gomock"
+)
+
+func limitedCoupon(limit int64) *domain_promotion_core.CouponDO {
+	return &domain_promotion_core.CouponDO{
+		ID:               "coupon_1",
+		Code:             "SAVE10",
+		Type:             domain_promotion_core.CouponTypeFixed,
+		Currency:         "CNY",
+		Amount:           1000,
+		PerCustomerLimit: limit,
+		StartAt:          time.Now().Add(-time.Hour),
+		Status:           domain_promotion_core.CouponStatusActive,
+	}
+}
+
+var serviceLines = []domain_promotion_core.PricingLine{
+	{ProductID: "prod_1", Quantity: 1, UnitPrice: 5000, Subtotal: 5000},
+}
+
+// TestPromotionDomainService_Price_CouponNotFound 券码不存在
+func TestPromotionDomainService_Price_CouponNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCouponRepo := mocks.NewMockCouponRepository(ctrl)
+	service := domain_promotion_core.NewPromotionDomainService(mockCouponRepo, mocks.NewMockCouponUsageRepository(ctrl))
+
+	mockCouponRepo.EXPECT().FindByCodes(gomock.Any(), []string{"SAVE10", "NOPE"}).Return([]*domain_promotion_core.CouponDO{limitedCoupon(0)}, nil)
+
+	_, err := service.Price(context.Background(), "cust_1", dmoney.CNY, serviceLines, []string{"SAVE10", "NOPE"})
+
+	assert.True(t, errors.Is(err, domain_promotion_core.ErrCouponNotFound))
+}
+
+// TestPromotionDomainService_Price_UsageLimit 客户使用次数已达上限
+func TestPromotionDomainService_Price_UsageLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCouponRepo := mocks.NewMockCouponRepository(ctrl)
+	mockUsageRepo := mocks.NewMockCouponUsageRepository(ctrl)
+	service := domain_promotion_core.NewPromotionDomainService(mockCouponRepo, mockUsageRepo)
+
+	mockCouponRepo.EXPECT().FindByCodes(gomock.Any(), gomock.Any()).Return([]*domain_promotion_core.CouponDO{limitedCoupon(2)}, nil)
+	mockUsageRepo.EXPECT().CountRedeemed(gomock.Any(), "coupon_1", "cust_1").Return(int64(2), nil)
+
+	_, err := service.Price(context.Background(), "cust_1", dmoney.CNY, serviceLines, []string{"SAVE10"})
+
+	assert.True(t, errors.Is(err, domain_promotion_core.ErrCouponUsageLimitExceeded))
+}
+
+// TestPromotionDomainService_PriceAndRedeem 计算优惠后按结果占用优惠券
+func TestPromotionDomainService_PriceAndRedeem(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCouponRepo := mocks.NewMockCouponRepository(ctrl)
+	mockUsageRepo := mocks.NewMockCouponUsageRepository(ctrl)
+	service := domain_promotion_core.NewPromotionDomainService(mockCouponRepo, mockUsageRepo)
+
+	mockCouponRepo.EXPECT().FindByCodes(gomock.Any(), gomock.Any()).Return([]*domain_promotion_core.CouponDO{limitedCoupon(2)}, nil)
+	mockUsageRepo.EXPECT().CountRedeemed(gomock.Any(), "coupon_1", "cust_1").Return(int64(1), nil)
+
+	result, err := service.Price(context.Background(), "cust_1", dmoney.CNY, serviceLines, []string{"SAVE10"})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1000}, result.LineDiscounts)
+
+	mockUsageRepo.EXPECT().Redeem(gomock.Any(), gomock.Any(), map[string]int64{"coupon_1": 2}).DoAndReturn(
+		func(ctx context.Context, usages []*domain_promotion_core.CouponUsageDO, limits map[string]int64) error {
+			require.Len(t, usages, 1)
+			assert.Equal(t, "order_1", usages[0].OrderID)
+			assert.Equal(t, "cust_1", usages[0].CustomerID)
+			assert.Equal(t, int64(1000), usages[0].Discount)
+			assert.Equal(t, domain_promotion_core.UsageStatusRedeemed, usages[0].Status)
+			return nil
+		})
+
+	assert.NoError(t, service.Redeem(context.Background(), "order_1", "cust_1", result))
+}
+
+// TestPromotionDomainService_Price_NoCodes 不使用优惠券时不查询仓储
+func TestPromotionDomainService_Price_NoCodes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := domain_promotion_core.NewPromotionDomainService(mocks.NewMockCouponRepository(ctrl), mocks.NewMockCouponUsageRepository(ctrl))
+
+	result, err := service.Price(context.Background(), "cust_1", dmoney.CNY, serviceLines, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, []int64{0}, result.LineDiscounts)
+	assert.NoError(t, service.Redeem(context.Background(), "order_1", "cust_1", result))
+}
@@ -0,0 +1,28 @@
+package domain_promotion_core
+
+import "time"
+
+// TableName 指定模型对应的数据库表名
+func (CouponUsageDO) TableName() string {
+	return "t_coupon_usage"
+}
+
+// CouponUsageDO 优惠券使用记录，一个订单对同一张优惠券只有一条记录
+type CouponUsageDO struct {
+	CouponID   string      `json:"coupon_id" gorm:"column:coupon_id;primaryKey"`
+	OrderID    string      `json:"order_id" gorm:"column:order_id;primaryKey"`
+	CustomerID string      `json:"customer_id" gorm:"column:customer_id"`
+	Currency   string      `json:"currency" gorm:"column:currency"`
+	Discount   int64       `json:"discount" gorm:"column:discount"` // 该优惠券在订单上的优惠金额
+	Status     UsageStatus `json:"status" gorm:"column:status"`
+	CreatedAt  time.Time   `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt  time.Time   `json:"updated_at" gorm:"column:updated_at"`
+}
+
+// UsageStatus 优惠券使用状态
+type UsageStatus string
+
+const (
+	UsageStatusRedeemed UsageStatus = "redeemed" // 已占用，计入使用次数
+	UsageStatusReleased UsageStatus = "released" // 订单取消后已释放，不计入使用次数
+)
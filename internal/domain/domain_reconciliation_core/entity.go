@@ -0,0 +1,88 @@
+package domain_reconciliation_core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
+)
+
+// TableName 指定模型对应的数据库表名
+func (DiscrepancyDO) TableName() string {
+	return "t_reconciliation_discrepancy"
+}
+
+// DiscrepancyDO 对账差异记录，本地支付数据与渠道不一致时写入，供财务核查处理
+type DiscrepancyDO struct {
+	ID            string            `json:"id" gorm:"column:id;primaryKey"`
+	Source        DiscrepancySource `json:"source" gorm:"column:source"`
+	Type          DiscrepancyType   `json:"type" gorm:"column:type"`
+	PaymentID     string            `json:"payment_id" gorm:"column:payment_id"`
+	RefundID      string            `json:"refund_id" gorm:"column:refund_id"` // 退款差异关联的退款单ID
+	OrderID       string            `json:"order_id" gorm:"column:order_id"`
+	TransactionID string            `json:"transaction_id" gorm:"column:transaction_id"`
+	LocalStatus   string            `json:"local_status" gorm:"column:local_status"`
+	RemoteStatus  string            `json:"remote_status" gorm:"column:remote_status"`
+	LocalAmount   int64             `json:"local_amount" gorm:"column:local_amount"`
+	RemoteAmount  int64             `json:"remote_amount" gorm:"column:remote_amount"`
+	Detail        string            `json:"detail" gorm:"column:detail"`
+	BillDate      *time.Time        `json:"bill_date" gorm:"column:bill_date"`
+	Status        DiscrepancyStatus `json:"status" gorm:"column:status"`
+	CreatedAt     time.Time         `json:"created_at" gorm:"column:created_at"`
+}
+
+// DiscrepancySource 差异来源
+type DiscrepancySource string
+
+const (
+	DiscrepancySourceGatewayQuery DiscrepancySource = "gateway_query" // 主动查询渠道支付状态
+	DiscrepancySourceStatement    DiscrepancySource = "statement"     // 渠道日对账单
+	DiscrepancySourceNotification DiscrepancySource = "notification"  // 渠道支付通知
+)
+
+// DiscrepancyType 差异类型
+type DiscrepancyType string
+
+const (
+	DiscrepancyTypeStatusMismatch DiscrepancyType = "status_mismatch" // 渠道已支付，本地未收到支付结果，已按渠道结果修正
+	DiscrepancyTypeOrderConflict  DiscrepancyType = "order_conflict"  // 渠道已支付，但订单无法流转为已支付(如已取消)，需人工退款
+	DiscrepancyTypeAmountMismatch DiscrepancyType = "amount_mismatch" // 对账单金额与本地不一致
+	DiscrepancyTypeMissingLocal   DiscrepancyType = "missing_local"   // 对账单有记录，本地没有成功的支付/退款
+	DiscrepancyTypeMissingRemote  DiscrepancyType = "missing_remote"  // 本地支付/退款成功，对账单没有记录
+)
+
+// DiscrepancyStatus 差异处理状态
+type DiscrepancyStatus string
+
+const (
+	DiscrepancyStatusOpen     DiscrepancyStatus = "open"     // 待核查
+	DiscrepancyStatusResolved DiscrepancyStatus = "resolved" // 已处理
+)
+
+// NewPaymentDiscrepancy 根据本地支付单和查询到的渠道交易创建差异记录，交易号和渠道侧金额取自渠道交易
+func NewPaymentDiscrepancy(source DiscrepancySource, typ DiscrepancyType, payment *domain_payment_core.PaymentDO, trade *domain_payment_core.ChannelTrade, detail string) *DiscrepancyDO {
+	return &DiscrepancyDO{
+		ID:            uuid.New().String(),
+		Source:        source,
+		Type:          typ,
+		PaymentID:     payment.ID,
+		OrderID:       payment.OrderID,
+		TransactionID: trade.TransactionID,
+		LocalStatus:   domain_payment_core.GetPaymentStatusDetail(payment.Status),
+		RemoteStatus:  domain_payment_core.GetPaymentStatusDetail(trade.Status),
+		LocalAmount:   payment.Amount,
+		RemoteAmount:  trade.Amount.Amount(),
+		Detail:        detail,
+		Status:        DiscrepancyStatusOpen,
+		CreatedAt:     time.Now(),
+	}
+}
+
+// NewNotificationDiscrepancy 根据支付通知创建差异记录，由差异类型、支付单和渠道交易号生成固定ID，渠道重复通知时ID不变
+func NewNotificationDiscrepancy(typ DiscrepancyType, payment *domain_payment_core.PaymentDO, trade *domain_payment_core.ChannelTrade, detail string) *DiscrepancyDO {
+	d := NewPaymentDiscrepancy(DiscrepancySourceNotification, typ, payment, trade, detail)
+	name := fmt.Sprintf("%s|%s|%s|%s", d.Source, typ, payment.ID, trade.TransactionID)
+	d.ID = uuid.NewSHA1(uuid.NameSpaceOID, []byte(name)).String()
+	return d
+}
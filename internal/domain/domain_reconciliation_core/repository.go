@@ -0,0 +1,11 @@
+package domain_reconciliation_core
+
+import "context"
+
+// DiscrepancyRepository 对账差异仓储接口
+type DiscrepancyRepository interface {
+	// Save 保存差异记录
+	Save(ctx context.Context, discrepancy *DiscrepancyDO) error
+	// Append 批量写入差异记录，ID 已存在的记录保持不变，重复对账同一份对账单不会产生重复记录
+	Append(ctx context.Context, discrepancies []*DiscrepancyDO) error
+}
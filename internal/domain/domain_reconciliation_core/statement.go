@@ -0,0 +1,202 @@
+package domain_reconciliation_core
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
+)
+
+// StatementRecordType 对账单记录类型
+type StatementRecordType string
+
+const (
+	StatementRecordPayment StatementRecordType = "payment" // 交易
+	StatementRecordRefund  StatementRecordType = "refund"  // 退款
+)
+
+// StatementRecord 渠道对账单中的一条记录
+type StatementRecord struct {
+	Type          StatementRecordType
+	TransactionID string    // 渠道交易号
+	OrderID       string    // 商户订单号，即本地订单ID
+	RefundID      string    // 退款请求号，即本地退款单ID，仅退款记录有值
+	Amount        int64     // 金额，单位：分，退款记录同样为正数
+	FinishedAt    time.Time // 渠道侧完成时间
+}
+
+// StatementParser 渠道对账单解析器，每个支付渠道注册一个实现
+type StatementParser interface {
+	// Channel 解析器对应的支付渠道
+	Channel() domain_payment_core.PaymentChannel
+	// Parse 解析对账单明细，汇总行等非明细内容直接跳过
+	Parse(r io.Reader) ([]*StatementRecord, error)
+}
+
+// StatementReport 对账单对账汇总
+type StatementReport struct {
+	Channel        domain_payment_core.PaymentChannel
+	BillDate       time.Time
+	RemoteCount    int // 对账单明细数量
+	LocalCount     int // 本地成功的支付和退款数量
+	Matched        int // 一致
+	AmountMismatch int // 金额不一致
+	MissingLocal   int // 本地缺失
+	MissingRemote  int // 对账单缺失
+	Discrepancies  []*DiscrepancyDO
+}
+
+// ReconcileStatement 将对账单明细与本地成功的支付单、退款单逐条核对
+// 交易记录优先按渠道交易号匹配支付单，本地未记录交易号时按商户订单号匹配；退款记录按退款请求号匹配退款单
+func ReconcileStatement(channel domain_payment_core.PaymentChannel, billDate time.Time, records []*StatementRecord,
+	payments []*domain_payment_core.PaymentDO, refunds []*domain_payment_core.RefundDO) *StatementReport {
+	report := &StatementReport{
+		Channel:     channel,
+		BillDate:    billDate,
+		RemoteCount: len(records),
+		LocalCount:  len(payments) + len(refunds),
+	}
+
+	paymentsByTransaction := make(map[string]*domain_payment_core.PaymentDO, len(payments))
+	paymentsByOrderID := make(map[string]*domain_payment_core.PaymentDO, len(payments))
+	unmatched := make(map[string]bool, len(payments)) // 尚未匹配的支付单ID
+	for _, p := range payments {
+		if p.TransactionID != "" {
+			paymentsByTransaction[p.TransactionID] = p
+		}
+		paymentsByOrderID[p.OrderID] = p
+		unmatched[p.ID] = true
+	}
+	refundsByID := make(map[string]*domain_payment_core.RefundDO, len(refunds))
+	for _, r := range refunds {
+		refundsByID[r.ID] = r
+	}
+
+	for _, record := range records {
+		switch record.Type {
+		case StatementRecordPayment:
+			p, ok := paymentsByTransaction[record.TransactionID]
+			if !ok {
+				p, ok = paymentsByOrderID[record.OrderID]
+			}
+			if !ok || !unmatched[p.ID] {
+				report.add(DiscrepancyTypeMissingLocal, newRecordDiscrepancy(report, record, "对账单有交易记录，本地无支付成功的支付单"))
+				continue
+			}
+			delete(unmatched, p.ID)
+			if err := compareAmount(p.Amount, record.Amount); err != nil {
+				report.add(DiscrepancyTypeAmountMismatch, newPaymentStatementDiscrepancy(report, p, record, err.Error()))
+				continue
+			}
+			report.Matched++
+		case StatementRecordRefund:
+			r, ok := refundsByID[record.RefundID]
+			if !ok {
+				report.add(DiscrepancyTypeMissingLocal, newRecordDiscrepancy(report, record, "对账单有退款记录，本地无退款成功的退款单"))
+				continue
+			}
+			delete(refundsByID, r.ID)
+			if err := compareAmount(r.Amount, record.Amount); err != nil {
+				report.add(DiscrepancyTypeAmountMismatch, newRefundStatementDiscrepancy(report, r, record, err.Error()))
+				continue
+			}
+			report.Matched++
+		}
+	}
+
+	// 剩余未匹配的本地记录在对账单中缺失，按原始顺序输出
+	for _, p := range payments {
+		if unmatched[p.ID] {
+			report.add(DiscrepancyTypeMissingRemote, newPaymentStatementDiscrepancy(report, p, nil, "本地支付成功，对账单无交易记录"))
+		}
+	}
+	for _, r := range refunds {
+		if _, ok := refundsByID[r.ID]; ok {
+			report.add(DiscrepancyTypeMissingRemote, newRefundStatementDiscrepancy(report, r, nil, "本地退款成功，对账单无退款记录"))
+		}
+	}
+	return report
+}
+
+// compareAmount 比较本地金额与对账单金额
+func compareAmount(local, remote int64) error {
+	if local != remote {
+		return fmt.Errorf("%w: 本地金额%d, 对账单金额%d", domain_payment_core.ErrPaymentAmountMismatch, local, remote)
+	}
+	return nil
+}
+
+// add 记录差异并累计对应分类的数量
+func (r *StatementReport) add(typ DiscrepancyType, d *DiscrepancyDO) {
+	d.Type = typ
+	d.ID = statementDiscrepancyID(r, typ, d)
+	switch typ {
+	case DiscrepancyTypeAmountMismatch:
+		r.AmountMismatch++
+	case DiscrepancyTypeMissingLocal:
+		r.MissingLocal++
+	case DiscrepancyTypeMissingRemote:
+		r.MissingRemote++
+	}
+	r.Discrepancies = append(r.Discrepancies, d)
+}
+
+// statementDiscrepancyID 由渠道、账单日期、差异类型和业务单号生成固定ID，重复对账时ID不变
+func statementDiscrepancyID(r *StatementReport, typ DiscrepancyType, d *DiscrepancyDO) string {
+	name := fmt.Sprintf("%d|%s|%s|%s|%s|%s|%s", r.Channel, r.BillDate.Format(time.DateOnly), typ, d.PaymentID, d.RefundID, d.TransactionID, d.Detail)
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(name)).String()
+}
+
+func newStatementDiscrepancy(r *StatementReport, detail string) *DiscrepancyDO {
+	billDate := r.BillDate
+	return &DiscrepancyDO{
+		Source:    DiscrepancySourceStatement,
+		Detail:    detail,
+		BillDate:  &billDate,
+		Status:    DiscrepancyStatusOpen,
+		CreatedAt: time.Now(),
+	}
+}
+
+// newRecordDiscrepancy 仅有对账单记录的差异
+func newRecordDiscrepancy(r *StatementReport, record *StatementRecord, detail string) *DiscrepancyDO {
+	d := newStatementDiscrepancy(r, detail)
+	d.OrderID = record.OrderID
+	d.RefundID = record.RefundID
+	d.TransactionID = record.TransactionID
+	d.RemoteAmount = record.Amount
+	return d
+}
+
+// newPaymentStatementDiscrepancy 支付单相关的差异，record 为空表示对账单缺失
+func newPaymentStatementDiscrepancy(r *StatementReport, p *domain_payment_core.PaymentDO, record *StatementRecord, detail string) *DiscrepancyDO {
+	d := newStatementDiscrepancy(r, detail)
+	d.PaymentID = p.ID
+	d.OrderID = p.OrderID
+	d.TransactionID = p.TransactionID
+	d.LocalStatus = domain_payment_core.GetPaymentStatusDetail(p.Status)
+	d.LocalAmount = p.Amount
+	if record != nil {
+		d.TransactionID = record.TransactionID
+		d.RemoteAmount = record.Amount
+	}
+	return d
+}
+
+// newRefundStatementDiscrepancy 退款单相关的差异，record 为空表示对账单缺失
+func newRefundStatementDiscrepancy(r *StatementReport, refund *domain_payment_core.RefundDO, record *StatementRecord, detail string) *DiscrepancyDO {
+	d := newStatementDiscrepancy(r, detail)
+	d.PaymentID = refund.PaymentID
+	d.RefundID = refund.ID
+	d.OrderID = refund.OrderID
+	d.TransactionID = refund.RefundTransactionID
+	d.LocalStatus = domain_payment_core.GetRefundStatusDetail(refund.Status)
+	d.LocalAmount = refund.Amount
+	if record != nil {
+		d.TransactionID = record.TransactionID
+		d.RemoteAmount = record.Amount
+	}
+	return d
+}
@@ -0,0 +1,90 @@
+package domain_reconciliation_core
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
+)
+
+// TestReconcileStatement 对账单明细与本地支付、退款逐条核对并分类
+func TestReconcileStatement(t *testing.T) {
+	billDate := time.Date(2024, 6, 1, 0, 0, 0, 0, time.Local)
+	records := []*StatementRecord{
+		{Type: StatementRecordPayment, TransactionID: "trade_1", OrderID: "order_1", Amount: 1000},
+		{Type: StatementRecordPayment, TransactionID: "trade_2", OrderID: "order_2", Amount: 2000}, // 本地未记录交易号，按商户订单号匹配
+		{Type: StatementRecordPayment, TransactionID: "trade_3", OrderID: "order_3", Amount: 2900}, // 金额不一致
+		{Type: StatementRecordPayment, TransactionID: "trade_9", OrderID: "order_9", Amount: 500},  // 本地缺失
+		{Type: StatementRecordRefund, TransactionID: "trade_1", OrderID: "order_1", RefundID: "refund_1", Amount: 300},
+		{Type: StatementRecordRefund, TransactionID: "trade_5", OrderID: "order_5", RefundID: "refund_5", Amount: 100}, // 本地缺失
+	}
+	payments := []*domain_payment_core.PaymentDO{
+		{ID: "pay_1", OrderID: "order_1", TransactionID: "trade_1", Amount: 1000, Status: domain_payment_core.PaymentStatusCompleted},
+		{ID: "pay_2", OrderID: "order_2", Amount: 2000, Status: domain_payment_core.PaymentStatusCompleted},
+		{ID: "pay_3", OrderID: "order_3", TransactionID: "trade_3", Amount: 3000, Status: domain_payment_core.PaymentStatusCompleted},
+		{ID: "pay_4", OrderID: "order_4", TransactionID: "trade_4", Amount: 4000, Status: domain_payment_core.PaymentStatusCompleted}, // 对账单缺失
+	}
+	refunds := []*domain_payment_core.RefundDO{
+		{ID: "refund_1", PaymentID: "pay_1", OrderID: "order_1", Amount: 300, Status: domain_payment_core.RefundStatusSucceeded},
+		{ID: "refund_2", PaymentID: "pay_2", OrderID: "order_2", Amount: 200, Status: domain_payment_core.RefundStatusSucceeded}, // 对账单缺失
+	}
+
+	report := ReconcileStatement(domain_payment_core.PaymentChannelAlipay, billDate, records, payments, refunds)
+
+	assert.Equal(t, 6, report.RemoteCount)
+	assert.Equal(t, 6, report.LocalCount)
+	assert.Equal(t, 3, report.Matched)
+	assert.Equal(t, 1, report.AmountMismatch)
+	assert.Equal(t, 2, report.MissingLocal)
+	assert.Equal(t, 2, report.MissingRemote)
+	if assert.Len(t, report.Discrepancies, 5) {
+		mismatch := report.Discrepancies[0]
+		assert.Equal(t, DiscrepancyTypeAmountMismatch, mismatch.Type)
+		assert.Equal(t, "pay_3", mismatch.PaymentID)
+		assert.Equal(t, int64(3000), mismatch.LocalAmount)
+		assert.Equal(t, int64(2900), mismatch.RemoteAmount)
+		assert.Equal(t, DiscrepancySourceStatement, mismatch.Source)
+		assert.Equal(t, billDate, *mismatch.BillDate)
+
+		assert.Equal(t, DiscrepancyTypeMissingLocal, report.Discrepancies[1].Type)
+		assert.Equal(t, "trade_9", report.Discrepancies[1].TransactionID)
+		assert.Equal(t, "order_9", report.Discrepancies[1].OrderID)
+		assert.Empty(t, report.Discrepancies[1].PaymentID)
+
+		// 退款差异的退款单ID记录在 RefundID，PaymentID 仍为支付单ID
+		assert.Equal(t, DiscrepancyTypeMissingLocal, report.Discrepancies[2].Type)
+		assert.Equal(t, "refund_5", report.Discrepancies[2].RefundID)
+		assert.Empty(t, report.Discrepancies[2].PaymentID)
+
+		assert.Equal(t, DiscrepancyTypeMissingRemote, report.Discrepancies[3].Type)
+		assert.Equal(t, "pay_4", report.Discrepancies[3].PaymentID)
+		assert.Equal(t, DiscrepancyTypeMissingRemote, report.Discrepancies[4].Type)
+		assert.Equal(t, "pay_2", report.Discrepancies[4].PaymentID)
+		assert.Equal(t, "refund_2", report.Discrepancies[4].RefundID)
+	}
+}
+
+// TestReconcileStatement_StableID 同一账单重复对账生成相同的差异ID
+func TestReconcileStatement_StableID(t *testing.T) {
+	billDate := time.Date(2024, 6, 1, 0, 0, 0, 0, time.Local)
+	records := []*StatementRecord{
+		{Type: StatementRecordPayment, TransactionID: "trade_9", OrderID: "order_9", Amount: 500},
+		{Type: StatementRecordRefund, TransactionID: "trade_8", OrderID: "order_8", RefundID: "refund_8", Amount: 100},
+	}
+
+	first := ReconcileStatement(domain_payment_core.PaymentChannelAlipay, billDate, records, nil, nil)
+	second := ReconcileStatement(domain_payment_core.PaymentChannelAlipay, billDate, records, nil, nil)
+	nextDay := ReconcileStatement(domain_payment_core.PaymentChannelAlipay, billDate.AddDate(0, 0, 1), records, nil, nil)
+
+	assert.Equal(t, first.Discrepancies[0].ID, second.Discrepancies[0].ID)
+	assert.NotEqual(t, first.Discrepancies[0].ID, first.Discrepancies[1].ID)
+	assert.NotEqual(t, first.Discrepancies[0].ID, nextDay.Discrepancies[0].ID)
+}
+
+// TestCompareAmount 金额不一致返回 ErrPaymentAmountMismatch
+func TestCompareAmount(t *testing.T) {
+	assert.NoError(t, compareAmount(100, 100))
+	assert.True(t, errors.Is(compareAmount(100, 99), domain_payment_core.ErrPaymentAmountMismatch))
+}
@@ -0,0 +1,10 @@
+package domain_uow_core
+
+import "context"
+
+// UnitOfWork 工作单元，保证跨多个仓储的修改原子地提交或回滚
+// 事务通过 ctx 传递，fn 内必须使用传入的 ctx 调用仓储，加入工作单元的仓储会在同一个事务中执行；
+// 已处于工作单元中时直接加入外层事务，由最外层统一提交
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
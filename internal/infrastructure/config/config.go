@@ -0,0 +1,292 @@
+// Package config 服务配置，从配置文件、环境配置文件和环境变量加载，启动时校验
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/vaynedu/ddd_order_example/pkg/database"
+)
+
+// 运行环境，每个环境可以有一个覆盖基础配置的配置文件，如 config/config.prod.yaml
+const (
+	ProfileDev  = "dev"
+	ProfileTest = "test"
+	ProfileProd = "prod"
+)
+
+// 存储驱动
+const (
+	StorageDatabase = "database"
+	StorageMemory   = "memory"
+)
+
+// ProfileEnv 指定运行环境的环境变量，命令行未指定时使用
+const ProfileEnv = "APP_PROFILE"
+
+// Config 服务配置
+// 标签 reload:"restart" 的配置项修改后需重启生效，其余配置项修改配置文件后自动生效；
+// 标签 secret:"true" 的配置项在管理接口中脱敏展示
+type Config struct {
+	Profile        string               `mapstructure:"profile" reload:"restart"`
+	Server         ServerConfig         `mapstructure:"server" reload:"restart"`
+	Storage        StorageConfig        `mapstructure:"storage" reload:"restart"`
+	Database       DatabaseConfig       `mapstructure:"database" reload:"restart"`
+	Order          OrderConfig          `mapstructure:"order"`
+	Reconciliation ReconciliationConfig `mapstructure:"reconciliation"`
+	Exchange       ExchangeConfig       `mapstructure:"exchange" reload:"restart"`
+	Saga           SagaConfig           `mapstructure:"saga"`
+	ProductAPI     ProductAPIConfig     `mapstructure:"product_api"`
+	Alipay         AlipayConfig         `mapstructure:"alipay" reload:"restart"`
+	RateLimit      RateLimitConfig      `mapstructure:"rate_limit"`
+	Admin          AdminConfig          `mapstructure:"admin"`
+	Logging        LoggingConfig        `mapstructure:"logging"`
+}
+
+// ServerConfig HTTP服务配置
+type ServerConfig struct {
+	Address string `mapstructure:"address"`
+}
+
+// StorageConfig 存储配置
+type StorageConfig struct {
+	Driver string `mapstructure:"driver"` // database 或 memory
+}
+
+// DatabaseConfig 数据库配置
+type DatabaseConfig struct {
+	Driver   string `mapstructure:"driver"` // mysql 或 sqlite
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password" secret:"true"`
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	Name     string `mapstructure:"name"`
+	Timeout  int    `mapstructure:"timeout"` // 连接超时，单位秒
+	Path     string `mapstructure:"path"`    // SQLite 数据库文件路径
+}
+
+// DSN 按驱动生成数据库连接串
+func (c DatabaseConfig) DSN() string {
+	if c.Driver == database.DriverSQLite {
+		return database.SQLiteDSN(c.Path)
+	}
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+		c.Username, c.Password, c.Host, c.Port, c.Name)
+	if c.Timeout > 0 {
+		dsn += fmt.Sprintf("&timeout=%ds", c.Timeout)
+	}
+	return dsn
+}
+
+// OrderConfig 订单配置
+type OrderConfig struct {
+	PaymentTimeout       time.Duration `mapstructure:"payment_timeout"`
+	TimeoutCheckInterval time.Duration `mapstructure:"timeout_check_interval" reload:"restart"`
+	TimeoutBatchSize     int           `mapstructure:"timeout_batch_size"`
+}
+
+// ReconciliationConfig 对账配置
+type ReconciliationConfig struct {
+	PaymentInterval time.Duration `mapstructure:"payment_interval" reload:"restart"`
+	PaymentMinAge   time.Duration `mapstructure:"payment_min_age"`
+	BatchSize       int           `mapstructure:"batch_size"`
+}
+
+// ExchangeConfig 汇率配置
+type ExchangeConfig struct {
+	RatesFile string `mapstructure:"rates_file"`
+}
+
+// SagaConfig saga配置
+type SagaConfig struct {
+	RecoveryInterval  time.Duration `mapstructure:"recovery_interval" reload:"restart"`
+	RecoveryMinAge    time.Duration `mapstructure:"recovery_min_age"`
+	RecoveryBatchSize int           `mapstructure:"recovery_batch_size"`
+}
+
+// ProductAPIConfig 第三方商品API配置
+type ProductAPIConfig struct {
+	BaseURL string        `mapstructure:"base_url" reload:"restart"`
+	APIKey  string        `mapstructure:"api_key" reload:"restart" secret:"true"`
+	Timeout time.Duration `mapstructure:"timeout"` // 单次请求超时
+}
+
+// AlipayConfig 支付宝配置，未开启时使用模拟支付渠道，支付通知不验签
+type AlipayConfig struct {
+	Enabled    bool   `mapstructure:"enabled"` // 是否开启支付宝渠道，开启后下单、查询、退款和支付通知都经由支付宝
+	AppID      string `mapstructure:"app_id"`
+	PrivateKey string `mapstructure:"private_key" secret:"true"` // 应用私钥，用于请求签名
+	PublicKey  string `mapstructure:"public_key"`                // 支付宝公钥，用于异步通知验签
+	NotifyURL  string `mapstructure:"notify_url"`                // 支付通知地址
+	Production bool   `mapstructure:"production"`                // 是否生产环境，否则使用沙箱
+}
+
+// RateLimitConfig 接口限流配置，所有 /api/ 请求共享一个令牌桶
+type RateLimitConfig struct {
+	RequestsPerSecond float64 `mapstructure:"requests_per_second"` // 每秒允许的请求数，0 表示不限流
+	Burst             int     `mapstructure:"burst"`               // 允许的突发请求数
+}
+
+// AdminConfig 管理接口配置
+type AdminConfig struct {
+	Token string `mapstructure:"token" secret:"true"` // 管理接口访问令牌，为空时关闭管理接口
+}
+
+// LoggingConfig 日志配置
+type LoggingConfig struct {
+	Level  string `mapstructure:"level"`                   // debug、info、warn 或 error
+	Format string `mapstructure:"format" reload:"restart"` // text 或 json
+}
+
+// SlogLevel 日志级别对应的 slog 级别，级别无效时返回 info
+func (c LoggingConfig) SlogLevel() slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Level)); err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
+
+// defaults 配置文件和环境变量都未设置时的默认值
+var defaults = map[string]any{
+	"profile":                         ProfileDev,
+	"server.address":                  ":8090",
+	"storage.driver":                  StorageDatabase,
+	"database.driver":                 database.DriverMySQL,
+	"database.port":                   3306,
+	"database.path":                   "data/orders.db",
+	"order.payment_timeout":           30 * time.Minute,
+	"order.timeout_check_interval":    time.Minute,
+	"order.timeout_batch_size":        100,
+	"reconciliation.payment_interval": 5 * time.Minute,
+	"reconciliation.payment_min_age":  5 * time.Minute,
+	"reconciliation.batch_size":       100,
+	"exchange.rates_file":             "config/exchange_rates.json",
+	"saga.recovery_interval":          time.Minute,
+	"saga.recovery_min_age":           5 * time.Minute,
+	"saga.recovery_batch_size":        100,
+	"product_api.timeout":             5 * time.Second,
+	"rate_limit.requests_per_second":  0,
+	"rate_limit.burst":                0,
+	"logging.level":                   "info",
+	"logging.format":                  "text",
+}
+
+// Load 加载配置，优先级从高到低：环境变量、环境配置文件、基础配置文件、默认值
+// path 为基础配置文件路径；profile 为空时依次取 APP_PROFILE 环境变量、配置文件中的 profile、默认的 dev
+// 环境配置文件与基础配置文件在同一目录，命名为 config.<profile>.yaml，不存在时只使用基础配置
+func Load(path, profile string) (*Config, error) {
+	v := viper.New()
+	for key, value := range defaults {
+		v.SetDefault(key, value)
+	}
+	for key, env := range EnvKeys {
+		if err := v.BindEnv(key, env); err != nil {
+			return nil, err
+		}
+	}
+
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("读取配置文件%s失败: %w", path, err)
+	}
+
+	if profile == "" {
+		profile = v.GetString("profile")
+	}
+	if !slices.Contains([]string{ProfileDev, ProfileTest, ProfileProd}, profile) {
+		return nil, fmt.Errorf("不支持的运行环境: %s，可选 dev/test/prod", profile)
+	}
+	v.Set("profile", profile)
+
+	if profilePath := profilePath(path, profile); fileExists(profilePath) {
+		v.SetConfigFile(profilePath)
+		if err := v.MergeInConfig(); err != nil {
+			return nil, fmt.Errorf("读取环境配置文件%s失败: %w", profilePath, err)
+		}
+	}
+
+	var cfg Config
+	if err := v.Unmarshal(&cfg); err != nil {
+		return nil, fmt.Errorf("解析配置失败: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// profilePath 环境配置文件路径，与基础配置文件在同一目录
+func profilePath(path, profile string) string {
+	return filepath.Join(filepath.Dir(path), fmt.Sprintf("config.%s%s", profile, filepath.Ext(path)))
+}
+
+// fileExists 文件是否存在
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// Validate 校验配置，返回全部错误，错误信息包含配置项和对应的环境变量
+func (c *Config) Validate() error {
+	var problems []string
+	require := func(ok bool, key, reason string) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf("配置项 %s(环境变量 %s) %s", key, EnvKeys[key], reason))
+		}
+	}
+
+	require(c.Server.Address != "", "server.address", "不能为空")
+	require(slices.Contains([]string{StorageDatabase, StorageMemory}, c.Storage.Driver), "storage.driver", "只能为 database 或 memory")
+	if c.Storage.Driver == StorageDatabase {
+		switch c.Database.Driver {
+		case database.DriverMySQL:
+			require(c.Database.Host != "", "database.host", "不能为空")
+			require(c.Database.Port > 0, "database.port", "必须大于0")
+			require(c.Database.Username != "", "database.username", "不能为空")
+			require(c.Database.Name != "", "database.name", "不能为空")
+			// 生产环境不允许空密码，避免误用本地配置连接生产库
+			require(c.Profile != ProfileProd || c.Database.Password != "", "database.password", "在 prod 环境不能为空")
+		case database.DriverSQLite:
+			require(c.Database.Path != "", "database.path", "不能为空")
+		default:
+			require(false, "database.driver", "只能为 mysql 或 sqlite")
+		}
+	}
+	if c.Alipay.Enabled {
+		require(c.Storage.Driver == StorageDatabase, "alipay.enabled", "只能在使用 database 存储时开启")
+		require(c.Alipay.AppID != "", "alipay.app_id", "开启支付宝时不能为空")
+		require(c.Alipay.PrivateKey != "", "alipay.private_key", "开启支付宝时不能为空")
+		require(c.Alipay.PublicKey != "", "alipay.public_key", "开启支付宝时不能为空")
+	}
+	// 模拟支付渠道接受不验签的支付通知，生产环境必须使用真实渠道
+	require(c.Profile != ProfileProd || c.Alipay.Enabled, "alipay.enabled", "在 prod 环境必须开启")
+
+	require(c.Order.PaymentTimeout > 0, "order.payment_timeout", "必须大于0")
+	require(c.Order.TimeoutCheckInterval > 0, "order.timeout_check_interval", "必须大于0")
+	require(c.Order.TimeoutBatchSize > 0, "order.timeout_batch_size", "必须大于0")
+	require(c.Reconciliation.PaymentInterval > 0, "reconciliation.payment_interval", "必须大于0")
+	require(c.Reconciliation.PaymentMinAge >= 0, "reconciliation.payment_min_age", "不能小于0")
+	require(c.Reconciliation.BatchSize > 0, "reconciliation.batch_size", "必须大于0")
+	require(c.Exchange.RatesFile != "", "exchange.rates_file", "不能为空")
+	require(c.Saga.RecoveryInterval > 0, "saga.recovery_interval", "必须大于0")
+	require(c.Saga.RecoveryMinAge >= 0, "saga.recovery_min_age", "不能小于0")
+	require(c.Saga.RecoveryBatchSize > 0, "saga.recovery_batch_size", "必须大于0")
+	require(c.ProductAPI.Timeout > 0, "product_api.timeout", "必须大于0")
+	require(c.RateLimit.RequestsPerSecond >= 0, "rate_limit.requests_per_second", "不能小于0")
+	require(c.RateLimit.RequestsPerSecond == 0 || c.RateLimit.Burst > 0, "rate_limit.burst", "开启限流时必须大于0")
+	var level slog.Level
+	require(level.UnmarshalText([]byte(c.Logging.Level)) == nil, "logging.level", "只能为 debug、info、warn 或 error")
+	require(slices.Contains([]string{"text", "json"}, c.Logging.Format), "logging.format", "只能为 text 或 json")
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("配置校验失败:\n  %s", strings.Join(problems, "\n  "))
+}
@@ -0,0 +1,192 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/config"
+)
+
+const baseConfig = `
+profile: "dev"
+server:
+  address: ":8090"
+database:
+  driver: "mysql"
+  username: "root"
+  password: "123456"
+  host: "localhost"
+  port: 3306
+  name: "orders"
+order:
+  payment_timeout: 15m
+alipay:
+  app_id: "2021000000000000"
+  private_key: "test-private-key"
+  public_key: "test-public-key"
+`
+
+// writeConfig 在临时目录写入配置文件，返回基础配置文件路径
+func writeConfig(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	return filepath.Join(dir, "config.yaml")
+}
+
+func TestLoad_FileAndDefaults(t *testing.T) {
+	path := writeConfig(t, map[string]string{"config.yaml": baseConfig})
+
+	cfg, err := config.Load(path, "")
+	require.NoError(t, err)
+	assert.Equal(t, config.ProfileDev, cfg.Profile)
+	assert.Equal(t, ":8090", cfg.Server.Address)
+	assert.Equal(t, config.StorageDatabase, cfg.Storage.Driver)
+	assert.Equal(t, "root", cfg.Database.Username)
+	assert.Equal(t, 15*time.Minute, cfg.Order.PaymentTimeout)
+	// 配置文件未设置的配置项使用默认值
+	assert.Equal(t, time.Minute, cfg.Order.TimeoutCheckInterval)
+	assert.Equal(t, 100, cfg.Reconciliation.BatchSize)
+	assert.Equal(t, "config/exchange_rates.json", cfg.Exchange.RatesFile)
+	assert.Equal(t, "root:123456@tcp(localhost:3306)/orders?charset=utf8mb4&parseTime=True&loc=Local", cfg.Database.DSN())
+}
+
+func TestLoad_MissingFile(t *testing.T) {
+	_, err := config.Load(filepath.Join(t.TempDir(), "config.yaml"), "")
+	assert.ErrorContains(t, err, "读取配置文件")
+}
+
+func TestLoad_EnvOverrides(t *testing.T) {
+	path := writeConfig(t, map[string]string{"config.yaml": baseConfig})
+	t.Setenv("DB_USERNAME", "app")
+	t.Setenv("DB_PORT", "3307")
+	t.Setenv("SERVER_ADDRESS", ":8080")
+	t.Setenv("ORDER_PAYMENT_TIMEOUT", "45m")
+
+	cfg, err := config.Load(path, "")
+	require.NoError(t, err)
+	assert.Equal(t, "app", cfg.Database.Username)
+	assert.Equal(t, 3307, cfg.Database.Port)
+	assert.Equal(t, ":8080", cfg.Server.Address)
+	assert.Equal(t, 45*time.Minute, cfg.Order.PaymentTimeout)
+}
+
+func TestLoad_Profile(t *testing.T) {
+	path := writeConfig(t, map[string]string{
+		"config.yaml": baseConfig,
+		"config.test.yaml": `
+storage:
+  driver: "memory"
+order:
+  payment_timeout: 1m
+`,
+		"config.prod.yaml": `
+database:
+  password: ""
+alipay:
+  enabled: true
+`,
+	})
+
+	// 命令行指定的环境优先
+	cfg, err := config.Load(path, config.ProfileTest)
+	require.NoError(t, err)
+	assert.Equal(t, config.ProfileTest, cfg.Profile)
+	assert.Equal(t, config.StorageMemory, cfg.Storage.Driver)
+	assert.Equal(t, time.Minute, cfg.Order.PaymentTimeout)
+	assert.Equal(t, "root", cfg.Database.Username)
+
+	// 未指定时取 APP_PROFILE；环境变量仍然覆盖环境配置文件
+	t.Setenv(config.ProfileEnv, config.ProfileProd)
+	_, err = config.Load(path, "")
+	assert.ErrorContains(t, err, "DB_PASSWORD")
+
+	t.Setenv("DB_PASSWORD", "secret")
+	cfg, err = config.Load(path, "")
+	require.NoError(t, err)
+	assert.Equal(t, config.ProfileProd, cfg.Profile)
+	assert.Equal(t, "secret", cfg.Database.Password)
+
+	// 没有环境配置文件时只使用基础配置
+	cfg, err = config.Load(path, config.ProfileDev)
+	require.NoError(t, err)
+	assert.Equal(t, config.StorageDatabase, cfg.Storage.Driver)
+
+	_, err = config.Load(path, "staging")
+	assert.ErrorContains(t, err, "不支持的运行环境")
+}
+
+func TestValidate(t *testing.T) {
+	path := writeConfig(t, map[string]string{"config.yaml": `
+server:
+  address: ""
+database:
+  driver: "mysql"
+  host: "localhost"
+order:
+  timeout_batch_size: 0
+`})
+
+	_, err := config.Load(path, "")
+	require.Error(t, err)
+	// 一次返回全部错误，并给出对应的环境变量
+	assert.ErrorContains(t, err, "server.address(环境变量 SERVER_ADDRESS) 不能为空")
+	assert.ErrorContains(t, err, "database.username(环境变量 DB_USERNAME) 不能为空")
+	assert.ErrorContains(t, err, "database.name(环境变量 DB_NAME) 不能为空")
+	assert.ErrorContains(t, err, "order.timeout_batch_size(环境变量 ORDER_TIMEOUT_BATCH_SIZE) 必须大于0")
+	assert.NotContains(t, err.Error(), "database.host")
+	// 未开启支付宝时使用模拟支付渠道，不需要支付宝配置
+	assert.NotContains(t, err.Error(), "alipay.")
+
+	t.Setenv("ALIPAY_ENABLED", "true")
+	_, err = config.Load(path, "")
+	assert.ErrorContains(t, err, "alipay.public_key(环境变量 ALIPAY_PUBLIC_KEY) 开启支付宝时不能为空")
+
+	t.Setenv("STORAGE_DRIVER", "memory")
+	_, err = config.Load(path, "")
+	assert.ErrorContains(t, err, "alipay.enabled(环境变量 ALIPAY_ENABLED) 只能在使用 database 存储时开启")
+
+	// 生产环境不允许使用接受不验签通知的模拟支付渠道
+	t.Setenv("ALIPAY_ENABLED", "false")
+	_, err = config.Load(path, config.ProfileProd)
+	assert.ErrorContains(t, err, "alipay.enabled(环境变量 ALIPAY_ENABLED) 在 prod 环境必须开启")
+
+	t.Setenv("STORAGE_DRIVER", "redis")
+	_, err = config.Load(path, "")
+	assert.ErrorContains(t, err, "storage.driver(环境变量 STORAGE_DRIVER) 只能为 database 或 memory")
+}
+
+func TestValidate_SQLite(t *testing.T) {
+	path := writeConfig(t, map[string]string{"config.yaml": `
+database:
+  driver: "sqlite"
+  path: "data/test.db"
+`})
+
+	// SQLite 不需要MySQL的连接信息，未开启支付宝时也不需要支付宝配置
+	cfg, err := config.Load(path, "")
+	require.NoError(t, err)
+	assert.Equal(t, "file:data/test.db?_busy_timeout=5000&_journal_mode=WAL&_txlock=immediate", cfg.Database.DSN())
+
+	t.Setenv("DB_DRIVER", "postgres")
+	_, err = config.Load(path, "")
+	assert.ErrorContains(t, err, "database.driver(环境变量 DB_DRIVER) 只能为 mysql 或 sqlite")
+}
+
+func TestLoad_RepositoryConfig(t *testing.T) {
+	path := filepath.Join("..", "..", "..", "config", "config.yaml")
+	t.Setenv("DB_PASSWORD", "secret")
+	t.Setenv("ALIPAY_APP_ID", "2021000000000000")
+	t.Setenv("ALIPAY_PRIVATE_KEY", "test-private-key")
+	t.Setenv("ALIPAY_PUBLIC_KEY", "test-public-key")
+
+	for _, profile := range []string{config.ProfileDev, config.ProfileTest, config.ProfileProd} {
+		_, err := config.Load(path, profile)
+		assert.NoError(t, err, profile)
+	}
+}
@@ -0,0 +1,42 @@
+package config
+
+// EnvKeys 配置项与环境变量的对应关系，环境变量优先级高于配置文件
+// 数据库配置沿用 .env.example 中的 DB_ 前缀，商品API沿用原有的 PRODUCT_API_URL、PRODUCT_API_KEY，
+// 其余配置项为大写的配置路径，点号换成下划线
+var EnvKeys = map[string]string{
+	"profile":                         ProfileEnv,
+	"server.address":                  "SERVER_ADDRESS",
+	"storage.driver":                  "STORAGE_DRIVER",
+	"database.driver":                 "DB_DRIVER",
+	"database.username":               "DB_USERNAME",
+	"database.password":               "DB_PASSWORD",
+	"database.host":                   "DB_HOST",
+	"database.port":                   "DB_PORT",
+	"database.name":                   "DB_NAME",
+	"database.timeout":                "DB_TIMEOUT",
+	"database.path":                   "DB_PATH",
+	"order.payment_timeout":           "ORDER_PAYMENT_TIMEOUT",
+	"order.timeout_check_interval":    "ORDER_TIMEOUT_CHECK_INTERVAL",
+	"order.timeout_batch_size":        "ORDER_TIMEOUT_BATCH_SIZE",
+	"reconciliation.payment_interval": "RECONCILIATION_PAYMENT_INTERVAL",
+	"reconciliation.payment_min_age":  "RECONCILIATION_PAYMENT_MIN_AGE",
+	"reconciliation.batch_size":       "RECONCILIATION_BATCH_SIZE",
+	"exchange.rates_file":             "EXCHANGE_RATES_FILE",
+	"saga.recovery_interval":          "SAGA_RECOVERY_INTERVAL",
+	"saga.recovery_min_age":           "SAGA_RECOVERY_MIN_AGE",
+	"saga.recovery_batch_size":        "SAGA_RECOVERY_BATCH_SIZE",
+	"product_api.base_url":            "PRODUCT_API_URL",
+	"product_api.api_key":             "PRODUCT_API_KEY",
+	"product_api.timeout":             "PRODUCT_API_TIMEOUT",
+	"alipay.enabled":                  "ALIPAY_ENABLED",
+	"alipay.app_id":                   "ALIPAY_APP_ID",
+	"alipay.private_key":              "ALIPAY_PRIVATE_KEY",
+	"alipay.public_key":               "ALIPAY_PUBLIC_KEY",
+	"alipay.notify_url":               "ALIPAY_NOTIFY_URL",
+	"alipay.production":               "ALIPAY_PRODUCTION",
+	"rate_limit.requests_per_second":  "RATE_LIMIT_REQUESTS_PER_SECOND",
+	"rate_limit.burst":                "RATE_LIMIT_BURST",
+	"admin.token":                     "ADMIN_TOKEN",
+	"logging.level":                   "LOGGING_LEVEL",
+	"logging.format":                  "LOGGING_FORMAT",
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// redactedValue 脱敏后展示的值
+const redactedValue = "******"
+
+// field 单个配置项
+type field struct {
+	key     string        // 配置路径，如 database.password
+	index   []int         // 在 Config 中的字段下标，用于定位另一个配置中的同一字段
+	value   reflect.Value // 字段值
+	restart bool          // 修改后需重启生效，结构体上的标签对其全部字段生效
+	secret  bool          // 敏感配置项
+}
+
+// walk 按配置路径遍历全部配置项
+func walk(v reflect.Value, prefix string, index []int, restart bool, visit func(f field)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		key := sf.Tag.Get("mapstructure")
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		f := field{
+			key:     key,
+			index:   append(slices.Clone(index), i),
+			value:   v.Field(i),
+			restart: restart || sf.Tag.Get("reload") == "restart",
+			secret:  sf.Tag.Get("secret") == "true",
+		}
+		if sf.Type.Kind() == reflect.Struct {
+			walk(f.value, f.key, f.index, f.restart, visit)
+			continue
+		}
+		visit(f)
+	}
+}
+
+// Redacted 按配置路径组织的配置，敏感配置项已脱敏，时长以 30m0s 的形式展示
+func (c *Config) Redacted() map[string]any {
+	result := make(map[string]any)
+	walk(reflect.ValueOf(c).Elem(), "", nil, false, func(f field) {
+		var value any = f.value.Interface()
+		switch {
+		case f.secret && !f.value.IsZero():
+			value = redactedValue
+		case f.value.Type() == reflect.TypeOf(time.Duration(0)):
+			value = time.Duration(f.value.Int()).String()
+		}
+
+		parts := strings.Split(f.key, ".")
+		node := result
+		for _, part := range parts[:len(parts)-1] {
+			child, ok := node[part].(map[string]any)
+			if !ok {
+				child = make(map[string]any)
+				node[part] = child
+			}
+			node = child
+		}
+		node[parts[len(parts)-1]] = value
+	})
+	return result
+}
+
+// keepRestartFields 需重启生效的配置项保留 current 中的值，返回被忽略修改的配置项
+func keepRestartFields(next, current *Config) []string {
+	var ignored []string
+	cur := reflect.ValueOf(current).Elem()
+	walk(reflect.ValueOf(next).Elem(), "", nil, false, func(f field) {
+		if !f.restart {
+			return
+		}
+		old := cur.FieldByIndex(f.index)
+		if !reflect.DeepEqual(old.Interface(), f.value.Interface()) {
+			ignored = append(ignored, f.key)
+			f.value.Set(old)
+		}
+	})
+	return ignored
+}
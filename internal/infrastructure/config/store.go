@@ -0,0 +1,97 @@
+package config
+
+import (
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// Store 当前生效的配置，配置文件修改后重新加载并通知订阅者，不需要重启服务
+// 配置通过原子指针整体替换，读取无锁；已发布的配置不会再被修改，调用方也不能修改
+type Store struct {
+	path    string
+	profile string
+	current atomic.Pointer[Config]
+
+	mu          sync.Mutex // 串行化重新加载和订阅
+	subscribers []func(cfg *Config)
+}
+
+// NewStore 加载配置，参数与 Load 相同；之后重新加载时沿用首次加载确定的运行环境
+func NewStore(path, profile string) (*Store, error) {
+	cfg, err := Load(path, profile)
+	if err != nil {
+		return nil, err
+	}
+	s := &Store{path: path, profile: cfg.Profile}
+	s.current.Store(cfg)
+	return s, nil
+}
+
+// NewStaticStore 创建不关联配置文件的配置，用于测试
+func NewStaticStore(cfg *Config) *Store {
+	s := &Store{profile: cfg.Profile}
+	s.current.Store(cfg)
+	return s
+}
+
+// Current 当前生效的配置
+func (s *Store) Current() *Config {
+	return s.current.Load()
+}
+
+// Subscribe 订阅配置变更，配置重新加载且有变化后按订阅顺序同步调用 fn
+func (s *Store) Subscribe(fn func(cfg *Config)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscribers = append(s.subscribers, fn)
+}
+
+// Reload 重新加载配置文件和环境变量
+// 加载或校验失败时返回错误并继续使用当前配置；需重启生效的配置项保持当前值，只记录日志
+func (s *Store) Reload() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next, err := Load(s.path, s.profile)
+	if err != nil {
+		return err
+	}
+	current := s.Current()
+	if ignored := keepRestartFields(next, current); len(ignored) > 0 {
+		slog.Warn("配置项修改后需重启生效，本次重新加载忽略", "keys", strings.Join(ignored, ", "))
+	}
+	if reflect.DeepEqual(next, current) {
+		return nil
+	}
+
+	s.current.Store(next)
+	for _, fn := range s.subscribers {
+		fn(next)
+	}
+	slog.Info("配置已重新加载")
+	return nil
+}
+
+// Watch 监听基础配置文件和环境配置文件，文件修改后自动重新加载，监听在进程退出前一直有效
+// 只监听启动时已存在的文件；重新加载失败时记录日志并继续使用当前配置
+func (s *Store) Watch() {
+	for _, path := range []string{s.path, profilePath(s.path, s.profile)} {
+		if !fileExists(path) {
+			continue
+		}
+		v := viper.New()
+		v.SetConfigFile(path)
+		v.OnConfigChange(func(fsnotify.Event) {
+			if err := s.Reload(); err != nil {
+				slog.Error("重新加载配置失败，继续使用当前配置", "error", err)
+			}
+		})
+		v.WatchConfig()
+	}
+}
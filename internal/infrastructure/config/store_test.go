@@ -0,0 +1,148 @@
+package config_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/config"
+)
+
+func TestStore_Reload(t *testing.T) {
+	path := writeConfig(t, map[string]string{"config.yaml": baseConfig})
+	store, err := config.NewStore(path, "")
+	require.NoError(t, err)
+	previous := store.Current()
+
+	var notified []*config.Config
+	store.Subscribe(func(cfg *config.Config) {
+		notified = append(notified, cfg)
+	})
+
+	// 没有变化时不通知订阅者
+	require.NoError(t, store.Reload())
+	assert.Empty(t, notified)
+	assert.Same(t, previous, store.Current())
+
+	require.NoError(t, os.WriteFile(path, []byte(baseConfig+`
+logging:
+  level: "debug"
+rate_limit:
+  requests_per_second: 50
+  burst: 100
+`), 0o644))
+	require.NoError(t, store.Reload())
+	current := store.Current()
+	assert.Equal(t, "debug", current.Logging.Level)
+	assert.Equal(t, 50.0, current.RateLimit.RequestsPerSecond)
+	require.Len(t, notified, 1)
+	assert.Same(t, current, notified[0])
+	// 已发布的配置不会被修改
+	assert.Equal(t, "info", previous.Logging.Level)
+}
+
+func TestStore_ReloadRejectsInvalidConfig(t *testing.T) {
+	path := writeConfig(t, map[string]string{"config.yaml": baseConfig})
+	store, err := config.NewStore(path, "")
+	require.NoError(t, err)
+	previous := store.Current()
+	store.Subscribe(func(cfg *config.Config) {
+		t.Fatal("配置校验失败时不应通知订阅者")
+	})
+
+	require.NoError(t, os.WriteFile(path, []byte(baseConfig+`
+logging:
+  level: "verbose"
+`), 0o644))
+	err = store.Reload()
+	assert.ErrorContains(t, err, "logging.level")
+	assert.Same(t, previous, store.Current())
+
+	require.NoError(t, os.WriteFile(path, []byte("order: [\n"), 0o644))
+	assert.Error(t, store.Reload())
+	assert.Same(t, previous, store.Current())
+}
+
+func TestStore_ReloadKeepsRestartFields(t *testing.T) {
+	path := writeConfig(t, map[string]string{"config.yaml": baseConfig})
+	store, err := config.NewStore(path, "")
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte(`
+profile: "dev"
+server:
+  address: ":9000"
+database:
+  driver: "mysql"
+  username: "root"
+  password: "123456"
+  host: "localhost"
+  port: 3306
+  name: "orders"
+order:
+  payment_timeout: 20m
+  timeout_check_interval: 10s
+alipay:
+  app_id: "2021000000000000"
+  private_key: "test-private-key"
+  public_key: "test-public-key"
+`), 0o644))
+	require.NoError(t, store.Reload())
+	current := store.Current()
+	assert.Equal(t, 20*time.Minute, current.Order.PaymentTimeout)
+	// 监听地址和定时任务间隔需重启生效
+	assert.Equal(t, ":8090", current.Server.Address)
+	assert.Equal(t, time.Minute, current.Order.TimeoutCheckInterval)
+}
+
+func TestStore_Watch(t *testing.T) {
+	path := writeConfig(t, map[string]string{"config.yaml": baseConfig})
+	store, err := config.NewStore(path, "")
+	require.NoError(t, err)
+
+	changed := make(chan *config.Config, 1)
+	store.Subscribe(func(cfg *config.Config) {
+		select {
+		case changed <- cfg:
+		default:
+		}
+	})
+	store.Watch()
+
+	require.NoError(t, os.WriteFile(path, []byte(baseConfig+`
+product_api:
+  timeout: 2s
+`), 0o644))
+	select {
+	case cfg := <-changed:
+		assert.Equal(t, 2*time.Second, cfg.ProductAPI.Timeout)
+	case <-time.After(5 * time.Second):
+		t.Fatal("修改配置文件后未重新加载")
+	}
+}
+
+func TestConfig_Redacted(t *testing.T) {
+	path := writeConfig(t, map[string]string{"config.yaml": baseConfig + `
+product_api:
+  base_url: "https://api.example.com"
+  api_key: "key"
+`})
+	cfg, err := config.Load(path, "")
+	require.NoError(t, err)
+
+	redacted := cfg.Redacted()
+	db := redacted["database"].(map[string]any)
+	assert.Equal(t, "******", db["password"])
+	assert.Equal(t, "root", db["username"])
+	productAPI := redacted["product_api"].(map[string]any)
+	assert.Equal(t, "******", productAPI["api_key"])
+	assert.Equal(t, "5s", productAPI["timeout"])
+	assert.Equal(t, "15m0s", redacted["order"].(map[string]any)["payment_timeout"])
+	assert.Equal(t, "dev", redacted["profile"])
+
+	// 未设置的敏感配置项展示为空，便于发现缺失的配置
+	cfg.Database.Password = ""
+	assert.Equal(t, "", cfg.Redacted()["database"].(map[string]any)["password"])
+}
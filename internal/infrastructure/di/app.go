@@ -0,0 +1,26 @@
+package di
+
+import (
+	"github.com/vaynedu/ddd_order_example/internal/application/saga"
+	"github.com/vaynedu/ddd_order_example/internal/application/service"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/outbox"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/scheduler"
+	"github.com/vaynedu/ddd_order_example/internal/interface/handler"
+)
+
+// App 启动服务需要的全部组件，同一存储实现的组件共享仓储实例
+type App struct {
+	OrderHandler          *handler.OrderHandler
+	PaymentHandler        *handler.PaymentHandler
+	ReconciliationHandler *handler.ReconciliationHandler
+	AdminHandler          *handler.AdminHandler
+
+	CouponReleaseHandler *service.CouponReleaseHandler
+	InventoryHandler     *service.InventoryReservationHandler
+
+	OutboxRelay             *outbox.Relay
+	OrderTimeoutService     *service.OrderTimeoutService
+	PaymentReconcileService *service.PaymentReconcileService
+	SagaRecovery            *saga.Recovery
+	Scheduler               *scheduler.Scheduler
+}
@@ -1,21 +1,41 @@
 package di
 
 import (
-	"os"
-
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
 	"github.com/vaynedu/ddd_order_example/internal/domain/domain_product_core"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/config"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/exchange"
 	"github.com/vaynedu/ddd_order_example/internal/infrastructure/external/product_api"
+	"github.com/vaynedu/ddd_order_example/internal/interface/handler"
 )
 
-// 提供第三方商品API客户端
-func NewProductAPIClient() *product_api.ThirdPartyProductAPI {
-	return product_api.NewThirdPartyProductAPI(
-		os.Getenv("PRODUCT_API_URL"),
-		os.Getenv("PRODUCT_API_KEY"),
-	)
+// 提供第三方商品API客户端，请求超时随 product_api.timeout 配置热更新
+func NewProductAPIClient(configs *config.Store) *product_api.ThirdPartyProductAPI {
+	cfg := configs.Current().ProductAPI
+	client := product_api.NewThirdPartyProductAPI(cfg.BaseURL, cfg.APIKey)
+	client.SetTimeout(cfg.Timeout)
+	configs.Subscribe(func(cfg *config.Config) {
+		client.SetTimeout(cfg.ProductAPI.Timeout)
+	})
+	return client
 }
 
 // NewProductService 创建商品服务实例
 func NewProductService(client *product_api.ThirdPartyProductAPI) domain_product_core.ProductService {
 	return product_api.NewProductServiceAdapter(client)
 }
+
+// NewConfigSnapshot 启动时的配置，只用于构造依赖需重启生效配置项的组件
+func NewConfigSnapshot(configs *config.Store) *config.Config {
+	return configs.Current()
+}
+
+// NewAdminHandler 初始化运维管理处理器
+func NewAdminHandler(configs *config.Store) *handler.AdminHandler {
+	return handler.NewAdminHandler(configs)
+}
+
+// NewExchangeRateProvider 创建本地文件汇率提供者，文件路径由 exchange.rates_file 配置
+func NewExchangeRateProvider(cfg config.ExchangeConfig) (domain_payment_core.ExchangeRateProvider, error) {
+	return exchange.NewFileRateProvider(cfg.RatesFile)
+}
@@ -5,14 +5,28 @@ package di
 
 import (
 	"github.com/google/wire"
+	"github.com/vaynedu/ddd_order_example/internal/application/saga"
 	"github.com/vaynedu/ddd_order_example/internal/application/service"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_idempotency_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_inventory_core"
 	"github.com/vaynedu/ddd_order_example/internal/domain/domain_order_core"
 	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
 	"github.com/vaynedu/ddd_order_example/internal/domain/domain_product_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_promotion_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_reconciliation_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_uow_core"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/config"
 	"github.com/vaynedu/ddd_order_example/internal/infrastructure/external/mocks"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/inventory"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/lock"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/memory"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/outbox"
 	"github.com/vaynedu/ddd_order_example/internal/infrastructure/payment"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/persistence"
 	"github.com/vaynedu/ddd_order_example/internal/infrastructure/repository"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/scheduler"
 	"github.com/vaynedu/ddd_order_example/internal/interface/handler"
+	"github.com/vaynedu/ddd_order_example/internal/shared/event"
 	"gorm.io/gorm"
 )
 
@@ -27,20 +41,106 @@ import (
 // 	return nil, nil
 // }
 
-// 测试环境依赖注入 - 使用Mock商品服务
-func InitializeTestOrderHandler(db *gorm.DB) (*handler.OrderHandler, error) {
-	wire.Build(
-		NewOrderRepository,    // 订单仓储
-		NewOrderDomainService, // 订单领域服务
-		NewMockProductService, // 商品服务
-
-		NewPaymentRepository,    // 支付仓储
-		NewPaymentDomainService, // 支付领域服务
-		NewMockPaymentProxy,     // 支付代理
-		NewPaymentService,       // 支付应用服务
-		NewOrderService,
-		NewOrderHandler,
-	)
+// mysqlSet 基于MySQL的存储实现
+var mysqlSet = wire.NewSet(
+	NewOrderRepository,       // 订单仓储
+	NewPaymentRepository,     // 支付仓储
+	NewRefundRepository,      // 退款仓储
+	NewIdempotencyRepository, // 幂等记录仓储
+	NewDiscrepancyRepository, // 对账差异仓储
+	NewCouponRepository,      // 优惠券仓储
+	NewCouponUsageRepository, // 优惠券使用记录仓储
+	NewInventoryService,      // 库存服务
+	NewSagaRepository,        // saga实例仓储
+	NewUnitOfWork,            // 工作单元
+	NewOutboxStore,           // 发件箱存储
+	NewLocker,                // 租约锁
+)
+
+// memorySet 内存存储实现，不依赖数据库，进程退出后数据丢失
+var memorySet = wire.NewSet(
+	NewMemoryOrderRepository,
+	NewMemoryPaymentRepository,
+	NewMemoryRefundRepository,
+	NewMemoryIdempotencyRepository,
+	NewMemoryDiscrepancyRepository,
+	NewMemoryCouponRepository,
+	NewMemoryCouponUsageRepository,
+	NewMemoryInventoryService,
+	NewMemorySagaRepository,
+	NewMemoryUnitOfWork,
+	NewMemoryOutboxStore,
+	NewMemoryLocker,
+)
+
+// alipaySet 支付宝支付渠道，下单、查询、退款和支付通知验签都经由支付宝
+var alipaySet = wire.NewSet(
+	NewAlipayAdapter,
+	wire.Bind(new(payment.PaymentProxy), new(*payment.AlipayAdapter)),
+	wire.Bind(new(payment.PaymentNotifier), new(*payment.AlipayAdapter)),
+)
+
+// mockPaymentSet 模拟支付渠道，支付通知不验签，用于本地开发和演示
+var mockPaymentSet = wire.NewSet(
+	NewMockPaymentProxy,    // 模拟支付代理
+	NewMockPaymentNotifier, // 模拟支付通知
+)
+
+// appSet 与存储和支付渠道无关的服务和处理器，使用Mock商品服务
+var appSet = wire.NewSet(
+	NewOrderDomainService, // 订单领域服务
+	NewMockProductService, // 商品服务
+
+	NewConfigSnapshot, // 启动时的配置
+	wire.FieldsOf(new(*config.Config), "Exchange"),
+	NewExchangeRateProvider, // 汇率
+	NewPaymentDomainService, // 支付领域服务
+	NewPaymentService,       // 支付应用服务
+
+	NewOutboxSink,      // 投递目标
+	NewEventDispatcher, // 领域事件分发
+	NewOutboxRelay,     // 发件箱投递器
+
+	NewPromotionDomainService, // 优惠领域服务
+	NewOrderService,           // 订单应用服务
+	NewIdempotencyService,     // 幂等应用服务
+	NewOrderHandler,
+
+	NewRefundDomainService,  // 退款领域服务
+	NewRefundService,        // 退款应用服务
+	NewPaymentNotifyService, // 支付通知应用服务
+	NewPaymentHandler,
+
+	NewStatementParsers, // 对账单解析器
+	NewStatementReconcileService,
+	NewReconciliationHandler,
+	NewAdminHandler,
+	NewPaymentReconcileService, // 支付状态对账
+
+	NewOrderTimeoutService,         // 超时未支付订单取消
+	NewCouponReleaseHandler,        // 订单取消后释放优惠券
+	NewInventoryReservationHandler, // 订单支付、取消后处理库存预占
+	NewSagaRecovery,                // saga恢复器
+	NewScheduler,                   // 定时任务调度器
+
+	wire.Struct(new(App), "*"),
+)
+
+// 基于数据库和支付宝渠道初始化整个应用
+func InitializeApp(configs *config.Store, db *gorm.DB, bus *event.EventBus) (*App, error) {
+	wire.Build(mysqlSet, alipaySet, appSet)
+	return nil, nil
+}
+
+// 基于数据库和模拟支付渠道初始化整个应用，用于未开启支付宝的本地开发
+func InitializeMockPaymentApp(configs *config.Store, db *gorm.DB, bus *event.EventBus) (*App, error) {
+	wire.Build(mysqlSet, mockPaymentSet, appSet)
+	return nil, nil
+}
+
+// 基于内存存储和模拟支付渠道初始化整个应用，用于不依赖数据库的本地运行和演示
+func InitializeMemoryApp(configs *config.Store, bus *event.EventBus) (*App, error) {
+	wire.Build(memorySet, mockPaymentSet, appSet)
 	return nil, nil
 }
 
@@ -55,8 +155,8 @@ func NewOrderDomainService(repo domain_order_core.OrderRepository) domain_order_
 }
 
 // NewOrderHandler 初始化处理器
-func NewOrderHandler(orderService *service.OrderService) *handler.OrderHandler {
-	return handler.NewOrderHandler(orderService)
+func NewOrderHandler(orderService *service.OrderService, idempotencyService *service.IdempotencyService) *handler.OrderHandler {
+	return handler.NewOrderHandler(orderService, idempotencyService)
 }
 
 // NewMockProductService 创建商品服务的Mock实现
@@ -70,8 +170,8 @@ func NewPaymentRepository(db *gorm.DB) domain_payment_core.Repository {
 }
 
 // NewPaymentDomainService 创建支付领域服务
-func NewPaymentDomainService(repo domain_payment_core.Repository) *domain_payment_core.PaymentDomainService {
-	return domain_payment_core.NewPaymentDomainService(repo)
+func NewPaymentDomainService(repo domain_payment_core.Repository, rateProvider domain_payment_core.ExchangeRateProvider) *domain_payment_core.PaymentDomainService {
+	return domain_payment_core.NewPaymentDomainService(repo, rateProvider)
 }
 
 // // NewPaymentProxy 创建真实支付代理， 这里可创建函数，封装不同的支付方式，待定
@@ -89,11 +189,264 @@ func NewPaymentService(domainService *domain_payment_core.PaymentDomainService,
 	return service.NewPaymentService(domainService, proxy)
 }
 
-// NewOrderService 创建订单应用服务, 包含订单领域服务, 支付应用服务, 商品服务
+// NewOrderService 创建订单应用服务, 包含订单领域服务, 支付应用服务, 商品服务, 优惠领域服务, 库存服务, 工作单元, saga实例仓储
 func NewOrderService(
 	productService domain_product_core.ProductService,
 	orderDomainService domain_order_core.OrderDomainService,
 	paymentService *service.PaymentService,
+	promotionService *domain_promotion_core.PromotionDomainService,
+	inventoryService domain_inventory_core.InventoryService,
+	uow domain_uow_core.UnitOfWork,
+	sagaRepo saga.Repository,
+	dispatcher event.Dispatcher,
 ) *service.OrderService {
-	return service.NewOrderService(orderDomainService, paymentService, productService)
+	return service.NewOrderService(orderDomainService, paymentService, productService, promotionService, inventoryService, uow, sagaRepo, dispatcher)
+}
+
+// NewIdempotencyRepository 创建幂等记录仓储
+func NewIdempotencyRepository(db *gorm.DB) domain_idempotency_core.Repository {
+	return repository.NewIdempotencyRepository(db)
+}
+
+// NewIdempotencyService 创建幂等应用服务
+func NewIdempotencyService(repo domain_idempotency_core.Repository) *service.IdempotencyService {
+	return service.NewIdempotencyService(repo)
+}
+
+// NewRefundRepository 创建退款仓储
+func NewRefundRepository(db *gorm.DB) domain_payment_core.RefundRepository {
+	return repository.NewRefundRepository(db)
+}
+
+// NewRefundDomainService 创建退款领域服务
+func NewRefundDomainService(paymentRepo domain_payment_core.Repository, refundRepo domain_payment_core.RefundRepository, uow domain_uow_core.UnitOfWork) *domain_payment_core.RefundDomainService {
+	return domain_payment_core.NewRefundDomainService(paymentRepo, refundRepo, uow)
+}
+
+// NewRefundService 创建退款应用服务
+func NewRefundService(
+	orderDomainService domain_order_core.OrderDomainService,
+	paymentService *service.PaymentService,
+	refundDomainService *domain_payment_core.RefundDomainService,
+	proxy payment.PaymentProxy,
+	dispatcher event.Dispatcher,
+) *service.RefundService {
+	return service.NewRefundService(orderDomainService, paymentService, refundDomainService, proxy, dispatcher)
+}
+
+// NewMockPaymentNotifier 创建Mock支付通知解析器，不验签，只用于未开启支付宝的本地开发
+func NewMockPaymentNotifier() payment.PaymentNotifier {
+	return payment.NewMockPaymentNotifier()
+}
+
+// NewAlipayAdapter 创建支付宝适配器，同时作为支付代理和支付通知解析器，通知用支付宝公钥验签
+func NewAlipayAdapter(cfg *config.Config) (*payment.AlipayAdapter, error) {
+	return payment.NewAlipayAdapter(payment.AlipayConfig{
+		AppID:           cfg.Alipay.AppID,
+		PrivateKey:      cfg.Alipay.PrivateKey,
+		AlipayPublicKey: cfg.Alipay.PublicKey,
+		NotifyURL:       cfg.Alipay.NotifyURL,
+		IsProduction:    cfg.Alipay.Production,
+	})
+}
+
+// NewPaymentNotifyService 创建支付通知应用服务
+func NewPaymentNotifyService(
+	notifier payment.PaymentNotifier,
+	paymentService *service.PaymentService,
+	orderDomainService domain_order_core.OrderDomainService,
+	discrepancyRepo domain_reconciliation_core.DiscrepancyRepository,
+	dispatcher event.Dispatcher,
+) *service.PaymentNotifyService {
+	return service.NewPaymentNotifyService(notifier, paymentService, orderDomainService, discrepancyRepo, dispatcher)
+}
+
+// NewPaymentHandler 初始化支付处理器
+func NewPaymentHandler(refundService *service.RefundService, notifyService *service.PaymentNotifyService) *handler.PaymentHandler {
+	return handler.NewPaymentHandler(refundService, notifyService)
+}
+
+// NewOutboxStore 创建发件箱存储
+func NewOutboxStore(db *gorm.DB) outbox.Store {
+	return outbox.NewGormStore(db)
+}
+
+// NewOutboxSink 创建事件总线投递目标，注册订单事件解码器
+func NewOutboxSink(bus *event.EventBus) outbox.Sink {
+	sink := outbox.NewEventBusSink(bus)
+	for _, name := range domain_order_core.EventNames() {
+		sink.RegisterDecoder(name, domain_order_core.DecodeEvent)
+	}
+	return sink
+}
+
+// NewOutboxRelay 创建发件箱投递器
+func NewOutboxRelay(store outbox.Store, sink outbox.Sink) *outbox.Relay {
+	return outbox.NewRelay(store, sink)
+}
+
+// NewEventDispatcher 创建领域事件分发器，事务提交后立即投递，失败由发件箱投递器重试
+func NewEventDispatcher(store outbox.Store, sink outbox.Sink) event.Dispatcher {
+	return outbox.NewDispatcher(store, sink)
+}
+
+// NewLocker 创建基于数据库的租约锁
+func NewLocker(db *gorm.DB) lock.Locker {
+	return lock.NewGormLocker(db)
+}
+
+// NewScheduler 创建定时任务调度器
+func NewScheduler(locker lock.Locker) *scheduler.Scheduler {
+	return scheduler.NewScheduler(locker)
+}
+
+// NewOrderTimeoutService 创建超时未支付订单取消服务
+func NewOrderTimeoutService(
+	orderDomainService domain_order_core.OrderDomainService,
+	paymentService *service.PaymentService,
+	dispatcher event.Dispatcher,
+) *service.OrderTimeoutService {
+	return service.NewOrderTimeoutService(orderDomainService, paymentService, dispatcher)
+}
+
+// NewDiscrepancyRepository 创建对账差异仓储
+func NewDiscrepancyRepository(db *gorm.DB) domain_reconciliation_core.DiscrepancyRepository {
+	return repository.NewDiscrepancyRepository(db)
+}
+
+// NewPaymentReconcileService 创建支付状态对账服务
+func NewPaymentReconcileService(
+	paymentService *service.PaymentService,
+	orderDomainService domain_order_core.OrderDomainService,
+	discrepancyRepo domain_reconciliation_core.DiscrepancyRepository,
+	dispatcher event.Dispatcher,
+) *service.PaymentReconcileService {
+	return service.NewPaymentReconcileService(paymentService, orderDomainService, discrepancyRepo, dispatcher)
+}
+
+// NewStatementParsers 创建各支付渠道的对账单解析器
+func NewStatementParsers() []domain_reconciliation_core.StatementParser {
+	return []domain_reconciliation_core.StatementParser{
+		payment.NewAlipayStatementParser(),
+	}
+}
+
+// NewStatementReconcileService 创建对账单对账服务
+func NewStatementReconcileService(
+	parsers []domain_reconciliation_core.StatementParser,
+	paymentService *service.PaymentService,
+	refundDomainService *domain_payment_core.RefundDomainService,
+	discrepancyRepo domain_reconciliation_core.DiscrepancyRepository,
+) *service.StatementReconcileService {
+	return service.NewStatementReconcileService(parsers, paymentService, refundDomainService, discrepancyRepo)
+}
+
+// NewReconciliationHandler 初始化对账处理器
+func NewReconciliationHandler(statementService *service.StatementReconcileService) *handler.ReconciliationHandler {
+	return handler.NewReconciliationHandler(statementService)
+}
+
+// NewCouponRepository 创建优惠券仓储
+func NewCouponRepository(db *gorm.DB) domain_promotion_core.CouponRepository {
+	return repository.NewCouponRepository(db)
+}
+
+// NewCouponUsageRepository 创建优惠券使用记录仓储
+func NewCouponUsageRepository(db *gorm.DB) domain_promotion_core.CouponUsageRepository {
+	return repository.NewCouponUsageRepository(db)
+}
+
+// NewPromotionDomainService 创建优惠领域服务
+func NewPromotionDomainService(couponRepo domain_promotion_core.CouponRepository, usageRepo domain_promotion_core.CouponUsageRepository) *domain_promotion_core.PromotionDomainService {
+	return domain_promotion_core.NewPromotionDomainService(couponRepo, usageRepo)
+}
+
+// NewCouponReleaseHandler 创建订单取消后释放优惠券的事件处理器
+func NewCouponReleaseHandler(promotionService *domain_promotion_core.PromotionDomainService) *service.CouponReleaseHandler {
+	return service.NewCouponReleaseHandler(promotionService)
+}
+
+// NewInventoryService 创建基于本地数据库的库存服务
+func NewInventoryService(db *gorm.DB) domain_inventory_core.InventoryService {
+	return inventory.NewGormInventoryService(db)
+}
+
+// NewInventoryReservationHandler 创建订单支付、取消后处理库存预占的事件处理器
+func NewInventoryReservationHandler(orderDomainService domain_order_core.OrderDomainService, inventoryService domain_inventory_core.InventoryService) *service.InventoryReservationHandler {
+	return service.NewInventoryReservationHandler(orderDomainService, inventoryService)
+}
+
+// NewSagaRepository 创建saga实例仓储
+func NewSagaRepository(db *gorm.DB) saga.Repository {
+	return repository.NewSagaRepository(db)
+}
+
+// NewSagaRecovery 创建saga恢复器，恢复订单服务编排的saga
+func NewSagaRecovery(repo saga.Repository, orderService *service.OrderService) *saga.Recovery {
+	return saga.NewRecovery(repo, orderService.Sagas()...)
+}
+
+// NewUnitOfWork 创建基于数据库事务的工作单元
+func NewUnitOfWork(db *gorm.DB) domain_uow_core.UnitOfWork {
+	return persistence.NewGormUnitOfWork(db)
+}
+
+// NewMemoryOrderRepository 创建内存订单仓储
+func NewMemoryOrderRepository() domain_order_core.OrderRepository {
+	return memory.NewOrderRepository()
+}
+
+// NewMemoryPaymentRepository 创建内存支付仓储
+func NewMemoryPaymentRepository() domain_payment_core.Repository {
+	return memory.NewPaymentRepository()
+}
+
+// NewMemoryRefundRepository 创建内存退款仓储
+func NewMemoryRefundRepository(paymentRepo domain_payment_core.Repository) domain_payment_core.RefundRepository {
+	return memory.NewRefundRepository(paymentRepo)
+}
+
+// NewMemoryIdempotencyRepository 创建内存幂等记录仓储
+func NewMemoryIdempotencyRepository() domain_idempotency_core.Repository {
+	return memory.NewIdempotencyRepository()
+}
+
+// NewMemoryDiscrepancyRepository 创建内存对账差异仓储
+func NewMemoryDiscrepancyRepository() domain_reconciliation_core.DiscrepancyRepository {
+	return memory.NewDiscrepancyRepository()
+}
+
+// NewMemoryCouponRepository 创建内存优惠券仓储
+func NewMemoryCouponRepository() domain_promotion_core.CouponRepository {
+	return memory.NewCouponRepository()
+}
+
+// NewMemoryCouponUsageRepository 创建内存优惠券使用记录仓储
+func NewMemoryCouponUsageRepository() domain_promotion_core.CouponUsageRepository {
+	return memory.NewCouponUsageRepository()
+}
+
+// NewMemoryInventoryService 创建内存库存服务，初始库存与初始迁移脚本中的种子数据一致
+func NewMemoryInventoryService() domain_inventory_core.InventoryService {
+	return memory.NewInventoryService(map[string]int64{"P001": 1000, "P002": 1000})
+}
+
+// NewMemorySagaRepository 创建内存saga实例仓储
+func NewMemorySagaRepository() saga.Repository {
+	return memory.NewSagaRepository()
+}
+
+// NewMemoryUnitOfWork 创建内存工作单元
+func NewMemoryUnitOfWork() domain_uow_core.UnitOfWork {
+	return memory.NewUnitOfWork()
+}
+
+// NewMemoryOutboxStore 创建内存发件箱存储
+func NewMemoryOutboxStore() outbox.Store {
+	return memory.NewOutboxStore()
+}
+
+// NewMemoryLocker 创建单实例租约锁
+func NewMemoryLocker() lock.Locker {
+	return memory.NewLocker()
 }
@@ -7,35 +7,306 @@
 package di
 
 import (
+	"github.com/google/wire"
+	"github.com/vaynedu/ddd_order_example/internal/application/saga"
 	"github.com/vaynedu/ddd_order_example/internal/application/service"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_idempotency_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_inventory_core"
 	"github.com/vaynedu/ddd_order_example/internal/domain/domain_order_core"
 	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
 	"github.com/vaynedu/ddd_order_example/internal/domain/domain_product_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_promotion_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_reconciliation_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_uow_core"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/config"
 	"github.com/vaynedu/ddd_order_example/internal/infrastructure/external/mocks"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/inventory"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/lock"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/memory"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/outbox"
 	"github.com/vaynedu/ddd_order_example/internal/infrastructure/payment"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/persistence"
 	"github.com/vaynedu/ddd_order_example/internal/infrastructure/repository"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/scheduler"
 	"github.com/vaynedu/ddd_order_example/internal/interface/handler"
+	"github.com/vaynedu/ddd_order_example/internal/shared/event"
 	"gorm.io/gorm"
 )
 
 // Injectors from wire.go:
 
-// 测试环境依赖注入 - 使用Mock商品服务
-func InitializeTestOrderHandler(db *gorm.DB) (*handler.OrderHandler, error) {
+// 基于数据库和支付宝渠道初始化整个应用
+func InitializeApp(configs *config.Store, db *gorm.DB, bus *event.EventBus) (*App, error) {
 	productService := NewMockProductService()
 	orderRepository := NewOrderRepository(db)
 	orderDomainService := NewOrderDomainService(orderRepository)
 	repository := NewPaymentRepository(db)
-	paymentDomainService := NewPaymentDomainService(repository)
+	configConfig := NewConfigSnapshot(configs)
+	exchangeConfig := configConfig.Exchange
+	exchangeRateProvider, err := NewExchangeRateProvider(exchangeConfig)
+	if err != nil {
+		return nil, err
+	}
+	paymentDomainService := NewPaymentDomainService(repository, exchangeRateProvider)
+	alipayAdapter, err := NewAlipayAdapter(configConfig)
+	if err != nil {
+		return nil, err
+	}
+	paymentService := NewPaymentService(paymentDomainService, alipayAdapter)
+	couponRepository := NewCouponRepository(db)
+	couponUsageRepository := NewCouponUsageRepository(db)
+	promotionDomainService := NewPromotionDomainService(couponRepository, couponUsageRepository)
+	inventoryService := NewInventoryService(db)
+	unitOfWork := NewUnitOfWork(db)
+	sagaRepository := NewSagaRepository(db)
+	store := NewOutboxStore(db)
+	sink := NewOutboxSink(bus)
+	dispatcher := NewEventDispatcher(store, sink)
+	orderService := NewOrderService(productService, orderDomainService, paymentService, promotionDomainService, inventoryService, unitOfWork, sagaRepository, dispatcher)
+	domain_idempotency_coreRepository := NewIdempotencyRepository(db)
+	idempotencyService := NewIdempotencyService(domain_idempotency_coreRepository)
+	orderHandler := NewOrderHandler(orderService, idempotencyService)
+	refundRepository := NewRefundRepository(db)
+	refundDomainService := NewRefundDomainService(repository, refundRepository, unitOfWork)
+	refundService := NewRefundService(orderDomainService, paymentService, refundDomainService, alipayAdapter, dispatcher)
+	discrepancyRepository := NewDiscrepancyRepository(db)
+	paymentNotifyService := NewPaymentNotifyService(alipayAdapter, paymentService, orderDomainService, discrepancyRepository, dispatcher)
+	paymentHandler := NewPaymentHandler(refundService, paymentNotifyService)
+	v := NewStatementParsers()
+	statementReconcileService := NewStatementReconcileService(v, paymentService, refundDomainService, discrepancyRepository)
+	reconciliationHandler := NewReconciliationHandler(statementReconcileService)
+	adminHandler := NewAdminHandler(configs)
+	couponReleaseHandler := NewCouponReleaseHandler(promotionDomainService)
+	inventoryReservationHandler := NewInventoryReservationHandler(orderDomainService, inventoryService)
+	relay := NewOutboxRelay(store, sink)
+	orderTimeoutService := NewOrderTimeoutService(orderDomainService, paymentService, dispatcher)
+	paymentReconcileService := NewPaymentReconcileService(paymentService, orderDomainService, discrepancyRepository, dispatcher)
+	recovery := NewSagaRecovery(sagaRepository, orderService)
+	locker := NewLocker(db)
+	scheduler := NewScheduler(locker)
+	app := &App{
+		OrderHandler:            orderHandler,
+		PaymentHandler:          paymentHandler,
+		ReconciliationHandler:   reconciliationHandler,
+		AdminHandler:            adminHandler,
+		CouponReleaseHandler:    couponReleaseHandler,
+		InventoryHandler:        inventoryReservationHandler,
+		OutboxRelay:             relay,
+		OrderTimeoutService:     orderTimeoutService,
+		PaymentReconcileService: paymentReconcileService,
+		SagaRecovery:            recovery,
+		Scheduler:               scheduler,
+	}
+	return app, nil
+}
+
+// 基于数据库和模拟支付渠道初始化整个应用，用于未开启支付宝的本地开发
+func InitializeMockPaymentApp(configs *config.Store, db *gorm.DB, bus *event.EventBus) (*App, error) {
+	productService := NewMockProductService()
+	orderRepository := NewOrderRepository(db)
+	orderDomainService := NewOrderDomainService(orderRepository)
+	repository := NewPaymentRepository(db)
+	configConfig := NewConfigSnapshot(configs)
+	exchangeConfig := configConfig.Exchange
+	exchangeRateProvider, err := NewExchangeRateProvider(exchangeConfig)
+	if err != nil {
+		return nil, err
+	}
+	paymentDomainService := NewPaymentDomainService(repository, exchangeRateProvider)
+	paymentProxy := NewMockPaymentProxy()
+	paymentService := NewPaymentService(paymentDomainService, paymentProxy)
+	couponRepository := NewCouponRepository(db)
+	couponUsageRepository := NewCouponUsageRepository(db)
+	promotionDomainService := NewPromotionDomainService(couponRepository, couponUsageRepository)
+	inventoryService := NewInventoryService(db)
+	unitOfWork := NewUnitOfWork(db)
+	sagaRepository := NewSagaRepository(db)
+	store := NewOutboxStore(db)
+	sink := NewOutboxSink(bus)
+	dispatcher := NewEventDispatcher(store, sink)
+	orderService := NewOrderService(productService, orderDomainService, paymentService, promotionDomainService, inventoryService, unitOfWork, sagaRepository, dispatcher)
+	domain_idempotency_coreRepository := NewIdempotencyRepository(db)
+	idempotencyService := NewIdempotencyService(domain_idempotency_coreRepository)
+	orderHandler := NewOrderHandler(orderService, idempotencyService)
+	refundRepository := NewRefundRepository(db)
+	refundDomainService := NewRefundDomainService(repository, refundRepository, unitOfWork)
+	refundService := NewRefundService(orderDomainService, paymentService, refundDomainService, paymentProxy, dispatcher)
+	paymentNotifier := NewMockPaymentNotifier()
+	discrepancyRepository := NewDiscrepancyRepository(db)
+	paymentNotifyService := NewPaymentNotifyService(paymentNotifier, paymentService, orderDomainService, discrepancyRepository, dispatcher)
+	paymentHandler := NewPaymentHandler(refundService, paymentNotifyService)
+	v := NewStatementParsers()
+	statementReconcileService := NewStatementReconcileService(v, paymentService, refundDomainService, discrepancyRepository)
+	reconciliationHandler := NewReconciliationHandler(statementReconcileService)
+	adminHandler := NewAdminHandler(configs)
+	couponReleaseHandler := NewCouponReleaseHandler(promotionDomainService)
+	inventoryReservationHandler := NewInventoryReservationHandler(orderDomainService, inventoryService)
+	relay := NewOutboxRelay(store, sink)
+	orderTimeoutService := NewOrderTimeoutService(orderDomainService, paymentService, dispatcher)
+	paymentReconcileService := NewPaymentReconcileService(paymentService, orderDomainService, discrepancyRepository, dispatcher)
+	recovery := NewSagaRecovery(sagaRepository, orderService)
+	locker := NewLocker(db)
+	scheduler := NewScheduler(locker)
+	app := &App{
+		OrderHandler:            orderHandler,
+		PaymentHandler:          paymentHandler,
+		ReconciliationHandler:   reconciliationHandler,
+		AdminHandler:            adminHandler,
+		CouponReleaseHandler:    couponReleaseHandler,
+		InventoryHandler:        inventoryReservationHandler,
+		OutboxRelay:             relay,
+		OrderTimeoutService:     orderTimeoutService,
+		PaymentReconcileService: paymentReconcileService,
+		SagaRecovery:            recovery,
+		Scheduler:               scheduler,
+	}
+	return app, nil
+}
+
+// 基于内存存储和模拟支付渠道初始化整个应用，用于不依赖数据库的本地运行和演示
+func InitializeMemoryApp(configs *config.Store, bus *event.EventBus) (*App, error) {
+	productService := NewMockProductService()
+	orderRepository := NewMemoryOrderRepository()
+	orderDomainService := NewOrderDomainService(orderRepository)
+	repository := NewMemoryPaymentRepository()
+	configConfig := NewConfigSnapshot(configs)
+	exchangeConfig := configConfig.Exchange
+	exchangeRateProvider, err := NewExchangeRateProvider(exchangeConfig)
+	if err != nil {
+		return nil, err
+	}
+	paymentDomainService := NewPaymentDomainService(repository, exchangeRateProvider)
 	paymentProxy := NewMockPaymentProxy()
 	paymentService := NewPaymentService(paymentDomainService, paymentProxy)
-	orderService := NewOrderService(productService, orderDomainService, paymentService)
-	orderHandler := NewOrderHandler(orderService)
-	return orderHandler, nil
+	couponRepository := NewMemoryCouponRepository()
+	couponUsageRepository := NewMemoryCouponUsageRepository()
+	promotionDomainService := NewPromotionDomainService(couponRepository, couponUsageRepository)
+	inventoryService := NewMemoryInventoryService()
+	unitOfWork := NewMemoryUnitOfWork()
+	sagaRepository := NewMemorySagaRepository()
+	store := NewMemoryOutboxStore()
+	sink := NewOutboxSink(bus)
+	dispatcher := NewEventDispatcher(store, sink)
+	orderService := NewOrderService(productService, orderDomainService, paymentService, promotionDomainService, inventoryService, unitOfWork, sagaRepository, dispatcher)
+	domain_idempotency_coreRepository := NewMemoryIdempotencyRepository()
+	idempotencyService := NewIdempotencyService(domain_idempotency_coreRepository)
+	orderHandler := NewOrderHandler(orderService, idempotencyService)
+	refundRepository := NewMemoryRefundRepository(repository)
+	refundDomainService := NewRefundDomainService(repository, refundRepository, unitOfWork)
+	refundService := NewRefundService(orderDomainService, paymentService, refundDomainService, paymentProxy, dispatcher)
+	paymentNotifier := NewMockPaymentNotifier()
+	discrepancyRepository := NewMemoryDiscrepancyRepository()
+	paymentNotifyService := NewPaymentNotifyService(paymentNotifier, paymentService, orderDomainService, discrepancyRepository, dispatcher)
+	paymentHandler := NewPaymentHandler(refundService, paymentNotifyService)
+	v := NewStatementParsers()
+	statementReconcileService := NewStatementReconcileService(v, paymentService, refundDomainService, discrepancyRepository)
+	reconciliationHandler := NewReconciliationHandler(statementReconcileService)
+	adminHandler := NewAdminHandler(configs)
+	couponReleaseHandler := NewCouponReleaseHandler(promotionDomainService)
+	inventoryReservationHandler := NewInventoryReservationHandler(orderDomainService, inventoryService)
+	relay := NewOutboxRelay(store, sink)
+	orderTimeoutService := NewOrderTimeoutService(orderDomainService, paymentService, dispatcher)
+	paymentReconcileService := NewPaymentReconcileService(paymentService, orderDomainService, discrepancyRepository, dispatcher)
+	recovery := NewSagaRecovery(sagaRepository, orderService)
+	locker := NewMemoryLocker()
+	scheduler := NewScheduler(locker)
+	app := &App{
+		OrderHandler:            orderHandler,
+		PaymentHandler:          paymentHandler,
+		ReconciliationHandler:   reconciliationHandler,
+		AdminHandler:            adminHandler,
+		CouponReleaseHandler:    couponReleaseHandler,
+		InventoryHandler:        inventoryReservationHandler,
+		OutboxRelay:             relay,
+		OrderTimeoutService:     orderTimeoutService,
+		PaymentReconcileService: paymentReconcileService,
+		SagaRecovery:            recovery,
+		Scheduler:               scheduler,
+	}
+	return app, nil
 }
 
 // wire.go:
 
+// mysqlSet 基于MySQL的存储实现
+var mysqlSet = wire.NewSet(
+	NewOrderRepository,
+	NewPaymentRepository,
+	NewRefundRepository,
+	NewIdempotencyRepository,
+	NewDiscrepancyRepository,
+	NewCouponRepository,
+	NewCouponUsageRepository,
+	NewInventoryService,
+	NewSagaRepository,
+	NewUnitOfWork,
+	NewOutboxStore,
+	NewLocker,
+)
+
+// memorySet 内存存储实现，不依赖数据库，进程退出后数据丢失
+var memorySet = wire.NewSet(
+	NewMemoryOrderRepository,
+	NewMemoryPaymentRepository,
+	NewMemoryRefundRepository,
+	NewMemoryIdempotencyRepository,
+	NewMemoryDiscrepancyRepository,
+	NewMemoryCouponRepository,
+	NewMemoryCouponUsageRepository,
+	NewMemoryInventoryService,
+	NewMemorySagaRepository,
+	NewMemoryUnitOfWork,
+	NewMemoryOutboxStore,
+	NewMemoryLocker,
+)
+
+// alipaySet 支付宝支付渠道，下单、查询、退款和支付通知验签都经由支付宝
+var alipaySet = wire.NewSet(
+	NewAlipayAdapter, wire.Bind(new(payment.PaymentProxy), new(*payment.AlipayAdapter)), wire.Bind(new(payment.PaymentNotifier), new(*payment.AlipayAdapter)),
+)
+
+// mockPaymentSet 模拟支付渠道，支付通知不验签，用于本地开发和演示
+var mockPaymentSet = wire.NewSet(
+	NewMockPaymentProxy,
+	NewMockPaymentNotifier,
+)
+
+// appSet 与存储和支付渠道无关的服务和处理器，使用Mock商品服务
+var appSet = wire.NewSet(
+	NewOrderDomainService,
+	NewMockProductService,
+
+	NewConfigSnapshot, wire.FieldsOf(new(*config.Config), "Exchange"), NewExchangeRateProvider,
+	NewPaymentDomainService,
+	NewPaymentService,
+
+	NewOutboxSink,
+	NewEventDispatcher,
+	NewOutboxRelay,
+
+	NewPromotionDomainService,
+	NewOrderService,
+	NewIdempotencyService,
+	NewOrderHandler,
+
+	NewRefundDomainService,
+	NewRefundService,
+	NewPaymentNotifyService,
+	NewPaymentHandler,
+
+	NewStatementParsers,
+	NewStatementReconcileService,
+	NewReconciliationHandler,
+	NewAdminHandler,
+	NewPaymentReconcileService,
+
+	NewOrderTimeoutService,
+	NewCouponReleaseHandler,
+	NewInventoryReservationHandler,
+	NewSagaRecovery,
+	NewScheduler, wire.Struct(new(App), "*"),
+)
+
 // NewOrderRepository - 初始化仓储
 func NewOrderRepository(db *gorm.DB) domain_order_core.OrderRepository {
 	return repository.NewOrderRepository(db)
@@ -47,8 +318,8 @@ func NewOrderDomainService(repo domain_order_core.OrderRepository) domain_order_
 }
 
 // NewOrderHandler 初始化处理器
-func NewOrderHandler(orderService *service.OrderService) *handler.OrderHandler {
-	return handler.NewOrderHandler(orderService)
+func NewOrderHandler(orderService *service.OrderService, idempotencyService *service.IdempotencyService) *handler.OrderHandler {
+	return handler.NewOrderHandler(orderService, idempotencyService)
 }
 
 // NewMockProductService 创建商品服务的Mock实现
@@ -62,8 +333,8 @@ func NewPaymentRepository(db *gorm.DB) domain_payment_core.Repository {
 }
 
 // NewPaymentDomainService 创建支付领域服务
-func NewPaymentDomainService(repo domain_payment_core.Repository) *domain_payment_core.PaymentDomainService {
-	return domain_payment_core.NewPaymentDomainService(repo)
+func NewPaymentDomainService(repo domain_payment_core.Repository, rateProvider domain_payment_core.ExchangeRateProvider) *domain_payment_core.PaymentDomainService {
+	return domain_payment_core.NewPaymentDomainService(repo, rateProvider)
 }
 
 // NewMockPaymentProxy 创建Mock支付代理
@@ -76,11 +347,262 @@ func NewPaymentService(domainService *domain_payment_core.PaymentDomainService,
 	return service.NewPaymentService(domainService, proxy)
 }
 
-// NewOrderService 创建订单应用服务, 包含订单领域服务, 支付应用服务, 商品服务
+// NewOrderService 创建订单应用服务, 包含订单领域服务, 支付应用服务, 商品服务, 优惠领域服务, 库存服务, 工作单元, saga实例仓储
 func NewOrderService(
 	productService domain_product_core.ProductService,
 	orderDomainService domain_order_core.OrderDomainService,
 	paymentService *service.PaymentService,
+	promotionService *domain_promotion_core.PromotionDomainService,
+	inventoryService domain_inventory_core.InventoryService,
+	uow domain_uow_core.UnitOfWork,
+	sagaRepo saga.Repository,
+	dispatcher event.Dispatcher,
 ) *service.OrderService {
-	return service.NewOrderService(orderDomainService, paymentService, productService)
+	return service.NewOrderService(orderDomainService, paymentService, productService, promotionService, inventoryService, uow, sagaRepo, dispatcher)
+}
+
+// NewIdempotencyRepository 创建幂等记录仓储
+func NewIdempotencyRepository(db *gorm.DB) domain_idempotency_core.Repository {
+	return repository.NewIdempotencyRepository(db)
+}
+
+// NewIdempotencyService 创建幂等应用服务
+func NewIdempotencyService(repo domain_idempotency_core.Repository) *service.IdempotencyService {
+	return service.NewIdempotencyService(repo)
+}
+
+// NewRefundRepository 创建退款仓储
+func NewRefundRepository(db *gorm.DB) domain_payment_core.RefundRepository {
+	return repository.NewRefundRepository(db)
+}
+
+// NewRefundDomainService 创建退款领域服务
+func NewRefundDomainService(paymentRepo domain_payment_core.Repository, refundRepo domain_payment_core.RefundRepository, uow domain_uow_core.UnitOfWork) *domain_payment_core.RefundDomainService {
+	return domain_payment_core.NewRefundDomainService(paymentRepo, refundRepo, uow)
+}
+
+// NewRefundService 创建退款应用服务
+func NewRefundService(
+	orderDomainService domain_order_core.OrderDomainService,
+	paymentService *service.PaymentService,
+	refundDomainService *domain_payment_core.RefundDomainService,
+	proxy payment.PaymentProxy,
+	dispatcher event.Dispatcher,
+) *service.RefundService {
+	return service.NewRefundService(orderDomainService, paymentService, refundDomainService, proxy, dispatcher)
+}
+
+// NewMockPaymentNotifier 创建Mock支付通知解析器，不验签，只用于未开启支付宝的本地开发
+func NewMockPaymentNotifier() payment.PaymentNotifier {
+	return payment.NewMockPaymentNotifier()
+}
+
+// NewAlipayAdapter 创建支付宝适配器，同时作为支付代理和支付通知解析器，通知用支付宝公钥验签
+func NewAlipayAdapter(cfg *config.Config) (*payment.AlipayAdapter, error) {
+	return payment.NewAlipayAdapter(payment.AlipayConfig{
+		AppID:           cfg.Alipay.AppID,
+		PrivateKey:      cfg.Alipay.PrivateKey,
+		AlipayPublicKey: cfg.Alipay.PublicKey,
+		NotifyURL:       cfg.Alipay.NotifyURL,
+		IsProduction:    cfg.Alipay.Production,
+	})
+}
+
+// NewPaymentNotifyService 创建支付通知应用服务
+func NewPaymentNotifyService(
+	notifier payment.PaymentNotifier,
+	paymentService *service.PaymentService,
+	orderDomainService domain_order_core.OrderDomainService,
+	discrepancyRepo domain_reconciliation_core.DiscrepancyRepository,
+	dispatcher event.Dispatcher,
+) *service.PaymentNotifyService {
+	return service.NewPaymentNotifyService(notifier, paymentService, orderDomainService, discrepancyRepo, dispatcher)
+}
+
+// NewPaymentHandler 初始化支付处理器
+func NewPaymentHandler(refundService *service.RefundService, notifyService *service.PaymentNotifyService) *handler.PaymentHandler {
+	return handler.NewPaymentHandler(refundService, notifyService)
+}
+
+// NewOutboxStore 创建发件箱存储
+func NewOutboxStore(db *gorm.DB) outbox.Store {
+	return outbox.NewGormStore(db)
+}
+
+// NewOutboxSink 创建事件总线投递目标，注册订单事件解码器
+func NewOutboxSink(bus *event.EventBus) outbox.Sink {
+	sink := outbox.NewEventBusSink(bus)
+	for _, name := range domain_order_core.EventNames() {
+		sink.RegisterDecoder(name, domain_order_core.DecodeEvent)
+	}
+	return sink
+}
+
+// NewOutboxRelay 创建发件箱投递器
+func NewOutboxRelay(store outbox.Store, sink outbox.Sink) *outbox.Relay {
+	return outbox.NewRelay(store, sink)
+}
+
+// NewEventDispatcher 创建领域事件分发器，事务提交后立即投递，失败由发件箱投递器重试
+func NewEventDispatcher(store outbox.Store, sink outbox.Sink) event.Dispatcher {
+	return outbox.NewDispatcher(store, sink)
+}
+
+// NewLocker 创建基于数据库的租约锁
+func NewLocker(db *gorm.DB) lock.Locker {
+	return lock.NewGormLocker(db)
+}
+
+// NewScheduler 创建定时任务调度器
+func NewScheduler(locker lock.Locker) *scheduler.Scheduler {
+	return scheduler.NewScheduler(locker)
+}
+
+// NewOrderTimeoutService 创建超时未支付订单取消服务
+func NewOrderTimeoutService(
+	orderDomainService domain_order_core.OrderDomainService,
+	paymentService *service.PaymentService,
+	dispatcher event.Dispatcher,
+) *service.OrderTimeoutService {
+	return service.NewOrderTimeoutService(orderDomainService, paymentService, dispatcher)
+}
+
+// NewDiscrepancyRepository 创建对账差异仓储
+func NewDiscrepancyRepository(db *gorm.DB) domain_reconciliation_core.DiscrepancyRepository {
+	return repository.NewDiscrepancyRepository(db)
+}
+
+// NewPaymentReconcileService 创建支付状态对账服务
+func NewPaymentReconcileService(
+	paymentService *service.PaymentService,
+	orderDomainService domain_order_core.OrderDomainService,
+	discrepancyRepo domain_reconciliation_core.DiscrepancyRepository,
+	dispatcher event.Dispatcher,
+) *service.PaymentReconcileService {
+	return service.NewPaymentReconcileService(paymentService, orderDomainService, discrepancyRepo, dispatcher)
+}
+
+// NewStatementParsers 创建各支付渠道的对账单解析器
+func NewStatementParsers() []domain_reconciliation_core.StatementParser {
+	return []domain_reconciliation_core.StatementParser{payment.NewAlipayStatementParser()}
+}
+
+// NewStatementReconcileService 创建对账单对账服务
+func NewStatementReconcileService(
+	parsers []domain_reconciliation_core.StatementParser,
+	paymentService *service.PaymentService,
+	refundDomainService *domain_payment_core.RefundDomainService,
+	discrepancyRepo domain_reconciliation_core.DiscrepancyRepository,
+) *service.StatementReconcileService {
+	return service.NewStatementReconcileService(parsers, paymentService, refundDomainService, discrepancyRepo)
+}
+
+// NewReconciliationHandler 初始化对账处理器
+func NewReconciliationHandler(statementService *service.StatementReconcileService) *handler.ReconciliationHandler {
+	return handler.NewReconciliationHandler(statementService)
+}
+
+// NewCouponRepository 创建优惠券仓储
+func NewCouponRepository(db *gorm.DB) domain_promotion_core.CouponRepository {
+	return repository.NewCouponRepository(db)
+}
+
+// NewCouponUsageRepository 创建优惠券使用记录仓储
+func NewCouponUsageRepository(db *gorm.DB) domain_promotion_core.CouponUsageRepository {
+	return repository.NewCouponUsageRepository(db)
+}
+
+// NewPromotionDomainService 创建优惠领域服务
+func NewPromotionDomainService(couponRepo domain_promotion_core.CouponRepository, usageRepo domain_promotion_core.CouponUsageRepository) *domain_promotion_core.PromotionDomainService {
+	return domain_promotion_core.NewPromotionDomainService(couponRepo, usageRepo)
+}
+
+// NewCouponReleaseHandler 创建订单取消后释放优惠券的事件处理器
+func NewCouponReleaseHandler(promotionService *domain_promotion_core.PromotionDomainService) *service.CouponReleaseHandler {
+	return service.NewCouponReleaseHandler(promotionService)
+}
+
+// NewInventoryService 创建基于本地数据库的库存服务
+func NewInventoryService(db *gorm.DB) domain_inventory_core.InventoryService {
+	return inventory.NewGormInventoryService(db)
+}
+
+// NewInventoryReservationHandler 创建订单支付、取消后处理库存预占的事件处理器
+func NewInventoryReservationHandler(orderDomainService domain_order_core.OrderDomainService, inventoryService domain_inventory_core.InventoryService) *service.InventoryReservationHandler {
+	return service.NewInventoryReservationHandler(orderDomainService, inventoryService)
+}
+
+// NewSagaRepository 创建saga实例仓储
+func NewSagaRepository(db *gorm.DB) saga.Repository {
+	return repository.NewSagaRepository(db)
+}
+
+// NewSagaRecovery 创建saga恢复器，恢复订单服务编排的saga
+func NewSagaRecovery(repo saga.Repository, orderService *service.OrderService) *saga.Recovery {
+	return saga.NewRecovery(repo, orderService.Sagas()...)
+}
+
+// NewUnitOfWork 创建基于数据库事务的工作单元
+func NewUnitOfWork(db *gorm.DB) domain_uow_core.UnitOfWork {
+	return persistence.NewGormUnitOfWork(db)
+}
+
+// NewMemoryOrderRepository 创建内存订单仓储
+func NewMemoryOrderRepository() domain_order_core.OrderRepository {
+	return memory.NewOrderRepository()
+}
+
+// NewMemoryPaymentRepository 创建内存支付仓储
+func NewMemoryPaymentRepository() domain_payment_core.Repository {
+	return memory.NewPaymentRepository()
+}
+
+// NewMemoryRefundRepository 创建内存退款仓储
+func NewMemoryRefundRepository(paymentRepo domain_payment_core.Repository) domain_payment_core.RefundRepository {
+	return memory.NewRefundRepository(paymentRepo)
+}
+
+// NewMemoryIdempotencyRepository 创建内存幂等记录仓储
+func NewMemoryIdempotencyRepository() domain_idempotency_core.Repository {
+	return memory.NewIdempotencyRepository()
+}
+
+// NewMemoryDiscrepancyRepository 创建内存对账差异仓储
+func NewMemoryDiscrepancyRepository() domain_reconciliation_core.DiscrepancyRepository {
+	return memory.NewDiscrepancyRepository()
+}
+
+// NewMemoryCouponRepository 创建内存优惠券仓储
+func NewMemoryCouponRepository() domain_promotion_core.CouponRepository {
+	return memory.NewCouponRepository()
+}
+
+// NewMemoryCouponUsageRepository 创建内存优惠券使用记录仓储
+func NewMemoryCouponUsageRepository() domain_promotion_core.CouponUsageRepository {
+	return memory.NewCouponUsageRepository()
+}
+
+// NewMemoryInventoryService 创建内存库存服务，初始库存与初始迁移脚本中的种子数据一致
+func NewMemoryInventoryService() domain_inventory_core.InventoryService {
+	return memory.NewInventoryService(map[string]int64{"P001": 1000, "P002": 1000})
+}
+
+// NewMemorySagaRepository 创建内存saga实例仓储
+func NewMemorySagaRepository() saga.Repository {
+	return memory.NewSagaRepository()
+}
+
+// NewMemoryUnitOfWork 创建内存工作单元
+func NewMemoryUnitOfWork() domain_uow_core.UnitOfWork {
+	return memory.NewUnitOfWork()
+}
+
+// NewMemoryOutboxStore 创建内存发件箱存储
+func NewMemoryOutboxStore() outbox.Store {
+	return memory.NewOutboxStore()
+}
+
+// NewMemoryLocker 创建单实例租约锁
+func NewMemoryLocker() lock.Locker {
+	return memory.NewLocker()
 }
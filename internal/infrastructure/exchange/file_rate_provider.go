@@ -0,0 +1,144 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
+	"github.com/vaynedu/ddd_order_example/pkg/dmoney"
+)
+
+// rateFile 本地汇率文件格式，汇率为十进制字符串，避免 JSON 数字按浮点数解析
+//
+//	{
+//	  "updated_at": "2024-06-01T00:00:00+08:00",
+//	  "rates": [{"from": "USD", "to": "CNY", "rate": "7.1234"}]
+//	}
+type rateFile struct {
+	UpdatedAt time.Time `json:"updated_at"`
+	Rates     []struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+		Rate string `json:"rate"`
+	} `json:"rates"`
+}
+
+type currencyPair struct {
+	from dmoney.Currency
+	to   dmoney.Currency
+}
+
+// FileRateProvider 从本地 JSON 文件读取汇率，适用于汇率由运营定期维护的场景
+// 文件修改后下次查询时自动重新加载；新文件解析失败时继续使用上一次加载成功的汇率
+// 只提供文件中配置的币种对，不自动推导反向汇率，避免反向换算引入额外的舍入
+type FileRateProvider struct {
+	path   string
+	source string
+
+	mu        sync.RWMutex
+	modTime   time.Time
+	updatedAt time.Time
+	rates     map[currencyPair]dmoney.ExchangeRate
+}
+
+// NewFileRateProvider 创建本地文件汇率提供者，首次加载失败时返回错误
+func NewFileRateProvider(path string) (*FileRateProvider, error) {
+	p := &FileRateProvider{path: path, source: "file:" + filepath.Base(path)}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取汇率文件失败: %w", err)
+	}
+	if err := p.load(info.ModTime()); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// GetRate 查询 from 兑换 to 的汇率，相同币种返回 1
+func (p *FileRateProvider) GetRate(ctx context.Context, from, to dmoney.Currency) (*domain_payment_core.ExchangeRateQuote, error) {
+	if from == to {
+		rate, err := dmoney.ParseExchangeRate(from, to, "1")
+		if err != nil {
+			return nil, err
+		}
+		return &domain_payment_core.ExchangeRateQuote{Rate: rate, Source: p.source, QuotedAt: time.Now()}, nil
+	}
+
+	p.reloadIfModified()
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	rate, ok := p.rates[currencyPair{from: from, to: to}]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s/%s", domain_payment_core.ErrExchangeRateNotFound, from, to)
+	}
+	return &domain_payment_core.ExchangeRateQuote{Rate: rate, Source: p.source, QuotedAt: p.updatedAt}, nil
+}
+
+// reloadIfModified 文件修改时间变化时重新加载
+func (p *FileRateProvider) reloadIfModified() {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		slog.Error("读取汇率文件失败，继续使用已加载的汇率", "error", err)
+		return
+	}
+
+	p.mu.RLock()
+	unchanged := info.ModTime().Equal(p.modTime)
+	p.mu.RUnlock()
+	if unchanged {
+		return
+	}
+	if err := p.load(info.ModTime()); err != nil {
+		slog.Error("重新加载汇率文件失败，继续使用已加载的汇率", "error", err)
+	}
+}
+
+// load 解析整个文件，全部校验通过后才替换内存中的汇率
+func (p *FileRateProvider) load(modTime time.Time) error {
+	content, err := os.ReadFile(p.path)
+	if err != nil {
+		return fmt.Errorf("读取汇率文件失败: %w", err)
+	}
+	var file rateFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return fmt.Errorf("解析汇率文件失败: %w", err)
+	}
+
+	rates := make(map[currencyPair]dmoney.ExchangeRate, len(file.Rates))
+	for _, item := range file.Rates {
+		from, err := dmoney.ParseCurrency(item.From)
+		if err != nil {
+			return fmt.Errorf("汇率文件币种无效: %w", err)
+		}
+		to, err := dmoney.ParseCurrency(item.To)
+		if err != nil {
+			return fmt.Errorf("汇率文件币种无效: %w", err)
+		}
+		pair := currencyPair{from: from, to: to}
+		if _, ok := rates[pair]; ok {
+			return fmt.Errorf("汇率文件中%s/%s重复配置", from, to)
+		}
+		if rates[pair], err = dmoney.ParseExchangeRate(from, to, item.Rate); err != nil {
+			return fmt.Errorf("汇率文件中%s/%s的汇率无效: %w", from, to, err)
+		}
+	}
+
+	updatedAt := file.UpdatedAt
+	if updatedAt.IsZero() {
+		updatedAt = modTime
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.modTime = modTime
+	p.updatedAt = updatedAt
+	p.rates = rates
+	return nil
+}
@@ -0,0 +1,96 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
+	"github.com/vaynedu/ddd_order_example/pkg/dmoney"
+)
+
+func writeRateFile(t *testing.T, path, content string, modTime time.Time) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+// TestFileRateProvider_GetRate 按文件配置的币种对查询汇率
+func TestFileRateProvider_GetRate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "exchange_rates.json")
+	writeRateFile(t, path, `{
+		"updated_at": "2024-06-01T00:00:00+08:00",
+		"rates": [
+			{"from": "USD", "to": "CNY", "rate": "7.1234"},
+			{"from": "jpy", "to": "cny", "rate": "0.0483"}
+		]
+	}`, time.Now())
+
+	provider, err := NewFileRateProvider(path)
+	require.NoError(t, err)
+
+	quote, err := provider.GetRate(context.Background(), dmoney.USD, dmoney.CNY)
+	require.NoError(t, err)
+	assert.Equal(t, "7.1234", quote.Rate.String())
+	assert.Equal(t, "file:exchange_rates.json", quote.Source)
+	assert.True(t, quote.QuotedAt.Equal(time.Date(2024, 6, 1, 0, 0, 0, 0, time.FixedZone("", 8*3600))))
+
+	quote, err = provider.GetRate(context.Background(), dmoney.JPY, dmoney.CNY)
+	require.NoError(t, err)
+	assert.Equal(t, dmoney.CNY, quote.Rate.To())
+
+	// 不推导反向汇率
+	_, err = provider.GetRate(context.Background(), dmoney.CNY, dmoney.USD)
+	assert.True(t, errors.Is(err, domain_payment_core.ErrExchangeRateNotFound))
+
+	quote, err = provider.GetRate(context.Background(), dmoney.CNY, dmoney.CNY)
+	require.NoError(t, err)
+	assert.Equal(t, "1", quote.Rate.String())
+}
+
+// TestFileRateProvider_Reload 文件修改后重新加载，新文件无效时保留原汇率
+func TestFileRateProvider_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "exchange_rates.json")
+	modTime := time.Now().Add(-time.Hour)
+	writeRateFile(t, path, `{"rates": [{"from": "USD", "to": "CNY", "rate": "7.10"}]}`, modTime)
+
+	provider, err := NewFileRateProvider(path)
+	require.NoError(t, err)
+
+	writeRateFile(t, path, `{"rates": [{"from": "USD", "to": "CNY", "rate": "7.20"}]}`, modTime.Add(time.Minute))
+	quote, err := provider.GetRate(context.Background(), dmoney.USD, dmoney.CNY)
+	require.NoError(t, err)
+	assert.Equal(t, "7.20", quote.Rate.String())
+	assert.True(t, quote.QuotedAt.Equal(modTime.Add(time.Minute)))
+
+	writeRateFile(t, path, `{"rates": [{"from": "USD", "to": "CNY", "rate": "-1"}]}`, modTime.Add(2*time.Minute))
+	quote, err = provider.GetRate(context.Background(), dmoney.USD, dmoney.CNY)
+	require.NoError(t, err)
+	assert.Equal(t, "7.20", quote.Rate.String())
+}
+
+// TestNewFileRateProvider_Invalid 首次加载失败时返回错误
+func TestNewFileRateProvider_Invalid(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := NewFileRateProvider(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+
+	cases := map[string]string{
+		"bad_json.json":       `{`,
+		"float_rate.json":     `{"rates": [{"from": "USD", "to": "CNY", "rate": 7.1}]}`,
+		"unknown_pair.json":   `{"rates": [{"from": "XXX", "to": "CNY", "rate": "1"}]}`,
+		"duplicate_pair.json": `{"rates": [{"from": "USD", "to": "CNY", "rate": "7.1"}, {"from": "usd", "to": "CNY", "rate": "7.2"}]}`,
+	}
+	for name, content := range cases {
+		path := filepath.Join(dir, name)
+		writeRateFile(t, path, content, time.Now())
+		_, err := NewFileRateProvider(path)
+		assert.Error(t, err, name)
+	}
+}
@@ -23,19 +23,34 @@ func (m *MockProductService) ValidateProduct(ctx context.Context, req *domain_pr
 		return m.ValidateFunc(ctx, req)
 	}
 
-	// 默认返回有效的商品信息
+	// 默认返回有效的商品信息，按请求的单价和币种定价
 	return &domain_product_core.ValidateProductResponse{
 		Product: &domain_product_core.Product{
-			ID:     req.ProductID,
-			Name:   req.Name,
-			Price:  req.Price,
-			Status: domain_product_core.StatusValid,
+			ID:       req.ProductID,
+			Name:     req.Name,
+			Price:    req.Price,
+			Currency: req.Currency,
+			Status:   domain_product_core.StatusValid,
 		},
 		IsValid:  true,
 		Messages: "",
 	}, nil
 }
 
+// ValidateProducts 实现ProductService接口，逐个调用 ValidateProduct
+func (m *MockProductService) ValidateProducts(ctx context.Context, reqs []*domain_product_core.ValidateProductRequest) ([]*domain_product_core.ValidateProductResult, error) {
+	results := make([]*domain_product_core.ValidateProductResult, len(reqs))
+	for i, req := range reqs {
+		resp, err := m.ValidateProduct(ctx, req)
+		results[i] = &domain_product_core.ValidateProductResult{
+			Request:  req,
+			Response: resp,
+			Err:      err,
+		}
+	}
+	return results, nil
+}
+
 // WithProductNotFound 设置商品不存在场景
 func (m *MockProductService) WithProductNotFound() {
 	m.ValidateFunc = func(ctx context.Context, req *domain_product_core.ValidateProductRequest) (*domain_product_core.ValidateProductResponse, error) {
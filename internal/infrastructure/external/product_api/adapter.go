@@ -3,8 +3,11 @@ package product_api
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sync"
 
 	"github.com/vaynedu/ddd_order_example/internal/domain/domain_product_core"
+	"github.com/vaynedu/ddd_order_example/pkg/dmoney"
 )
 
 // ThirdPartyProductResponse 第三方API响应结构
@@ -17,18 +20,64 @@ type ThirdPartyProductResponse struct {
 	// 其他第三方字段...
 }
 
+// defaultMaxConcurrency 批量校验时对第三方API的最大并发请求数
+const defaultMaxConcurrency = 8
+
 // ProductServiceAdapter 适配第三方API到领域接口
 type ProductServiceAdapter struct {
-	client *ThirdPartyProductAPI
+	client         *ThirdPartyProductAPI
+	maxConcurrency int
 }
 
 // NewProductServiceAdapter 创建适配器实例
 func NewProductServiceAdapter(client *ThirdPartyProductAPI) domain_product_core.ProductService {
 	return &ProductServiceAdapter{
-		client: client,
+		client:         client,
+		maxConcurrency: defaultMaxConcurrency,
 	}
 }
 
+// ValidateProducts 批量校验商品，第三方暂无批量接口，使用有界并发扇出单个查询
+func (a *ProductServiceAdapter) ValidateProducts(ctx context.Context, reqs []*domain_product_core.ValidateProductRequest) ([]*domain_product_core.ValidateProductResult, error) {
+	concurrency := a.maxConcurrency
+	if concurrency <= 0 {
+		concurrency = defaultMaxConcurrency
+	}
+
+	results := make([]*domain_product_core.ValidateProductResult, len(reqs))
+	sem := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+	for i, req := range reqs {
+		// 获取并发令牌，ctx取消时不再发起新请求
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return nil, ctx.Err()
+		}
+
+		wg.Add(1)
+		go func(i int, req *domain_product_core.ValidateProductRequest) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			resp, err := a.ValidateProduct(ctx, req)
+			results[i] = &domain_product_core.ValidateProductResult{
+				Request:  req,
+				Response: resp,
+				Err:      err,
+			}
+		}(i, req)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
 // ValidateProduct 实现领域接口
 func (a *ProductServiceAdapter) ValidateProduct(ctx context.Context, req *domain_product_core.ValidateProductRequest) (*domain_product_core.ValidateProductResponse, error) {
 	resp, err := a.client.GetProductStatus(ctx, req.ProductID)
@@ -43,6 +92,10 @@ func (a *ProductServiceAdapter) ValidateProduct(ctx context.Context, req *domain
 	if resp.Status != 0 {
 		return nil, errors.New("product status is invalid")
 	}
+	// 第三方商品价格以人民币分为单位，不能用于其他币种的订单
+	if req.Currency != string(dmoney.CNY) {
+		return nil, fmt.Errorf("product price currency is CNY, not %s", req.Currency)
+	}
 	// 价格校验
 	if resp.Price != req.Price {
 		return nil, errors.New("product price is invalid")
@@ -51,9 +104,10 @@ func (a *ProductServiceAdapter) ValidateProduct(ctx context.Context, req *domain
 
 	// 转换第三方响应为领域模型
 	domainProduct := &domain_product_core.Product{
-		ID:    resp.ProductID,
-		Name:  resp.Name,
-		Price: resp.Price,
+		ID:       resp.ProductID,
+		Name:     resp.Name,
+		Price:    resp.Price,
+		Currency: string(dmoney.CNY),
 	}
 
 	// 状态映射
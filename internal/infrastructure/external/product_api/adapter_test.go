@@ -0,0 +1,83 @@
+package product_api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_product_core"
+)
+
+// TestProductServiceAdapter_ValidateProducts 批量校验结果按下标对应，且并发数受限
+func TestProductServiceAdapter_ValidateProducts(t *testing.T) {
+	var inflight, maxInflight int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cur := atomic.AddInt32(&inflight, 1)
+		defer atomic.AddInt32(&inflight, -1)
+		for {
+			old := atomic.LoadInt32(&maxInflight)
+			if cur <= old || atomic.CompareAndSwapInt32(&maxInflight, old, cur) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		productID := strings.TrimPrefix(r.URL.Path, "/products/")
+		status := 0
+		if productID == "p_deleted" {
+			status = 1
+		}
+		_ = json.NewEncoder(w).Encode(ThirdPartyProductResponse{
+			ProductID: productID,
+			Name:      "name_" + productID,
+			Price:     100,
+			Status:    status,
+		})
+	}))
+	defer server.Close()
+
+	adapter := &ProductServiceAdapter{
+		client:         NewThirdPartyProductAPI(server.URL, "key"),
+		maxConcurrency: 2,
+	}
+
+	var reqs []*domain_product_core.ValidateProductRequest
+	for _, id := range []string{"p1", "p2", "p_deleted", "p4", "p5", "p6"} {
+		reqs = append(reqs, &domain_product_core.ValidateProductRequest{ProductID: id, Price: 100, Currency: "CNY", Quantity: 1})
+	}
+	reqs[3].Price = 99
+	reqs[4].Currency = "USD"
+
+	results, err := adapter.ValidateProducts(context.Background(), reqs)
+
+	assert.NoError(t, err)
+	assert.Len(t, results, len(reqs))
+	for i, result := range results {
+		assert.Same(t, reqs[i], result.Request)
+	}
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, "p1", results[0].Response.Product.ID)
+	assert.Error(t, results[2].Err)
+	assert.Error(t, results[3].Err)
+	// 第三方商品价格为人民币，其他币种的订单校验失败
+	assert.ErrorContains(t, results[4].Err, "USD")
+	assert.LessOrEqual(t, atomic.LoadInt32(&maxInflight), int32(2))
+}
+
+// TestProductServiceAdapter_ValidateProducts_ContextCanceled ctx取消时整体返回错误
+func TestProductServiceAdapter_ValidateProducts_ContextCanceled(t *testing.T) {
+	adapter := &ProductServiceAdapter{client: NewThirdPartyProductAPI("http://127.0.0.1:0", "key")}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := adapter.ValidateProducts(ctx, []*domain_product_core.ValidateProductRequest{{ProductID: "p1"}})
+
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -4,31 +4,39 @@ import (
     "context"
     "encoding/json"
     "net/http"
+    "sync/atomic"
     "time"
 )
 
 // ThirdPartyProductAPI 第三方商品API客户端
 type ThirdPartyProductAPI struct {
     baseURL    string
-    timeout    time.Duration
+    timeout    atomic.Int64 // 单次请求超时，可在运行时调整
     httpClient *http.Client
     apiKey     string
 }
 
 // NewThirdPartyProductAPI 创建客户端实例
 func NewThirdPartyProductAPI(baseURL, apiKey string) *ThirdPartyProductAPI {
-    return &ThirdPartyProductAPI{
-        baseURL: baseURL,
-        timeout: 5 * time.Second,
-        httpClient: &http.Client{
-            Timeout: 5 * time.Second,
-        },
-        apiKey: apiKey,
+    c := &ThirdPartyProductAPI{
+        baseURL:    baseURL,
+        httpClient: &http.Client{},
+        apiKey:     apiKey,
     }
+    c.SetTimeout(5 * time.Second)
+    return c
+}
+
+// SetTimeout 调整单次请求超时，对之后发起的请求生效
+func (c *ThirdPartyProductAPI) SetTimeout(timeout time.Duration) {
+    c.timeout.Store(int64(timeout))
 }
 
 // GetProductStatus 调用第三方API获取商品状态
 func (c *ThirdPartyProductAPI) GetProductStatus(ctx context.Context, productID string) (*ThirdPartyProductResponse, error) {
+    ctx, cancel := context.WithTimeout(ctx, time.Duration(c.timeout.Load()))
+    defer cancel()
+
     // 实现HTTP请求逻辑...
     req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/products/"+productID, nil)
     if err != nil {
@@ -0,0 +1,33 @@
+package product_api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestThirdPartyProductAPI_SetTimeout 运行时调整的超时对之后的请求生效
+func TestThirdPartyProductAPI_SetTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(100 * time.Millisecond):
+		case <-r.Context().Done():
+			return
+		}
+		w.Write([]byte(`{"status":1}`))
+	}))
+	defer server.Close()
+
+	client := NewThirdPartyProductAPI(server.URL, "key")
+	_, err := client.GetProductStatus(context.Background(), "P001")
+	require.NoError(t, err)
+
+	client.SetTimeout(20 * time.Millisecond)
+	_, err = client.GetProductStatus(context.Background(), "P001")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,193 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_inventory_core"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/persistence"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TableName 指定模型对应的数据库表名
+func (Stock) TableName() string {
+	return "t_inventory"
+}
+
+// Stock 商品库存，可售库存与预占库存分开记录，预占时从可售转入预占
+type Stock struct {
+	ProductID string    `json:"product_id" gorm:"column:product_id;primaryKey"`
+	Available int64     `json:"available" gorm:"column:available"` // 可售库存
+	Reserved  int64     `json:"reserved" gorm:"column:reserved"`   // 已预占未确认的库存
+	UpdatedAt time.Time `json:"updated_at" gorm:"column:updated_at"`
+}
+
+// GormInventoryService 基于本地数据库的库存服务
+// 库存变更都通过带条件的 UPDATE 完成，并发预占同一商品时不会超卖；在工作单元中调用时加入外层事务
+type GormInventoryService struct {
+	db  *gorm.DB
+	now func() time.Time
+}
+
+// NewGormInventoryService 创建库存服务
+func NewGormInventoryService(db *gorm.DB) domain_inventory_core.InventoryService {
+	return &GormInventoryService{db: db, now: time.Now}
+}
+
+// ReserveStock 在一个事务内为订单的全部商品预占库存
+// 按商品ID顺序加锁，避免并发订单以不同顺序更新同一批商品时死锁
+func (s *GormInventoryService) ReserveStock(ctx context.Context, orderID string, items []domain_inventory_core.StockItem) ([]*domain_inventory_core.ReservationDO, error) {
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return strings.Compare(items[a].ProductID, items[b].ProductID)
+	})
+
+	reservations := make([]*domain_inventory_core.ReservationDO, len(items))
+	err := persistence.DB(ctx, s.db).Transaction(func(tx *gorm.DB) error {
+		now := s.now()
+		for _, i := range order {
+			item := items[i]
+			if item.Quantity <= 0 {
+				return fmt.Errorf("商品[%s]预占数量必须大于0", item.ProductID)
+			}
+
+			// 同一订单重复预占时返回已有记录
+			var existing domain_inventory_core.ReservationDO
+			err := tx.Where("order_id = ? AND product_id = ?", orderID, item.ProductID).First(&existing).Error
+			if err == nil {
+				if existing.Status == domain_inventory_core.ReservationStatusReleased {
+					return fmt.Errorf("%w: 订单[%s]商品[%s]", domain_inventory_core.ErrReservationReleased, orderID, item.ProductID)
+				}
+				reservations[i] = &existing
+				continue
+			}
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+
+			result := tx.Model(&Stock{}).
+				Where("product_id = ? AND available >= ?", item.ProductID, item.Quantity).
+				Updates(map[string]any{
+					"available":  gorm.Expr("available - ?", item.Quantity),
+					"reserved":   gorm.Expr("reserved + ?", item.Quantity),
+					"updated_at": now,
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return fmt.Errorf("%w: 商品[%s]需要%d件", domain_inventory_core.ErrInsufficientStock, item.ProductID, item.Quantity)
+			}
+
+			reservation := &domain_inventory_core.ReservationDO{
+				ID:        uuid.New().String(),
+				OrderID:   orderID,
+				ProductID: item.ProductID,
+				Quantity:  item.Quantity,
+				Status:    domain_inventory_core.ReservationStatusReserved,
+				CreatedAt: now,
+				UpdatedAt: now,
+			}
+			if err := tx.Create(reservation).Error; err != nil {
+				return err
+			}
+			reservations[i] = reservation
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reservations, nil
+}
+
+// ConfirmReservation 确认预占，预占库存扣减
+func (s *GormInventoryService) ConfirmReservation(ctx context.Context, reservationID string) error {
+	return persistence.DB(ctx, s.db).Transaction(func(tx *gorm.DB) error {
+		reservation, err := lockReservation(tx, reservationID)
+		if err != nil {
+			return err
+		}
+		switch reservation.Status {
+		case domain_inventory_core.ReservationStatusConfirmed:
+			return nil
+		case domain_inventory_core.ReservationStatusReleased:
+			return fmt.Errorf("%w: %s", domain_inventory_core.ErrReservationReleased, reservationID)
+		}
+
+		now := s.now()
+		if err := updateStock(tx, reservation.ProductID, map[string]any{
+			"reserved":   gorm.Expr("reserved - ?", reservation.Quantity),
+			"updated_at": now,
+		}); err != nil {
+			return err
+		}
+		return updateStatus(tx, reservation, domain_inventory_core.ReservationStatusConfirmed, now)
+	})
+}
+
+// ReleaseReservation 释放预占，未确认的从预占归还可售，已确认的(已支付订单取消)直接补回可售
+func (s *GormInventoryService) ReleaseReservation(ctx context.Context, reservationID string) error {
+	return persistence.DB(ctx, s.db).Transaction(func(tx *gorm.DB) error {
+		reservation, err := lockReservation(tx, reservationID)
+		if err != nil {
+			return err
+		}
+
+		now := s.now()
+		changes := map[string]any{
+			"available":  gorm.Expr("available + ?", reservation.Quantity),
+			"updated_at": now,
+		}
+		switch reservation.Status {
+		case domain_inventory_core.ReservationStatusReleased:
+			return nil
+		case domain_inventory_core.ReservationStatusReserved:
+			changes["reserved"] = gorm.Expr("reserved - ?", reservation.Quantity)
+		}
+		if err := updateStock(tx, reservation.ProductID, changes); err != nil {
+			return err
+		}
+		return updateStatus(tx, reservation, domain_inventory_core.ReservationStatusReleased, now)
+	})
+}
+
+// lockReservation 加行锁读取预占记录，同一预占的并发确认、释放串行执行
+func lockReservation(tx *gorm.DB, reservationID string) (*domain_inventory_core.ReservationDO, error) {
+	var reservation domain_inventory_core.ReservationDO
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", reservationID).First(&reservation).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: %s", domain_inventory_core.ErrReservationNotFound, reservationID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+func updateStock(tx *gorm.DB, productID string, changes map[string]any) error {
+	result := tx.Model(&Stock{}).Where("product_id = ?", productID).Updates(changes)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("商品[%s]没有库存记录", productID)
+	}
+	return nil
+}
+
+func updateStatus(tx *gorm.DB, reservation *domain_inventory_core.ReservationDO, status domain_inventory_core.ReservationStatus, now time.Time) error {
+	return tx.Model(reservation).Updates(map[string]any{
+		"status":     status,
+		"updated_at": now,
+	}).Error
+}
@@ -0,0 +1,85 @@
+package lock
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Locker 租约锁，多实例部署时保证同一时刻只有一个实例持有同名租约
+type Locker interface {
+	// TryAcquire 获取或续期租约，租约被其他实例持有且未过期时返回 false
+	TryAcquire(ctx context.Context, name string, ttl time.Duration) (bool, error)
+	// Release 释放本实例持有的租约
+	Release(ctx context.Context, name string) error
+}
+
+// TableName 指定模型对应的数据库表名
+func (Lease) TableName() string {
+	return "t_lease"
+}
+
+// Lease 租约记录，过期时间由持有者续期，持有者宕机后租约到期即可被其他实例接管
+type Lease struct {
+	Name      string    `json:"name" gorm:"column:name;primaryKey"`
+	Owner     string    `json:"owner" gorm:"column:owner"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"column:expires_at"`
+}
+
+// GormLocker 基于数据库行的租约锁
+// 过期判断使用各实例本地时间，租约时长应远大于实例间的时钟偏差
+type GormLocker struct {
+	db    *gorm.DB
+	table string
+	owner string
+	now   func() time.Time
+}
+
+// NewGormLocker 创建租约锁，持有者标识由主机名、进程号和随机串组成
+func NewGormLocker(db *gorm.DB) Locker {
+	return NewGormLockerOnTable(db, Lease{}.TableName())
+}
+
+// NewGormLockerOnTable 创建使用指定表的租约锁，表结构与 t_lease 相同
+// 用于 t_lease 尚未创建时的场景，如执行数据库迁移
+func NewGormLockerOnTable(db *gorm.DB, table string) Locker {
+	host, _ := os.Hostname()
+	return &GormLocker{
+		db:    db,
+		table: table,
+		owner: fmt.Sprintf("%s-%d-%s", host, os.Getpid(), uuid.New().String()[:8]),
+		now:   time.Now,
+	}
+}
+
+// TryAcquire 租约不存在时插入，存在时仅在本实例持有或已过期时更新
+func (l *GormLocker) TryAcquire(ctx context.Context, name string, ttl time.Duration) (bool, error) {
+	now := l.now()
+	lease := &Lease{Name: name, Owner: l.owner, ExpiresAt: now.Add(ttl)}
+
+	result := l.db.WithContext(ctx).Table(l.table).Clauses(clause.OnConflict{DoNothing: true}).Create(lease)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return true, nil
+	}
+
+	result = l.db.WithContext(ctx).Table(l.table).
+		Where("name = ? AND (owner = ? OR expires_at < ?)", name, l.owner, now).
+		Updates(map[string]interface{}{
+			"owner":      l.owner,
+			"expires_at": lease.ExpiresAt,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// Release 删除本实例持有的租约，其他实例无需等待过期即可接管
+func (l *GormLocker) Release(ctx context.Context, name string) error {
+	return l.db.WithContext(ctx).Table(l.table).Where("name = ? AND owner = ?", name, l.owner).Delete(&Lease{}).Error
+}
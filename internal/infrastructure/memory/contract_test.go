@@ -0,0 +1,39 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_idempotency_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_order_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/repositorytest"
+)
+
+func TestOrderRepository_Contract(t *testing.T) {
+	repositorytest.OrderRepositoryContract(t, func(t *testing.T) domain_order_core.OrderRepository {
+		return NewOrderRepository()
+	})
+}
+
+func TestPaymentRepository_Contract(t *testing.T) {
+	repositorytest.PaymentRepositoryContract(t, func(t *testing.T) domain_payment_core.Repository {
+		return NewPaymentRepository()
+	})
+}
+
+func TestRefundRepository_ConcurrencyContract(t *testing.T) {
+	repositorytest.RefundConcurrencyContract(t, func(t *testing.T) repositorytest.RefundDeps {
+		payments := NewPaymentRepository()
+		return repositorytest.RefundDeps{
+			UnitOfWork:  NewUnitOfWork(),
+			PaymentRepo: payments,
+			RefundRepo:  NewRefundRepository(payments),
+		}
+	})
+}
+
+func TestIdempotencyRepository_Contract(t *testing.T) {
+	repositorytest.IdempotencyRepositoryContract(t, func(t *testing.T) domain_idempotency_core.Repository {
+		return NewIdempotencyRepository()
+	})
+}
@@ -0,0 +1,152 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_promotion_core"
+)
+
+// CouponRepository 内存实现的优惠券仓储
+type CouponRepository struct {
+	mu      sync.RWMutex
+	coupons map[string]*domain_promotion_core.CouponDO
+}
+
+// NewCouponRepository 创建内存优惠券仓储
+func NewCouponRepository() *CouponRepository {
+	return &CouponRepository{coupons: make(map[string]*domain_promotion_core.CouponDO)}
+}
+
+// Save 保存优惠券定义副本
+func (r *CouponRepository) Save(ctx context.Context, coupon *domain_promotion_core.CouponDO) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.coupons[coupon.ID] = cloneCoupon(coupon)
+	return nil
+}
+
+// FindByCodes 按券码批量查询优惠券，不存在的券码不返回
+func (r *CouponRepository) FindByCodes(ctx context.Context, codes []string) ([]*domain_promotion_core.CouponDO, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	coupons := []*domain_promotion_core.CouponDO{}
+	for _, coupon := range r.coupons {
+		if slices.Contains(codes, coupon.Code) {
+			coupons = append(coupons, cloneCoupon(coupon))
+		}
+	}
+	return coupons, nil
+}
+
+// cloneCoupon 深拷贝优惠券
+func cloneCoupon(coupon *domain_promotion_core.CouponDO) *domain_promotion_core.CouponDO {
+	cp := *coupon
+	cp.ProductIDs = slices.Clone(coupon.ProductIDs)
+	cp.CustomerIDs = slices.Clone(coupon.CustomerIDs)
+	cp.EndAt = cloneTime(coupon.EndAt)
+	return &cp
+}
+
+// usageKey 优惠券使用记录主键
+type usageKey struct {
+	couponID string
+	orderID  string
+}
+
+// customerKey 客户维度的使用次数主键
+type customerKey struct {
+	couponID   string
+	customerID string
+}
+
+// CouponUsageRepository 内存实现的优惠券使用记录仓储，占用和释放在一把锁内完成，与数据库事务一样原子
+type CouponUsageRepository struct {
+	mu     sync.Mutex
+	usages map[usageKey]domain_promotion_core.CouponUsageDO
+	counts map[customerKey]int64
+	now    func() time.Time
+}
+
+// NewCouponUsageRepository 创建内存优惠券使用记录仓储
+func NewCouponUsageRepository() *CouponUsageRepository {
+	return &CouponUsageRepository{
+		usages: make(map[usageKey]domain_promotion_core.CouponUsageDO),
+		counts: make(map[customerKey]int64),
+		now:    time.Now,
+	}
+}
+
+// CountRedeemed 查询客户已占用的使用次数
+func (r *CouponUsageRepository) CountRedeemed(ctx context.Context, couponID, customerID string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.counts[customerKey{couponID, customerID}], nil
+}
+
+// Redeem 写入使用记录并占用次数，同一订单重复占用时不重复计数，任一优惠券超过上限时不做任何修改
+func (r *CouponUsageRepository) Redeem(ctx context.Context, usages []*domain_promotion_core.CouponUsageDO, limits map[string]int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	added := make(map[usageKey]bool, len(usages))
+	counts := make(map[customerKey]int64)
+	for _, usage := range usages {
+		key := usageKey{usage.CouponID, usage.OrderID}
+		if _, ok := r.usages[key]; ok || added[key] {
+			continue
+		}
+		added[key] = true
+
+		ck := customerKey{usage.CouponID, usage.CustomerID}
+		count, ok := counts[ck]
+		if !ok {
+			count = r.counts[ck]
+		}
+		limit := limits[usage.CouponID]
+		if limit != 0 && count >= limit {
+			return fmt.Errorf("%w: 优惠券[%s]每人限用%d次", domain_promotion_core.ErrCouponUsageLimitExceeded, usage.CouponID, limit)
+		}
+		counts[ck] = count + 1
+	}
+
+	for _, usage := range usages {
+		key := usageKey{usage.CouponID, usage.OrderID}
+		if added[key] {
+			r.usages[key] = *usage
+			delete(added, key)
+		}
+	}
+	for ck, count := range counts {
+		r.counts[ck] = count
+	}
+	return nil
+}
+
+// ReleaseByOrderID 把订单仍为已占用的使用记录标记为已释放并归还次数，重复释放不会重复归还
+func (r *CouponUsageRepository) ReleaseByOrderID(ctx context.Context, orderID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	for key, usage := range r.usages {
+		if key.orderID != orderID || usage.Status != domain_promotion_core.UsageStatusRedeemed {
+			continue
+		}
+		usage.Status = domain_promotion_core.UsageStatusReleased
+		usage.UpdatedAt = now
+		r.usages[key] = usage
+
+		ck := customerKey{usage.CouponID, usage.CustomerID}
+		if r.counts[ck] > 0 {
+			r.counts[ck]--
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_reconciliation_core"
+)
+
+// DiscrepancyRepository 内存实现的对账差异仓储
+type DiscrepancyRepository struct {
+	mu            sync.Mutex
+	discrepancies map[string]*domain_reconciliation_core.DiscrepancyDO
+}
+
+// NewDiscrepancyRepository 创建内存对账差异仓储
+func NewDiscrepancyRepository() *DiscrepancyRepository {
+	return &DiscrepancyRepository{discrepancies: make(map[string]*domain_reconciliation_core.DiscrepancyDO)}
+}
+
+// Save 保存差异记录副本
+func (r *DiscrepancyRepository) Save(ctx context.Context, discrepancy *domain_reconciliation_core.DiscrepancyDO) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.discrepancies[discrepancy.ID] = cloneDiscrepancy(discrepancy)
+	return nil
+}
+
+// Append 批量写入差异记录，ID 已存在的记录忽略
+func (r *DiscrepancyRepository) Append(ctx context.Context, discrepancies []*domain_reconciliation_core.DiscrepancyDO) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, discrepancy := range discrepancies {
+		if _, ok := r.discrepancies[discrepancy.ID]; !ok {
+			r.discrepancies[discrepancy.ID] = cloneDiscrepancy(discrepancy)
+		}
+	}
+	return nil
+}
+
+// cloneDiscrepancy 深拷贝差异记录
+func cloneDiscrepancy(discrepancy *domain_reconciliation_core.DiscrepancyDO) *domain_reconciliation_core.DiscrepancyDO {
+	cp := *discrepancy
+	cp.BillDate = cloneTime(discrepancy.BillDate)
+	return &cp
+}
@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_idempotency_core"
+)
+
+// IdempotencyRepository 内存实现的幂等记录仓储
+type IdempotencyRepository struct {
+	mu      sync.RWMutex
+	records map[string]domain_idempotency_core.IdempotencyRecordDO
+}
+
+// NewIdempotencyRepository 创建内存幂等记录仓储
+func NewIdempotencyRepository() *IdempotencyRepository {
+	return &IdempotencyRepository{records: make(map[string]domain_idempotency_core.IdempotencyRecordDO)}
+}
+
+// Create 占用幂等键，键已存在时返回 ErrIdempotencyKeyExists
+func (r *IdempotencyRepository) Create(ctx context.Context, record *domain_idempotency_core.IdempotencyRecordDO) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.records[record.Key]; ok {
+		return domain_idempotency_core.ErrIdempotencyKeyExists
+	}
+	r.records[record.Key] = *record
+	return nil
+}
+
+// FindByKey 根据幂等键查询记录，不存在时返回 ErrIdempotencyKeyNotFound
+func (r *IdempotencyRepository) FindByKey(ctx context.Context, key string) (*domain_idempotency_core.IdempotencyRecordDO, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	record, ok := r.records[key]
+	if !ok {
+		return nil, domain_idempotency_core.ErrIdempotencyKeyNotFound
+	}
+	return &record, nil
+}
+
+// TakeOver 接管更新时间早于 staleBefore 的处理中记录
+func (r *IdempotencyRepository) TakeOver(ctx context.Context, key string, staleBefore, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record, ok := r.records[key]
+	if !ok || record.IsCompleted() || !record.UpdatedAt.Before(staleBefore) {
+		return domain_idempotency_core.ErrIdempotencyKeyInProgress
+	}
+	record.UpdatedAt = now
+	r.records[key] = record
+	return nil
+}
+
+// Complete 保存业务结果，租约已被接管时返回 ErrIdempotencyLeaseLost
+func (r *IdempotencyRepository) Complete(ctx context.Context, record *domain_idempotency_core.IdempotencyRecordDO, leasedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.heldBy(record.Key, leasedAt) {
+		return domain_idempotency_core.ErrIdempotencyLeaseLost
+	}
+	r.records[record.Key] = *record
+	return nil
+}
+
+// Release 删除仍由本次租约持有的处理中记录，释放幂等键
+func (r *IdempotencyRepository) Release(ctx context.Context, key string, leasedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.heldBy(key, leasedAt) {
+		delete(r.records, key)
+	}
+	return nil
+}
+
+// heldBy 记录是否仍处理中且租约未被接管，调用方需持有锁
+func (r *IdempotencyRepository) heldBy(key string, leasedAt time.Time) bool {
+	record, ok := r.records[key]
+	return ok && !record.IsCompleted() && !record.UpdatedAt.After(leasedAt)
+}
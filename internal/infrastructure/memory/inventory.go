@@ -0,0 +1,145 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_inventory_core"
+)
+
+// stock 商品库存，可售库存与预占库存分开记录
+type stock struct {
+	available int64
+	reserved  int64
+}
+
+// InventoryService 内存实现的库存服务，语义与基于数据库的库存服务一致
+type InventoryService struct {
+	mu           sync.Mutex
+	stocks       map[string]*stock
+	reservations map[string]*domain_inventory_core.ReservationDO
+	now          func() time.Time
+}
+
+// NewInventoryService 创建内存库存服务，available 为各商品的初始可售库存
+func NewInventoryService(available map[string]int64) *InventoryService {
+	s := &InventoryService{
+		stocks:       make(map[string]*stock, len(available)),
+		reservations: make(map[string]*domain_inventory_core.ReservationDO),
+		now:          time.Now,
+	}
+	for productID, quantity := range available {
+		s.stocks[productID] = &stock{available: quantity}
+	}
+	return s
+}
+
+// ReserveStock 为订单的全部商品预占库存，任一商品库存不足时不做任何修改
+func (s *InventoryService) ReserveStock(ctx context.Context, orderID string, items []domain_inventory_core.StockItem) ([]*domain_inventory_core.ReservationDO, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	reservations := make([]*domain_inventory_core.ReservationDO, len(items))
+	needed := make(map[string]int64)
+	for i, item := range items {
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("商品[%s]预占数量必须大于0", item.ProductID)
+		}
+
+		// 同一订单重复预占时返回已有记录
+		if existing := s.findReservation(orderID, item.ProductID); existing != nil {
+			if existing.Status == domain_inventory_core.ReservationStatusReleased {
+				return nil, fmt.Errorf("%w: 订单[%s]商品[%s]", domain_inventory_core.ErrReservationReleased, orderID, item.ProductID)
+			}
+			cp := *existing
+			reservations[i] = &cp
+			continue
+		}
+
+		st, ok := s.stocks[item.ProductID]
+		if !ok || st.available < needed[item.ProductID]+item.Quantity {
+			return nil, fmt.Errorf("%w: 商品[%s]需要%d件", domain_inventory_core.ErrInsufficientStock, item.ProductID, item.Quantity)
+		}
+		needed[item.ProductID] += item.Quantity
+		reservations[i] = &domain_inventory_core.ReservationDO{
+			ID:        uuid.New().String(),
+			OrderID:   orderID,
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			Status:    domain_inventory_core.ReservationStatusReserved,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+	}
+
+	for productID, quantity := range needed {
+		s.stocks[productID].available -= quantity
+		s.stocks[productID].reserved += quantity
+	}
+	for _, reservation := range reservations {
+		if _, ok := s.reservations[reservation.ID]; !ok {
+			cp := *reservation
+			s.reservations[reservation.ID] = &cp
+		}
+	}
+	return reservations, nil
+}
+
+// ConfirmReservation 确认预占，预占库存扣减
+func (s *InventoryService) ConfirmReservation(ctx context.Context, reservationID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reservation, ok := s.reservations[reservationID]
+	if !ok {
+		return fmt.Errorf("%w: %s", domain_inventory_core.ErrReservationNotFound, reservationID)
+	}
+	switch reservation.Status {
+	case domain_inventory_core.ReservationStatusConfirmed:
+		return nil
+	case domain_inventory_core.ReservationStatusReleased:
+		return fmt.Errorf("%w: %s", domain_inventory_core.ErrReservationReleased, reservationID)
+	}
+
+	s.stocks[reservation.ProductID].reserved -= reservation.Quantity
+	reservation.Status = domain_inventory_core.ReservationStatusConfirmed
+	reservation.UpdatedAt = s.now()
+	return nil
+}
+
+// ReleaseReservation 释放预占，未确认的从预占归还可售，已确认的直接补回可售
+func (s *InventoryService) ReleaseReservation(ctx context.Context, reservationID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reservation, ok := s.reservations[reservationID]
+	if !ok {
+		return fmt.Errorf("%w: %s", domain_inventory_core.ErrReservationNotFound, reservationID)
+	}
+
+	st := s.stocks[reservation.ProductID]
+	switch reservation.Status {
+	case domain_inventory_core.ReservationStatusReleased:
+		return nil
+	case domain_inventory_core.ReservationStatusReserved:
+		st.reserved -= reservation.Quantity
+	}
+	st.available += reservation.Quantity
+	reservation.Status = domain_inventory_core.ReservationStatusReleased
+	reservation.UpdatedAt = s.now()
+	return nil
+}
+
+// findReservation 查询订单对某商品的预占记录
+func (s *InventoryService) findReservation(orderID, productID string) *domain_inventory_core.ReservationDO {
+	for _, reservation := range s.reservations {
+		if reservation.OrderID == orderID && reservation.ProductID == productID {
+			return reservation
+		}
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package memory
+
+import (
+	"context"
+	"time"
+)
+
+// Locker 单实例使用的租约锁，内存模式只有一个实例，总能获取租约
+type Locker struct{}
+
+// NewLocker 创建单实例租约锁
+func NewLocker() *Locker {
+	return &Locker{}
+}
+
+// TryAcquire 总是获取成功
+func (l *Locker) TryAcquire(ctx context.Context, name string, ttl time.Duration) (bool, error) {
+	return true, nil
+}
+
+// Release 没有需要释放的租约
+func (l *Locker) Release(ctx context.Context, name string) error {
+	return nil
+}
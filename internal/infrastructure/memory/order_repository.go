@@ -0,0 +1,174 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_order_core"
+	"gorm.io/gorm"
+	"gorm.io/plugin/optimisticlock"
+)
+
+// OrderRepository 内存实现的订单仓储，语义与MySQL实现一致，用于不依赖数据库的本地运行和测试
+// 领域事件不写入发件箱，由事件分发器在保存后直接投递
+type OrderRepository struct {
+	mu         sync.RWMutex
+	orders     map[string]*domain_order_core.OrderDO
+	nextItemID int64
+	now        func() time.Time
+}
+
+// NewOrderRepository 创建内存订单仓储
+func NewOrderRepository() *OrderRepository {
+	return &OrderRepository{orders: make(map[string]*domain_order_core.OrderDO), now: time.Now}
+}
+
+// Save 保存订单副本
+// 新订单重复保存返回 gorm.ErrDuplicatedKey；已有订单版本号不一致返回 ErrConcurrentModification，成功后版本号加1
+func (r *OrderRepository) Save(ctx context.Context, o *domain_order_core.OrderDO) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	stored, exists := r.orders[o.ID]
+	if !o.Version.Valid {
+		if exists {
+			return gorm.ErrDuplicatedKey
+		}
+		if o.CreatedAt.IsZero() {
+			o.CreatedAt = now
+		}
+		if o.UpdatedAt.IsZero() {
+			o.UpdatedAt = now
+		}
+		o.Version = optimisticlock.Version{Int64: 1, Valid: true}
+	} else {
+		if !exists || stored.Version.Int64 != o.Version.Int64 {
+			return domain_order_core.ErrConcurrentModification
+		}
+		o.UpdatedAt = now
+		o.Version.Int64++
+	}
+
+	// 未保存过的订单行分配ID，与数据库自增主键一致
+	seen := make(map[int64]bool, len(o.Items))
+	for i := range o.Items {
+		item := &o.Items[i]
+		if item.ID == 0 || seen[item.ID] || !hasItem(stored, item.ID) {
+			r.nextItemID++
+			item.ID = r.nextItemID
+		}
+		seen[item.ID] = true
+	}
+
+	// 保存的订单行与数据库中的行一致，补齐订单ID和币种
+	saved := cloneOrder(o)
+	for i := range saved.Items {
+		saved.Items[i].OrderID = o.ID
+		saved.Items[i].Currency = string(saved.Items[i].ItemCurrency())
+	}
+	r.orders[o.ID] = saved
+	o.TakeSnapshot()
+	return nil
+}
+
+// FindByID 查询订单副本，不存在时返回 ErrOrderNotFound
+func (r *OrderRepository) FindByID(ctx context.Context, id string) (*domain_order_core.OrderDO, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, ok := r.orders[id]
+	if !ok {
+		return nil, domain_order_core.ErrOrderNotFound
+	}
+	return loadOrder(stored), nil
+}
+
+// Find 按条件分页查询订单，按 created_at,id 倒序，多查一条判断是否有下一页
+func (r *OrderRepository) Find(ctx context.Context, query domain_order_core.OrderQuery) (*domain_order_core.OrderPage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matched []*domain_order_core.OrderDO
+	for _, o := range r.orders {
+		if matchOrder(o, query) {
+			matched = append(matched, o)
+		}
+	}
+	slices.SortFunc(matched, func(a, b *domain_order_core.OrderDO) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(b.ID, a.ID)
+	})
+	if len(matched) > query.Limit+1 {
+		matched = matched[:query.Limit+1]
+	}
+
+	orders := make([]*domain_order_core.OrderDO, len(matched))
+	for i, o := range matched {
+		orders[i] = loadOrder(o)
+	}
+	return domain_order_core.NewOrderPage(orders, query.Limit), nil
+}
+
+// matchOrder 订单是否满足查询条件，与MySQL实现的过滤条件一致
+func matchOrder(o *domain_order_core.OrderDO, query domain_order_core.OrderQuery) bool {
+	switch {
+	case query.CustomerID != "" && o.CustomerID != query.CustomerID:
+		return false
+	case len(query.Statuses) > 0 && !slices.Contains(query.Statuses, o.Status):
+		return false
+	case query.CreatedFrom != nil && o.CreatedAt.Before(*query.CreatedFrom):
+		return false
+	case query.CreatedTo != nil && !o.CreatedAt.Before(*query.CreatedTo):
+		return false
+	case query.Currency != "" && o.Currency != query.Currency:
+		return false
+	case query.MinAmount != nil && o.TotalAmount < *query.MinAmount:
+		return false
+	case query.MaxAmount != nil && o.TotalAmount > *query.MaxAmount:
+		return false
+	}
+	if cursor := query.Cursor; cursor != nil {
+		return o.CreatedAt.Before(cursor.CreatedAt) || (o.CreatedAt.Equal(cursor.CreatedAt) && o.ID < cursor.ID)
+	}
+	return true
+}
+
+// hasItem 已保存的订单中是否有该订单行
+func hasItem(o *domain_order_core.OrderDO, id int64) bool {
+	if o == nil {
+		return false
+	}
+	return slices.ContainsFunc(o.Items, func(item domain_order_core.OrderItemDO) bool {
+		return item.ID == id
+	})
+}
+
+// loadOrder 复制已保存的订单并记录快照，与从数据库加载的订单一致
+func loadOrder(stored *domain_order_core.OrderDO) *domain_order_core.OrderDO {
+	o := cloneOrder(stored)
+	o.TakeSnapshot()
+	return o
+}
+
+// cloneOrder 深拷贝订单，不包含未取出的领域事件和持久化快照
+func cloneOrder(o *domain_order_core.OrderDO) *domain_order_core.OrderDO {
+	return &domain_order_core.OrderDO{
+		ID:             o.ID,
+		CustomerID:     o.CustomerID,
+		Items:          slices.Clone(o.Items),
+		Status:         o.Status,
+		Currency:       o.Currency,
+		TotalAmount:    o.TotalAmount,
+		DiscountAmount: o.DiscountAmount,
+		CouponIDs:      slices.Clone(o.CouponIDs),
+		CreatedAt:      o.CreatedAt,
+		UpdatedAt:      o.UpdatedAt,
+		Version:        o.Version,
+	}
+}
@@ -0,0 +1,32 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/outbox"
+)
+
+// OutboxStore 内存模式的发件箱存储
+// 内存仓储保存聚合时不写入发件箱，领域事件只由分发器直接投递，因此没有需要补偿投递的消息
+type OutboxStore struct{}
+
+// NewOutboxStore 创建内存发件箱存储
+func NewOutboxStore() *OutboxStore {
+	return &OutboxStore{}
+}
+
+// FetchPending 没有待投递的消息
+func (s *OutboxStore) FetchPending(ctx context.Context, now time.Time, limit int) ([]*outbox.Message, error) {
+	return nil, nil
+}
+
+// Save 没有需要更新的消息
+func (s *OutboxStore) Save(ctx context.Context, msg *outbox.Message) error {
+	return nil
+}
+
+// MarkDelivered 没有需要标记的消息
+func (s *OutboxStore) MarkDelivered(ctx context.Context, eventID string, deliveredAt time.Time) error {
+	return nil
+}
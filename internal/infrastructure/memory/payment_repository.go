@@ -0,0 +1,147 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
+	"gorm.io/gorm"
+)
+
+// PaymentRepository 内存实现的支付仓储，语义与MySQL实现一致
+type PaymentRepository struct {
+	mu       sync.RWMutex
+	payments map[string]*domain_payment_core.PaymentDO
+	now      func() time.Time
+}
+
+// NewPaymentRepository 创建内存支付仓储
+func NewPaymentRepository() *PaymentRepository {
+	return &PaymentRepository{payments: make(map[string]*domain_payment_core.PaymentDO), now: time.Now}
+}
+
+// Save 保存支付单副本，不存在时插入；与 gorm Save 一致，每次保存都刷新更新时间
+func (r *PaymentRepository) Save(ctx context.Context, payment *domain_payment_core.PaymentDO) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	if payment.CreatedAt.IsZero() {
+		payment.CreatedAt = now
+	}
+	payment.UpdatedAt = now
+	r.payments[payment.ID] = clonePayment(payment)
+	return nil
+}
+
+// FindByID 根据ID查询支付单，不存在时返回 gorm.ErrRecordNotFound
+func (r *PaymentRepository) FindByID(ctx context.Context, id string) (*domain_payment_core.PaymentDO, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	payment, ok := r.payments[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return clonePayment(payment), nil
+}
+
+// FindByIDForUpdate 根据ID查询支付单，内存工作单元串行执行，无需单独加锁
+func (r *PaymentRepository) FindByIDForUpdate(ctx context.Context, id string) (*domain_payment_core.PaymentDO, error) {
+	return r.FindByID(ctx, id)
+}
+
+// FindByOrderID 根据订单ID查询支付单，有多笔时返回ID最小的一笔，不存在时返回 gorm.ErrRecordNotFound
+func (r *PaymentRepository) FindByOrderID(ctx context.Context, orderID string) (*domain_payment_core.PaymentDO, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var found *domain_payment_core.PaymentDO
+	for _, payment := range r.payments {
+		if payment.OrderID == orderID && (found == nil || payment.ID < found.ID) {
+			found = payment
+		}
+	}
+	if found == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return clonePayment(found), nil
+}
+
+// FindByStatuses 按更新时间升序分页查询指定状态且更新时间早于 updatedBefore 的支付单
+func (r *PaymentRepository) FindByStatuses(ctx context.Context, statuses []domain_payment_core.PaymentStatus, updatedBefore time.Time, cursor *domain_payment_core.PaymentCursor, limit int) (*domain_payment_core.PaymentPage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matched []*domain_payment_core.PaymentDO
+	for _, payment := range r.payments {
+		if !slices.Contains(statuses, payment.Status) || !payment.UpdatedAt.Before(updatedBefore) {
+			continue
+		}
+		if cursor != nil && !(payment.UpdatedAt.After(cursor.UpdatedAt) || (payment.UpdatedAt.Equal(cursor.UpdatedAt) && payment.ID > cursor.ID)) {
+			continue
+		}
+		matched = append(matched, payment)
+	}
+	slices.SortFunc(matched, func(a, b *domain_payment_core.PaymentDO) int {
+		if c := a.UpdatedAt.Compare(b.UpdatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	if len(matched) > limit+1 {
+		matched = matched[:limit+1]
+	}
+	return domain_payment_core.NewPaymentPage(clonePayments(matched), limit), nil
+}
+
+// FindCompletedBetween 查询指定渠道在 [from, to) 内支付完成的支付单，按完成时间排序
+func (r *PaymentRepository) FindCompletedBetween(ctx context.Context, channel domain_payment_core.PaymentChannel, from, to time.Time) ([]*domain_payment_core.PaymentDO, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matched []*domain_payment_core.PaymentDO
+	for _, payment := range r.payments {
+		if payment.Channel == int(channel) && inRange(payment.CompletedAt, from, to) {
+			matched = append(matched, payment)
+		}
+	}
+	slices.SortFunc(matched, func(a, b *domain_payment_core.PaymentDO) int {
+		return a.CompletedAt.Compare(*b.CompletedAt)
+	})
+	return clonePayments(matched), nil
+}
+
+// inRange t 是否在 [from, to) 内，为空时不在任何区间内
+func inRange(t *time.Time, from, to time.Time) bool {
+	return t != nil && !t.Before(from) && t.Before(to)
+}
+
+// clonePayments 复制支付单列表
+func clonePayments(payments []*domain_payment_core.PaymentDO) []*domain_payment_core.PaymentDO {
+	cloned := make([]*domain_payment_core.PaymentDO, len(payments))
+	for i, payment := range payments {
+		cloned[i] = clonePayment(payment)
+	}
+	return cloned
+}
+
+// clonePayment 深拷贝支付单
+func clonePayment(payment *domain_payment_core.PaymentDO) *domain_payment_core.PaymentDO {
+	cp := *payment
+	cp.CompletedAt = cloneTime(payment.CompletedAt)
+	cp.RateQuotedAt = cloneTime(payment.RateQuotedAt)
+	return &cp
+}
+
+// cloneTime 复制可为空的时间
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	cp := *t
+	return &cp
+}
@@ -0,0 +1,100 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
+	"gorm.io/gorm"
+)
+
+// RefundRepository 内存实现的退款仓储，渠道取自支付仓储中关联的支付单
+type RefundRepository struct {
+	mu       sync.RWMutex
+	refunds  map[string]*domain_payment_core.RefundDO
+	payments domain_payment_core.Repository
+}
+
+// NewRefundRepository 创建内存退款仓储
+func NewRefundRepository(payments domain_payment_core.Repository) *RefundRepository {
+	return &RefundRepository{refunds: make(map[string]*domain_payment_core.RefundDO), payments: payments}
+}
+
+// Save 保存退款记录副本
+func (r *RefundRepository) Save(ctx context.Context, refund *domain_payment_core.RefundDO) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.refunds[refund.ID] = cloneRefund(refund)
+	return nil
+}
+
+// FindByID 根据ID查询退款记录，不存在时返回 ErrRefundNotFound
+func (r *RefundRepository) FindByID(ctx context.Context, id string) (*domain_payment_core.RefundDO, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	refund, ok := r.refunds[id]
+	if !ok {
+		return nil, domain_payment_core.ErrRefundNotFound
+	}
+	return cloneRefund(refund), nil
+}
+
+// FindByPaymentID 查询支付单下的全部退款记录，按创建时间排序
+func (r *RefundRepository) FindByPaymentID(ctx context.Context, paymentID string) ([]*domain_payment_core.RefundDO, error) {
+	return r.find(func(refund *domain_payment_core.RefundDO) bool {
+		return refund.PaymentID == paymentID
+	}, func(a, b *domain_payment_core.RefundDO) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	}), nil
+}
+
+// FindCompletedBetween 按完成时间查询退款成功的退款单
+func (r *RefundRepository) FindCompletedBetween(ctx context.Context, channel domain_payment_core.PaymentChannel, from, to time.Time) ([]*domain_payment_core.RefundDO, error) {
+	refunds := r.find(func(refund *domain_payment_core.RefundDO) bool {
+		return refund.Status == domain_payment_core.RefundStatusSucceeded && inRange(refund.CompletedAt, from, to)
+	}, func(a, b *domain_payment_core.RefundDO) int {
+		return a.CompletedAt.Compare(*b.CompletedAt)
+	})
+
+	matched := refunds[:0]
+	for _, refund := range refunds {
+		payment, err := r.payments.FindByID(ctx, refund.PaymentID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if payment.Channel == int(channel) {
+			matched = append(matched, refund)
+		}
+	}
+	return matched, nil
+}
+
+// find 按条件查询退款记录副本并排序
+func (r *RefundRepository) find(match func(*domain_payment_core.RefundDO) bool, cmp func(a, b *domain_payment_core.RefundDO) int) []*domain_payment_core.RefundDO {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var refunds []*domain_payment_core.RefundDO
+	for _, refund := range r.refunds {
+		if match(refund) {
+			refunds = append(refunds, cloneRefund(refund))
+		}
+	}
+	slices.SortFunc(refunds, cmp)
+	return refunds
+}
+
+// cloneRefund 深拷贝退款记录
+func cloneRefund(refund *domain_payment_core.RefundDO) *domain_payment_core.RefundDO {
+	cp := *refund
+	cp.CompletedAt = cloneTime(refund.CompletedAt)
+	return &cp
+}
@@ -0,0 +1,63 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/vaynedu/ddd_order_example/internal/application/saga"
+	"gorm.io/gorm"
+)
+
+// SagaRepository 内存实现的saga实例仓储
+type SagaRepository struct {
+	mu    sync.RWMutex
+	insts map[string]saga.Instance
+}
+
+// NewSagaRepository 创建内存saga实例仓储
+func NewSagaRepository() *SagaRepository {
+	return &SagaRepository{insts: make(map[string]saga.Instance)}
+}
+
+// Create 写入新的saga实例，ID已存在时返回 gorm.ErrDuplicatedKey
+func (r *SagaRepository) Create(ctx context.Context, inst *saga.Instance) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.insts[inst.ID]; ok {
+		return gorm.ErrDuplicatedKey
+	}
+	r.insts[inst.ID] = *inst
+	return nil
+}
+
+// Save 更新saga实例的执行进度
+func (r *SagaRepository) Save(ctx context.Context, inst *saga.Instance) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.insts[inst.ID] = *inst
+	return nil
+}
+
+// FindUnfinished 查询更新时间早于 updatedBefore 仍未结束的实例，按更新时间升序
+func (r *SagaRepository) FindUnfinished(ctx context.Context, updatedBefore time.Time, limit int) ([]*saga.Instance, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var insts []*saga.Instance
+	for _, inst := range r.insts {
+		if !inst.IsFinished() && inst.UpdatedAt.Before(updatedBefore) {
+			insts = append(insts, &inst)
+		}
+	}
+	slices.SortFunc(insts, func(a, b *saga.Instance) int {
+		return a.UpdatedAt.Compare(b.UpdatedAt)
+	})
+	if len(insts) > limit {
+		insts = insts[:limit]
+	}
+	return insts, nil
+}
@@ -0,0 +1,30 @@
+package memory
+
+import (
+	"context"
+	"sync"
+)
+
+// uowKey ctx 中标记已处于内存工作单元的键
+type uowKey struct{}
+
+// UnitOfWork 内存模式的工作单元，串行执行 fn，效果相当于锁住全部数据
+// 内存仓储的每次写入立即生效，fn 失败时已执行的写入不会回滚，只适合本地运行和测试
+type UnitOfWork struct {
+	mu sync.Mutex
+}
+
+// NewUnitOfWork 创建内存工作单元
+func NewUnitOfWork() *UnitOfWork {
+	return &UnitOfWork{}
+}
+
+// Do 执行 fn，已处于工作单元中时直接执行，避免重入死锁
+func (u *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(uowKey{}) != nil {
+		return fn(ctx)
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return fn(context.WithValue(ctx, uowKey{}, true))
+}
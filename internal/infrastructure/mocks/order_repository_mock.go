@@ -41,6 +41,21 @@ func (m *MockOrderRepository) EXPECT() *MockOrderRepositoryMockRecorder {
 	return m.recorder
 }
 
+// Find mocks base method.
+func (m *MockOrderRepository) Find(ctx context.Context, query domain_order_core.OrderQuery) (*domain_order_core.OrderPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Find", ctx, query)
+	ret0, _ := ret[0].(*domain_order_core.OrderPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Find indicates an expected call of Find.
+func (mr *MockOrderRepositoryMockRecorder) Find(ctx, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Find", reflect.TypeOf((*MockOrderRepository)(nil).Find), ctx, query)
+}
+
 // FindByID mocks base method.
 func (m *MockOrderRepository) FindByID(ctx context.Context, id string) (*domain_order_core.OrderDO, error) {
 	m.ctrl.T.Helper()
@@ -14,6 +14,7 @@ import (
 	reflect "reflect"
 
 	domain_payment_core "github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
+	dmoney "github.com/vaynedu/ddd_order_example/pkg/dmoney"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -41,8 +42,22 @@ func (m *MockPaymentProxy) EXPECT() *MockPaymentProxyMockRecorder {
 	return m.recorder
 }
 
+// CloseTrade mocks base method.
+func (m *MockPaymentProxy) CloseTrade(ctx context.Context, orderID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseTrade", ctx, orderID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CloseTrade indicates an expected call of CloseTrade.
+func (mr *MockPaymentProxyMockRecorder) CloseTrade(ctx, orderID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseTrade", reflect.TypeOf((*MockPaymentProxy)(nil).CloseTrade), ctx, orderID)
+}
+
 // CreatePayment mocks base method.
-func (m *MockPaymentProxy) CreatePayment(ctx context.Context, orderID string, amount int64) (string, error) {
+func (m *MockPaymentProxy) CreatePayment(ctx context.Context, orderID string, amount dmoney.Money) (string, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreatePayment", ctx, orderID, amount)
 	ret0, _ := ret[0].(string)
@@ -57,16 +72,31 @@ func (mr *MockPaymentProxyMockRecorder) CreatePayment(ctx, orderID, amount any)
 }
 
 // QueryPaymentStatus mocks base method.
-func (m *MockPaymentProxy) QueryPaymentStatus(ctx context.Context, paymentID string) (domain_payment_core.PaymentStatus, error) {
+func (m *MockPaymentProxy) QueryPaymentStatus(ctx context.Context, orderID string) (*domain_payment_core.ChannelTrade, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "QueryPaymentStatus", ctx, paymentID)
-	ret0, _ := ret[0].(domain_payment_core.PaymentStatus)
+	ret := m.ctrl.Call(m, "QueryPaymentStatus", ctx, orderID)
+	ret0, _ := ret[0].(*domain_payment_core.ChannelTrade)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // QueryPaymentStatus indicates an expected call of QueryPaymentStatus.
-func (mr *MockPaymentProxyMockRecorder) QueryPaymentStatus(ctx, paymentID any) *gomock.Call {
+func (mr *MockPaymentProxyMockRecorder) QueryPaymentStatus(ctx, orderID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryPaymentStatus", reflect.TypeOf((*MockPaymentProxy)(nil).QueryPaymentStatus), ctx, orderID)
+}
+
+// Refund mocks base method.
+func (m *MockPaymentProxy) Refund(ctx context.Context, orderID string, refundID string, amount dmoney.Money) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Refund", ctx, orderID, refundID, amount)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Refund indicates an expected call of Refund.
+func (mr *MockPaymentProxyMockRecorder) Refund(ctx, orderID, refundID, amount any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryPaymentStatus", reflect.TypeOf((*MockPaymentProxy)(nil).QueryPaymentStatus), ctx, paymentID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Refund", reflect.TypeOf((*MockPaymentProxy)(nil).Refund), ctx, orderID, refundID, amount)
 }
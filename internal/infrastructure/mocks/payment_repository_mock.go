@@ -12,6 +12,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	domain_payment_core "github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
 	gomock "go.uber.org/mock/gomock"
@@ -56,6 +57,21 @@ func (mr *MockRepositoryMockRecorder) FindByID(ctx, id any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByID", reflect.TypeOf((*MockRepository)(nil).FindByID), ctx, id)
 }
 
+// FindByIDForUpdate mocks base method.
+func (m *MockRepository) FindByIDForUpdate(ctx context.Context, id string) (*domain_payment_core.PaymentDO, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByIDForUpdate", ctx, id)
+	ret0, _ := ret[0].(*domain_payment_core.PaymentDO)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByIDForUpdate indicates an expected call of FindByIDForUpdate.
+func (mr *MockRepositoryMockRecorder) FindByIDForUpdate(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByIDForUpdate", reflect.TypeOf((*MockRepository)(nil).FindByIDForUpdate), ctx, id)
+}
+
 // FindByOrderID mocks base method.
 func (m *MockRepository) FindByOrderID(ctx context.Context, orderID string) (*domain_payment_core.PaymentDO, error) {
 	m.ctrl.T.Helper()
@@ -71,6 +87,36 @@ func (mr *MockRepositoryMockRecorder) FindByOrderID(ctx, orderID any) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByOrderID", reflect.TypeOf((*MockRepository)(nil).FindByOrderID), ctx, orderID)
 }
 
+// FindByStatuses mocks base method.
+func (m *MockRepository) FindByStatuses(ctx context.Context, statuses []domain_payment_core.PaymentStatus, updatedBefore time.Time, cursor *domain_payment_core.PaymentCursor, limit int) (*domain_payment_core.PaymentPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByStatuses", ctx, statuses, updatedBefore, cursor, limit)
+	ret0, _ := ret[0].(*domain_payment_core.PaymentPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByStatuses indicates an expected call of FindByStatuses.
+func (mr *MockRepositoryMockRecorder) FindByStatuses(ctx, statuses, updatedBefore, cursor, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByStatuses", reflect.TypeOf((*MockRepository)(nil).FindByStatuses), ctx, statuses, updatedBefore, cursor, limit)
+}
+
+// FindCompletedBetween mocks base method.
+func (m *MockRepository) FindCompletedBetween(ctx context.Context, channel domain_payment_core.PaymentChannel, from time.Time, to time.Time) ([]*domain_payment_core.PaymentDO, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindCompletedBetween", ctx, channel, from, to)
+	ret0, _ := ret[0].([]*domain_payment_core.PaymentDO)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindCompletedBetween indicates an expected call of FindCompletedBetween.
+func (mr *MockRepositoryMockRecorder) FindCompletedBetween(ctx, channel, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindCompletedBetween", reflect.TypeOf((*MockRepository)(nil).FindCompletedBetween), ctx, channel, from, to)
+}
+
 // Save mocks base method.
 func (m *MockRepository) Save(ctx context.Context, payment *domain_payment_core.PaymentDO) error {
 	m.ctrl.T.Helper()
@@ -84,3 +130,86 @@ func (mr *MockRepositoryMockRecorder) Save(ctx, payment any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockRepository)(nil).Save), ctx, payment)
 }
+
+// MockRefundRepository is a mock of RefundRepository interface.
+type MockRefundRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRefundRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockRefundRepositoryMockRecorder is the mock recorder for MockRefundRepository.
+type MockRefundRepositoryMockRecorder struct {
+	mock *MockRefundRepository
+}
+
+// NewMockRefundRepository creates a new mock instance.
+func NewMockRefundRepository(ctrl *gomock.Controller) *MockRefundRepository {
+	mock := &MockRefundRepository{ctrl: ctrl}
+	mock.recorder = &MockRefundRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRefundRepository) EXPECT() *MockRefundRepositoryMockRecorder {
+	return m.recorder
+}
+
+// FindByID mocks base method.
+func (m *MockRefundRepository) FindByID(ctx context.Context, id string) (*domain_payment_core.RefundDO, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByID", ctx, id)
+	ret0, _ := ret[0].(*domain_payment_core.RefundDO)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByID indicates an expected call of FindByID.
+func (mr *MockRefundRepositoryMockRecorder) FindByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByID", reflect.TypeOf((*MockRefundRepository)(nil).FindByID), ctx, id)
+}
+
+// FindByPaymentID mocks base method.
+func (m *MockRefundRepository) FindByPaymentID(ctx context.Context, paymentID string) ([]*domain_payment_core.RefundDO, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByPaymentID", ctx, paymentID)
+	ret0, _ := ret[0].([]*domain_payment_core.RefundDO)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByPaymentID indicates an expected call of FindByPaymentID.
+func (mr *MockRefundRepositoryMockRecorder) FindByPaymentID(ctx, paymentID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByPaymentID", reflect.TypeOf((*MockRefundRepository)(nil).FindByPaymentID), ctx, paymentID)
+}
+
+// FindCompletedBetween mocks base method.
+func (m *MockRefundRepository) FindCompletedBetween(ctx context.Context, channel domain_payment_core.PaymentChannel, from time.Time, to time.Time) ([]*domain_payment_core.RefundDO, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindCompletedBetween", ctx, channel, from, to)
+	ret0, _ := ret[0].([]*domain_payment_core.RefundDO)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindCompletedBetween indicates an expected call of FindCompletedBetween.
+func (mr *MockRefundRepositoryMockRecorder) FindCompletedBetween(ctx, channel, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindCompletedBetween", reflect.TypeOf((*MockRefundRepository)(nil).FindCompletedBetween), ctx, channel, from, to)
+}
+
+// Save mocks base method.
+func (m *MockRefundRepository) Save(ctx context.Context, refund *domain_payment_core.RefundDO) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, refund)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockRefundRepositoryMockRecorder) Save(ctx, refund any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockRefundRepository)(nil).Save), ctx, refund)
+}
@@ -55,3 +55,18 @@ func (mr *MockProductServiceMockRecorder) ValidateProduct(ctx, req any) *gomock.
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateProduct", reflect.TypeOf((*MockProductService)(nil).ValidateProduct), ctx, req)
 }
+
+// ValidateProducts mocks base method.
+func (m *MockProductService) ValidateProducts(ctx context.Context, reqs []*domain_product_core.ValidateProductRequest) ([]*domain_product_core.ValidateProductResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateProducts", ctx, reqs)
+	ret0, _ := ret[0].([]*domain_product_core.ValidateProductResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ValidateProducts indicates an expected call of ValidateProducts.
+func (mr *MockProductServiceMockRecorder) ValidateProducts(ctx, reqs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateProducts", reflect.TypeOf((*MockProductService)(nil).ValidateProducts), ctx, reqs)
+}
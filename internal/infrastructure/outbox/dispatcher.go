@@ -0,0 +1,37 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/vaynedu/ddd_order_example/internal/shared/event"
+)
+
+// Dispatcher 事件分发的快速路径：事务提交后立即投递并标记发件箱消息已投递
+// 立即投递失败的消息仍在发件箱中，由 Relay 重试，因此分发失败不影响业务结果
+type Dispatcher struct {
+	store Store
+	sink  Sink
+}
+
+// NewDispatcher 创建事件分发器
+func NewDispatcher(store Store, sink Sink) *Dispatcher {
+	return &Dispatcher{store: store, sink: sink}
+}
+
+// Dispatch 投递已随聚合写入发件箱的事件，实现 event.Dispatcher
+func (d *Dispatcher) Dispatch(ctx context.Context, events ...event.Event) error {
+	for _, evt := range events {
+		msg, err := NewMessage(evt)
+		if err != nil {
+			return err
+		}
+		if err := d.sink.Deliver(ctx, msg); err != nil {
+			return err
+		}
+		if err := d.store.MarkDelivered(ctx, msg.EventID, time.Now()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_order_core"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/mocks"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/outbox"
+	"go.uber.org/mock/gomock"
+)
+
+// TestDispatcher_Dispatch 立即投递成功后按事件ID标记发件箱消息已投递
+func TestDispatcher_Dispatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockStore(ctrl)
+	mockSink := mocks.NewMockSink(ctrl)
+	dispatcher := outbox.NewDispatcher(mockStore, mockSink)
+
+	order := &domain_order_core.OrderDO{ID: "order_123", Status: domain_order_core.OrderStatusPaid}
+	require.NoError(t, order.Cancel())
+	evt := order.PullEvents()[0].(*domain_order_core.OrderCancelledEvent)
+
+	mockSink.EXPECT().Deliver(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, msg *outbox.Message) error {
+		assert.Equal(t, evt.EventID, msg.EventID)
+		assert.Equal(t, domain_order_core.EventNameOrderCancelled, msg.EventName)
+		return nil
+	})
+	mockStore.EXPECT().MarkDelivered(gomock.Any(), evt.EventID, gomock.Any()).Return(nil)
+
+	assert.NoError(t, dispatcher.Dispatch(context.Background(), evt))
+}
+
+// TestDispatcher_Dispatch_SinkFailed 立即投递失败时不标记，消息留给投递器重试
+func TestDispatcher_Dispatch_SinkFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockStore(ctrl)
+	mockSink := mocks.NewMockSink(ctrl)
+	dispatcher := outbox.NewDispatcher(mockStore, mockSink)
+
+	order := &domain_order_core.OrderDO{ID: "order_123", Status: domain_order_core.OrderStatusPaid}
+	require.NoError(t, order.Cancel())
+
+	mockSink.EXPECT().Deliver(gomock.Any(), gomock.Any()).Return(errors.New("broker unavailable"))
+	mockStore.EXPECT().MarkDelivered(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	assert.Error(t, dispatcher.Dispatch(context.Background(), order.PullEvents()...))
+}
@@ -0,0 +1,91 @@
+package outbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/vaynedu/ddd_order_example/internal/shared/event"
+)
+
+// TableName 指定模型对应的数据库表名
+func (Message) TableName() string {
+	return "t_outbox"
+}
+
+// Message 发件箱消息，与业务数据在同一个事务内写入，由 Relay 异步投递
+type Message struct {
+	ID            int64         `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	EventID       string        `json:"event_id" gorm:"column:event_id"`
+	EventName     string        `json:"event_name" gorm:"column:event_name"`
+	AggregateID   string        `json:"aggregate_id" gorm:"column:aggregate_id"`
+	Payload       string        `json:"payload" gorm:"column:payload"`
+	Status        MessageStatus `json:"status" gorm:"column:status"`
+	Attempts      int           `json:"attempts" gorm:"column:attempts"`
+	LastError     string        `json:"last_error" gorm:"column:last_error"`
+	NextAttemptAt time.Time     `json:"next_attempt_at" gorm:"column:next_attempt_at"`
+	CreatedAt     time.Time     `json:"created_at" gorm:"column:created_at"`
+	DeliveredAt   *time.Time    `json:"delivered_at" gorm:"column:delivered_at"`
+}
+
+// MessageStatus 发件箱消息状态
+type MessageStatus string
+
+const (
+	MessageStatusPending   MessageStatus = "pending"   // 待投递(含等待重试)
+	MessageStatusDelivered MessageStatus = "delivered" // 已投递
+	MessageStatusDead      MessageStatus = "dead"      // 超过最大重试次数，需人工处理
+)
+
+// identifiedEvent 携带事件ID和聚合ID的事件
+type identifiedEvent interface {
+	event.Event
+	GetEventID() string
+	AggregateID() string
+}
+
+// NewMessage 将领域事件序列化为发件箱消息
+func NewMessage(evt event.Event) (*Message, error) {
+	e, ok := evt.(identifiedEvent)
+	if !ok {
+		return nil, fmt.Errorf("事件 %s 缺少事件ID或聚合ID", evt.Name())
+	}
+
+	payload, err := json.Marshal(evt)
+	if err != nil {
+		return nil, fmt.Errorf("序列化事件 %s 失败: %w", evt.Name(), err)
+	}
+
+	now := time.Now()
+	return &Message{
+		EventID:       e.GetEventID(),
+		EventName:     evt.Name(),
+		AggregateID:   e.AggregateID(),
+		Payload:       string(payload),
+		Status:        MessageStatusPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}, nil
+}
+
+// Name 实现 event.Event，未注册解码器的消息以原始形式发布
+func (m *Message) Name() string {
+	return m.EventName
+}
+
+// MarkDelivered 标记投递成功
+func (m *Message) MarkDelivered(now time.Time) {
+	m.Status = MessageStatusDelivered
+	m.DeliveredAt = &now
+	m.LastError = ""
+}
+
+// MarkFailed 记录投递失败，超过最大重试次数后不再投递
+func (m *Message) MarkFailed(err error, nextAttemptAt time.Time, maxAttempts int) {
+	m.Attempts++
+	m.LastError = err.Error()
+	m.NextAttemptAt = nextAttemptAt
+	if maxAttempts > 0 && m.Attempts >= maxAttempts {
+		m.Status = MessageStatusDead
+	}
+}
@@ -0,0 +1,127 @@
+package outbox
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// 投递默认参数
+const (
+	defaultBatchSize    = 100
+	defaultPollInterval = time.Second
+	defaultMaxAttempts  = 10
+	defaultBaseBackoff  = time.Second
+	defaultMaxBackoff   = 5 * time.Minute
+)
+
+// Relay 轮询发件箱并投递消息，投递失败按指数退避重试
+// 投递语义为至少一次，下游需按 EventID 去重；目前按单实例运行设计
+type Relay struct {
+	store        Store
+	sink         Sink
+	batchSize    int
+	pollInterval time.Duration
+	maxAttempts  int
+	baseBackoff  time.Duration
+	maxBackoff   time.Duration
+	now          func() time.Time
+}
+
+// RelayOption 投递参数
+type RelayOption func(*Relay)
+
+// WithBatchSize 单次轮询的最大消息数
+func WithBatchSize(n int) RelayOption {
+	return func(r *Relay) { r.batchSize = n }
+}
+
+// WithPollInterval 轮询间隔
+func WithPollInterval(d time.Duration) RelayOption {
+	return func(r *Relay) { r.pollInterval = d }
+}
+
+// WithMaxAttempts 最大投递次数，达到后消息标记为 dead
+func WithMaxAttempts(n int) RelayOption {
+	return func(r *Relay) { r.maxAttempts = n }
+}
+
+// WithBackoff 退避的初始间隔和上限
+func WithBackoff(base, max time.Duration) RelayOption {
+	return func(r *Relay) {
+		r.baseBackoff = base
+		r.maxBackoff = max
+	}
+}
+
+// NewRelay 创建发件箱投递器
+func NewRelay(store Store, sink Sink, opts ...RelayOption) *Relay {
+	r := &Relay{
+		store:        store,
+		sink:         sink,
+		batchSize:    defaultBatchSize,
+		pollInterval: defaultPollInterval,
+		maxAttempts:  defaultMaxAttempts,
+		baseBackoff:  defaultBaseBackoff,
+		maxBackoff:   defaultMaxBackoff,
+		now:          time.Now,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Run 持续轮询投递，直到 ctx 取消
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.RelayOnce(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("发件箱投递失败", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayOnce 投递一批到期消息，返回投递成功的数量
+// 单条消息投递失败只推迟该消息，不影响同批其他消息
+func (r *Relay) RelayOnce(ctx context.Context) (int, error) {
+	msgs, err := r.store.FetchPending(ctx, r.now(), r.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, msg := range msgs {
+		if err := r.sink.Deliver(ctx, msg); err != nil {
+			msg.MarkFailed(err, r.now().Add(r.backoff(msg.Attempts)), r.maxAttempts)
+		} else {
+			msg.MarkDelivered(r.now())
+			delivered++
+		}
+
+		if err := r.store.Save(ctx, msg); err != nil {
+			return delivered, err
+		}
+	}
+	return delivered, nil
+}
+
+// backoff 第 attempts 次失败后的等待时间：base * 2^attempts，不超过上限
+func (r *Relay) backoff(attempts int) time.Duration {
+	d := r.baseBackoff
+	for i := 0; i < attempts; i++ {
+		d *= 2
+		if d >= r.maxBackoff {
+			return r.maxBackoff
+		}
+	}
+	return d
+}
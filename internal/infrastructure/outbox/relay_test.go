@@ -0,0 +1,125 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_order_core"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/mocks"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/outbox"
+	"github.com/vaynedu/ddd_order_example/internal/shared/event"
+	"go.uber.org/mock/gomock"
+)
+
+func newOrderPaidMessage(t *testing.T) *outbox.Message {
+	order := &domain_order_core.OrderDO{
+		ID:          "order_123",
+		Status:      domain_order_core.OrderStatusPending,
+		TotalAmount: 1000,
+	}
+	require.NoError(t, order.MarkAsPaid())
+
+	msg, err := outbox.NewMessage(order.PullEvents()[0])
+	require.NoError(t, err)
+	return msg
+}
+
+// TestRelay_RelayOnce_Delivered 投递成功的消息标记为已投递
+func TestRelay_RelayOnce_Delivered(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockStore(ctrl)
+	mockSink := mocks.NewMockSink(ctrl)
+	relay := outbox.NewRelay(mockStore, mockSink)
+
+	msg := newOrderPaidMessage(t)
+	mockStore.EXPECT().FetchPending(gomock.Any(), gomock.Any(), 100).Return([]*outbox.Message{msg}, nil)
+	mockSink.EXPECT().Deliver(gomock.Any(), msg).Return(nil)
+	mockStore.EXPECT().Save(gomock.Any(), msg).Return(nil)
+
+	delivered, err := relay.RelayOnce(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+	assert.Equal(t, outbox.MessageStatusDelivered, msg.Status)
+	assert.NotNil(t, msg.DeliveredAt)
+}
+
+// TestRelay_RelayOnce_Backoff 投递失败按指数退避推迟，达到最大次数后不再投递
+func TestRelay_RelayOnce_Backoff(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockStore(ctrl)
+	mockSink := mocks.NewMockSink(ctrl)
+	relay := outbox.NewRelay(mockStore, mockSink, outbox.WithMaxAttempts(3), outbox.WithBackoff(time.Second, 3*time.Second))
+
+	msg := newOrderPaidMessage(t)
+	mockStore.EXPECT().FetchPending(gomock.Any(), gomock.Any(), gomock.Any()).Return([]*outbox.Message{msg}, nil).Times(3)
+	mockSink.EXPECT().Deliver(gomock.Any(), msg).Return(errors.New("broker unavailable")).Times(3)
+	mockStore.EXPECT().Save(gomock.Any(), msg).Return(nil).Times(3)
+
+	expectedDelays := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}
+	for i, expected := range expectedDelays {
+		before := time.Now()
+		delivered, err := relay.RelayOnce(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, delivered)
+		assert.Equal(t, i+1, msg.Attempts)
+		assert.Equal(t, "broker unavailable", msg.LastError)
+		assert.WithinDuration(t, before.Add(expected), msg.NextAttemptAt, 100*time.Millisecond)
+	}
+	assert.Equal(t, outbox.MessageStatusDead, msg.Status)
+}
+
+// TestRelay_RelayOnce_PartialFailure 单条消息失败不影响同批其他消息
+func TestRelay_RelayOnce_PartialFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockStore(ctrl)
+	mockSink := mocks.NewMockSink(ctrl)
+	relay := outbox.NewRelay(mockStore, mockSink)
+
+	failed, ok := newOrderPaidMessage(t), newOrderPaidMessage(t)
+	mockStore.EXPECT().FetchPending(gomock.Any(), gomock.Any(), gomock.Any()).Return([]*outbox.Message{failed, ok}, nil)
+	mockSink.EXPECT().Deliver(gomock.Any(), failed).Return(errors.New("timeout"))
+	mockSink.EXPECT().Deliver(gomock.Any(), ok).Return(nil)
+	mockStore.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+	delivered, err := relay.RelayOnce(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+	assert.Equal(t, outbox.MessageStatusPending, failed.Status)
+	assert.Equal(t, outbox.MessageStatusDelivered, ok.Status)
+}
+
+// TestEventBusSink_Deliver 注册了解码器的消息以具体事件类型发布到事件总线
+func TestEventBusSink_Deliver(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	bus := event.NewEventBus()
+	mockHandler := mocks.NewMockHandler(ctrl)
+	bus.RegisterHandler(domain_order_core.EventNameOrderPaid, mockHandler)
+	sink := outbox.NewEventBusSink(bus).RegisterDecoder(domain_order_core.EventNameOrderPaid, domain_order_core.DecodeEvent)
+
+	msg := newOrderPaidMessage(t)
+	mockHandler.EXPECT().Handle(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, evt event.Event) error {
+		paid, ok := evt.(*domain_order_core.OrderPaidEvent)
+		require.True(t, ok)
+		assert.Equal(t, msg.EventID, paid.EventID)
+		assert.Equal(t, "order_123", paid.OrderID)
+		assert.Equal(t, int64(1000), paid.Amount)
+		return nil
+	})
+
+	assert.NoError(t, sink.Deliver(context.Background(), msg))
+}
@@ -0,0 +1,49 @@
+package outbox
+
+import (
+	"context"
+
+	"github.com/vaynedu/ddd_order_example/internal/shared/event"
+)
+
+// Sink 发件箱消息的投递目标，可替换为消息队列等外部系统
+type Sink interface {
+	Deliver(ctx context.Context, msg *Message) error
+}
+
+// DecodeFunc 将消息还原为具体的领域事件
+type DecodeFunc func(name string, payload []byte) (event.Event, error)
+
+// EventBusSink 投递到进程内事件总线
+type EventBusSink struct {
+	bus      *event.EventBus
+	decoders map[string]DecodeFunc
+}
+
+// NewEventBusSink 创建事件总线投递目标
+func NewEventBusSink(bus *event.EventBus) *EventBusSink {
+	return &EventBusSink{
+		bus:      bus,
+		decoders: make(map[string]DecodeFunc),
+	}
+}
+
+// RegisterDecoder 为事件注册解码器，处理器收到的是具体的事件类型
+func (s *EventBusSink) RegisterDecoder(eventName string, decode DecodeFunc) *EventBusSink {
+	s.decoders[eventName] = decode
+	return s
+}
+
+// Deliver 解码后发布到事件总线，未注册解码器的消息以 *Message 发布
+func (s *EventBusSink) Deliver(ctx context.Context, msg *Message) error {
+	decode, ok := s.decoders[msg.EventName]
+	if !ok {
+		return s.bus.Publish(ctx, msg)
+	}
+
+	evt, err := decode(msg.EventName, []byte(msg.Payload))
+	if err != nil {
+		return err
+	}
+	return s.bus.Publish(ctx, evt)
+}
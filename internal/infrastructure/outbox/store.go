@@ -0,0 +1,65 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/persistence"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Store 发件箱消息存储
+type Store interface {
+	// FetchPending 查询到达投递时间的待投递消息，按写入顺序返回
+	FetchPending(ctx context.Context, now time.Time, limit int) ([]*Message, error)
+	// Save 更新消息投递结果
+	Save(ctx context.Context, msg *Message) error
+	// MarkDelivered 按事件ID标记待投递的消息为已投递
+	MarkDelivered(ctx context.Context, eventID string, deliveredAt time.Time) error
+}
+
+// GormStore 基于gorm的发件箱存储，在工作单元中调用时加入外层事务
+type GormStore struct {
+	db *gorm.DB
+}
+
+// NewGormStore 创建发件箱存储实例
+func NewGormStore(db *gorm.DB) Store {
+	return &GormStore{db: db}
+}
+
+// Append 在调用方的事务内写入发件箱消息，保证与业务数据同时提交或回滚
+// 同一事件重复写入(聚合事件未取出时再次保存)按 event_id 忽略
+func Append(tx *gorm.DB, msgs []*Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&msgs).Error
+}
+
+// FetchPending 查询到达投递时间的待投递消息
+func (s *GormStore) FetchPending(ctx context.Context, now time.Time, limit int) ([]*Message, error) {
+	var msgs []*Message
+	err := persistence.DB(ctx, s.db).
+		Where("status = ? AND next_attempt_at <= ?", MessageStatusPending, now).
+		Order("id").
+		Limit(limit).
+		Find(&msgs).Error
+	return msgs, err
+}
+
+// Save 更新消息投递结果
+func (s *GormStore) Save(ctx context.Context, msg *Message) error {
+	return persistence.DB(ctx, s.db).Save(msg).Error
+}
+
+// MarkDelivered 按事件ID标记待投递的消息为已投递
+func (s *GormStore) MarkDelivered(ctx context.Context, eventID string, deliveredAt time.Time) error {
+	return persistence.DB(ctx, s.db).Model(&Message{}).
+		Where("event_id = ? AND status = ?", eventID, MessageStatusPending).
+		Updates(map[string]interface{}{
+			"status":       MessageStatusDelivered,
+			"delivered_at": deliveredAt,
+		}).Error
+}
@@ -2,35 +2,70 @@ package payment
 
 import (
 	"context"
-	"strconv"
+	"errors"
+	"fmt"
+	"net/url"
 
 	"github.com/smartwalle/alipay/v3"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
+	"github.com/vaynedu/ddd_order_example/pkg/dmoney"
+)
+
+// AlipayConfig 支付宝配置
+type AlipayConfig struct {
+	AppID           string // 应用ID
+	PrivateKey      string // 应用私钥，用于请求签名
+	AlipayPublicKey string // 支付宝公钥，用于异步通知验签
+	NotifyURL       string // 支付通知地址
+	ReturnURL       string // 支付成功返回地址
+	IsProduction    bool   // 是否生产环境，否则使用沙箱
+	SandboxGateway  string // 沙箱网关地址，为空时使用支付宝默认沙箱网关
+}
+
+const (
+	alipayTradeNotExist    = "ACQ.TRADE_NOT_EXIST"    // 交易查询、关闭时交易不存在的业务错误码
+	alipayTradeStatusError = "ACQ.TRADE_STATUS_ERROR" // 交易关闭时交易已支付或已关闭的业务错误码
+	alipaySystemError      = "ACQ.SYSTEM_ERROR"       // 支付宝系统错误，结果未知，需使用相同参数重试
 )
 
 // 支付宝适配器实现
 type AlipayAdapter struct {
 	client *alipay.Client
+	config AlipayConfig
 }
 
 // NewAlipayAdapter 创建支付宝适配器实例
-func NewAlipayAdapter(appID, privateKey string, debugMode bool) (*AlipayAdapter, error) {
-	client, err := alipay.New(appID, privateKey, debugMode)
+func NewAlipayAdapter(config AlipayConfig) (*AlipayAdapter, error) {
+	var opts []alipay.OptionFunc
+	if config.SandboxGateway != "" {
+		opts = append(opts, alipay.WithSandboxGateway(config.SandboxGateway))
+	}
+	client, err := alipay.New(config.AppID, config.PrivateKey, config.IsProduction, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	return &AlipayAdapter{client: client}, nil
+	// 加载支付宝公钥，异步通知必须验签
+	if err := client.LoadAliPayPublicKey(config.AlipayPublicKey); err != nil {
+		return nil, fmt.Errorf("加载支付宝公钥失败: %w", err)
+	}
+
+	return &AlipayAdapter{client: client, config: config}, nil
 }
 
 // CreatePayment 发起支付宝支付
-func (a *AlipayAdapter) CreatePayment(ctx context.Context, orderID string, amount int64) (string, error) {
+func (a *AlipayAdapter) CreatePayment(ctx context.Context, orderID string, amount dmoney.Money) (string, error) {
+	if amount.Currency() != dmoney.CNY {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedCurrency, amount.Currency())
+	}
+
 	// 构建支付请求参数
 	payReq := alipay.TradePagePay{}
-	payReq.NotifyURL = "http://notify.example.com" // 支付通知地址
-	payReq.ReturnURL = "http://return.example.com" // 支付成功返回地址
+	payReq.NotifyURL = a.config.NotifyURL // 支付通知地址
+	payReq.ReturnURL = a.config.ReturnURL // 支付成功返回地址
 	payReq.Subject = "订单支付"
 	payReq.OutTradeNo = orderID
-	payReq.TotalAmount = strconv.FormatInt(amount, 10)
+	payReq.TotalAmount = amount.String()
 	payReq.ProductCode = "FAST_INSTANT_TRADE_PAY"
 
 	// 发起支付请求
@@ -42,3 +77,145 @@ func (a *AlipayAdapter) CreatePayment(ctx context.Context, orderID string, amoun
 	// fixme 这里先写mock数据
 	return payResp.Fragment, nil
 }
+
+// Refund 发起支付宝退款，部分退款时 OutRequestNo 必传且同一笔交易内唯一
+func (a *AlipayAdapter) Refund(ctx context.Context, orderID, refundID string, amount dmoney.Money) (string, error) {
+	if amount.Currency() != dmoney.CNY {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedCurrency, amount.Currency())
+	}
+
+	refundReq := alipay.TradeRefund{}
+	refundReq.OutTradeNo = orderID
+	refundReq.OutRequestNo = refundID
+	refundReq.RefundAmount = amount.String()
+
+	refundResp, err := a.client.TradeRefund(ctx, refundReq)
+	if err != nil {
+		var alipayErr *alipay.Error
+		if errors.As(err, &alipayErr) && alipayErr.SubCode != alipaySystemError {
+			return "", fmt.Errorf("%w: %w", ErrRefundRejected, err)
+		}
+		return "", err
+	}
+	if refundResp.IsFailure() {
+		if refundResp.SubCode == alipaySystemError {
+			return "", refundResp.Error
+		}
+		return "", fmt.Errorf("%w: %w", ErrRefundRejected, refundResp.Error)
+	}
+
+	// 支付宝退款接口不返回单独的退款流水号，使用支付宝交易号+退款请求号标识本次退款
+	return refundResp.TradeNo + "_" + refundID, nil
+}
+
+// QueryPaymentStatus 按商户订单号查询支付宝交易，与下单和退款使用同一个 out_trade_no
+// 用户未扫码时支付宝侧交易不存在，返回 ErrTradeNotExist，由调用方结合支付时限判断是否可以取消
+func (a *AlipayAdapter) QueryPaymentStatus(ctx context.Context, orderID string) (*domain_payment_core.ChannelTrade, error) {
+	queryReq := alipay.TradeQuery{}
+	queryReq.OutTradeNo = orderID
+
+	queryResp, err := a.client.TradeQuery(ctx, queryReq)
+	if err != nil {
+		// 响应未签名时 SDK 直接把业务错误作为 error 返回
+		var alipayErr *alipay.Error
+		if errors.As(err, &alipayErr) && alipayErr.SubCode == alipayTradeNotExist {
+			return nil, ErrTradeNotExist
+		}
+		return nil, err
+	}
+	if queryResp.IsFailure() {
+		if queryResp.SubCode == alipayTradeNotExist {
+			return nil, ErrTradeNotExist
+		}
+		return nil, queryResp.Error
+	}
+
+	status, err := convertTradeStatus(queryResp.TradeStatus)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := dmoney.Parse(queryResp.TotalAmount, dmoney.CNY)
+	if err != nil {
+		return nil, fmt.Errorf("total_amount格式错误 %s: %w", queryResp.TotalAmount, err)
+	}
+	return &domain_payment_core.ChannelTrade{
+		Status:        status,
+		TransactionID: queryResp.TradeNo,
+		Amount:        amount,
+	}, nil
+}
+
+// CloseTrade 按商户订单号关闭支付宝交易，用户未扫码时支付宝侧交易不存在，返回 ErrTradeNotExist
+func (a *AlipayAdapter) CloseTrade(ctx context.Context, orderID string) error {
+	closeReq := alipay.TradeClose{}
+	closeReq.OutTradeNo = orderID
+
+	closeResp, err := a.client.TradeClose(ctx, closeReq)
+	if err != nil {
+		var alipayErr *alipay.Error
+		if errors.As(err, &alipayErr) {
+			return convertCloseError(alipayErr.SubCode, err)
+		}
+		return err
+	}
+	if closeResp.IsFailure() {
+		return convertCloseError(closeResp.SubCode, closeResp.Error)
+	}
+	return nil
+}
+
+// convertCloseError 转换交易关闭的业务错误码
+func convertCloseError(subCode string, err error) error {
+	switch subCode {
+	case alipayTradeNotExist:
+		return ErrTradeNotExist
+	case alipayTradeStatusError:
+		return ErrTradeNotClosable
+	default:
+		return err
+	}
+}
+
+// ParseNotification 验签并解析支付宝异步通知
+func (a *AlipayAdapter) ParseNotification(ctx context.Context, values url.Values) (*PaymentNotification, error) {
+	notification, err := a.client.DecodeNotification(values)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidNotification, err)
+	}
+	// 防止其他应用的通知被重放到本应用
+	if notification.AppId != a.config.AppID {
+		return nil, fmt.Errorf("%w: app_id不匹配 %s", ErrInvalidNotification, notification.AppId)
+	}
+
+	status, err := convertTradeStatus(notification.TradeStatus)
+	if err != nil {
+		return nil, err
+	}
+
+	// 支付宝交易金额单位为人民币元，精确到分
+	amount, err := dmoney.Parse(notification.TotalAmount, dmoney.CNY)
+	if err != nil {
+		return nil, fmt.Errorf("%w: total_amount格式错误 %s", ErrInvalidNotification, notification.TotalAmount)
+	}
+
+	return &PaymentNotification{
+		OrderID:       notification.OutTradeNo,
+		TransactionID: notification.TradeNo,
+		Status:        status,
+		Amount:        amount,
+	}, nil
+}
+
+// convertTradeStatus 支付宝交易状态映射为支付状态
+func convertTradeStatus(status alipay.TradeStatus) (domain_payment_core.PaymentStatus, error) {
+	switch status {
+	case alipay.TradeStatusWaitBuyerPay:
+		return domain_payment_core.PaymentStatusPending, nil
+	case alipay.TradeStatusSuccess, alipay.TradeStatusFinished:
+		return domain_payment_core.PaymentStatusCompleted, nil
+	case alipay.TradeStatusClosed:
+		return domain_payment_core.PaymentStatusClosed, nil
+	default:
+		return domain_payment_core.PaymentStatusUnknown, fmt.Errorf("%w: %s", ErrUnknownTradeStatus, status)
+	}
+}
@@ -0,0 +1,242 @@
+package payment
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/smartwalle/alipay/v3"
+	"github.com/smartwalle/ncrypto"
+	"github.com/smartwalle/nsign"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
+	"github.com/vaynedu/ddd_order_example/pkg/dmoney"
+)
+
+const testAppID = "2021000000000000"
+
+// newTestAlipayAdapter 使用 testdata 下的本地密钥对创建适配器，gateway 为空时使用默认沙箱网关
+// 测试中应用私钥同时充当支付宝私钥为通知和接口响应签名，对应公钥用于验签
+func newTestAlipayAdapter(t *testing.T, gateway string) (*AlipayAdapter, string) {
+	privateKey, err := os.ReadFile("testdata/alipay_test_private_key.pem")
+	require.NoError(t, err)
+	publicKey, err := os.ReadFile("testdata/alipay_test_public_key.pem")
+	require.NoError(t, err)
+
+	adapter, err := NewAlipayAdapter(AlipayConfig{
+		AppID:           testAppID,
+		PrivateKey:      string(privateKey),
+		AlipayPublicKey: string(publicKey),
+		NotifyURL:       "http://localhost:8090/api/payments/notify/alipay",
+		SandboxGateway:  gateway,
+	})
+	require.NoError(t, err)
+	return adapter, string(privateKey)
+}
+
+// signNotification 按支付宝规则为通知参数签名
+func signNotification(t *testing.T, privateKey string, values url.Values) url.Values {
+	priKey, err := ncrypto.DecodePrivateKey([]byte(privateKey)).PKCS1().RSAPrivateKey()
+	require.NoError(t, err)
+
+	signer := nsign.New(nsign.WithMethod(nsign.NewRSAMethod(crypto.SHA256, priKey, nil)), nsign.WithEncoder(&alipay.Encoder{}))
+	values.Set("sign_type", "RSA2")
+	signature, err := signer.SignValues(values, nsign.WithIgnore("sign", "sign_type"))
+	require.NoError(t, err)
+	values.Set("sign", base64.StdEncoding.EncodeToString(signature))
+	return values
+}
+
+// newAlipayGateway 模拟支付宝网关，记录 method 接口的业务参数并返回签名后的响应
+func newAlipayGateway(t *testing.T, privateKey, method, response string, bizContent *string) *httptest.Server {
+	priKey, err := ncrypto.DecodePrivateKey([]byte(privateKey)).PKCS1().RSAPrivateKey()
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, method, r.PostForm.Get("method"))
+		*bizContent = r.PostForm.Get("biz_content")
+
+		digest := sha256.Sum256([]byte(response))
+		signature, err := rsa.SignPKCS1v15(rand.Reader, priKey, crypto.SHA256, digest[:])
+		assert.NoError(t, err)
+		fmt.Fprintf(w, `{"%s_response":%s,"sign":"%s"}`, strings.ReplaceAll(method, ".", "_"), response, base64.StdEncoding.EncodeToString(signature))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newNotificationValues(tradeStatus string) url.Values {
+	values := url.Values{}
+	values.Set("app_id", testAppID)
+	values.Set("notify_id", "notify_1")
+	values.Set("notify_type", "trade_status_sync")
+	values.Set("out_trade_no", "order_123")
+	values.Set("trade_no", "2024010122001400000000000001")
+	values.Set("trade_status", tradeStatus)
+	values.Set("total_amount", "10.29")
+	return values
+}
+
+// TestAlipayAdapter_ParseNotification 验签通过后映射交易状态和金额
+func TestAlipayAdapter_ParseNotification(t *testing.T) {
+	adapter, privateKey := newTestAlipayAdapter(t, "")
+
+	cases := []struct {
+		tradeStatus string
+		expected    domain_payment_core.PaymentStatus
+	}{
+		{"TRADE_SUCCESS", domain_payment_core.PaymentStatusCompleted},
+		{"TRADE_FINISHED", domain_payment_core.PaymentStatusCompleted},
+		{"WAIT_BUYER_PAY", domain_payment_core.PaymentStatusPending},
+		{"TRADE_CLOSED", domain_payment_core.PaymentStatusClosed},
+	}
+
+	for _, c := range cases {
+		t.Run(c.tradeStatus, func(t *testing.T) {
+			values := signNotification(t, privateKey, newNotificationValues(c.tradeStatus))
+
+			notification, err := adapter.ParseNotification(context.Background(), values)
+
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, notification.Status)
+			assert.Equal(t, "order_123", notification.OrderID)
+			assert.Equal(t, "2024010122001400000000000001", notification.TransactionID)
+			assert.Equal(t, dmoney.New(1029, dmoney.CNY), notification.Amount)
+		})
+	}
+}
+
+// TestAlipayAdapter_ParseNotification_Tampered 签名后被篡改的通知验签失败
+func TestAlipayAdapter_ParseNotification_Tampered(t *testing.T) {
+	adapter, privateKey := newTestAlipayAdapter(t, "")
+
+	values := signNotification(t, privateKey, newNotificationValues("TRADE_SUCCESS"))
+	values.Set("total_amount", "0.01")
+
+	_, err := adapter.ParseNotification(context.Background(), values)
+
+	assert.ErrorIs(t, err, ErrInvalidNotification)
+}
+
+// TestAlipayAdapter_ParseNotification_AppIDMismatch 其他应用的通知即使签名正确也拒绝
+func TestAlipayAdapter_ParseNotification_AppIDMismatch(t *testing.T) {
+	adapter, privateKey := newTestAlipayAdapter(t, "")
+
+	values := newNotificationValues("TRADE_SUCCESS")
+	values.Set("app_id", "2021999999999999")
+	values = signNotification(t, privateKey, values)
+
+	_, err := adapter.ParseNotification(context.Background(), values)
+
+	assert.ErrorIs(t, err, ErrInvalidNotification)
+}
+
+// TestAlipayAdapter_ParseNotification_UnknownStatus 未知的交易状态不做处理
+func TestAlipayAdapter_ParseNotification_UnknownStatus(t *testing.T) {
+	adapter, privateKey := newTestAlipayAdapter(t, "")
+
+	values := signNotification(t, privateKey, newNotificationValues("TRADE_UNKNOWN"))
+
+	_, err := adapter.ParseNotification(context.Background(), values)
+
+	assert.ErrorIs(t, err, ErrUnknownTradeStatus)
+}
+
+// TestAlipayAdapter_QueryPaymentStatus 按下单时的商户订单号查询交易状态、交易号和金额
+func TestAlipayAdapter_QueryPaymentStatus(t *testing.T) {
+	_, privateKey := newTestAlipayAdapter(t, "")
+	var bizContent string
+	gateway := newAlipayGateway(t, privateKey, "alipay.trade.query", `{"code":"10000","msg":"Success","trade_no":"2024010122001400000000000001","out_trade_no":"order_123","trade_status":"TRADE_SUCCESS","total_amount":"10.29"}`, &bizContent)
+	adapter, _ := newTestAlipayAdapter(t, gateway.URL)
+
+	trade, err := adapter.QueryPaymentStatus(context.Background(), "order_123")
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain_payment_core.PaymentStatusCompleted, trade.Status)
+	assert.Equal(t, "2024010122001400000000000001", trade.TransactionID)
+	assert.True(t, trade.Amount.Equal(dmoney.New(1029, dmoney.CNY)))
+	assert.JSONEq(t, `{"out_trade_no":"order_123"}`, bizContent)
+}
+
+// TestAlipayAdapter_QueryPaymentStatus_TradeNotExist 用户未扫码时支付宝侧交易不存在
+func TestAlipayAdapter_QueryPaymentStatus_TradeNotExist(t *testing.T) {
+	_, privateKey := newTestAlipayAdapter(t, "")
+	var bizContent string
+	gateway := newAlipayGateway(t, privateKey, "alipay.trade.query", `{"code":"40004","msg":"Business Failed","sub_code":"ACQ.TRADE_NOT_EXIST","sub_msg":"交易不存在"}`, &bizContent)
+	adapter, _ := newTestAlipayAdapter(t, gateway.URL)
+
+	trade, err := adapter.QueryPaymentStatus(context.Background(), "order_123")
+
+	assert.ErrorIs(t, err, ErrTradeNotExist)
+	assert.Nil(t, trade)
+}
+
+// TestAlipayAdapter_CloseTrade 按商户订单号关闭交易，交易不存在、已支付或已关闭时返回对应错误
+func TestAlipayAdapter_CloseTrade(t *testing.T) {
+	cases := []struct {
+		name     string
+		response string
+		err      error
+	}{
+		{name: "关闭成功", response: `{"code":"10000","msg":"Success","trade_no":"2024010122001400000000000001","out_trade_no":"order_123"}`},
+		{name: "交易不存在", response: `{"code":"40004","msg":"Business Failed","sub_code":"ACQ.TRADE_NOT_EXIST","sub_msg":"交易不存在"}`, err: ErrTradeNotExist},
+		{name: "交易状态不合法", response: `{"code":"40004","msg":"Business Failed","sub_code":"ACQ.TRADE_STATUS_ERROR","sub_msg":"交易状态不合法"}`, err: ErrTradeNotClosable},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, privateKey := newTestAlipayAdapter(t, "")
+			var bizContent string
+			gateway := newAlipayGateway(t, privateKey, "alipay.trade.close", c.response, &bizContent)
+			adapter, _ := newTestAlipayAdapter(t, gateway.URL)
+
+			err := adapter.CloseTrade(context.Background(), "order_123")
+
+			if c.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, c.err)
+			}
+			assert.JSONEq(t, `{"out_trade_no":"order_123"}`, bizContent)
+		})
+	}
+}
+
+// TestAlipayAdapter_Refund_Failure 支付宝明确拒绝退款时返回 ErrRefundRejected，系统错误时结果未知
+func TestAlipayAdapter_Refund_Failure(t *testing.T) {
+	cases := []struct {
+		name     string
+		response string
+		rejected bool
+	}{
+		{name: "拒绝退款", response: `{"code":"40004","msg":"Business Failed","sub_code":"ACQ.TRADE_STATUS_ERROR","sub_msg":"交易状态不合法"}`, rejected: true},
+		{name: "系统错误", response: `{"code":"20000","msg":"Service Currently Unavailable","sub_code":"ACQ.SYSTEM_ERROR","sub_msg":"系统错误"}`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, privateKey := newTestAlipayAdapter(t, "")
+			var bizContent string
+			gateway := newAlipayGateway(t, privateKey, "alipay.trade.refund", c.response, &bizContent)
+			adapter, _ := newTestAlipayAdapter(t, gateway.URL)
+
+			_, err := adapter.Refund(context.Background(), "order_123", "refund_1", dmoney.New(100, dmoney.CNY))
+
+			assert.Error(t, err)
+			assert.Equal(t, c.rejected, errors.Is(err, ErrRefundRejected))
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package payment
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_reconciliation_core"
+	"github.com/vaynedu/ddd_order_example/pkg/dmoney"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// 支付宝业务明细对账单的列名
+const (
+	alipayColumnTradeNo    = "支付宝交易号"
+	alipayColumnOutTradeNo = "商户订单号"
+	alipayColumnBizType    = "业务类型"
+	alipayColumnFinishedAt = "完成时间"
+	alipayColumnAmount     = "订单金额（元）"
+	alipayColumnRefundNo   = "退款批次号/请求号"
+)
+
+// 支付宝业务明细中的业务类型
+const (
+	alipayBizTypeTrade  = "交易"
+	alipayBizTypeRefund = "退款"
+)
+
+// alipayStatementTimeLayout 对账单时间格式，时间为北京时间
+const alipayStatementTimeLayout = "2006-01-02 15:04:05"
+
+// AlipayStatementParser 解析支付宝业务明细对账单(CSV)
+// 对账单以 # 开头的行为说明和汇总信息，明细部分第一行为表头；下载的原始文件为 GBK 编码
+type AlipayStatementParser struct{}
+
+func NewAlipayStatementParser() *AlipayStatementParser {
+	return &AlipayStatementParser{}
+}
+
+// Channel 对应支付宝渠道
+func (p *AlipayStatementParser) Channel() domain_payment_core.PaymentChannel {
+	return domain_payment_core.PaymentChannelAlipay
+}
+
+// Parse 解析对账单明细，只保留交易和退款记录
+func (p *AlipayStatementParser) Parse(r io.Reader) ([]*domain_reconciliation_core.StatementRecord, error) {
+	content, err := readStatementContent(r)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("对账单缺少明细表头")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取对账单表头失败: %w", err)
+	}
+	columns, err := alipayStatementColumns(header)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []*domain_reconciliation_core.StatementRecord
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("读取对账单明细失败: %w", err)
+		}
+
+		record, err := parseAlipayStatementRow(row, columns)
+		if err != nil {
+			line, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf("解析对账单第%d行失败: %w", line, err)
+		}
+		if record != nil {
+			records = append(records, record)
+		}
+	}
+}
+
+// readStatementContent 读取对账单内容，非 UTF-8 时按 GBK 解码，并去掉 UTF-8 BOM
+func readStatementContent(r io.Reader) ([]byte, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("读取对账单失败: %w", err)
+	}
+	if !utf8.Valid(content) {
+		content, err = simplifiedchinese.GBK.NewDecoder().Bytes(content)
+		if err != nil {
+			return nil, fmt.Errorf("对账单GBK解码失败: %w", err)
+		}
+	}
+	return bytes.TrimPrefix(content, []byte("\xef\xbb\xbf")), nil
+}
+
+// alipayStatementColumns 按列名定位所需字段的下标
+func alipayStatementColumns(header []string) (map[string]int, error) {
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[trimStatementField(name)] = i
+	}
+	for _, name := range []string{alipayColumnTradeNo, alipayColumnOutTradeNo, alipayColumnBizType,
+		alipayColumnFinishedAt, alipayColumnAmount, alipayColumnRefundNo} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("对账单缺少列: %s", name)
+		}
+	}
+	return columns, nil
+}
+
+// parseAlipayStatementRow 解析一行明细，非交易、退款的业务类型返回 nil
+func parseAlipayStatementRow(row []string, columns map[string]int) (*domain_reconciliation_core.StatementRecord, error) {
+	field := func(name string) string {
+		i := columns[name]
+		if i >= len(row) {
+			return ""
+		}
+		return trimStatementField(row[i])
+	}
+
+	record := &domain_reconciliation_core.StatementRecord{
+		TransactionID: field(alipayColumnTradeNo),
+		OrderID:       field(alipayColumnOutTradeNo),
+	}
+	switch field(alipayColumnBizType) {
+	case alipayBizTypeTrade:
+		record.Type = domain_reconciliation_core.StatementRecordPayment
+	case alipayBizTypeRefund:
+		record.Type = domain_reconciliation_core.StatementRecordRefund
+		record.RefundID = field(alipayColumnRefundNo)
+	default:
+		return nil, nil
+	}
+
+	amount, err := dmoney.Parse(field(alipayColumnAmount), dmoney.CNY)
+	if err != nil {
+		return nil, fmt.Errorf("订单金额格式错误: %w", err)
+	}
+	// 退款记录金额为负数，统一按正数比较
+	if amount.IsNegative() {
+		if amount, err = amount.Neg(); err != nil {
+			return nil, fmt.Errorf("订单金额格式错误: %w", err)
+		}
+	}
+	record.Amount = amount.Amount()
+
+	if finishedAt := field(alipayColumnFinishedAt); finishedAt != "" {
+		record.FinishedAt, err = time.ParseInLocation(alipayStatementTimeLayout, finishedAt, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("完成时间格式错误: %w", err)
+		}
+	}
+	return record, nil
+}
+
+// trimStatementField 去掉字段两端的空白，支付宝对账单的字段常带制表符以防止表格软件转换格式
+func trimStatementField(s string) string {
+	return strings.Trim(s, " \t\r\n")
+}
@@ -0,0 +1,67 @@
+package payment
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_reconciliation_core"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// testAlipayStatement 支付宝业务明细对账单样例，字段带制表符，包含一条非交易退款的业务类型
+const testAlipayStatement = `#支付宝业务明细查询
+#账号：[20880000000000000156]
+#起始日期：[2024年06月01日 00:00:00]   终止日期：[2024年06月02日 00:00:00]
+#-----------------------------------------业务明细列表----------------------------------------
+支付宝交易号,商户订单号,业务类型,商品名称,创建时间,完成时间,门店编号,门店名称,操作员,终端号,对方账户,订单金额（元）,商家实收（元）,支付宝红包（元）,集分宝（元）,支付宝优惠（元）,商家优惠（元）,券核销金额（元）,券名称,商家红包消费金额（元）,卡消费金额（元）,退款批次号/请求号,服务费（元）,分润（元）,备注
+2024060122001400000000000001	,order_1	,交易	,订单支付	,2024-06-01 10:00:00,2024-06-01 10:00:05,,,,,buyer@example.com,9.99,9.99,0.00,0.00,0.00,0.00,0.00,,0.00,0.00,	,-0.06,0.00,
+2024060122001400000000000001	,order_1	,退款	,订单支付	,2024-06-01 12:00:00,2024-06-01 12:00:01,,,,,buyer@example.com,-0.29,-0.29,0.00,0.00,0.00,0.00,0.00,,0.00,0.00,refund_1	,0.00,0.00,
+2024060122001400000000000002	,order_2	,在线支付	,其他业务	,2024-06-01 13:00:00,2024-06-01 13:00:00,,,,,,1.00,1.00,0.00,0.00,0.00,0.00,0.00,,0.00,0.00,	,0.00,0.00,
+#-----------------------------------------业务明细列表结束------------------------------------
+#交易合计：1笔，商家实收：9.99元，商家实收（优惠）：0.00元
+#退款合计：1笔，商家实收：-0.29元，商家实收（优惠）：0.00元
+#导出时间：[2024年06月02日 09:00:00]
+`
+
+func TestAlipayStatementParser_Parse(t *testing.T) {
+	gbk, err := simplifiedchinese.GBK.NewEncoder().String(testAlipayStatement)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name    string
+		content string
+	}{
+		{name: "UTF-8", content: testAlipayStatement},
+		{name: "GBK", content: gbk},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			records, err := NewAlipayStatementParser().Parse(strings.NewReader(c.content))
+
+			require.NoError(t, err)
+			require.Len(t, records, 2)
+			assert.Equal(t, &domain_reconciliation_core.StatementRecord{
+				Type:          domain_reconciliation_core.StatementRecordPayment,
+				TransactionID: "2024060122001400000000000001",
+				OrderID:       "order_1",
+				Amount:        999,
+				FinishedAt:    time.Date(2024, 6, 1, 10, 0, 5, 0, time.Local),
+			}, records[0])
+			assert.Equal(t, domain_reconciliation_core.StatementRecordRefund, records[1].Type)
+			assert.Equal(t, "refund_1", records[1].RefundID)
+			assert.Equal(t, int64(29), records[1].Amount)
+		})
+	}
+}
+
+func TestAlipayStatementParser_Parse_MissingColumn(t *testing.T) {
+	content := "#支付宝业务明细查询\n支付宝交易号,商户订单号,业务类型\n"
+
+	_, err := NewAlipayStatementParser().Parse(strings.NewReader(content))
+
+	assert.ErrorContains(t, err, "完成时间")
+}
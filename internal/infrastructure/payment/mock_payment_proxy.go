@@ -3,8 +3,11 @@ package payment
 import (
 	"context"
 	"fmt"
+	"net/url"
 
+	"github.com/smartwalle/alipay/v3"
 	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
+	"github.com/vaynedu/ddd_order_example/pkg/dmoney"
 )
 
 // MockPaymentProxy 支付代理的Mock实现
@@ -26,7 +29,7 @@ func NewMockPaymentProxy() PaymentProxy {
 }
 
 // CreatePayment 模拟创建支付请求
-func (m *MockPaymentProxy) CreatePayment(ctx context.Context, orderID string, amount int64) (string, error) {
+func (m *MockPaymentProxy) CreatePayment(ctx context.Context, orderID string, amount dmoney.Money) (string, error) {
 	if m.CustomError != nil {
 		return "", m.CustomError
 	}
@@ -38,6 +41,14 @@ func (m *MockPaymentProxy) CreatePayment(ctx context.Context, orderID string, am
 	return m.TransactionID, nil
 }
 
+// NewMockPaymentNotifier 创建Mock支付通知解析器，不做验签，便于本地模拟回调
+func NewMockPaymentNotifier() PaymentNotifier {
+	return &MockPaymentProxy{
+		ReturnSuccess: true,
+		TransactionID: "mock_transaction_123",
+	}
+}
+
 // WithReturnSuccess 设置是否返回成功
 func (m *MockPaymentProxy) WithReturnSuccess(success bool) *MockPaymentProxy {
 	m.ReturnSuccess = success
@@ -61,10 +72,53 @@ func (m *MockPaymentProxy) WithCustomError(err error) *MockPaymentProxy {
 	return m
 }
 
-func (m *MockPaymentProxy) QueryPaymentStatus(ctx context.Context, paymentID string) (domain_payment_core.PaymentStatus, error) {
+// QueryPaymentStatus 模拟查询渠道支付状态，模拟环境的支付结果以模拟回调为准，渠道侧始终为待支付
+func (m *MockPaymentProxy) QueryPaymentStatus(ctx context.Context, orderID string) (*domain_payment_core.ChannelTrade, error) {
+	if m.CustomError != nil {
+		return nil, m.CustomError
+	}
+
+	return &domain_payment_core.ChannelTrade{Status: domain_payment_core.PaymentStatusPending}, nil
+}
+
+// Refund 模拟发起退款
+func (m *MockPaymentProxy) Refund(ctx context.Context, orderID, refundID string, amount dmoney.Money) (string, error) {
+	if m.CustomError != nil {
+		return "", m.CustomError
+	}
+
+	if !m.ReturnSuccess {
+		return "", fmt.Errorf("%w: mock error", ErrRefundRejected)
+	}
+
+	return "mock_refund_" + refundID, nil
+}
+
+// CloseTrade 模拟关闭渠道交易
+func (m *MockPaymentProxy) CloseTrade(ctx context.Context, orderID string) error {
+	return m.CustomError
+}
+
+// ParseNotification 模拟解析支付通知，字段与支付宝通知保持一致，不校验签名
+func (m *MockPaymentProxy) ParseNotification(ctx context.Context, values url.Values) (*PaymentNotification, error) {
 	if m.CustomError != nil {
-		return domain_payment_core.PaymentStatusCanceled, m.CustomError
+		return nil, m.CustomError
+	}
+
+	status, err := convertTradeStatus(alipay.TradeStatus(values.Get("trade_status")))
+	if err != nil {
+		return nil, err
+	}
+
+	amount, err := dmoney.Parse(values.Get("total_amount"), dmoney.CNY)
+	if err != nil {
+		return nil, fmt.Errorf("%w: total_amount格式错误 %s", ErrInvalidNotification, values.Get("total_amount"))
 	}
 
-	return domain_payment_core.PaymentStatusCompleted, nil
+	return &PaymentNotification{
+		OrderID:       values.Get("out_trade_no"),
+		TransactionID: values.Get("trade_no"),
+		Status:        status,
+		Amount:        amount,
+	}, nil
 }
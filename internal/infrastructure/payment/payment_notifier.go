@@ -0,0 +1,30 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"net/url"
+
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
+	"github.com/vaynedu/ddd_order_example/pkg/dmoney"
+)
+
+// 支付通知错误定义
+var (
+	ErrInvalidNotification = errors.New("invalid payment notification")
+	ErrUnknownTradeStatus  = errors.New("unknown trade status")
+)
+
+// PaymentNotification 支付渠道异步通知，已完成验签
+type PaymentNotification struct {
+	OrderID       string                            // 商户订单号(out_trade_no)
+	TransactionID string                            // 渠道交易号
+	Status        domain_payment_core.PaymentStatus // 渠道交易状态映射后的支付状态
+	Amount        dmoney.Money                      // 交易金额
+}
+
+// 支付通知解析接口（验签并解析外部支付系统的异步通知）
+type PaymentNotifier interface {
+	// ParseNotification 验签失败返回 ErrInvalidNotification
+	ParseNotification(ctx context.Context, values url.Values) (*PaymentNotification, error)
+}
@@ -2,13 +2,33 @@ package payment
 
 import (
 	"context"
+	"errors"
 
 	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
+	"github.com/vaynedu/ddd_order_example/pkg/dmoney"
 )
 
+// ErrUnsupportedCurrency 支付渠道不支持该币种
+var ErrUnsupportedCurrency = errors.New("支付渠道不支持该币种")
+
+// ErrTradeNotExist 支付渠道侧不存在该交易，通常是用户尚未扫码或下单
+var ErrTradeNotExist = errors.New("支付渠道交易不存在")
+
+// ErrTradeNotClosable 支付渠道交易已支付或已关闭，不能关闭
+var ErrTradeNotClosable = errors.New("支付渠道交易已支付或已关闭")
+
+// ErrRefundRejected 支付渠道明确拒绝退款，渠道侧没有退款，网络超时等结果未知的错误不属于此类
+var ErrRefundRejected = errors.New("支付渠道拒绝退款")
+
 // 支付代理接口（与外部支付系统通信）
 type PaymentProxy interface {
-	CreatePayment(ctx context.Context, orderID string, amount int64) (string, error)
-	QueryPaymentStatus(ctx context.Context, paymentID string) (domain_payment_core.PaymentStatus, error)
+	CreatePayment(ctx context.Context, orderID string, amount dmoney.Money) (string, error)
+	// QueryPaymentStatus 按商户订单号查询渠道交易的状态、交易号和金额，渠道侧交易不存在时返回 ErrTradeNotExist
+	QueryPaymentStatus(ctx context.Context, orderID string) (*domain_payment_core.ChannelTrade, error)
+	// Refund 发起退款，refundID 作为渠道侧的退款请求号保证同一笔退款不会重复退，返回渠道退款流水号
+	// 渠道明确拒绝时返回 ErrRefundRejected，其他错误时渠道可能已受理退款
+	Refund(ctx context.Context, orderID, refundID string, amount dmoney.Money) (string, error)
+	// CloseTrade 关闭未支付的渠道交易，关闭后用户不能再支付；交易不存在时返回 ErrTradeNotExist，已支付或已关闭时返回 ErrTradeNotClosable
+	CloseTrade(ctx context.Context, orderID string) error
 	// QueryPayment(ctx context.Context, paymentID string) (*domain_payment_core.PaymentDO, error)
 }
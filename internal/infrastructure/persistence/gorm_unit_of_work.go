@@ -0,0 +1,39 @@
+package persistence
+
+import (
+	"context"
+
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_uow_core"
+	"gorm.io/gorm"
+)
+
+// txKey ctx 中保存当前事务的键
+type txKey struct{}
+
+// GormUnitOfWork 基于gorm事务的工作单元
+type GormUnitOfWork struct {
+	db *gorm.DB
+}
+
+// NewGormUnitOfWork 创建工作单元
+func NewGormUnitOfWork(db *gorm.DB) domain_uow_core.UnitOfWork {
+	return &GormUnitOfWork{db: db}
+}
+
+// Do 在事务中执行 fn，fn 返回错误或 panic 时回滚；ctx 中已有事务时加入该事务
+func (u *GormUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+	return u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// DB 返回 ctx 中的事务，不在工作单元中时返回 db，仓储通过它加入工作单元
+func DB(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
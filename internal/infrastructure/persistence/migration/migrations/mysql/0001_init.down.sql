@@ -0,0 +1,4 @@
+-- 回滚初始表结构，删除订单、订单项和支付表及数据
+DROP TABLE t_payment;
+DROP TABLE t_order_items;
+DROP TABLE t_order;
@@ -0,0 +1,12 @@
+-- 创建幂等键表
+-- 记录创建订单请求的幂等键，利用主键唯一约束保证并发重复请求只有一个能执行
+CREATE TABLE t_idempotency_key (
+    idempotency_key VARCHAR(64) PRIMARY KEY COMMENT '客户端传入的Idempotency-Key',
+    request_hash CHAR(64) NOT NULL COMMENT '请求体摘要(sha256)，用于识别同一个键被不同请求复用',
+    order_id VARCHAR(36) NOT NULL DEFAULT '' COMMENT '创建成功的订单ID',
+    response TEXT COMMENT '首次请求的响应体，重放时原样返回',
+    status VARCHAR(16) NOT NULL COMMENT '状态(processing:处理中 completed:已完成)',
+    created_at TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) COMMENT '创建时间,精确到毫秒',
+    updated_at TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3) COMMENT '更新时间，精确到毫秒',
+    INDEX idx_created_at (created_at)
+)ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='幂等键表';
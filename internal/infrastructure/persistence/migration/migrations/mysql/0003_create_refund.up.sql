@@ -0,0 +1,16 @@
+-- 创建退款表
+-- 一笔支付可以有多次部分退款，退款中和退款成功的累计金额不超过支付金额
+CREATE TABLE t_refund (
+    id VARCHAR(36) PRIMARY KEY COMMENT '主键id，同时作为渠道侧的退款请求号',
+    payment_id VARCHAR(36) NOT NULL COMMENT '关联支付表的ID',
+    order_id VARCHAR(36) NOT NULL COMMENT '关联订单主表的ID',
+    amount BIGINT NOT NULL COMMENT '退款金额，单位：分',
+    status TINYINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '退款状态(0:未知 1:退款中 2:退款成功 3:退款失败)',
+    reason VARCHAR(255) NOT NULL DEFAULT '' COMMENT '退款原因',
+    refund_transaction_id VARCHAR(64) COMMENT '第三方退款交易流水号',
+    created_at TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) COMMENT '创建时间,精确到毫秒',
+    updated_at TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3) COMMENT '更新时间，精确到毫秒',
+    completed_at TIMESTAMP(3) NULL COMMENT '退款完成时间,精确到毫秒',
+    INDEX idx_payment_id (payment_id),
+    INDEX idx_order_id (order_id)
+)ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='退款表';
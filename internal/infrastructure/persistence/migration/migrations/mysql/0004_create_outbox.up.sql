@@ -0,0 +1,17 @@
+-- 创建发件箱表
+-- 领域事件与业务数据在同一个事务内写入，由投递器轮询发布，保证事件不因进程崩溃丢失
+CREATE TABLE t_outbox (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY COMMENT '主键id，投递按id顺序进行',
+    event_id VARCHAR(36) NOT NULL COMMENT '事件唯一ID，下游按此去重',
+    event_name VARCHAR(64) NOT NULL COMMENT '事件名称，如order.created',
+    aggregate_id VARCHAR(36) NOT NULL COMMENT '事件所属聚合ID',
+    payload TEXT NOT NULL COMMENT '事件内容(json)',
+    status VARCHAR(16) NOT NULL DEFAULT 'pending' COMMENT '状态(pending:待投递 delivered:已投递 dead:超过重试次数)',
+    attempts INT NOT NULL DEFAULT 0 COMMENT '失败次数',
+    last_error VARCHAR(1024) NOT NULL DEFAULT '' COMMENT '最近一次投递失败原因',
+    next_attempt_at TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) COMMENT '下次投递时间,精确到毫秒',
+    created_at TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) COMMENT '创建时间,精确到毫秒',
+    delivered_at TIMESTAMP(3) NULL COMMENT '投递成功时间,精确到毫秒',
+    UNIQUE KEY uk_event_id (event_id),
+    INDEX idx_status_next_attempt (status, next_attempt_at)
+)ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COMMENT='发件箱表';
@@ -0,0 +1,7 @@
+-- 创建租约表
+-- 定时任务等多实例互斥场景使用，持有者定期续期，宕机后租约到期由其他实例接管
+CREATE TABLE t_lease (
+    name VARCHAR(64) PRIMARY KEY COMMENT '租约名称，如定时任务名称',
+    owner VARCHAR(128) NOT NULL COMMENT '持有者标识(主机名-进程号-随机串)',
+    expires_at TIMESTAMP(3) NOT NULL COMMENT '租约过期时间,精确到毫秒'
+)ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='租约表';
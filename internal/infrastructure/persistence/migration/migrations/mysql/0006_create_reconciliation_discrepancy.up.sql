@@ -0,0 +1,19 @@
+-- 创建对账差异表
+-- 对账发现本地支付数据与渠道不一致时写入，供财务核查处理
+CREATE TABLE t_reconciliation_discrepancy (
+    id VARCHAR(36) PRIMARY KEY COMMENT '主键id',
+    source VARCHAR(32) NOT NULL COMMENT '差异来源(gateway_query:主动查询渠道)',
+    type VARCHAR(32) NOT NULL COMMENT '差异类型(status_mismatch:状态不一致 order_conflict:订单无法同步支付结果)',
+    payment_id VARCHAR(36) NOT NULL DEFAULT '' COMMENT '关联支付表的ID',
+    order_id VARCHAR(36) NOT NULL DEFAULT '' COMMENT '关联订单主表的ID',
+    transaction_id VARCHAR(64) NOT NULL DEFAULT '' COMMENT '第三方交易流水号',
+    local_status VARCHAR(32) NOT NULL DEFAULT '' COMMENT '本地状态',
+    remote_status VARCHAR(32) NOT NULL DEFAULT '' COMMENT '渠道状态',
+    local_amount BIGINT NOT NULL DEFAULT 0 COMMENT '本地金额，单位：分',
+    remote_amount BIGINT NOT NULL DEFAULT 0 COMMENT '渠道金额，单位：分',
+    detail VARCHAR(255) NOT NULL DEFAULT '' COMMENT '差异说明',
+    status VARCHAR(16) NOT NULL DEFAULT 'open' COMMENT '处理状态(open:待核查 resolved:已处理)',
+    created_at TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) COMMENT '创建时间,精确到毫秒',
+    INDEX idx_status_created (status, created_at),
+    INDEX idx_order_id (order_id)
+)ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='对账差异表';
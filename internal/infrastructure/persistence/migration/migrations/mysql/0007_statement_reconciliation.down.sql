@@ -0,0 +1,11 @@
+ALTER TABLE t_reconciliation_discrepancy
+    DROP INDEX idx_bill_date,
+    DROP COLUMN bill_date,
+    MODIFY COLUMN source VARCHAR(32) NOT NULL COMMENT '差异来源(gateway_query:主动查询渠道)',
+    MODIFY COLUMN type VARCHAR(32) NOT NULL COMMENT '差异类型(status_mismatch:状态不一致 order_conflict:订单无法同步支付结果)';
+
+ALTER TABLE t_refund DROP INDEX idx_completed_at;
+
+ALTER TABLE t_payment
+    DROP INDEX idx_channel_completed,
+    MODIFY COLUMN channel TINYINT UNSIGNED NOT NULL COMMENT '支付渠道(1:支付宝 2:微信 3:银行卡)';
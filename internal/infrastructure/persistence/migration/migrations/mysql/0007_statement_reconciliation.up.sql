@@ -0,0 +1,12 @@
+-- 渠道对账单对账：按渠道和完成时间查询支付单、按完成时间查询退款单，差异记录账单日
+ALTER TABLE t_payment
+    MODIFY COLUMN channel TINYINT UNSIGNED NOT NULL COMMENT '支付渠道(1:支付宝 2:微信 3:银联 4:ApplePay 5:京东支付)',
+    ADD INDEX idx_channel_completed (channel, completed_at);
+
+ALTER TABLE t_refund ADD INDEX idx_completed_at (completed_at);
+
+ALTER TABLE t_reconciliation_discrepancy
+    MODIFY COLUMN source VARCHAR(32) NOT NULL COMMENT '差异来源(gateway_query:主动查询渠道 statement:渠道对账单)',
+    MODIFY COLUMN type VARCHAR(32) NOT NULL COMMENT '差异类型(status_mismatch:状态不一致 order_conflict:订单无法同步支付结果 amount_mismatch:金额不一致 missing_local:本地缺失 missing_remote:对账单缺失)',
+    ADD COLUMN bill_date DATE NULL COMMENT '对账单账单日，仅对账单差异有值' AFTER transaction_id,
+    ADD INDEX idx_bill_date (bill_date);
@@ -0,0 +1,17 @@
+ALTER TABLE t_payment
+    DROP COLUMN rate_quoted_at,
+    DROP COLUMN rate_source,
+    DROP COLUMN exchange_rate,
+    DROP COLUMN order_currency,
+    DROP COLUMN order_amount,
+    MODIFY COLUMN currency CHAR(3) NOT NULL DEFAULT 'CNY' COMMENT '货币类型,如CNY/USD/EUR',
+    MODIFY COLUMN amount BIGINT NOT NULL COMMENT '支付金额，单位：分';
+
+ALTER TABLE t_order_items
+    DROP COLUMN currency,
+    MODIFY COLUMN unit_price BIGINT(20) NOT NULL COMMENT '商品单价，单位：分',
+    MODIFY COLUMN subtotal BIGINT(20) NOT NULL COMMENT '商品小计金额，单位：分';
+
+ALTER TABLE t_order
+    DROP COLUMN currency,
+    MODIFY COLUMN total_amount BIGINT(20) NOT NULL COMMENT '订单总金额，单位：分';
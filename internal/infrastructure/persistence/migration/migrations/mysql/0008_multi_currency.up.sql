@@ -0,0 +1,18 @@
+-- 多币种订单：订单和订单项记录计价币种，支付单记录下单时的订单金额和汇率快照
+ALTER TABLE t_order
+    ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'CNY' COMMENT '订单计价币种,如CNY/USD，订单项币种与之一致' AFTER status,
+    MODIFY COLUMN total_amount BIGINT(20) NOT NULL COMMENT '订单总金额，单位：订单币种的最小单位';
+
+ALTER TABLE t_order_items
+    ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'CNY' COMMENT '计价币种，与订单币种一致' AFTER quantity,
+    MODIFY COLUMN unit_price BIGINT(20) NOT NULL COMMENT '商品单价，单位：币种的最小单位',
+    MODIFY COLUMN subtotal BIGINT(20) NOT NULL COMMENT '商品小计金额，单位：币种的最小单位';
+
+ALTER TABLE t_payment
+    MODIFY COLUMN amount BIGINT NOT NULL COMMENT '支付金额，单位：支付币种的最小单位',
+    MODIFY COLUMN currency CHAR(3) NOT NULL DEFAULT 'CNY' COMMENT '支付币种(渠道收款币种),如CNY/USD/EUR',
+    ADD COLUMN order_amount BIGINT NOT NULL DEFAULT 0 COMMENT '订单金额，单位：订单币种的最小单位，仅支付币种与订单币种不同时有值' AFTER currency,
+    ADD COLUMN order_currency CHAR(3) NOT NULL DEFAULT '' COMMENT '订单币种，仅支付币种与订单币种不同时有值' AFTER order_amount,
+    ADD COLUMN exchange_rate VARCHAR(32) NOT NULL DEFAULT '' COMMENT '下单时使用的汇率快照，1单位订单币种兑换的支付币种数量' AFTER order_currency,
+    ADD COLUMN rate_source VARCHAR(64) NOT NULL DEFAULT '' COMMENT '汇率来源' AFTER exchange_rate,
+    ADD COLUMN rate_quoted_at TIMESTAMP(3) NULL COMMENT '汇率生效时间,精确到毫秒' AFTER rate_source;
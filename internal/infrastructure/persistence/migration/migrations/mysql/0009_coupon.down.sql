@@ -0,0 +1,10 @@
+DROP TABLE t_coupon_customer_usage;
+DROP TABLE t_coupon_usage;
+DROP TABLE t_coupon;
+
+ALTER TABLE t_order_items DROP COLUMN discount;
+
+ALTER TABLE t_order
+    DROP COLUMN coupon_ids,
+    DROP COLUMN discount_amount,
+    MODIFY COLUMN total_amount BIGINT(20) NOT NULL COMMENT '订单总金额，单位：订单币种的最小单位';
@@ -0,0 +1,59 @@
+-- 优惠券：订单记录优惠金额和使用的优惠券，订单项记录分摊的优惠金额
+ALTER TABLE t_order
+    MODIFY COLUMN total_amount BIGINT(20) NOT NULL COMMENT '订单应付金额(商品小计之和减去优惠金额)，单位：订单币种的最小单位',
+    ADD COLUMN discount_amount BIGINT(20) NOT NULL DEFAULT 0 COMMENT '订单优惠金额，等于各订单项分摊的优惠之和' AFTER total_amount,
+    ADD COLUMN coupon_ids VARCHAR(512) NULL COMMENT '使用的优惠券ID列表(json)，未使用优惠券时为NULL' AFTER discount_amount;
+
+ALTER TABLE t_order_items
+    ADD COLUMN discount BIGINT(20) NOT NULL DEFAULT 0 COMMENT '分摊到该订单项的优惠金额，部分退款时按比例计算可退金额' AFTER subtotal;
+
+-- 创建优惠券表
+-- 优惠券定义，金额字段均为 currency 币种的最小单位
+CREATE TABLE t_coupon (
+    id VARCHAR(36) PRIMARY KEY COMMENT '主键id',
+    code VARCHAR(64) NOT NULL COMMENT '券码，下单时客户端传入',
+    name VARCHAR(128) NOT NULL DEFAULT '' COMMENT '优惠券名称',
+    type VARCHAR(16) NOT NULL COMMENT '类型(fixed:立减 percentage:折扣 threshold:满减 buy_n_get_m:买N送M)',
+    currency CHAR(3) NOT NULL DEFAULT '' COMMENT '金额类优惠的币种，只能用于同币种订单',
+    amount BIGINT NOT NULL DEFAULT 0 COMMENT '立减、满减的优惠金额',
+    percent_off INT NOT NULL DEFAULT 0 COMMENT '折扣比例，单位：万分之一',
+    max_discount BIGINT NOT NULL DEFAULT 0 COMMENT '折扣券最高优惠金额，0表示不限',
+    threshold BIGINT NOT NULL DEFAULT 0 COMMENT '满减门槛',
+    buy_n INT NOT NULL DEFAULT 0 COMMENT '买N送M：每购买N件',
+    get_m INT NOT NULL DEFAULT 0 COMMENT '买N送M：赠送M件',
+    product_ids TEXT COMMENT '适用商品ID列表(json)，为空表示全部商品',
+    customer_ids TEXT COMMENT '可用客户ID列表(json)，为空表示全部客户',
+    per_customer_limit INT NOT NULL DEFAULT 0 COMMENT '每个客户可使用次数，0表示不限',
+    exclusive TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否独占，独占券不能与其他优惠券叠加',
+    start_at TIMESTAMP(3) NOT NULL COMMENT '生效时间(含)',
+    end_at TIMESTAMP(3) NULL COMMENT '失效时间(不含)，为空表示长期有效',
+    status VARCHAR(16) NOT NULL DEFAULT 'active' COMMENT '状态(active:可用 disabled:已停用)',
+    created_at TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) COMMENT '创建时间,精确到毫秒',
+    updated_at TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3) COMMENT '更新时间，精确到毫秒',
+    UNIQUE KEY uk_code (code)
+)ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='优惠券表';
+
+-- 创建优惠券使用记录表
+-- 一个订单对同一张优惠券只有一条记录，订单取消后释放
+CREATE TABLE t_coupon_usage (
+    coupon_id VARCHAR(36) NOT NULL COMMENT '关联优惠券表的ID',
+    order_id VARCHAR(36) NOT NULL COMMENT '关联订单主表的ID',
+    customer_id VARCHAR(36) NOT NULL COMMENT '客户id',
+    currency CHAR(3) NOT NULL DEFAULT 'CNY' COMMENT '订单币种',
+    discount BIGINT NOT NULL DEFAULT 0 COMMENT '该优惠券在订单上的优惠金额',
+    status VARCHAR(16) NOT NULL COMMENT '状态(redeemed:已占用 released:已释放)',
+    created_at TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) COMMENT '创建时间,精确到毫秒',
+    updated_at TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3) COMMENT '更新时间，精确到毫秒',
+    PRIMARY KEY (coupon_id, order_id),
+    INDEX idx_order_id (order_id)
+)ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='优惠券使用记录表';
+
+-- 创建客户优惠券使用次数表
+-- 占用时带上限条件自增，保证并发下单时每个客户的使用次数不超过上限
+CREATE TABLE t_coupon_customer_usage (
+    coupon_id VARCHAR(36) NOT NULL COMMENT '关联优惠券表的ID',
+    customer_id VARCHAR(36) NOT NULL COMMENT '客户id',
+    used_count INT NOT NULL DEFAULT 0 COMMENT '已占用次数',
+    updated_at TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3) COMMENT '更新时间，精确到毫秒',
+    PRIMARY KEY (coupon_id, customer_id)
+)ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='客户优惠券使用次数表';
@@ -0,0 +1,4 @@
+DROP TABLE t_inventory_reservation;
+DROP TABLE t_inventory;
+
+ALTER TABLE t_order_items DROP COLUMN reservation_id;
@@ -0,0 +1,28 @@
+-- 库存预占：下单时预占库存，订单项记录对应的预占记录
+ALTER TABLE t_order_items
+    ADD COLUMN reservation_id VARCHAR(36) NOT NULL DEFAULT '' COMMENT '库存预占ID，关联库存预占表的ID' AFTER discount;
+
+-- 创建库存表
+-- available 为可售库存，reserved 为已预占未确认的库存，扣减时带条件更新防止超卖
+CREATE TABLE t_inventory (
+    product_id VARCHAR(36) PRIMARY KEY COMMENT '商品id',
+    available BIGINT NOT NULL DEFAULT 0 COMMENT '可售库存',
+    reserved BIGINT NOT NULL DEFAULT 0 COMMENT '已预占库存',
+    updated_at TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3) COMMENT '更新时间，精确到毫秒'
+)ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='库存表';
+
+-- 创建库存预占表
+-- 一个订单对同一商品只有一条预占记录，重复预占、确认、释放均幂等
+CREATE TABLE t_inventory_reservation (
+    id VARCHAR(36) PRIMARY KEY COMMENT '主键id',
+    order_id VARCHAR(36) NOT NULL COMMENT '关联订单主表的ID',
+    product_id VARCHAR(36) NOT NULL COMMENT '商品id',
+    quantity BIGINT NOT NULL COMMENT '预占数量',
+    status VARCHAR(16) NOT NULL COMMENT '状态(reserved:已预占 confirmed:已确认 released:已释放)',
+    created_at TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) COMMENT '创建时间,精确到毫秒',
+    updated_at TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3) COMMENT '更新时间，精确到毫秒',
+    UNIQUE KEY uk_order_product (order_id, product_id)
+)ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='库存预占表';
+
+-- 初始化示例商品库存
+INSERT INTO t_inventory (product_id, available, reserved) VALUES ('P001', 1000, 0), ('P002', 1000, 0);
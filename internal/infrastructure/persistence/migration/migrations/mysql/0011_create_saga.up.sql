@@ -0,0 +1,15 @@
+-- 创建saga实例表
+-- 记录下单、发起支付等跨服务流程的执行进度，进程崩溃或补偿失败后由恢复任务继续执行
+CREATE TABLE t_saga (
+    id VARCHAR(36) PRIMARY KEY COMMENT '主键id',
+    name VARCHAR(64) NOT NULL COMMENT 'saga名称(create_order:下单 pay_order:发起支付)',
+    biz_id VARCHAR(36) NOT NULL DEFAULT '' COMMENT '业务ID，如订单ID',
+    status VARCHAR(16) NOT NULL COMMENT '状态(running:执行中 compensating:补偿中 completed:已完成 compensated:已补偿)',
+    step INT NOT NULL DEFAULT 0 COMMENT '已执行成功且尚未补偿的步骤数',
+    data TEXT NOT NULL COMMENT '步骤间共享的业务数据(json)',
+    last_error VARCHAR(1024) NOT NULL DEFAULT '' COMMENT '最近一次失败原因',
+    created_at TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) COMMENT '创建时间,精确到毫秒',
+    updated_at TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3) COMMENT '更新时间，精确到毫秒',
+    INDEX idx_biz_id (biz_id),
+    INDEX idx_status_updated_at (status, updated_at)
+)ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='saga实例表';
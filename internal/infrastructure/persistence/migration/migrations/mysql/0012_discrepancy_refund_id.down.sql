@@ -0,0 +1 @@
+ALTER TABLE t_reconciliation_discrepancy DROP COLUMN refund_id;
@@ -0,0 +1,44 @@
+-- SQLite 初始表结构，与 mysql/0001_init.up.sql 一一对应，之后的版本也与MySQL迁移版本号一致
+-- SQLite 没有 ENUM，状态字段使用 CHECK 约束；没有 ON UPDATE CURRENT_TIMESTAMP，updated_at 均由应用写入
+-- 时间字段声明为 TIMESTAMP，驱动读写时与 time.Time 互相转换
+
+-- 订单主表
+CREATE TABLE t_order (
+    id VARCHAR(36) PRIMARY KEY,
+    customer_id VARCHAR(36) NOT NULL,
+    status VARCHAR(16) NOT NULL CHECK (status IN ('unknown', 'created', 'pending', 'paid', 'shipped', 'completed', 'cancelled')),
+    total_amount BIGINT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    version BIGINT NOT NULL DEFAULT 0
+);
+CREATE INDEX idx_order_customer_id ON t_order (customer_id);
+CREATE INDEX idx_order_status ON t_order (status);
+
+-- 订单项表
+CREATE TABLE t_order_items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    order_id VARCHAR(36) NOT NULL,
+    product_id VARCHAR(36) NOT NULL,
+    quantity BIGINT NOT NULL,
+    unit_price BIGINT NOT NULL,
+    subtotal BIGINT NOT NULL
+);
+CREATE INDEX idx_order_items_order_id ON t_order_items (order_id);
+
+-- 支付表
+CREATE TABLE t_payment (
+    id VARCHAR(36) PRIMARY KEY,
+    order_id VARCHAR(36) NOT NULL,
+    amount BIGINT NOT NULL,
+    currency CHAR(3) NOT NULL DEFAULT 'CNY',
+    channel SMALLINT NOT NULL,
+    status SMALLINT NOT NULL DEFAULT 0,
+    transaction_id VARCHAR(64),
+    refund_transaction_id VARCHAR(64),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP NULL
+);
+CREATE INDEX idx_payment_order_id ON t_payment (order_id);
+CREATE INDEX idx_payment_status_updated ON t_payment (status, updated_at);
@@ -0,0 +1 @@
+DROP TABLE t_idempotency_key;
@@ -0,0 +1,11 @@
+-- 幂等键表
+CREATE TABLE t_idempotency_key (
+    idempotency_key VARCHAR(64) PRIMARY KEY,
+    request_hash CHAR(64) NOT NULL,
+    order_id VARCHAR(36) NOT NULL DEFAULT '',
+    response TEXT,
+    status VARCHAR(16) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_idempotency_key_created_at ON t_idempotency_key (created_at);
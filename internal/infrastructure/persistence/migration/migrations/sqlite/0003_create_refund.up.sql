@@ -0,0 +1,15 @@
+-- 退款表
+CREATE TABLE t_refund (
+    id VARCHAR(36) PRIMARY KEY,
+    payment_id VARCHAR(36) NOT NULL,
+    order_id VARCHAR(36) NOT NULL,
+    amount BIGINT NOT NULL,
+    status SMALLINT NOT NULL DEFAULT 0,
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    refund_transaction_id VARCHAR(64),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP NULL
+);
+CREATE INDEX idx_refund_payment_id ON t_refund (payment_id);
+CREATE INDEX idx_refund_order_id ON t_refund (order_id);
@@ -0,0 +1,6 @@
+-- 租约表
+CREATE TABLE t_lease (
+    name VARCHAR(64) PRIMARY KEY,
+    owner VARCHAR(128) NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
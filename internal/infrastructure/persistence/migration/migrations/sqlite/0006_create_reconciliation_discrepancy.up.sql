@@ -0,0 +1,18 @@
+-- 对账差异表
+CREATE TABLE t_reconciliation_discrepancy (
+    id VARCHAR(36) PRIMARY KEY,
+    source VARCHAR(32) NOT NULL,
+    type VARCHAR(32) NOT NULL,
+    payment_id VARCHAR(36) NOT NULL DEFAULT '',
+    order_id VARCHAR(36) NOT NULL DEFAULT '',
+    transaction_id VARCHAR(64) NOT NULL DEFAULT '',
+    local_status VARCHAR(32) NOT NULL DEFAULT '',
+    remote_status VARCHAR(32) NOT NULL DEFAULT '',
+    local_amount BIGINT NOT NULL DEFAULT 0,
+    remote_amount BIGINT NOT NULL DEFAULT 0,
+    detail VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL DEFAULT 'open',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_discrepancy_status_created ON t_reconciliation_discrepancy (status, created_at);
+CREATE INDEX idx_discrepancy_order_id ON t_reconciliation_discrepancy (order_id);
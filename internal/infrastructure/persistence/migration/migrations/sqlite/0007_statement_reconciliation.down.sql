@@ -0,0 +1,4 @@
+DROP INDEX idx_discrepancy_bill_date;
+ALTER TABLE t_reconciliation_discrepancy DROP COLUMN bill_date;
+DROP INDEX idx_refund_completed_at;
+DROP INDEX idx_payment_channel_completed;
@@ -0,0 +1,5 @@
+-- 渠道对账单对账：按渠道和完成时间查询支付单、按完成时间查询退款单，差异记录账单日
+CREATE INDEX idx_payment_channel_completed ON t_payment (channel, completed_at);
+CREATE INDEX idx_refund_completed_at ON t_refund (completed_at);
+ALTER TABLE t_reconciliation_discrepancy ADD COLUMN bill_date TIMESTAMP NULL;
+CREATE INDEX idx_discrepancy_bill_date ON t_reconciliation_discrepancy (bill_date);
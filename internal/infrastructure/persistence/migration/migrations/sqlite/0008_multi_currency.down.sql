@@ -0,0 +1,7 @@
+ALTER TABLE t_payment DROP COLUMN rate_quoted_at;
+ALTER TABLE t_payment DROP COLUMN rate_source;
+ALTER TABLE t_payment DROP COLUMN exchange_rate;
+ALTER TABLE t_payment DROP COLUMN order_currency;
+ALTER TABLE t_payment DROP COLUMN order_amount;
+ALTER TABLE t_order_items DROP COLUMN currency;
+ALTER TABLE t_order DROP COLUMN currency;
@@ -0,0 +1,8 @@
+-- 多币种订单：订单和订单项记录计价币种，支付单记录下单时的订单金额和汇率快照
+ALTER TABLE t_order ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'CNY';
+ALTER TABLE t_order_items ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'CNY';
+ALTER TABLE t_payment ADD COLUMN order_amount BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE t_payment ADD COLUMN order_currency CHAR(3) NOT NULL DEFAULT '';
+ALTER TABLE t_payment ADD COLUMN exchange_rate VARCHAR(32) NOT NULL DEFAULT '';
+ALTER TABLE t_payment ADD COLUMN rate_source VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE t_payment ADD COLUMN rate_quoted_at TIMESTAMP NULL;
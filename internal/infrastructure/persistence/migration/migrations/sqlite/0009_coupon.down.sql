@@ -0,0 +1,6 @@
+DROP TABLE t_coupon_customer_usage;
+DROP TABLE t_coupon_usage;
+DROP TABLE t_coupon;
+ALTER TABLE t_order_items DROP COLUMN discount;
+ALTER TABLE t_order DROP COLUMN coupon_ids;
+ALTER TABLE t_order DROP COLUMN discount_amount;
@@ -0,0 +1,51 @@
+-- 优惠券：订单记录优惠金额和使用的优惠券，订单项记录分摊的优惠金额
+ALTER TABLE t_order ADD COLUMN discount_amount BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE t_order ADD COLUMN coupon_ids VARCHAR(512);
+ALTER TABLE t_order_items ADD COLUMN discount BIGINT NOT NULL DEFAULT 0;
+
+-- 优惠券表
+CREATE TABLE t_coupon (
+    id VARCHAR(36) PRIMARY KEY,
+    code VARCHAR(64) NOT NULL UNIQUE,
+    name VARCHAR(128) NOT NULL DEFAULT '',
+    type VARCHAR(16) NOT NULL,
+    currency CHAR(3) NOT NULL DEFAULT '',
+    amount BIGINT NOT NULL DEFAULT 0,
+    percent_off INT NOT NULL DEFAULT 0,
+    max_discount BIGINT NOT NULL DEFAULT 0,
+    threshold BIGINT NOT NULL DEFAULT 0,
+    buy_n INT NOT NULL DEFAULT 0,
+    get_m INT NOT NULL DEFAULT 0,
+    product_ids TEXT,
+    customer_ids TEXT,
+    per_customer_limit INT NOT NULL DEFAULT 0,
+    exclusive BOOLEAN NOT NULL DEFAULT 0,
+    start_at TIMESTAMP NOT NULL,
+    end_at TIMESTAMP NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'active',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 优惠券使用记录表
+CREATE TABLE t_coupon_usage (
+    coupon_id VARCHAR(36) NOT NULL,
+    order_id VARCHAR(36) NOT NULL,
+    customer_id VARCHAR(36) NOT NULL,
+    currency CHAR(3) NOT NULL DEFAULT 'CNY',
+    discount BIGINT NOT NULL DEFAULT 0,
+    status VARCHAR(16) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (coupon_id, order_id)
+);
+CREATE INDEX idx_coupon_usage_order_id ON t_coupon_usage (order_id);
+
+-- 客户优惠券使用次数表
+CREATE TABLE t_coupon_customer_usage (
+    coupon_id VARCHAR(36) NOT NULL,
+    customer_id VARCHAR(36) NOT NULL,
+    used_count INT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (coupon_id, customer_id)
+);
@@ -0,0 +1,3 @@
+DROP TABLE t_inventory_reservation;
+DROP TABLE t_inventory;
+ALTER TABLE t_order_items DROP COLUMN reservation_id;
@@ -0,0 +1,25 @@
+-- 库存预占：下单时预占库存，订单项记录对应的预占记录
+ALTER TABLE t_order_items ADD COLUMN reservation_id VARCHAR(36) NOT NULL DEFAULT '';
+
+-- 库存表
+CREATE TABLE t_inventory (
+    product_id VARCHAR(36) PRIMARY KEY,
+    available BIGINT NOT NULL DEFAULT 0,
+    reserved BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 库存预占表
+CREATE TABLE t_inventory_reservation (
+    id VARCHAR(36) PRIMARY KEY,
+    order_id VARCHAR(36) NOT NULL,
+    product_id VARCHAR(36) NOT NULL,
+    quantity BIGINT NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (order_id, product_id)
+);
+
+-- 初始化示例商品库存
+INSERT INTO t_inventory (product_id, available, reserved) VALUES ('P001', 1000, 0), ('P002', 1000, 0);
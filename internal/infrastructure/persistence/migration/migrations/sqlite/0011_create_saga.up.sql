@@ -0,0 +1,14 @@
+-- saga实例表
+CREATE TABLE t_saga (
+    id VARCHAR(36) PRIMARY KEY,
+    name VARCHAR(64) NOT NULL,
+    biz_id VARCHAR(36) NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL,
+    step INT NOT NULL DEFAULT 0,
+    data TEXT NOT NULL,
+    last_error VARCHAR(1024) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_saga_biz_id ON t_saga (biz_id);
+CREATE INDEX idx_saga_status_updated_at ON t_saga (status, updated_at);
@@ -0,0 +1,2 @@
+-- 退款差异单独记录退款单ID，payment_id 始终为支付表的ID
+ALTER TABLE t_reconciliation_discrepancy ADD COLUMN refund_id VARCHAR(36) NOT NULL DEFAULT '';
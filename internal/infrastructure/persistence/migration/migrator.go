@@ -0,0 +1,350 @@
+package migration
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/lock"
+	"gorm.io/gorm"
+)
+
+// 迁移记录表和迁移锁由迁移器自行创建，不依赖任何迁移脚本
+const (
+	migrationsTable = "t_schema_migrations"
+	lockTable       = "t_schema_migration_lock"
+	lockName        = "schema_migration"
+	lockTTL         = 10 * time.Minute
+)
+
+// bootstrapStatements 创建迁移记录表和迁移锁表，只使用MySQL和SQLite都支持的语法
+var bootstrapStatements = []string{
+	`CREATE TABLE IF NOT EXISTS t_schema_migrations (
+    version BIGINT NOT NULL PRIMARY KEY,
+    name VARCHAR(128) NOT NULL,
+    checksum CHAR(64) NOT NULL,
+    dirty BOOLEAN NOT NULL DEFAULT FALSE,
+    applied_at DATETIME NOT NULL
+)`,
+	`CREATE TABLE IF NOT EXISTS t_schema_migration_lock (
+    name VARCHAR(64) PRIMARY KEY,
+    owner VARCHAR(128) NOT NULL,
+    expires_at DATETIME NOT NULL
+)`,
+}
+
+var (
+	// ErrLocked 其他进程正在执行迁移
+	ErrLocked = errors.New("其他进程正在执行数据库迁移")
+	// ErrDirty 有迁移上次执行到一半失败，需人工修复
+	ErrDirty = errors.New("数据库迁移处于未完成状态")
+	// ErrChecksumMismatch 已执行的迁移脚本被修改
+	ErrChecksumMismatch = errors.New("已执行的迁移脚本被修改")
+	// ErrUnknownVersion 数据库中有当前程序不认识的迁移版本，通常是数据库已被更新版本的程序迁移
+	ErrUnknownVersion = errors.New("数据库中有未知的迁移版本")
+)
+
+// State 迁移状态
+type State string
+
+const (
+	StatePending  State = "pending"  // 未执行
+	StateApplied  State = "applied"  // 已执行
+	StateDirty    State = "dirty"    // 上次执行失败，需人工修复
+	StateModified State = "modified" // 已执行但脚本被修改
+	StateUnknown  State = "unknown"  // 数据库中已执行，但当前程序没有该版本的脚本
+)
+
+// Status 单个迁移版本的状态
+type Status struct {
+	Version   int64
+	Name      string
+	State     State
+	AppliedAt *time.Time
+}
+
+// appliedMigration 迁移记录
+type appliedMigration struct {
+	Version   int64     `gorm:"column:version;primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"column:name"`
+	Checksum  string    `gorm:"column:checksum"`
+	Dirty     bool      `gorm:"column:dirty"`
+	AppliedAt time.Time `gorm:"column:applied_at"`
+}
+
+// TableName 指定模型对应的数据库表名
+func (appliedMigration) TableName() string {
+	return migrationsTable
+}
+
+// Migrator 迁移执行器
+// 每个版本在一个事务内执行并写入迁移记录；MySQL的DDL会隐式提交事务，执行失败时已生效的语句无法回滚，
+// 迁移记录保留为 dirty，之后的迁移拒绝执行，需人工修复数据库后更正该记录
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+	locker     lock.Locker
+	now        func() time.Time
+}
+
+// New 创建使用内置迁移脚本的执行器，driver 为 database.DriverMySQL 或 database.DriverSQLite
+func New(db *gorm.DB, driver string) (*Migrator, error) {
+	migrations, err := Embedded(driver)
+	if err != nil {
+		return nil, err
+	}
+	return NewMigrator(db, migrations), nil
+}
+
+// NewMigrator 创建迁移执行器，migrations 需按版本号升序
+func NewMigrator(db *gorm.DB, migrations []Migration) *Migrator {
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+		locker:     lock.NewGormLockerOnTable(db, lockTable),
+		now:        time.Now,
+	}
+}
+
+// Up 按版本号升序执行全部未执行的迁移，返回本次执行的迁移
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var executed []Migration
+	err := m.withLock(ctx, func(applied map[int64]appliedMigration) error {
+		var latest int64
+		for version := range applied {
+			latest = max(latest, version)
+		}
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+			if mig.Version < latest {
+				return fmt.Errorf("迁移版本%d低于已执行的版本%d，不能补执行", mig.Version, latest)
+			}
+			if err := m.renewLock(ctx); err != nil {
+				return err
+			}
+			if err := m.apply(ctx, mig); err != nil {
+				return err
+			}
+			executed = append(executed, mig)
+		}
+		return nil
+	})
+	return executed, err
+}
+
+// Down 按版本号倒序回滚最近执行的 steps 个迁移，返回本次回滚的迁移
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.withLock(ctx, func(applied map[int64]appliedMigration) error {
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			mig := m.migrations[i]
+			if _, ok := applied[mig.Version]; !ok {
+				continue
+			}
+			if mig.Down == "" {
+				return fmt.Errorf("迁移版本%d没有回滚脚本", mig.Version)
+			}
+			if err := m.renewLock(ctx); err != nil {
+				return err
+			}
+			if err := m.revert(ctx, mig); err != nil {
+				return err
+			}
+			reverted = append(reverted, mig)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Baseline 把版本号不超过 version 的迁移记录为已执行但不执行脚本，返回本次记录的迁移
+// 用于接入迁移前已按旧版建表脚本建好表的数据库，只能在没有任何迁移记录时执行
+func (m *Migrator) Baseline(ctx context.Context, version int64) ([]Migration, error) {
+	if !slices.ContainsFunc(m.migrations, func(mig Migration) bool { return mig.Version == version }) {
+		return nil, fmt.Errorf("迁移版本%d不存在", version)
+	}
+
+	var recorded []Migration
+	err := m.withLock(ctx, func(applied map[int64]appliedMigration) error {
+		if len(applied) > 0 {
+			return errors.New("数据库已有迁移记录，不能设置基线")
+		}
+		return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			for _, mig := range m.migrations {
+				if mig.Version > version {
+					break
+				}
+				record := &appliedMigration{Version: mig.Version, Name: mig.Name, Checksum: mig.Checksum, AppliedAt: m.now()}
+				if err := tx.Create(record).Error; err != nil {
+					return fmt.Errorf("写入迁移记录失败: %w", err)
+				}
+				recorded = append(recorded, mig)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return recorded, nil
+}
+
+// Status 返回全部迁移版本的状态，按版本号升序，不修改数据库
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied := make(map[int64]appliedMigration)
+	if m.db.WithContext(ctx).Migrator().HasTable(migrationsTable) {
+		var err error
+		if applied, err = m.loadApplied(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		status := Status{Version: mig.Version, Name: mig.Name, State: StatePending}
+		if record, ok := applied[mig.Version]; ok {
+			status.AppliedAt = &record.AppliedAt
+			status.State = recordState(record, mig)
+			delete(applied, mig.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, record := range applied {
+		statuses = append(statuses, Status{Version: record.Version, Name: record.Name, State: StateUnknown, AppliedAt: &record.AppliedAt})
+	}
+	slices.SortFunc(statuses, func(a, b Status) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+	return statuses, nil
+}
+
+// withLock 创建迁移表并获取迁移锁，校验已执行的迁移后执行 fn
+func (m *Migrator) withLock(ctx context.Context, fn func(applied map[int64]appliedMigration) error) error {
+	for _, stmt := range bootstrapStatements {
+		if err := m.db.WithContext(ctx).Exec(stmt).Error; err != nil {
+			return fmt.Errorf("创建迁移记录表失败: %w", err)
+		}
+	}
+
+	if err := m.renewLock(ctx); err != nil {
+		return err
+	}
+	defer func() {
+		// 使用独立的 ctx，调用方取消后仍然释放锁
+		if err := m.locker.Release(context.WithoutCancel(ctx), lockName); err != nil {
+			slog.Warn("释放迁移锁失败", "error", err)
+		}
+	}()
+
+	applied, err := m.loadApplied(ctx)
+	if err != nil {
+		return err
+	}
+	if err := m.validate(applied); err != nil {
+		return err
+	}
+	return fn(applied)
+}
+
+// renewLock 获取或续期迁移锁，每执行一个版本前续期，长时间的迁移不会被其他进程接管
+func (m *Migrator) renewLock(ctx context.Context) error {
+	acquired, err := m.locker.TryAcquire(ctx, lockName, lockTTL)
+	if err != nil {
+		return fmt.Errorf("获取迁移锁失败: %w", err)
+	}
+	if !acquired {
+		return ErrLocked
+	}
+	return nil
+}
+
+// loadApplied 查询迁移记录
+func (m *Migrator) loadApplied(ctx context.Context) (map[int64]appliedMigration, error) {
+	var records []appliedMigration
+	if err := m.db.WithContext(ctx).Order("version").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("查询迁移记录失败: %w", err)
+	}
+	applied := make(map[int64]appliedMigration, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// validate 校验已执行的迁移：不能有未完成的迁移，脚本不能被修改，不能有未知版本
+func (m *Migrator) validate(applied map[int64]appliedMigration) error {
+	known := make(map[int64]Migration, len(m.migrations))
+	for _, mig := range m.migrations {
+		known[mig.Version] = mig
+	}
+	for _, record := range applied {
+		mig, ok := known[record.Version]
+		if !ok {
+			return fmt.Errorf("%w: %d_%s", ErrUnknownVersion, record.Version, record.Name)
+		}
+		switch recordState(record, mig) {
+		case StateDirty:
+			return fmt.Errorf("%w: 版本%d_%s执行失败，需人工修复数据库后更正 %s 中的该条记录",
+				ErrDirty, record.Version, record.Name, migrationsTable)
+		case StateModified:
+			return fmt.Errorf("%w: %d_%s", ErrChecksumMismatch, record.Version, record.Name)
+		}
+	}
+	return nil
+}
+
+// apply 执行升级脚本，先写入 dirty 记录，全部语句成功后清除 dirty 标记
+func (m *Migrator) apply(ctx context.Context, mig Migration) error {
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		record := &appliedMigration{Version: mig.Version, Name: mig.Name, Checksum: mig.Checksum, Dirty: true, AppliedAt: m.now()}
+		if err := tx.Create(record).Error; err != nil {
+			return fmt.Errorf("写入迁移记录失败: %w", err)
+		}
+		if err := execScript(tx, mig.Up); err != nil {
+			return fmt.Errorf("执行迁移%d_%s失败: %w", mig.Version, mig.Name, err)
+		}
+		return tx.Model(record).Update("dirty", false).Error
+	})
+}
+
+// revert 执行回滚脚本，先把记录标记为 dirty，全部语句成功后删除记录
+func (m *Migrator) revert(ctx context.Context, mig Migration) error {
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		record := &appliedMigration{Version: mig.Version}
+		if err := tx.Model(record).Update("dirty", true).Error; err != nil {
+			return fmt.Errorf("更新迁移记录失败: %w", err)
+		}
+		if err := execScript(tx, mig.Down); err != nil {
+			return fmt.Errorf("回滚迁移%d_%s失败: %w", mig.Version, mig.Name, err)
+		}
+		return tx.Delete(record).Error
+	})
+}
+
+// execScript 逐条执行脚本中的语句
+func execScript(tx *gorm.DB, script string) error {
+	for _, stmt := range splitStatements(script) {
+		if err := tx.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordState 根据迁移记录和脚本判断已执行迁移的状态
+func recordState(record appliedMigration, mig Migration) State {
+	switch {
+	case record.Dirty:
+		return StateDirty
+	case record.Checksum != mig.Checksum:
+		return StateModified
+	default:
+		return StateApplied
+	}
+}
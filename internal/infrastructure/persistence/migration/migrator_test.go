@@ -0,0 +1,284 @@
+package migration
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/lock"
+	"github.com/vaynedu/ddd_order_example/pkg/database"
+	"gorm.io/gorm"
+)
+
+func openSQLite(t *testing.T) *gorm.DB {
+	db, err := database.InitSQLite(context.Background(), database.SQLiteDSN(filepath.Join(t.TempDir(), "migrate.db")))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// testMigrations 两个版本的迁移：建表、加列并写入数据
+func testMigrations(t *testing.T) []Migration {
+	migrations, err := Load(fstest.MapFS{
+		"0001_create_item.up.sql": {Data: []byte(`-- 创建测试表
+CREATE TABLE t_item (
+    id VARCHAR(36) PRIMARY KEY
+);
+`)},
+		"0001_create_item.down.sql": {Data: []byte("DROP TABLE t_item;\n")},
+		"0002_add_name.up.sql": {Data: []byte(`ALTER TABLE t_item ADD COLUMN name VARCHAR(64) NOT NULL DEFAULT '';
+INSERT INTO t_item (id, name) VALUES ('a', 'x;y');
+`)},
+		"0002_add_name.down.sql": {Data: []byte("ALTER TABLE t_item DROP COLUMN name;\n")},
+	})
+	require.NoError(t, err)
+	return migrations
+}
+
+func states(t *testing.T, m *Migrator) []State {
+	statuses, err := m.Status(context.Background())
+	require.NoError(t, err)
+	var result []State
+	for _, status := range statuses {
+		result = append(result, status.State)
+	}
+	return result
+}
+
+func TestLoad(t *testing.T) {
+	migrations := testMigrations(t)
+	require.Len(t, migrations, 2)
+	assert.Equal(t, int64(1), migrations[0].Version)
+	assert.Equal(t, "create_item", migrations[0].Name)
+	assert.NotEmpty(t, migrations[0].Down)
+	assert.Len(t, migrations[0].Checksum, 64)
+	assert.Equal(t, int64(2), migrations[1].Version)
+
+	_, err := Load(fstest.MapFS{"init.sql": {Data: []byte("SELECT 1;")}})
+	assert.Error(t, err)
+
+	_, err = Load(fstest.MapFS{"0001_init.down.sql": {Data: []byte("SELECT 1;")}})
+	assert.Error(t, err)
+
+	_, err = Load(fstest.MapFS{
+		"0001_a.up.sql": {Data: []byte("SELECT 1;")},
+		"0001_b.up.sql": {Data: []byte("SELECT 1;")},
+	})
+	assert.Error(t, err)
+}
+
+func TestEmbedded(t *testing.T) {
+	for _, driver := range []string{database.DriverMySQL, database.DriverSQLite} {
+		migrations, err := Embedded(driver)
+		require.NoError(t, err, driver)
+		assert.Equal(t, int64(1), migrations[0].Version, driver)
+		for _, m := range migrations {
+			assert.NotEmpty(t, m.Down, "%s 迁移%d缺少回滚脚本", driver, m.Version)
+		}
+	}
+
+	_, err := Embedded("postgres")
+	assert.Error(t, err)
+}
+
+func TestSplitStatements(t *testing.T) {
+	statements := splitStatements(`-- 注释
+CREATE TABLE t (
+    name VARCHAR(8) COMMENT 'a;b'
+);
+
+INSERT INTO t VALUES ('x');
+SELECT 1`)
+	assert.Equal(t, []string{
+		"CREATE TABLE t (\n    name VARCHAR(8) COMMENT 'a;b'\n);",
+		"INSERT INTO t VALUES ('x');",
+		"SELECT 1",
+	}, statements)
+}
+
+func TestMigrator_UpDown(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+	m := NewMigrator(db, testMigrations(t))
+	assert.Equal(t, []State{StatePending, StatePending}, states(t, m))
+
+	executed, err := m.Up(ctx)
+	require.NoError(t, err)
+	assert.Len(t, executed, 2)
+	assert.Equal(t, []State{StateApplied, StateApplied}, states(t, m))
+	var name string
+	require.NoError(t, db.Raw("SELECT name FROM t_item WHERE id = 'a'").Scan(&name).Error)
+	assert.Equal(t, "x;y", name)
+
+	// 重复执行不做任何修改
+	executed, err = m.Up(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, executed)
+
+	reverted, err := m.Down(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, reverted, 1)
+	assert.Equal(t, int64(2), reverted[0].Version)
+	assert.Equal(t, []State{StateApplied, StatePending}, states(t, m))
+
+	reverted, err = m.Down(ctx, 5)
+	require.NoError(t, err)
+	assert.Len(t, reverted, 1)
+	assert.False(t, db.Migrator().HasTable("t_item"))
+
+	executed, err = m.Up(ctx)
+	require.NoError(t, err)
+	assert.Len(t, executed, 2)
+}
+
+func TestMigrator_Embedded(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+	m, err := New(db, database.DriverSQLite)
+	require.NoError(t, err)
+
+	applied, err := m.Up(ctx)
+	require.NoError(t, err)
+	assert.True(t, db.Migrator().HasTable("t_order"))
+	assert.True(t, db.Migrator().HasColumn("t_order_items", "reservation_id"))
+	var available int64
+	require.NoError(t, db.Raw("SELECT available FROM t_inventory WHERE product_id = 'P001'").Scan(&available).Error)
+	assert.Equal(t, int64(1000), available)
+
+	// 逐个版本回滚到空库
+	_, err = m.Down(ctx, len(applied))
+	require.NoError(t, err)
+	assert.False(t, db.Migrator().HasTable("t_order"))
+	assert.False(t, db.Migrator().HasTable("t_inventory"))
+}
+
+func TestMigrator_Baseline(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+	// 模拟按旧版建表脚本建好的数据库
+	require.NoError(t, db.Exec("CREATE TABLE t_item (id VARCHAR(36) PRIMARY KEY)").Error)
+	m := NewMigrator(db, testMigrations(t))
+
+	_, err := m.Baseline(ctx, 3)
+	assert.ErrorContains(t, err, "不存在")
+
+	recorded, err := m.Baseline(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, recorded, 1)
+	assert.Equal(t, int64(1), recorded[0].Version)
+	assert.Equal(t, []State{StateApplied, StatePending}, states(t, m))
+
+	// 基线之后的版本正常执行
+	executed, err := m.Up(ctx)
+	require.NoError(t, err)
+	require.Len(t, executed, 1)
+	assert.Equal(t, int64(2), executed[0].Version)
+	assert.True(t, db.Migrator().HasColumn("t_item", "name"))
+
+	// 已有迁移记录时拒绝设置基线
+	_, err = m.Baseline(ctx, 2)
+	assert.ErrorContains(t, err, "已有迁移记录")
+}
+
+func TestMigrator_FailedMigrationRollsBack(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+	migrations := testMigrations(t)
+	migrations[1].Up = "ALTER TABLE t_item ADD COLUMN name VARCHAR(64);\nINSERT INTO t_missing VALUES (1);\n"
+	m := NewMigrator(db, migrations)
+
+	executed, err := m.Up(ctx)
+	require.Error(t, err)
+	assert.Len(t, executed, 1)
+	// SQLite 的DDL支持事务，失败的版本整体回滚，不留下 dirty 记录
+	assert.Equal(t, []State{StateApplied, StatePending}, states(t, m))
+	assert.False(t, db.Migrator().HasColumn("t_item", "name"))
+}
+
+func TestMigrator_ChecksumMismatch(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+	_, err := NewMigrator(db, testMigrations(t)).Up(ctx)
+	require.NoError(t, err)
+
+	migrations := testMigrations(t)
+	migrations[0].Checksum = "changed"
+	m := NewMigrator(db, migrations)
+	assert.Equal(t, []State{StateModified, StateApplied}, states(t, m))
+	_, err = m.Up(ctx)
+	assert.ErrorIs(t, err, ErrChecksumMismatch)
+	_, err = m.Down(ctx, 1)
+	assert.ErrorIs(t, err, ErrChecksumMismatch)
+}
+
+func TestMigrator_Dirty(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+	m := NewMigrator(db, testMigrations(t))
+	_, err := m.Up(ctx)
+	require.NoError(t, err)
+
+	// 模拟MySQL上执行到一半失败的迁移
+	require.NoError(t, db.Model(&appliedMigration{Version: 2}).Update("dirty", true).Error)
+	assert.Equal(t, []State{StateApplied, StateDirty}, states(t, m))
+	_, err = m.Up(ctx)
+	assert.ErrorIs(t, err, ErrDirty)
+}
+
+func TestMigrator_UnknownVersion(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+	_, err := NewMigrator(db, testMigrations(t)).Up(ctx)
+	require.NoError(t, err)
+
+	m := NewMigrator(db, testMigrations(t)[:1])
+	assert.Equal(t, []State{StateApplied, StateUnknown}, states(t, m))
+	_, err = m.Up(ctx)
+	assert.ErrorIs(t, err, ErrUnknownVersion)
+}
+
+func TestMigrator_OutOfOrder(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+	migrations := testMigrations(t)
+	migrations[1].Up = "CREATE TABLE t_other (id INT);\n"
+	_, err := NewMigrator(db, migrations[1:]).Up(ctx)
+	require.NoError(t, err)
+	_, err = NewMigrator(db, migrations).Up(ctx)
+	assert.ErrorContains(t, err, "不能补执行")
+}
+
+func TestMigrator_Locked(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+	m := NewMigrator(db, testMigrations(t))
+	_, err := m.Status(ctx)
+	require.NoError(t, err)
+	_, err = m.Down(ctx, 1)
+	require.NoError(t, err) // 创建迁移表，没有可回滚的版本
+
+	other := lock.NewGormLockerOnTable(db, lockTable)
+	acquired, err := other.TryAcquire(ctx, lockName, time.Minute)
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	_, err = m.Up(ctx)
+	assert.ErrorIs(t, err, ErrLocked)
+	assert.Equal(t, []State{StatePending, StatePending}, states(t, m))
+
+	// 其他进程释放后可以执行，执行结束后释放锁
+	require.NoError(t, other.Release(ctx, lockName))
+	_, err = m.Up(ctx)
+	require.NoError(t, err)
+	acquired, err = other.TryAcquire(ctx, lockName, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_idempotency_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_order_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/persistence"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/persistence/migration"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/repositorytest"
+	"github.com/vaynedu/ddd_order_example/pkg/database"
+	"gorm.io/gorm"
+)
+
+// forEachDB 在临时的SQLite数据库上运行用例；配置 TEST_MYSQL_DSN 时同时在MySQL上运行
+func forEachDB(t *testing.T, fn func(t *testing.T, db *gorm.DB)) {
+	t.Run("sqlite", func(t *testing.T) {
+		fn(t, openSQLite(t))
+	})
+	t.Run("mysql", func(t *testing.T) {
+		fn(t, openMySQL(t))
+	})
+}
+
+// openSQLite 创建临时SQLite数据库并执行迁移
+func openSQLite(t testing.TB) *gorm.DB {
+	db, err := database.InitSQLite(context.Background(), database.SQLiteDSN(filepath.Join(t.TempDir(), "orders.db")))
+	if err != nil {
+		t.Fatalf("打开SQLite数据库失败: %v", err)
+	}
+	migrator, err := migration.New(db, database.DriverSQLite)
+	if err != nil {
+		t.Fatalf("加载迁移脚本失败: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("执行数据库迁移失败: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// openMySQL 连接 TEST_MYSQL_DSN 指定的数据库，需已执行 migrate up；未配置时跳过
+func openMySQL(t *testing.T) *gorm.DB {
+	dsn := os.Getenv("TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("未配置 TEST_MYSQL_DSN，跳过MySQL仓储测试")
+	}
+	db, err := database.InitMySQL(context.Background(), dsn)
+	if err != nil {
+		t.Fatalf("连接数据库失败: %v", err)
+	}
+	return db
+}
+
+func TestOrderRepository_Contract(t *testing.T) {
+	forEachDB(t, func(t *testing.T, db *gorm.DB) {
+		repositorytest.OrderRepositoryContract(t, func(t *testing.T) domain_order_core.OrderRepository {
+			return NewOrderRepository(db)
+		})
+	})
+}
+
+func TestPaymentRepository_Contract(t *testing.T) {
+	forEachDB(t, func(t *testing.T, db *gorm.DB) {
+		repositorytest.PaymentRepositoryContract(t, func(t *testing.T) domain_payment_core.Repository {
+			return NewPaymentRepository(db)
+		})
+	})
+}
+
+func TestRefundRepository_ConcurrencyContract(t *testing.T) {
+	forEachDB(t, func(t *testing.T, db *gorm.DB) {
+		repositorytest.RefundConcurrencyContract(t, func(t *testing.T) repositorytest.RefundDeps {
+			return repositorytest.RefundDeps{
+				UnitOfWork:  persistence.NewGormUnitOfWork(db),
+				PaymentRepo: NewPaymentRepository(db),
+				RefundRepo:  NewRefundRepository(db),
+			}
+		})
+	})
+}
+
+func TestIdempotencyRepository_Contract(t *testing.T) {
+	forEachDB(t, func(t *testing.T, db *gorm.DB) {
+		repositorytest.IdempotencyRepositoryContract(t, func(t *testing.T) domain_idempotency_core.Repository {
+			return NewIdempotencyRepository(db)
+		})
+	})
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_promotion_core"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/persistence"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CouponRepositoryMySQL MySQL实现的优惠券仓储
+type CouponRepositoryMySQL struct {
+	db *gorm.DB
+}
+
+// NewCouponRepository 创建优惠券仓储实例
+func NewCouponRepository(db *gorm.DB) domain_promotion_core.CouponRepository {
+	return &CouponRepositoryMySQL{db: db}
+}
+
+// Save 保存优惠券定义
+func (r *CouponRepositoryMySQL) Save(ctx context.Context, coupon *domain_promotion_core.CouponDO) error {
+	return persistence.DB(ctx, r.db).Save(coupon).Error
+}
+
+// FindByCodes 按券码批量查询优惠券，走 uk_code 唯一索引
+func (r *CouponRepositoryMySQL) FindByCodes(ctx context.Context, codes []string) ([]*domain_promotion_core.CouponDO, error) {
+	var coupons []*domain_promotion_core.CouponDO
+	if len(codes) == 0 {
+		return coupons, nil
+	}
+	err := persistence.DB(ctx, r.db).Where("code IN ?", codes).Find(&coupons).Error
+	return coupons, err
+}
+
+// couponCustomerUsage 客户维度的优惠券使用次数，占用时带条件自增，保证并发下不超过上限
+type couponCustomerUsage struct {
+	CouponID   string    `gorm:"column:coupon_id;primaryKey"`
+	CustomerID string    `gorm:"column:customer_id;primaryKey"`
+	UsedCount  int64     `gorm:"column:used_count"`
+	UpdatedAt  time.Time `gorm:"column:updated_at"`
+}
+
+func (couponCustomerUsage) TableName() string {
+	return "t_coupon_customer_usage"
+}
+
+// CouponUsageRepositoryMySQL MySQL实现的优惠券使用记录仓储，在工作单元中调用时加入外层事务
+type CouponUsageRepositoryMySQL struct {
+	db *gorm.DB
+}
+
+// NewCouponUsageRepository 创建优惠券使用记录仓储实例
+func NewCouponUsageRepository(db *gorm.DB) domain_promotion_core.CouponUsageRepository {
+	return &CouponUsageRepositoryMySQL{db: db}
+}
+
+// CountRedeemed 查询客户已占用的使用次数，没有记录时为0
+func (r *CouponUsageRepositoryMySQL) CountRedeemed(ctx context.Context, couponID, customerID string) (int64, error) {
+	var counts []int64
+	err := persistence.DB(ctx, r.db).Model(&couponCustomerUsage{}).
+		Where("coupon_id = ? AND customer_id = ?", couponID, customerID).
+		Pluck("used_count", &counts).Error
+	if err != nil || len(counts) == 0 {
+		return 0, err
+	}
+	return counts[0], nil
+}
+
+// Redeem 在一个事务内写入使用记录并占用次数
+// 使用记录以 (coupon_id, order_id) 为主键，同一订单重复占用时插入被忽略且不重复计数
+// 次数通过带上限条件的 UPDATE 自增，未更新到行说明已达上限，整个事务回滚
+func (r *CouponUsageRepositoryMySQL) Redeem(ctx context.Context, usages []*domain_promotion_core.CouponUsageDO, limits map[string]int64) error {
+	return persistence.DB(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		for _, usage := range usages {
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(usage)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				continue
+			}
+
+			counter := &couponCustomerUsage{CouponID: usage.CouponID, CustomerID: usage.CustomerID, UpdatedAt: usage.CreatedAt}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(counter).Error; err != nil {
+				return err
+			}
+			limit := limits[usage.CouponID]
+			result = tx.Model(&couponCustomerUsage{}).
+				Where("coupon_id = ? AND customer_id = ?", usage.CouponID, usage.CustomerID).
+				Where("? = 0 OR used_count < ?", limit, limit).
+				Updates(map[string]any{
+					"used_count": gorm.Expr("used_count + 1"),
+					"updated_at": usage.CreatedAt,
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return fmt.Errorf("%w: 优惠券[%s]每人限用%d次", domain_promotion_core.ErrCouponUsageLimitExceeded, usage.CouponID, limit)
+			}
+		}
+		return nil
+	})
+}
+
+// ReleaseByOrderID 在一个事务内把订单的使用记录标记为已释放并归还次数
+// 只处理仍为已占用的记录，重复释放不会重复归还
+func (r *CouponUsageRepositoryMySQL) ReleaseByOrderID(ctx context.Context, orderID string) error {
+	return persistence.DB(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var usages []*domain_promotion_core.CouponUsageDO
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("order_id = ? AND status = ?", orderID, domain_promotion_core.UsageStatusRedeemed).
+			Find(&usages).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, usage := range usages {
+			result := tx.Model(&domain_promotion_core.CouponUsageDO{}).
+				Where("coupon_id = ? AND order_id = ? AND status = ?", usage.CouponID, orderID, domain_promotion_core.UsageStatusRedeemed).
+				Updates(map[string]any{"status": domain_promotion_core.UsageStatusReleased, "updated_at": now})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				continue
+			}
+			if err := tx.Model(&couponCustomerUsage{}).
+				Where("coupon_id = ? AND customer_id = ? AND used_count > 0", usage.CouponID, usage.CustomerID).
+				Updates(map[string]any{
+					"used_count": gorm.Expr("used_count - 1"),
+					"updated_at": now,
+				}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
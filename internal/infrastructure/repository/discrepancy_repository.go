@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_reconciliation_core"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DiscrepancyRepositoryMySQL MySQL实现的对账差异仓储
+type DiscrepancyRepositoryMySQL struct {
+	db *gorm.DB
+}
+
+// NewDiscrepancyRepository 创建对账差异仓储实例
+func NewDiscrepancyRepository(db *gorm.DB) domain_reconciliation_core.DiscrepancyRepository {
+	return &DiscrepancyRepositoryMySQL{db: db}
+}
+
+// Save 保存差异记录
+func (r *DiscrepancyRepositoryMySQL) Save(ctx context.Context, discrepancy *domain_reconciliation_core.DiscrepancyDO) error {
+	return r.db.WithContext(ctx).Save(discrepancy).Error
+}
+
+// Append 批量写入差异记录，主键冲突的记录忽略，已核查的差异不会被重置
+func (r *DiscrepancyRepositoryMySQL) Append(ctx context.Context, discrepancies []*domain_reconciliation_core.DiscrepancyDO) error {
+	if len(discrepancies) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&discrepancies).Error
+}
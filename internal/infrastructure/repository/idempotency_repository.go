@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_idempotency_core"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/persistence"
+	"gorm.io/gorm"
+)
+
+// IdempotencyRepositoryMySQL MySQL实现的幂等记录仓储
+type IdempotencyRepositoryMySQL struct {
+	db *gorm.DB
+}
+
+// NewIdempotencyRepository 创建幂等记录仓储实例
+func NewIdempotencyRepository(db *gorm.DB) domain_idempotency_core.Repository {
+	return &IdempotencyRepositoryMySQL{db: db}
+}
+
+// Create 依赖主键唯一约束占用幂等键，并发的重复请求只有一个能插入成功
+func (r *IdempotencyRepositoryMySQL) Create(ctx context.Context, record *domain_idempotency_core.IdempotencyRecordDO) error {
+	err := persistence.DB(ctx, r.db).Create(record).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return domain_idempotency_core.ErrIdempotencyKeyExists
+	}
+	return err
+}
+
+// FindByKey 根据幂等键查询记录
+func (r *IdempotencyRepositoryMySQL) FindByKey(ctx context.Context, key string) (*domain_idempotency_core.IdempotencyRecordDO, error) {
+	var record domain_idempotency_core.IdempotencyRecordDO
+	err := persistence.DB(ctx, r.db).Where("idempotency_key = ?", key).First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain_idempotency_core.ErrIdempotencyKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// TakeOver 按租约开始时间条件更新，并发接管同一条记录时只有一个能成功
+func (r *IdempotencyRepositoryMySQL) TakeOver(ctx context.Context, key string, staleBefore, now time.Time) error {
+	result := persistence.DB(ctx, r.db).Model(&domain_idempotency_core.IdempotencyRecordDO{}).
+		Where("idempotency_key = ? AND status = ? AND updated_at < ?", key, domain_idempotency_core.IdempotencyStatusProcessing, staleBefore).
+		Update("updated_at", now)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain_idempotency_core.ErrIdempotencyKeyInProgress
+	}
+	return nil
+}
+
+// Complete 保存业务结果，在工作单元中时与业务数据一起提交
+// 租约被接管后更新时间会晚于 leasedAt，条件更新不再命中
+func (r *IdempotencyRepositoryMySQL) Complete(ctx context.Context, record *domain_idempotency_core.IdempotencyRecordDO, leasedAt time.Time) error {
+	result := persistence.DB(ctx, r.db).Model(&domain_idempotency_core.IdempotencyRecordDO{}).
+		Where("idempotency_key = ? AND status = ? AND updated_at <= ?", record.Key, domain_idempotency_core.IdempotencyStatusProcessing, leasedAt).
+		Updates(map[string]any{
+			"order_id":   record.OrderID,
+			"response":   record.Response,
+			"status":     record.Status,
+			"updated_at": record.UpdatedAt,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain_idempotency_core.ErrIdempotencyLeaseLost
+	}
+	return nil
+}
+
+// Release 删除仍由本次租约持有的处理中记录，释放幂等键
+func (r *IdempotencyRepositoryMySQL) Release(ctx context.Context, key string, leasedAt time.Time) error {
+	return persistence.DB(ctx, r.db).
+		Where("idempotency_key = ? AND status = ? AND updated_at <= ?", key, domain_idempotency_core.IdempotencyStatusProcessing, leasedAt).
+		Delete(&domain_idempotency_core.IdempotencyRecordDO{}).Error
+}
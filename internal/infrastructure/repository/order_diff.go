@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"slices"
+
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_order_core"
+)
+
+// orderWritePlan 保存订单需要执行的写操作
+// 有快照时只写入变化的列和订单行；没有快照(如从saga数据反序列化的订单)时整单重写
+type orderWritePlan struct {
+	insert        bool                            // 新订单，插入主表和全部订单行
+	rewriteItems  bool                            // 没有快照，删除全部订单行后重新插入
+	orderColumns  []string                        // 需要更新的主表列
+	insertItems   []domain_order_core.OrderItemDO // 需要插入的订单行
+	insertIndexes []int                           // 插入的订单行在 OrderDO.Items 中的下标，用于回填ID
+	updateItems   []itemUpdate                    // 需要更新的订单行
+	deleteItemIDs []int64                         // 需要删除的订单行
+}
+
+// itemUpdate 订单行需要更新的列
+type itemUpdate struct {
+	row     domain_order_core.OrderItemDO
+	columns []string
+}
+
+// planOrderWrite 对比订单与持久化快照，计算需要执行的写操作
+func planOrderWrite(o *domain_order_core.OrderDO) orderWritePlan {
+	if !o.Version.Valid {
+		plan := orderWritePlan{insert: true}
+		plan.insertAll(o)
+		return plan
+	}
+
+	snapshot := o.Snapshot()
+	if snapshot == nil {
+		plan := orderWritePlan{rewriteItems: true, orderColumns: []string{"*"}}
+		plan.insertAll(o)
+		return plan
+	}
+
+	plan := orderWritePlan{orderColumns: changedOrderColumns(&snapshot.Order, o)}
+	kept := make(map[int64]bool, len(o.Items))
+	for i, item := range o.Items {
+		old, ok := snapshot.Items[item.ID]
+		if !ok || kept[item.ID] {
+			// 未保存过的订单行，或与其他订单行重复的ID，都作为新行插入
+			plan.insertItem(o, i)
+			continue
+		}
+		kept[item.ID] = true
+
+		row := itemRow(o, item)
+		if columns := changedItemColumns(itemRow(o, old), row); len(columns) > 0 {
+			plan.updateItems = append(plan.updateItems, itemUpdate{row: row, columns: columns})
+		}
+	}
+	for id := range snapshot.Items {
+		if !kept[id] {
+			plan.deleteItemIDs = append(plan.deleteItemIDs, id)
+		}
+	}
+	slices.Sort(plan.deleteItemIDs)
+	return plan
+}
+
+// changedOrderColumns 与快照相比发生变化的主表列
+// 乐观锁版本号由插件维护，每次更新都会校验并加1，不在这里比较
+func changedOrderColumns(old, o *domain_order_core.OrderDO) []string {
+	var columns []string
+	if old.CustomerID != o.CustomerID {
+		columns = append(columns, "customer_id")
+	}
+	if old.Status != o.Status {
+		columns = append(columns, "status")
+	}
+	if old.Currency != o.Currency {
+		columns = append(columns, "currency")
+	}
+	if old.TotalAmount != o.TotalAmount {
+		columns = append(columns, "total_amount")
+	}
+	if old.DiscountAmount != o.DiscountAmount {
+		columns = append(columns, "discount_amount")
+	}
+	if !slices.Equal(old.CouponIDs, o.CouponIDs) {
+		columns = append(columns, "coupon_ids")
+	}
+	if !old.CreatedAt.Equal(o.CreatedAt) {
+		columns = append(columns, "created_at")
+	}
+	if !old.UpdatedAt.Equal(o.UpdatedAt) {
+		columns = append(columns, "updated_at")
+	}
+	return columns
+}
+
+// changedItemColumns 订单行发生变化的列
+func changedItemColumns(old, row domain_order_core.OrderItemDO) []string {
+	var columns []string
+	if old.ProductID != row.ProductID {
+		columns = append(columns, "product_id")
+	}
+	if old.Quantity != row.Quantity {
+		columns = append(columns, "quantity")
+	}
+	if old.Currency != row.Currency {
+		columns = append(columns, "currency")
+	}
+	if old.UnitPrice != row.UnitPrice {
+		columns = append(columns, "unit_price")
+	}
+	if old.Subtotal != row.Subtotal {
+		columns = append(columns, "subtotal")
+	}
+	if old.Discount != row.Discount {
+		columns = append(columns, "discount")
+	}
+	if old.ReservationID != row.ReservationID {
+		columns = append(columns, "reservation_id")
+	}
+	return columns
+}
+
+// insertAll 插入全部订单行
+func (p *orderWritePlan) insertAll(o *domain_order_core.OrderDO) {
+	for i := range o.Items {
+		p.insertItem(o, i)
+	}
+}
+
+// insertItem 插入第 i 个订单行，ID由数据库生成
+func (p *orderWritePlan) insertItem(o *domain_order_core.OrderDO, i int) {
+	row := itemRow(o, o.Items[i])
+	row.ID = 0
+	p.insertItems = append(p.insertItems, row)
+	p.insertIndexes = append(p.insertIndexes, i)
+}
+
+// itemRow 订单行转换为 t_order_items 的行，补齐订单ID和币种
+func itemRow(o *domain_order_core.OrderDO, item domain_order_core.OrderItemDO) domain_order_core.OrderItemDO {
+	return domain_order_core.OrderItemDO{
+		ID:            item.ID,
+		OrderID:       o.ID,
+		ProductID:     item.ProductID,
+		Quantity:      item.Quantity,
+		Currency:      string(item.ItemCurrency()),
+		UnitPrice:     item.UnitPrice,
+		Subtotal:      item.Subtotal,
+		Discount:      item.Discount,
+		ReservationID: item.ReservationID,
+	}
+}
@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_order_core"
+	"gorm.io/gorm"
+	"gorm.io/plugin/optimisticlock"
+)
+
+// loadedOrder 模拟从仓储加载的订单，itemCount 个订单行均已保存
+func loadedOrder(itemCount int, snapshot bool) *domain_order_core.OrderDO {
+	o := &domain_order_core.OrderDO{
+		ID:         "order_1",
+		CustomerID: "cust_1",
+		Status:     domain_order_core.OrderStatusCreated,
+		Currency:   "CNY",
+		CreatedAt:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		UpdatedAt:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Version:    optimisticlock.Version{Int64: 3, Valid: true},
+	}
+	for i := 0; i < itemCount; i++ {
+		o.Items = append(o.Items, domain_order_core.OrderItemDO{
+			ID:            int64(i + 1),
+			OrderID:       o.ID,
+			ProductID:     fmt.Sprintf("P%03d", i+1),
+			Quantity:      1,
+			Currency:      "CNY",
+			UnitPrice:     100,
+			Subtotal:      100,
+			ReservationID: fmt.Sprintf("r_%d", i+1),
+		})
+	}
+	o.TotalAmount = int64(itemCount) * 100
+	if snapshot {
+		o.TakeSnapshot()
+	}
+	return o
+}
+
+// TestPlanOrderWrite_NewOrder 新订单插入主表和全部订单行
+func TestPlanOrderWrite_NewOrder(t *testing.T) {
+	o := loadedOrder(2, false)
+	o.Version = optimisticlock.Version{}
+	o.Items[0].ID = 0
+	o.Items[1].ID = 0
+
+	plan := planOrderWrite(o)
+
+	assert.True(t, plan.insert)
+	assert.Len(t, plan.insertItems, 2)
+	assert.Equal(t, []int{0, 1}, plan.insertIndexes)
+	assert.Empty(t, plan.updateItems)
+	assert.Empty(t, plan.deleteItemIDs)
+}
+
+// TestPlanOrderWrite_StatusOnly 只变更状态时不写订单行，主表只更新变化的列
+func TestPlanOrderWrite_StatusOnly(t *testing.T) {
+	o := loadedOrder(3, true)
+	require.NoError(t, o.MarkAsPendingPayment())
+	o.UpdatedAt = o.UpdatedAt.Add(time.Minute)
+
+	plan := planOrderWrite(o)
+
+	assert.False(t, plan.insert)
+	assert.False(t, plan.rewriteItems)
+	assert.Equal(t, []string{"status", "updated_at"}, plan.orderColumns)
+	assert.Empty(t, plan.insertItems)
+	assert.Empty(t, plan.updateItems)
+	assert.Empty(t, plan.deleteItemIDs)
+}
+
+// TestPlanOrderWrite_ItemDiff 订单行按ID对比，分别插入、更新变化的列、删除
+func TestPlanOrderWrite_ItemDiff(t *testing.T) {
+	o := loadedOrder(3, false)
+	// 已预占库存的订单不能替换商品，模拟预占前保存的订单
+	for i := range o.Items {
+		o.Items[i].ReservationID = ""
+	}
+	o.TakeSnapshot()
+	require.NoError(t, o.ReplaceItems([]domain_order_core.OrderItemDO{
+		{ProductID: "P001", Quantity: 1, Currency: "CNY", UnitPrice: 100, Subtotal: 100},
+		{ProductID: "P003", Quantity: 2, Currency: "CNY", UnitPrice: 100, Subtotal: 200},
+		{ProductID: "P004", Quantity: 1, Currency: "CNY", UnitPrice: 50, Subtotal: 50},
+	}))
+
+	plan := planOrderWrite(o)
+
+	assert.Equal(t, []string{"total_amount"}, plan.orderColumns)
+	// P001 未变化不更新
+	require.Len(t, plan.updateItems, 1)
+	assert.Equal(t, int64(3), plan.updateItems[0].row.ID)
+	assert.Equal(t, []string{"quantity", "subtotal"}, plan.updateItems[0].columns)
+	require.Len(t, plan.insertItems, 1)
+	assert.Equal(t, "P004", plan.insertItems[0].ProductID)
+	assert.Equal(t, "order_1", plan.insertItems[0].OrderID)
+	assert.Equal(t, []int{2}, plan.insertIndexes)
+	assert.Equal(t, []int64{2}, plan.deleteItemIDs)
+}
+
+// TestPlanOrderWrite_DuplicatedItemID 重复的订单行ID只保留第一个，其余作为新行插入
+func TestPlanOrderWrite_DuplicatedItemID(t *testing.T) {
+	o := loadedOrder(1, true)
+	o.Items = append(o.Items, o.Items[0])
+
+	plan := planOrderWrite(o)
+
+	assert.Empty(t, plan.updateItems)
+	require.Len(t, plan.insertItems, 1)
+	assert.Zero(t, plan.insertItems[0].ID)
+	assert.Equal(t, []int{1}, plan.insertIndexes)
+	assert.Empty(t, plan.deleteItemIDs)
+}
+
+// TestPlanOrderWrite_NoSnapshot 没有快照的已有订单整单重写
+func TestPlanOrderWrite_NoSnapshot(t *testing.T) {
+	o := loadedOrder(2, false)
+
+	plan := planOrderWrite(o)
+
+	assert.True(t, plan.rewriteItems)
+	assert.Equal(t, []string{"*"}, plan.orderColumns)
+	assert.Len(t, plan.insertItems, 2)
+	assert.Zero(t, plan.insertItems[0].ID)
+}
+
+// writeCounter 统计数据库执行的写语句数和影响的行数
+type writeCounter struct {
+	statements int
+	rows       int64
+}
+
+// countWrites 在 db 的插入、更新、删除回调之后计数
+func countWrites(tb testing.TB, db *gorm.DB) *writeCounter {
+	c := &writeCounter{}
+	count := func(tx *gorm.DB) {
+		if tx.Error == nil {
+			c.statements++
+			c.rows += tx.Statement.RowsAffected
+		}
+	}
+	require.NoError(tb, db.Callback().Create().After("gorm:create").Register("test:count_create", count))
+	require.NoError(tb, db.Callback().Update().After("gorm:update").Register("test:count_update", count))
+	require.NoError(tb, db.Callback().Delete().After("gorm:delete").Register("test:count_delete", count))
+	return c
+}
+
+// withoutSnapshot 复制订单的持久化字段，得到没有快照的已有订单，保存时整单重写
+func withoutSnapshot(o *domain_order_core.OrderDO) *domain_order_core.OrderDO {
+	return &domain_order_core.OrderDO{
+		ID:             o.ID,
+		CustomerID:     o.CustomerID,
+		Items:          slices.Clone(o.Items),
+		Status:         o.Status,
+		Currency:       o.Currency,
+		TotalAmount:    o.TotalAmount,
+		DiscountAmount: o.DiscountAmount,
+		CouponIDs:      o.CouponIDs,
+		CreatedAt:      o.CreatedAt,
+		UpdatedAt:      o.UpdatedAt,
+		Version:        o.Version,
+	}
+}
+
+// BenchmarkOrderSave_StatusOnly 10个订单行的订单置为待支付后保存到SQLite：整单重写与按快照差异写入的写语句数和写入行数对比
+func BenchmarkOrderSave_StatusOnly(b *testing.B) {
+	const itemCount = 10
+	for _, bc := range []struct {
+		name     string
+		snapshot bool
+	}{
+		{"rewrite", false},
+		{"diff", true},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			ctx := context.Background()
+			db := openSQLite(b)
+			repo := NewOrderRepository(db)
+			counter := countWrites(b, db)
+
+			var statements int
+			var rows int64
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				o := loadedOrder(itemCount, false)
+				o.ID = uuid.New().String()
+				o.Version = optimisticlock.Version{}
+				for j := range o.Items {
+					o.Items[j].ID = 0
+					o.Items[j].OrderID = o.ID
+				}
+				require.NoError(b, repo.Save(ctx, o))
+				loaded, err := repo.FindByID(ctx, o.ID)
+				require.NoError(b, err)
+				if !bc.snapshot {
+					loaded = withoutSnapshot(loaded)
+				}
+				require.NoError(b, loaded.MarkAsPendingPayment())
+				loaded.UpdatedAt = loaded.UpdatedAt.Add(time.Second)
+				before := *counter
+				b.StartTimer()
+
+				require.NoError(b, repo.Save(ctx, loaded))
+
+				b.StopTimer()
+				statements += counter.statements - before.statements
+				rows += counter.rows - before.rows
+				b.StartTimer()
+			}
+			b.ReportMetric(float64(statements)/float64(b.N), "statements/op")
+			b.ReportMetric(float64(rows)/float64(b.N), "rows/op")
+		})
+	}
+}
@@ -3,87 +3,216 @@ package repository
 import (
 	"context"
 	"errors"
+	"slices"
 
 	"github.com/vaynedu/ddd_order_example/internal/domain/domain_order_core"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/outbox"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/persistence"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-// OrderRepositoryMySQL MySQL实现的订单仓储
-type OrderRepositoryMySQL struct {
+// GormOrderRepository 基于gorm的订单仓储，只使用MySQL和SQLite都支持的SQL
+type GormOrderRepository struct {
 	db *gorm.DB
 }
 
 // NewOrderRepository 创建订单仓储实例
 func NewOrderRepository(db *gorm.DB) domain_order_core.OrderRepository {
-	return &OrderRepositoryMySQL{db: db}
+	return &GormOrderRepository{db: db}
 }
 
 // Save 保存订单
-func (r *OrderRepositoryMySQL) Save(ctx context.Context, o *domain_order_core.OrderDO) error {
-	// 开始事务
-	tx := r.db.Begin()
-	tx = tx.WithContext(ctx)
-	if err := tx.Error; err != nil {
+// 订单、订单项和发件箱消息在同一个事务内写入；在工作单元中时加入外层事务，随外层一起提交或回滚
+// 未保存过的订单直接插入，已有订单按读取时的版本号更新，版本号不一致时返回 ErrConcurrentModification；
+// 从仓储加载的订单只更新变化的主表列和订单行，不再整单删除重建
+func (r *GormOrderRepository) Save(ctx context.Context, o *domain_order_core.OrderDO) error {
+	plan := planOrderWrite(o)
+	version := o.Version
+	err := persistence.DB(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := writeOrder(tx, o, plan); err != nil {
+			return err
+		}
+
+		// 聚合记录的领域事件写入发件箱，与订单在同一个事务内提交
+		msgs := make([]*outbox.Message, 0, len(o.Events()))
+		for _, evt := range o.Events() {
+			msg, err := outbox.NewMessage(evt)
+			if err != nil {
+				return err
+			}
+			msgs = append(msgs, msg)
+		}
+		return outbox.Append(tx, msgs)
+	})
+	if err != nil {
+		// 插入时乐观锁插件会修改版本号，失败时恢复，重试时仍按原状态保存
+		o.Version = version
 		return err
 	}
-	defer tx.Rollback()
 
-	// 使用GORM保存订单主表
-	if err := tx.Table("t_order").Save(o).Error; err != nil {
-		return err
+	if !plan.insert {
+		o.Version.Int64++
 	}
+	o.TakeSnapshot()
+	return nil
+}
 
-	// 删除原有订单项
-	if err := tx.Table("t_order_items").Where("order_id = ?", o.ID).Delete(&domain_order_core.OrderItemDO{}).Error; err != nil {
+// writeOrder 按写入计划保存订单主表和订单行，插入的订单行回填数据库生成的ID
+func writeOrder(tx *gorm.DB, o *domain_order_core.OrderDO, plan orderWritePlan) error {
+	if err := saveOrderRow(tx, o, plan); err != nil {
 		return err
 	}
 
-	// 批量插入新订单项
-	orderItems := make([]domain_order_core.OrderItemDO, len(o.Items))
-	for i, item := range o.Items {
-		orderItems[i] = domain_order_core.OrderItemDO{
-			OrderID:   o.ID,
-			ProductID: item.ProductID,
-			Quantity:  item.Quantity,
-			UnitPrice: item.UnitPrice,
-			Subtotal:  item.Subtotal,
+	if plan.rewriteItems {
+		if err := tx.Where("order_id = ?", o.ID).Delete(&domain_order_core.OrderItemDO{}).Error; err != nil {
+			return err
 		}
 	}
-	if err := tx.Table("t_order_items").Create(&orderItems).Error; err != nil {
-		return err
+	if len(plan.deleteItemIDs) > 0 {
+		if err := tx.Where("order_id = ? AND id IN ?", o.ID, plan.deleteItemIDs).Delete(&domain_order_core.OrderItemDO{}).Error; err != nil {
+			return err
+		}
+	}
+	for _, update := range plan.updateItems {
+		if err := tx.Model(&update.row).Select(update.columns).Updates(&update.row).Error; err != nil {
+			return err
+		}
+	}
+	if len(plan.insertItems) > 0 {
+		if err := tx.Create(&plan.insertItems).Error; err != nil {
+			return err
+		}
+		for i, idx := range plan.insertIndexes {
+			o.Items[idx].ID = plan.insertItems[i].ID
+		}
 	}
+	return nil
+}
 
-	// 提交事务
-	err := tx.Commit().Error
-	if err != nil {
-		return err
+// saveOrderRow 写入订单主表，更新时只写入变化的列
+// 不能使用 Save：更新影响0行时 Save 会退化为 upsert，覆盖并发写入且不会报错
+func saveOrderRow(tx *gorm.DB, o *domain_order_core.OrderDO, plan orderWritePlan) error {
+	if plan.insert {
+		// 插入时乐观锁插件把版本号置为1，重复插入返回 gorm.ErrDuplicatedKey
+		return tx.Omit(clause.Associations).Create(o).Error
+	}
+
+	// 即使主表没有列变化也要更新：乐观锁插件追加 version = 读取时版本号 的条件并把版本号加1，
+	// 订单行的修改同样受版本号保护；插件设置的 version 列同样受 Select 限制，需要显式选中
+	columns := plan.orderColumns
+	if len(columns) == 0 {
+		columns = []string{"updated_at"}
+	}
+	columns = append(slices.Clone(columns), "version")
+	result := tx.Model(o).Select(columns).Omit(clause.Associations).Updates(o)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain_order_core.ErrConcurrentModification
 	}
 	return nil
 }
 
 // FindByID 根据ID查找订单
-func (r *OrderRepositoryMySQL) FindByID(ctx context.Context, id string) (*domain_order_core.OrderDO, error) {
+func (r *GormOrderRepository) FindByID(ctx context.Context, id string) (*domain_order_core.OrderDO, error) {
 	// 查询订单主表
 	var o domain_order_core.OrderDO
-	if err := r.db.WithContext(ctx).Table("t_order").First(&o, "id = ?", id).Error; err != nil {
+	if err := persistence.DB(ctx, r.db).Table("t_order").First(&o, "id = ?", id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("订单不存在") // 针对错误码重构
+			return nil, domain_order_core.ErrOrderNotFound
 		}
 		return nil, err
 	}
 
 	// 查询订单项
 	query := `
-        SELECT product_id, quantity, unit_price, subtotal
+        SELECT id, order_id, product_id, quantity, currency, unit_price, subtotal, discount, reservation_id
         FROM t_order_items
         WHERE order_id = ?
+        ORDER BY id
     `
 
 	var items []domain_order_core.OrderItemDO
-	if err := r.db.WithContext(ctx).Table(domain_order_core.OrderItemDO{}.TableName()).Raw(query, id).Scan(&items).Error; err != nil {
+	if err := persistence.DB(ctx, r.db).Table(domain_order_core.OrderItemDO{}.TableName()).Raw(query, id).Scan(&items).Error; err != nil {
 		return nil, err
 	}
 
 	o.Items = items
+	o.TakeSnapshot()
 	return &o, nil
 }
+
+// Find 按条件分页查询订单，按 created_at,id 倒序，多查一条判断是否有下一页
+// 客户和状态条件分别可以使用 idx_customer_id、idx_status 索引
+func (r *GormOrderRepository) Find(ctx context.Context, query domain_order_core.OrderQuery) (*domain_order_core.OrderPage, error) {
+	db := persistence.DB(ctx, r.db).Table("t_order")
+	if query.CustomerID != "" {
+		db = db.Where("customer_id = ?", query.CustomerID)
+	}
+	if len(query.Statuses) > 0 {
+		db = db.Where("status IN ?", query.Statuses)
+	}
+	if query.CreatedFrom != nil {
+		db = db.Where("created_at >= ?", *query.CreatedFrom)
+	}
+	if query.CreatedTo != nil {
+		db = db.Where("created_at < ?", *query.CreatedTo)
+	}
+	if query.Currency != "" {
+		db = db.Where("currency = ?", query.Currency)
+	}
+	if query.MinAmount != nil {
+		db = db.Where("total_amount >= ?", *query.MinAmount)
+	}
+	if query.MaxAmount != nil {
+		db = db.Where("total_amount <= ?", *query.MaxAmount)
+	}
+	if query.Cursor != nil {
+		db = db.Where("(created_at < ? OR (created_at = ? AND id < ?))", query.Cursor.CreatedAt, query.Cursor.CreatedAt, query.Cursor.ID)
+	}
+
+	var orders []*domain_order_core.OrderDO
+	if err := db.Order("created_at DESC, id DESC").Limit(query.Limit + 1).Find(&orders).Error; err != nil {
+		return nil, err
+	}
+
+	page := domain_order_core.NewOrderPage(orders, query.Limit)
+	if err := r.loadItems(ctx, page.Orders); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// loadItems 一次查询批量加载多个订单的订单项
+func (r *GormOrderRepository) loadItems(ctx context.Context, orders []*domain_order_core.OrderDO) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(orders))
+	byID := make(map[string]*domain_order_core.OrderDO, len(orders))
+	for i, o := range orders {
+		ids[i] = o.ID
+		byID[o.ID] = o
+	}
+
+	var items []domain_order_core.OrderItemDO
+	if err := persistence.DB(ctx, r.db).Table(domain_order_core.OrderItemDO{}.TableName()).
+		Select("id, order_id, product_id, quantity, currency, unit_price, subtotal, discount, reservation_id").
+		Where("order_id IN ?", ids).
+		Order("id").
+		Find(&items).Error; err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		o := byID[item.OrderID]
+		o.Items = append(o.Items, item)
+	}
+	for _, o := range orders {
+		o.TakeSnapshot()
+	}
+	return nil
+}
@@ -2,36 +2,71 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/persistence"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-// PaymentRepositoryMySQL MySQL实现的支付仓储
-type PaymentRepositoryMySQL struct {
+// GormPaymentRepository 基于gorm的支付仓储，只使用MySQL和SQLite都支持的SQL
+type GormPaymentRepository struct {
 	db *gorm.DB
 }
 
 // NewPaymentRepository 创建订单仓储实例
 func NewPaymentRepository(db *gorm.DB) domain_payment_core.Repository {
-	return &PaymentRepositoryMySQL{db: db}
+	return &GormPaymentRepository{db: db}
 }
 
-// Save 保存支付记录
-func (r *PaymentRepositoryMySQL) Save(ctx context.Context, payment *domain_payment_core.PaymentDO) error {
-	return r.db.WithContext(ctx).Table("t_payment").Save(payment).Error
+// Save 保存支付记录，在工作单元中时加入外层事务
+func (r *GormPaymentRepository) Save(ctx context.Context, payment *domain_payment_core.PaymentDO) error {
+	return persistence.DB(ctx, r.db).Table("t_payment").Save(payment).Error
 }
 
 // FindByID 根据ID查询支付记录
-func (r *PaymentRepositoryMySQL) FindByID(ctx context.Context, id string) (*domain_payment_core.PaymentDO, error) {
+func (r *GormPaymentRepository) FindByID(ctx context.Context, id string) (*domain_payment_core.PaymentDO, error) {
 	var payment domain_payment_core.PaymentDO
-	err := r.db.WithContext(ctx).Table("t_payment").Where("id = ?", id).First(&payment).Error
+	err := persistence.DB(ctx, r.db).Table("t_payment").Where("id = ?", id).First(&payment).Error
+	return &payment, err
+}
+
+// FindByIDForUpdate 根据ID查询并锁定支付记录，SQLite不支持行锁，由写事务串行保证
+func (r *GormPaymentRepository) FindByIDForUpdate(ctx context.Context, id string) (*domain_payment_core.PaymentDO, error) {
+	var payment domain_payment_core.PaymentDO
+	err := persistence.DB(ctx, r.db).Table("t_payment").Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&payment).Error
 	return &payment, err
 }
 
 // FindByOrderID 根据订单ID查询支付记录
-func (r *PaymentRepositoryMySQL) FindByOrderID(ctx context.Context, orderID string) (*domain_payment_core.PaymentDO, error) {
+func (r *GormPaymentRepository) FindByOrderID(ctx context.Context, orderID string) (*domain_payment_core.PaymentDO, error) {
 	var payment domain_payment_core.PaymentDO
-	err := r.db.WithContext(ctx).Table("t_payment").Where("order_id = ?", orderID).First(&payment).Error
+	err := persistence.DB(ctx, r.db).Table("t_payment").Where("order_id = ?", orderID).First(&payment).Error
 	return &payment, err
 }
+
+// FindByStatuses 按状态和更新时间分页查询支付单，使用 idx_status_updated 索引，多查一条判断是否有下一页
+func (r *GormPaymentRepository) FindByStatuses(ctx context.Context, statuses []domain_payment_core.PaymentStatus, updatedBefore time.Time, cursor *domain_payment_core.PaymentCursor, limit int) (*domain_payment_core.PaymentPage, error) {
+	db := persistence.DB(ctx, r.db).Table("t_payment").
+		Where("status IN ? AND updated_at < ?", statuses, updatedBefore)
+	if cursor != nil {
+		db = db.Where("(updated_at > ? OR (updated_at = ? AND id > ?))", cursor.UpdatedAt, cursor.UpdatedAt, cursor.ID)
+	}
+
+	var payments []*domain_payment_core.PaymentDO
+	if err := db.Order("updated_at, id").Limit(limit + 1).Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	return domain_payment_core.NewPaymentPage(payments, limit), nil
+}
+
+// FindCompletedBetween 按完成时间查询支付单，完成时间仅在支付成功时写入
+func (r *GormPaymentRepository) FindCompletedBetween(ctx context.Context, channel domain_payment_core.PaymentChannel, from, to time.Time) ([]*domain_payment_core.PaymentDO, error) {
+	var payments []*domain_payment_core.PaymentDO
+	err := persistence.DB(ctx, r.db).Table("t_payment").
+		Where("channel = ? AND completed_at >= ? AND completed_at < ?", channel, from, to).
+		Order("completed_at").
+		Find(&payments).Error
+	return payments, err
+}
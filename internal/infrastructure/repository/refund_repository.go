@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/persistence"
+	"gorm.io/gorm"
+)
+
+// RefundRepositoryMySQL MySQL实现的退款仓储
+type RefundRepositoryMySQL struct {
+	db *gorm.DB
+}
+
+// NewRefundRepository 创建退款仓储实例
+func NewRefundRepository(db *gorm.DB) domain_payment_core.RefundRepository {
+	return &RefundRepositoryMySQL{db: db}
+}
+
+// Save 保存退款记录，在工作单元中时加入外层事务
+func (r *RefundRepositoryMySQL) Save(ctx context.Context, refund *domain_payment_core.RefundDO) error {
+	return persistence.DB(ctx, r.db).Table("t_refund").Save(refund).Error
+}
+
+// FindByID 根据ID查询退款记录
+func (r *RefundRepositoryMySQL) FindByID(ctx context.Context, id string) (*domain_payment_core.RefundDO, error) {
+	var refund domain_payment_core.RefundDO
+	err := persistence.DB(ctx, r.db).Table("t_refund").Where("id = ?", id).First(&refund).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain_payment_core.ErrRefundNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+// FindByPaymentID 查询支付单下的全部退款记录，按创建时间排序
+func (r *RefundRepositoryMySQL) FindByPaymentID(ctx context.Context, paymentID string) ([]*domain_payment_core.RefundDO, error) {
+	var refunds []*domain_payment_core.RefundDO
+	err := persistence.DB(ctx, r.db).Table("t_refund").Where("payment_id = ?", paymentID).Order("created_at").Find(&refunds).Error
+	return refunds, err
+}
+
+// FindCompletedBetween 按完成时间查询退款成功的退款单，渠道取自关联的支付单
+func (r *RefundRepositoryMySQL) FindCompletedBetween(ctx context.Context, channel domain_payment_core.PaymentChannel, from, to time.Time) ([]*domain_payment_core.RefundDO, error) {
+	var refunds []*domain_payment_core.RefundDO
+	err := persistence.DB(ctx, r.db).Table("t_refund").
+		Select("t_refund.*").
+		Joins("JOIN t_payment ON t_payment.id = t_refund.payment_id").
+		Where("t_payment.channel = ? AND t_refund.status = ? AND t_refund.completed_at >= ? AND t_refund.completed_at < ?",
+			channel, domain_payment_core.RefundStatusSucceeded, from, to).
+		Order("t_refund.completed_at").
+		Find(&refunds).Error
+	return refunds, err
+}
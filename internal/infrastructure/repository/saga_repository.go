@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/vaynedu/ddd_order_example/internal/application/saga"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/persistence"
+	"gorm.io/gorm"
+)
+
+// SagaRepositoryMySQL MySQL实现的saga实例仓储，在工作单元中调用时加入外层事务
+type SagaRepositoryMySQL struct {
+	db *gorm.DB
+}
+
+// NewSagaRepository 创建saga实例仓储
+func NewSagaRepository(db *gorm.DB) saga.Repository {
+	return &SagaRepositoryMySQL{db: db}
+}
+
+// Create 写入新的saga实例
+func (r *SagaRepositoryMySQL) Create(ctx context.Context, inst *saga.Instance) error {
+	return persistence.DB(ctx, r.db).Create(inst).Error
+}
+
+// Save 更新saga实例的执行进度
+func (r *SagaRepositoryMySQL) Save(ctx context.Context, inst *saga.Instance) error {
+	return persistence.DB(ctx, r.db).Save(inst).Error
+}
+
+// FindUnfinished 查询更新时间早于 updatedBefore 仍未结束的实例，使用 idx_status_updated_at 索引
+func (r *SagaRepositoryMySQL) FindUnfinished(ctx context.Context, updatedBefore time.Time, limit int) ([]*saga.Instance, error) {
+	var insts []*saga.Instance
+	err := persistence.DB(ctx, r.db).
+		Where("status IN ? AND updated_at < ?", []saga.Status{saga.StatusRunning, saga.StatusCompensating}, updatedBefore).
+		Order("updated_at").
+		Limit(limit).
+		Find(&insts).Error
+	return insts, err
+}
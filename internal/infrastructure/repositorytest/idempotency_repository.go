@@ -0,0 +1,105 @@
+package repositorytest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_idempotency_core"
+)
+
+// IdempotencyRepositoryContract 幂等记录仓储契约测试，重点校验租约接管后的条件更新
+func IdempotencyRepositoryContract(t *testing.T, newRepo func(t *testing.T) domain_idempotency_core.Repository) {
+	t.Run("CreateDuplicate", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		record := newIdempotencyRecord(time.Now().Truncate(time.Millisecond))
+		require.NoError(t, repo.Create(ctx, record))
+
+		err := repo.Create(ctx, newIdempotencyRecordWithKey(record.Key, record.UpdatedAt))
+		assert.ErrorIs(t, err, domain_idempotency_core.ErrIdempotencyKeyExists)
+	})
+
+	t.Run("CompleteAndRelease", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		leasedAt := time.Now().Truncate(time.Millisecond)
+		record := newIdempotencyRecord(leasedAt)
+		require.NoError(t, repo.Create(ctx, record))
+
+		record.Complete("order_1", []byte(`{"order_id":"order_1"}`))
+		require.NoError(t, repo.Complete(ctx, record, leasedAt))
+		found, err := repo.FindByKey(ctx, record.Key)
+		require.NoError(t, err)
+		assert.True(t, found.IsCompleted())
+		assert.Equal(t, "order_1", found.OrderID)
+
+		// 已完成的记录不会被释放或接管
+		require.NoError(t, repo.Release(ctx, record.Key, leasedAt))
+		_, err = repo.FindByKey(ctx, record.Key)
+		assert.NoError(t, err)
+		err = repo.TakeOver(ctx, record.Key, time.Now().Add(time.Hour), time.Now())
+		assert.ErrorIs(t, err, domain_idempotency_core.ErrIdempotencyKeyInProgress)
+	})
+
+	t.Run("TakeOver", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		leasedAt := time.Now().Add(-2 * domain_idempotency_core.ProcessingLease).Truncate(time.Millisecond)
+		record := newIdempotencyRecord(leasedAt)
+		require.NoError(t, repo.Create(ctx, record))
+
+		// 租约未过期时不能接管
+		err := repo.TakeOver(ctx, record.Key, leasedAt, time.Now())
+		assert.ErrorIs(t, err, domain_idempotency_core.ErrIdempotencyKeyInProgress)
+
+		now := time.Now().Truncate(time.Millisecond)
+		require.NoError(t, repo.TakeOver(ctx, record.Key, now.Add(-domain_idempotency_core.ProcessingLease), now))
+		// 同一条记录只能被接管一次
+		err = repo.TakeOver(ctx, record.Key, now.Add(-domain_idempotency_core.ProcessingLease), now)
+		assert.ErrorIs(t, err, domain_idempotency_core.ErrIdempotencyKeyInProgress)
+
+		// 原持有者的租约已失效，既不能保存结果也不能释放幂等键
+		stale := newIdempotencyRecordWithKey(record.Key, leasedAt)
+		stale.Complete("order_stale", nil)
+		assert.ErrorIs(t, repo.Complete(ctx, stale, leasedAt), domain_idempotency_core.ErrIdempotencyLeaseLost)
+		require.NoError(t, repo.Release(ctx, record.Key, leasedAt))
+		found, err := repo.FindByKey(ctx, record.Key)
+		require.NoError(t, err)
+		assert.False(t, found.IsCompleted())
+
+		// 接管者以新的租约开始时间保存结果
+		taken := newIdempotencyRecordWithKey(record.Key, now)
+		taken.Complete("order_1", nil)
+		require.NoError(t, repo.Complete(ctx, taken, now))
+	})
+
+	t.Run("Release", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		leasedAt := time.Now().Truncate(time.Millisecond)
+		record := newIdempotencyRecord(leasedAt)
+		require.NoError(t, repo.Create(ctx, record))
+
+		require.NoError(t, repo.Release(ctx, record.Key, leasedAt))
+		_, err := repo.FindByKey(ctx, record.Key)
+		assert.ErrorIs(t, err, domain_idempotency_core.ErrIdempotencyKeyNotFound)
+	})
+}
+
+func newIdempotencyRecord(leasedAt time.Time) *domain_idempotency_core.IdempotencyRecordDO {
+	return newIdempotencyRecordWithKey(uuid.New().String(), leasedAt)
+}
+
+func newIdempotencyRecordWithKey(key string, leasedAt time.Time) *domain_idempotency_core.IdempotencyRecordDO {
+	return &domain_idempotency_core.IdempotencyRecordDO{
+		Key:         key,
+		RequestHash: "hash_1",
+		Status:      domain_idempotency_core.IdempotencyStatusProcessing,
+		CreatedAt:   leasedAt,
+		UpdatedAt:   leasedAt,
+	}
+}
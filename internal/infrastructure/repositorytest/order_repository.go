@@ -0,0 +1,198 @@
+// Package repositorytest 仓储契约测试，内存实现和基于gorm的实现（SQLite、MySQL）运行同一套用例，保证语义一致
+package repositorytest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_order_core"
+	"gorm.io/gorm"
+)
+
+// OrderRepositoryContract 订单仓储契约测试，newRepo 为每个用例创建仓储
+// 用例使用随机的订单ID和客户ID，可以在已有数据的数据库上运行
+func OrderRepositoryContract(t *testing.T, newRepo func(t *testing.T) domain_order_core.OrderRepository) {
+	t.Run("SaveAndFind", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		o := newOrder(newCustomerID(), 2)
+
+		require.NoError(t, repo.Save(ctx, o))
+		assert.Equal(t, int64(1), o.CurrentVersion())
+		for _, item := range o.Items {
+			assert.NotZero(t, item.ID)
+		}
+
+		found, err := repo.FindByID(ctx, o.ID)
+		require.NoError(t, err)
+		assert.Equal(t, o.ID, found.ID)
+		assert.Equal(t, o.CustomerID, found.CustomerID)
+		assert.Equal(t, o.Status, found.Status)
+		assert.Equal(t, o.TotalAmount, found.TotalAmount)
+		assert.Equal(t, int64(1), found.CurrentVersion())
+		assert.WithinDuration(t, o.CreatedAt, found.CreatedAt, 0)
+		require.Len(t, found.Items, 2)
+		for i, item := range found.Items {
+			assert.Equal(t, o.Items[i].ID, item.ID)
+			assert.Equal(t, o.ID, item.OrderID)
+			assert.Equal(t, o.Items[i].ProductID, item.ProductID)
+			assert.Equal(t, o.Items[i].Subtotal, item.Subtotal)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, err := repo.FindByID(context.Background(), uuid.New().String())
+		assert.ErrorIs(t, err, domain_order_core.ErrOrderNotFound)
+	})
+
+	t.Run("DuplicatedKey", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		o := newOrder(newCustomerID(), 1)
+		require.NoError(t, repo.Save(ctx, o))
+
+		dup := newOrder(o.CustomerID, 1)
+		dup.ID = o.ID
+		assert.ErrorIs(t, repo.Save(ctx, dup), gorm.ErrDuplicatedKey)
+	})
+
+	t.Run("DeepCopy", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		o := newOrder(newCustomerID(), 1)
+		require.NoError(t, repo.Save(ctx, o))
+
+		// 保存后修改调用方持有的订单不影响已保存的数据
+		o.Status = domain_order_core.OrderStatusCancelled
+		o.Items[0].Quantity = 99
+		found, err := repo.FindByID(ctx, o.ID)
+		require.NoError(t, err)
+		assert.Equal(t, domain_order_core.OrderStatusCreated, found.Status)
+		assert.Equal(t, int64(1), found.Items[0].Quantity)
+
+		// 修改查询返回的订单不影响已保存的数据
+		found.Items[0].ProductID = "changed"
+		again, err := repo.FindByID(ctx, o.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "P001", again.Items[0].ProductID)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		o := newOrder(newCustomerID(), 2)
+		require.NoError(t, repo.Save(ctx, o))
+
+		loaded, err := repo.FindByID(ctx, o.ID)
+		require.NoError(t, err)
+		require.NoError(t, loaded.MarkAsPendingPayment())
+		require.NoError(t, loaded.ReplaceItems([]domain_order_core.OrderItemDO{
+			{ProductID: "P001", Quantity: 3, UnitPrice: 100, Subtotal: 300},
+			{ProductID: "P003", Quantity: 1, UnitPrice: 50, Subtotal: 50},
+		}))
+		keptID := loaded.Items[0].ID
+		require.NoError(t, repo.Save(ctx, loaded))
+		assert.Equal(t, int64(2), loaded.CurrentVersion())
+		assert.Equal(t, keptID, loaded.Items[0].ID)
+		assert.NotZero(t, loaded.Items[1].ID)
+
+		found, err := repo.FindByID(ctx, o.ID)
+		require.NoError(t, err)
+		assert.Equal(t, domain_order_core.OrderStatusPending, found.Status)
+		assert.Equal(t, int64(2), found.CurrentVersion())
+		require.Len(t, found.Items, 2)
+		assert.Equal(t, keptID, found.Items[0].ID)
+		assert.Equal(t, int64(3), found.Items[0].Quantity)
+		assert.Equal(t, "P003", found.Items[1].ProductID)
+		assert.Equal(t, int64(350), found.TotalAmount)
+	})
+
+	t.Run("VersionConflict", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		o := newOrder(newCustomerID(), 1)
+		require.NoError(t, repo.Save(ctx, o))
+
+		first, err := repo.FindByID(ctx, o.ID)
+		require.NoError(t, err)
+		second, err := repo.FindByID(ctx, o.ID)
+		require.NoError(t, err)
+
+		require.NoError(t, first.MarkAsPendingPayment())
+		require.NoError(t, repo.Save(ctx, first))
+
+		require.NoError(t, second.Cancel())
+		assert.ErrorIs(t, repo.Save(ctx, second), domain_order_core.ErrConcurrentModification)
+
+		found, err := repo.FindByID(ctx, o.ID)
+		require.NoError(t, err)
+		assert.Equal(t, domain_order_core.OrderStatusPending, found.Status)
+		assert.Equal(t, int64(2), found.CurrentVersion())
+	})
+
+	t.Run("FindPaged", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		customerID := newCustomerID()
+		base := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+		var ids []string
+		for i := 0; i < 3; i++ {
+			o := newOrder(customerID, 1)
+			o.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+			o.UpdatedAt = o.CreatedAt
+			require.NoError(t, repo.Save(ctx, o))
+			ids = append(ids, o.ID)
+		}
+		cancelled := newOrder(customerID, 1)
+		cancelled.Status = domain_order_core.OrderStatusCancelled
+		require.NoError(t, repo.Save(ctx, cancelled))
+
+		query := domain_order_core.OrderQuery{
+			CustomerID: customerID,
+			Statuses:   []domain_order_core.OrderStatus{domain_order_core.OrderStatusCreated},
+			Limit:      2,
+		}
+		page, err := repo.Find(ctx, query)
+		require.NoError(t, err)
+		require.Len(t, page.Orders, 2)
+		assert.Equal(t, ids[2], page.Orders[0].ID)
+		assert.Equal(t, ids[1], page.Orders[1].ID)
+		assert.Len(t, page.Orders[0].Items, 1)
+		require.NotNil(t, page.NextCursor)
+
+		query.Cursor = page.NextCursor
+		page, err = repo.Find(ctx, query)
+		require.NoError(t, err)
+		require.Len(t, page.Orders, 1)
+		assert.Equal(t, ids[0], page.Orders[0].ID)
+		assert.Nil(t, page.NextCursor)
+	})
+}
+
+// newCustomerID 生成用例独占的客户ID
+func newCustomerID() string {
+	return "cust_" + uuid.New().String()
+}
+
+// newOrder 创建待保存的新订单，订单行商品为 P001、P002...
+func newOrder(customerID string, itemCount int) *domain_order_core.OrderDO {
+	now := time.Now().Truncate(time.Millisecond)
+	o := &domain_order_core.OrderDO{
+		ID:         uuid.New().String(),
+		CustomerID: customerID,
+		Status:     domain_order_core.OrderStatusCreated,
+		Currency:   "CNY",
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	for i := 0; i < itemCount; i++ {
+		_ = o.AddItem([]string{"P001", "P002", "P003"}[i], 1, 100)
+	}
+	return o
+}
@@ -0,0 +1,176 @@
+package repositorytest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
+	"gorm.io/gorm"
+)
+
+// PaymentRepositoryContract 支付仓储契约测试，newRepo 为每个用例创建仓储
+// 用例使用随机的支付单ID和订单ID，可以在已有数据的数据库上运行
+func PaymentRepositoryContract(t *testing.T, newRepo func(t *testing.T) domain_payment_core.Repository) {
+	t.Run("SaveAndFind", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		payment := newPayment(uuid.New().String())
+		quotedAt := time.Now().Add(-time.Minute).Truncate(time.Millisecond)
+		payment.RateQuotedAt = &quotedAt
+
+		require.NoError(t, repo.Save(ctx, payment))
+		assert.False(t, payment.UpdatedAt.IsZero())
+
+		found, err := repo.FindByID(ctx, payment.ID)
+		require.NoError(t, err)
+		assert.Equal(t, payment.OrderID, found.OrderID)
+		assert.Equal(t, payment.Amount, found.Amount)
+		assert.Equal(t, payment.Status, found.Status)
+		require.NotNil(t, found.RateQuotedAt)
+		assert.WithinDuration(t, quotedAt, *found.RateQuotedAt, 0)
+
+		byOrder, err := repo.FindByOrderID(ctx, payment.OrderID)
+		require.NoError(t, err)
+		assert.Equal(t, payment.ID, byOrder.ID)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+
+		_, err := repo.FindByID(ctx, uuid.New().String())
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		_, err = repo.FindByOrderID(ctx, uuid.New().String())
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+
+	t.Run("DeepCopy", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		payment := newPayment(uuid.New().String())
+		completedAt := time.Now().Truncate(time.Millisecond)
+		payment.CompletedAt = new(time.Time)
+		*payment.CompletedAt = completedAt
+		require.NoError(t, repo.Save(ctx, payment))
+
+		// 保存后修改调用方持有的支付单不影响已保存的数据
+		payment.Status = domain_payment_core.PaymentStatusFailed
+		*payment.CompletedAt = completedAt.Add(time.Hour)
+		found, err := repo.FindByID(ctx, payment.ID)
+		require.NoError(t, err)
+		assert.Equal(t, domain_payment_core.PaymentStatusCreated, found.Status)
+		assert.WithinDuration(t, completedAt, *found.CompletedAt, 0)
+
+		// 修改查询返回的支付单不影响已保存的数据
+		*found.CompletedAt = completedAt.Add(time.Hour)
+		again, err := repo.FindByID(ctx, payment.ID)
+		require.NoError(t, err)
+		assert.WithinDuration(t, completedAt, *again.CompletedAt, 0)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		payment := newPayment(uuid.New().String())
+		require.NoError(t, repo.Save(ctx, payment))
+
+		payment.Status = domain_payment_core.PaymentStatusPaid
+		payment.TransactionID = "txn_" + payment.ID
+		require.NoError(t, repo.Save(ctx, payment))
+
+		found, err := repo.FindByID(ctx, payment.ID)
+		require.NoError(t, err)
+		assert.Equal(t, domain_payment_core.PaymentStatusPaid, found.Status)
+		assert.Equal(t, payment.TransactionID, found.TransactionID)
+	})
+
+	t.Run("FindByStatusesPaged", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		// 更新时间由仓储在保存时写入，按保存顺序递增
+		var ids []string
+		for i := 0; i < 3; i++ {
+			payment := newPayment(uuid.New().String())
+			payment.Status = domain_payment_core.PaymentStatusRefunding
+			require.NoError(t, repo.Save(ctx, payment))
+			ids = append(ids, payment.ID)
+			time.Sleep(2 * time.Millisecond)
+		}
+
+		// 库中可能已有其他支付单，翻到最后一页，只检查本用例写入的支付单
+		statuses := []domain_payment_core.PaymentStatus{domain_payment_core.PaymentStatusRefunding}
+		before := time.Now().Add(time.Minute)
+		var cursor *domain_payment_core.PaymentCursor
+		var seen []string
+		var last *domain_payment_core.PaymentDO
+		for {
+			page, err := repo.FindByStatuses(ctx, statuses, before, cursor, 2)
+			require.NoError(t, err)
+			assert.LessOrEqual(t, len(page.Payments), 2)
+			for _, payment := range page.Payments {
+				if last != nil {
+					assert.False(t, payment.UpdatedAt.Before(last.UpdatedAt), "支付单需要按更新时间升序返回")
+				}
+				last = payment
+				for _, id := range ids {
+					if payment.ID == id {
+						seen = append(seen, id)
+					}
+				}
+			}
+			if page.NextCursor == nil {
+				break
+			}
+			cursor = page.NextCursor
+		}
+		assert.Equal(t, ids, seen)
+
+		page, err := repo.FindByStatuses(ctx, statuses, time.Now().Add(-time.Hour), nil, 10)
+		require.NoError(t, err)
+		for _, payment := range page.Payments {
+			assert.NotContains(t, ids, payment.ID)
+		}
+	})
+
+	t.Run("FindCompletedBetween", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		// 使用较早的随机时间段，避免与库中已有数据重叠
+		from := time.Date(2001, 1, 1, 0, 0, 0, 0, time.Local).Add(time.Duration(uuid.New().ID()%100000) * time.Hour)
+		to := from.Add(time.Hour)
+		var ids []string
+		for _, completedAt := range []time.Time{from.Add(30 * time.Minute), from, to, from.Add(-time.Millisecond)} {
+			payment := newPayment(uuid.New().String())
+			payment.Status = domain_payment_core.PaymentStatusPaid
+			payment.CompletedAt = &completedAt
+			require.NoError(t, repo.Save(ctx, payment))
+			ids = append(ids, payment.ID)
+		}
+
+		payments, err := repo.FindCompletedBetween(ctx, domain_payment_core.PaymentChannelAlipay, from, to)
+		require.NoError(t, err)
+		require.Len(t, payments, 2)
+		assert.Equal(t, ids[1], payments[0].ID)
+		assert.Equal(t, ids[0], payments[1].ID)
+
+		payments, err = repo.FindCompletedBetween(ctx, domain_payment_core.PaymentChannelUnknown, from, to)
+		require.NoError(t, err)
+		assert.Empty(t, payments)
+	})
+}
+
+// newPayment 创建待保存的支付单
+func newPayment(orderID string) *domain_payment_core.PaymentDO {
+	return &domain_payment_core.PaymentDO{
+		ID:       uuid.New().String(),
+		OrderID:  orderID,
+		Amount:   1999,
+		Currency: "CNY",
+		Channel:  int(domain_payment_core.PaymentChannelAlipay),
+		Status:   domain_payment_core.PaymentStatusCreated,
+	}
+}
@@ -0,0 +1,80 @@
+package repositorytest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_uow_core"
+)
+
+// RefundDeps 退款并发契约测试依赖的仓储和工作单元，三者需共享同一份存储
+type RefundDeps struct {
+	UnitOfWork  domain_uow_core.UnitOfWork
+	PaymentRepo domain_payment_core.Repository
+	RefundRepo  domain_payment_core.RefundRepository
+}
+
+// RefundConcurrencyContract 退款并发契约测试，同一支付单的并发退款累计金额不能超过支付金额
+func RefundConcurrencyContract(t *testing.T, newDeps func(t *testing.T) RefundDeps) {
+	t.Run("ConcurrentCreateRefund", func(t *testing.T) {
+		deps := newDeps(t)
+		ctx := context.Background()
+		service := domain_payment_core.NewRefundDomainService(deps.PaymentRepo, slowRefundRepository{deps.RefundRepo}, deps.UnitOfWork)
+
+		payment := newPayment(uuid.New().String())
+		payment.Status = domain_payment_core.PaymentStatusCompleted
+		require.NoError(t, deps.PaymentRepo.Save(ctx, payment))
+
+		// 支付金额1999，每笔退款500，最多成功3笔
+		const workers = 8
+		var wg sync.WaitGroup
+		start := make(chan struct{})
+		errs := make([]error, workers)
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				<-start
+				_, errs[i] = service.CreateRefund(ctx, payment.ID, 500, "")
+			}(i)
+		}
+		close(start)
+		wg.Wait()
+
+		succeeded := 0
+		for _, err := range errs {
+			if err == nil {
+				succeeded++
+				continue
+			}
+			assert.ErrorIs(t, err, domain_payment_core.ErrRefundAmountExceeded)
+		}
+		assert.Equal(t, 3, succeeded)
+
+		refunds, err := deps.RefundRepo.FindByPaymentID(ctx, payment.ID)
+		require.NoError(t, err)
+		var total int64
+		for _, refund := range refunds {
+			total += refund.Amount
+		}
+		assert.Len(t, refunds, 3)
+		assert.Equal(t, int64(1500), total)
+	})
+}
+
+// slowRefundRepository 查询退款单后等待一段时间，放大统计金额和写入之间的并发窗口
+type slowRefundRepository struct {
+	domain_payment_core.RefundRepository
+}
+
+func (r slowRefundRepository) FindByPaymentID(ctx context.Context, paymentID string) ([]*domain_payment_core.RefundDO, error) {
+	refunds, err := r.RefundRepository.FindByPaymentID(ctx, paymentID)
+	time.Sleep(10 * time.Millisecond)
+	return refunds, err
+}
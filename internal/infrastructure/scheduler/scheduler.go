@@ -0,0 +1,84 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/lock"
+)
+
+// leaseIntervals 租约时长为任务间隔的倍数，持有者按间隔续期，宕机后最多等待该时长即可被接管
+const leaseIntervals = 3
+
+// Job 定时任务
+type Job struct {
+	Name     string                          // 任务名称，同时作为租约名称
+	Interval time.Duration                   // 执行间隔
+	Run      func(ctx context.Context) error // 任务逻辑，需自身保证幂等
+}
+
+// Scheduler 按固定间隔执行定时任务
+// 多实例部署时通过租约保证同一任务只在一个实例上执行；
+// 任务执行超过租约时长时可能被其他实例接管，任务逻辑需依赖业务侧的并发控制兜底
+type Scheduler struct {
+	locker lock.Locker
+	jobs   []Job
+}
+
+// NewScheduler 创建定时任务调度器
+func NewScheduler(locker lock.Locker) *Scheduler {
+	return &Scheduler{locker: locker}
+}
+
+// Register 注册定时任务，需在 Run 之前调用
+func (s *Scheduler) Register(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+// Run 启动全部任务，直到 ctx 取消；返回时任务均已停止并释放租约
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, job := range s.jobs {
+		wg.Add(1)
+		go func(job Job) {
+			defer wg.Done()
+			s.runJob(ctx, job)
+		}(job)
+	}
+	wg.Wait()
+}
+
+// runJob 按间隔持续执行单个任务
+func (s *Scheduler) runJob(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+	defer func() {
+		// ctx 已取消，使用新的 ctx 释放租约
+		if err := s.locker.Release(context.Background(), job.Name); err != nil {
+			slog.Warn("释放定时任务租约失败", "job", job.Name, "error", err)
+		}
+	}()
+
+	for {
+		if _, err := s.RunOnce(ctx, job); err != nil && ctx.Err() == nil {
+			slog.Error("定时任务执行失败", "job", job.Name, "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce 获取租约后执行一次任务，未获取到租约时返回 false
+func (s *Scheduler) RunOnce(ctx context.Context, job Job) (bool, error) {
+	acquired, err := s.locker.TryAcquire(ctx, job.Name, leaseIntervals*job.Interval)
+	if err != nil || !acquired {
+		return false, err
+	}
+	return true, job.Run(ctx)
+}
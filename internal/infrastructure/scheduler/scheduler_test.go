@@ -0,0 +1,74 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/mocks"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/scheduler"
+	"go.uber.org/mock/gomock"
+)
+
+// TestScheduler_RunOnce 持有租约才执行任务，租约时长为任务间隔的数倍
+func TestScheduler_RunOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLocker := mocks.NewMockLocker(ctrl)
+	s := scheduler.NewScheduler(mockLocker)
+
+	runs := 0
+	job := scheduler.Job{
+		Name:     "order_timeout_cancel",
+		Interval: time.Minute,
+		Run: func(ctx context.Context) error {
+			runs++
+			return nil
+		},
+	}
+
+	mockLocker.EXPECT().TryAcquire(gomock.Any(), "order_timeout_cancel", 3*time.Minute).Return(true, nil)
+	ran, err := s.RunOnce(context.Background(), job)
+	assert.NoError(t, err)
+	assert.True(t, ran)
+	assert.Equal(t, 1, runs)
+
+	mockLocker.EXPECT().TryAcquire(gomock.Any(), "order_timeout_cancel", 3*time.Minute).Return(false, nil)
+	ran, err = s.RunOnce(context.Background(), job)
+	assert.NoError(t, err)
+	assert.False(t, ran)
+	assert.Equal(t, 1, runs)
+
+	mockLocker.EXPECT().TryAcquire(gomock.Any(), "order_timeout_cancel", 3*time.Minute).Return(false, errors.New("db down"))
+	ran, err = s.RunOnce(context.Background(), job)
+	assert.Error(t, err)
+	assert.False(t, ran)
+	assert.Equal(t, 1, runs)
+}
+
+// TestScheduler_Run_ReleaseOnStop 停止时释放租约
+func TestScheduler_Run_ReleaseOnStop(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLocker := mocks.NewMockLocker(ctrl)
+	s := scheduler.NewScheduler(mockLocker)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Register(scheduler.Job{
+		Name:     "order_timeout_cancel",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			cancel()
+			return nil
+		},
+	})
+
+	mockLocker.EXPECT().TryAcquire(gomock.Any(), "order_timeout_cancel", gomock.Any()).Return(true, nil)
+	mockLocker.EXPECT().Release(gomock.Any(), "order_timeout_cancel").Return(nil)
+
+	s.Run(ctx)
+}
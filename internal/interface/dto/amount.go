@@ -0,0 +1,37 @@
+package dto
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/vaynedu/ddd_order_example/pkg/dmoney"
+)
+
+// parseAmount 将客户端传入的金额按十进制精确转换为币种最小单位，兼容JSON数字和字符串，未传时为0
+func parseAmount(field string, amount json.Number, currency dmoney.Currency) (int64, error) {
+	if amount == "" {
+		return 0, nil
+	}
+	money, err := dmoney.Parse(amount.String(), currency)
+	if err != nil {
+		return 0, fmt.Errorf("%s无效: %w", field, err)
+	}
+	return money.Amount(), nil
+}
+
+// parseCurrency 解析客户端传入的币种，未传时使用 fallback
+func parseCurrency(currency string, fallback dmoney.Currency) (dmoney.Currency, error) {
+	if currency == "" {
+		return fallback, nil
+	}
+	parsed, err := dmoney.ParseCurrency(currency)
+	if err != nil {
+		return "", fmt.Errorf("币种无效: %w", err)
+	}
+	return parsed, nil
+}
+
+// formatAmount 将分格式化为元的十进制字符串，如 1999 格式化为 "19.99"
+func formatAmount(amount int64) string {
+	return dmoney.New(amount, dmoney.DefaultCurrency).String()
+}
@@ -1,6 +1,11 @@
 package dto
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/vaynedu/ddd_order_example/internal/domain/domain_order_core"
@@ -9,49 +14,82 @@ import (
 
 // CreateOrderRequest 订单创建请求DTO
 type CreateOrderRequest struct {
-	CustomerID string             `json:"customer_id"`
-	Items      []OrderItemRequest `json:"items"`
+	CustomerID  string             `json:"customer_id"`
+	Currency    string             `json:"currency,omitempty"` // 订单币种，如CNY/USD，不传为CNY
+	Items       []OrderItemRequest `json:"items"`
+	CouponCodes []string           `json:"coupon_codes,omitempty"` // 使用的优惠券券码，可叠加使用
 }
 
 // OrderItemRequest 订单项请求DTO
 type OrderItemRequest struct {
-	ProductID string  `json:"product_id"`
-	Quantity  int64   `json:"quantity"`
-	UnitPrice float64 `json:"unit_price"` // 元
-	Subtotal  float64 `json:"subtotal"`   // 元
+	ProductID string      `json:"product_id"`
+	Quantity  int64       `json:"quantity"`
+	Currency  string      `json:"currency,omitempty"` // 不传时与订单币种相同，传入时必须与订单币种一致
+	UnitPrice json.Number `json:"unit_price"`         // 数字或十进制字符串，小数位数不超过币种精度
+	Subtotal  json.Number `json:"subtotal"`           // 仅为兼容旧客户端保留，服务端会按单价重新计算
 }
 
 // ToDomain 将DTO转换为领域模型
-func (r *CreateOrderRequest) ToDomain() []*domain_order_core.OrderItemDO {
+// 客户端传入的小计不可信，不参与转换，由订单聚合根根据校验后的单价计算
+func (r *CreateOrderRequest) ToDomain() ([]*domain_order_core.OrderItemDO, error) {
+	orderCurrency, err := parseCurrency(r.Currency, dmoney.DefaultCurrency)
+	if err != nil {
+		return nil, err
+	}
+
 	var items []*domain_order_core.OrderItemDO
 	for _, item := range r.Items {
+		currency, err := parseCurrency(item.Currency, orderCurrency)
+		if err != nil {
+			return nil, err
+		}
+		unitPrice, err := parseAmount("商品单价", item.UnitPrice, currency)
+		if err != nil {
+			return nil, err
+		}
 		items = append(items, &domain_order_core.OrderItemDO{
 			ProductID: item.ProductID,
 			Quantity:  item.Quantity,
-			UnitPrice: int64(dmoney.ConvertFloat64ToCent(item.UnitPrice)),
-			Subtotal:  int64(dmoney.ConvertFloat64ToCent(item.Subtotal)),
+			Currency:  string(currency),
+			UnitPrice: unitPrice,
 		})
 	}
-	return items
+	return items, nil
+}
+
+// HashRequest 计算请求的摘要，用于判断幂等键是否被不同的请求复用
+// 使用解析后重新序列化的结果，忽略字段顺序和空白等格式差异
+func HashRequest(req any) (string, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:]), nil
 }
 
 // OrderResponse 订单响应DTO
 type OrderResponse struct {
-	ID          string              `json:"id"`
-	CustomerID  string              `json:"customer_id"`
-	Status      string              `json:"status"`
-	TotalAmount float64             `json:"total_amount"` // 元
-	CreatedAt   time.Time           `json:"created_at"`
-	UpdatedAt   time.Time           `json:"updated_at"`
-	Items       []OrderItemResponse `json:"items"`
+	ID             string              `json:"id"`
+	CustomerID     string              `json:"customer_id"`
+	Status         string              `json:"status"`
+	Currency       string              `json:"currency"`
+	TotalAmount    string              `json:"total_amount"`    // 应付金额，十进制字符串，按订单币种精度格式化
+	DiscountAmount string              `json:"discount_amount"` // 优惠金额，十进制字符串
+	CouponIDs      []string            `json:"coupon_ids,omitempty"`
+	CreatedAt      time.Time           `json:"created_at"`
+	UpdatedAt      time.Time           `json:"updated_at"`
+	Items          []OrderItemResponse `json:"items"`
+	Version        int64               `json:"version"` // 更新订单时作为期望版本号回传
 }
 
 // OrderItemResponse 订单项响应DTO
 type OrderItemResponse struct {
-	ProductID string  `json:"product_id"`
-	Quantity  int64   `json:"quantity"`
-	UnitPrice float64 `json:"unit_price"` // 元
-	Subtotal  float64 `json:"subtotal"`   // 元
+	ProductID string `json:"product_id"`
+	Quantity  int64  `json:"quantity"`
+	UnitPrice string `json:"unit_price"` // 十进制字符串
+	Subtotal  string `json:"subtotal"`   // 十进制字符串
+	Discount  string `json:"discount"`   // 分摊到该商品的优惠金额，十进制字符串
 }
 
 // NewOrderResponse 从领域模型创建响应DTO
@@ -61,19 +99,24 @@ func NewOrderResponse(order *domain_order_core.OrderDO) *OrderResponse {
 		items[i] = OrderItemResponse{
 			ProductID: item.ProductID,
 			Quantity:  item.Quantity,
-			UnitPrice: dmoney.ConvertCentToFloat64(int64(item.UnitPrice)),
-			Subtotal:  dmoney.ConvertCentToFloat64(int64(item.Subtotal)),
+			UnitPrice: item.UnitPriceMoney().String(),
+			Subtotal:  item.SubtotalMoney().String(),
+			Discount:  item.DiscountMoney().String(),
 		}
 	}
 
 	return &OrderResponse{
-		ID:          order.ID,
-		CustomerID:  order.CustomerID,
-		Status:      string(order.Status),
-		TotalAmount: dmoney.ConvertCentToFloat64(int64(order.TotalAmount)),
-		CreatedAt:   order.CreatedAt,
-		UpdatedAt:   order.UpdatedAt,
-		Items:       items,
+		ID:             order.ID,
+		CustomerID:     order.CustomerID,
+		Status:         string(order.Status),
+		Currency:       string(order.OrderCurrency()),
+		TotalAmount:    order.Total().String(),
+		DiscountAmount: order.Discount().String(),
+		CouponIDs:      order.CouponIDs,
+		CreatedAt:      order.CreatedAt,
+		UpdatedAt:      order.UpdatedAt,
+		Items:          items,
+		Version:        order.CurrentVersion(),
 	}
 }
 
@@ -81,33 +124,150 @@ func NewOrderResponse(order *domain_order_core.OrderDO) *OrderResponse {
 type UpdateOrderRequest struct {
 	OrderID    string                   `json:"order_id"`
 	CustomerID string                   `json:"customer_id"`
-	Status     string                   `json:"status"`
+	Status     string                   `json:"status"` // 只能为 cancelled，用于取消未支付的订单
 	Items      []UpdateOrderItemRequest `json:"items,omitempty"`
+	// 客户端读取订单时的版本号(查询订单返回的 version)，与当前版本不一致时拒绝更新
+	Version *int64 `json:"version"`
 }
 
 type UpdateOrderItemRequest struct {
-	ProductID string  `json:"product_id"`
-	Quantity  int64   `json:"quantity"`
-	UnitPrice float64 `json:"unit_price"` // 元
-	Subtotal  float64 `json:"subtotal"`   // 元
+	ProductID string      `json:"product_id"`
+	Quantity  int64       `json:"quantity"`
+	Currency  string      `json:"currency,omitempty"` // 不传时与订单币种相同，订单币种不允许修改
+	UnitPrice json.Number `json:"unit_price"`         // 数字或十进制字符串，小数位数不超过币种精度
+	Subtotal  json.Number `json:"subtotal"`           // 数字或十进制字符串，小数位数不超过币种精度
 }
 
-// ToDomain 将更新订单请求DTO转换为领域模型
-func (req *UpdateOrderRequest) ToDomain() *domain_order_core.OrderDO {
+// ToDomain 将更新订单请求DTO转换为领域模型，orderCurrency 为原订单的币种
+// 注意：Status 不在此处转换，状态变更必须通过订单状态机完成
+func (req *UpdateOrderRequest) ToDomain(orderCurrency dmoney.Currency) (*domain_order_core.OrderDO, error) {
 	orderItems := make([]domain_order_core.OrderItemDO, 0, len(req.Items))
 	for _, item := range req.Items {
+		currency, err := parseCurrency(item.Currency, orderCurrency)
+		if err != nil {
+			return nil, err
+		}
+		unitPrice, err := parseAmount("商品单价", item.UnitPrice, currency)
+		if err != nil {
+			return nil, err
+		}
+		subtotal, err := parseAmount("商品小计", item.Subtotal, currency)
+		if err != nil {
+			return nil, err
+		}
 		orderItems = append(orderItems, domain_order_core.OrderItemDO{
 			ProductID: item.ProductID,
 			Quantity:  item.Quantity,
-			UnitPrice: int64(dmoney.ConvertFloat64ToCent(item.UnitPrice)),
-			Subtotal:  int64(dmoney.ConvertFloat64ToCent(item.Subtotal)),
+			Currency:  string(currency),
+			UnitPrice: unitPrice,
+			Subtotal:  subtotal,
 		})
 	}
 
 	return &domain_order_core.OrderDO{
 		ID:         req.OrderID,
-		Status:     domain_order_core.OrderStatus(req.Status),
 		Items:      orderItems,
 		CustomerID: req.CustomerID,
+		Currency:   string(orderCurrency),
+	}, nil
+}
+
+// ListOrdersRequest 订单列表查询请求DTO，未填写的条件不参与过滤
+type ListOrdersRequest struct {
+	CustomerID  string      `json:"customer_id"`
+	Statuses    []string    `json:"statuses,omitempty"`
+	CreatedFrom *time.Time  `json:"created_from,omitempty"` // RFC3339，含
+	CreatedTo   *time.Time  `json:"created_to,omitempty"`   // RFC3339，不含
+	Currency    string      `json:"currency,omitempty"`     // 订单币种，按金额过滤且不传时为CNY
+	MinAmount   json.Number `json:"min_amount,omitempty"`   // 含，Currency 币种的十进制金额
+	MaxAmount   json.Number `json:"max_amount,omitempty"`   // 含，Currency 币种的十进制金额
+	Cursor      string      `json:"cursor,omitempty"`       // 上一页返回的 next_cursor
+	Limit       int         `json:"limit,omitempty"`
+}
+
+// ToDomain 将列表查询请求转换为领域查询条件
+func (r *ListOrdersRequest) ToDomain() (domain_order_core.OrderQuery, error) {
+	query := domain_order_core.OrderQuery{
+		CustomerID:  r.CustomerID,
+		CreatedFrom: r.CreatedFrom,
+		CreatedTo:   r.CreatedTo,
+		Limit:       r.Limit,
+	}
+	for _, status := range r.Statuses {
+		query.Statuses = append(query.Statuses, domain_order_core.OrderStatus(status))
+	}
+	currency, err := parseCurrency(r.Currency, dmoney.DefaultCurrency)
+	if err != nil {
+		return query, err
+	}
+	if r.Currency != "" {
+		query.Currency = string(currency)
+	}
+	if r.MinAmount != "" {
+		amount, err := parseAmount("最小金额", r.MinAmount, currency)
+		if err != nil {
+			return query, err
+		}
+		query.MinAmount = &amount
+	}
+	if r.MaxAmount != "" {
+		amount, err := parseAmount("最大金额", r.MaxAmount, currency)
+		if err != nil {
+			return query, err
+		}
+		query.MaxAmount = &amount
+	}
+	if r.Cursor != "" {
+		cursor, err := DecodeOrderCursor(r.Cursor)
+		if err != nil {
+			return query, err
+		}
+		query.Cursor = cursor
+	}
+	return query, nil
+}
+
+// ListOrdersResponse 订单列表响应DTO，next_cursor 为空表示没有下一页
+type ListOrdersResponse struct {
+	Orders     []*OrderResponse `json:"orders"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+// NewListOrdersResponse 从分页结果创建响应DTO
+func NewListOrdersResponse(page *domain_order_core.OrderPage) *ListOrdersResponse {
+	orders := make([]*OrderResponse, len(page.Orders))
+	for i, order := range page.Orders {
+		orders[i] = NewOrderResponse(order)
+	}
+
+	response := &ListOrdersResponse{Orders: orders}
+	if page.NextCursor != nil {
+		response.NextCursor = EncodeOrderCursor(page.NextCursor)
+	}
+	return response
+}
+
+// orderCursorPayload 游标序列化格式，对客户端不透明
+type orderCursorPayload struct {
+	CreatedAt time.Time `json:"t"`
+	ID        string    `json:"id"`
+}
+
+// EncodeOrderCursor 将分页游标编码为URL安全的字符串
+func EncodeOrderCursor(cursor *domain_order_core.OrderCursor) string {
+	body, _ := json.Marshal(orderCursorPayload{CreatedAt: cursor.CreatedAt, ID: cursor.ID})
+	return base64.RawURLEncoding.EncodeToString(body)
+}
+
+// DecodeOrderCursor 解析客户端传回的分页游标
+func DecodeOrderCursor(s string) (*domain_order_core.OrderCursor, error) {
+	body, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.New("分页游标无效")
+	}
+	var payload orderCursorPayload
+	if err := json.Unmarshal(body, &payload); err != nil || payload.ID == "" {
+		return nil, errors.New("分页游标无效")
 	}
+	return &domain_order_core.OrderCursor{CreatedAt: payload.CreatedAt, ID: payload.ID}, nil
 }
@@ -0,0 +1,43 @@
+package dto
+
+import (
+	"encoding/json"
+
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
+	"github.com/vaynedu/ddd_order_example/pkg/dmoney"
+)
+
+// RefundRequest 退款请求DTO
+type RefundRequest struct {
+	OrderID string      `json:"order_id"`
+	Amount  json.Number `json:"amount"` // 元，不传或为0表示退回剩余全部金额
+	Reason  string      `json:"reason"`
+}
+
+// AmountInCent 退款金额，单位：分
+// 退款按支付币种进行，目前接入的渠道均以人民币收款
+func (r *RefundRequest) AmountInCent() (int64, error) {
+	return parseAmount("退款金额", r.Amount, dmoney.CNY)
+}
+
+// RefundResponse 退款响应DTO
+type RefundResponse struct {
+	RefundID            string `json:"refund_id"`
+	OrderID             string `json:"order_id"`
+	PaymentID           string `json:"payment_id"`
+	Amount              string `json:"amount"` // 元，十进制字符串
+	Status              string `json:"status"`
+	RefundTransactionID string `json:"refund_transaction_id"`
+}
+
+// NewRefundResponse 从领域模型创建退款响应DTO
+func NewRefundResponse(refund *domain_payment_core.RefundDO) *RefundResponse {
+	return &RefundResponse{
+		RefundID:            refund.ID,
+		OrderID:             refund.OrderID,
+		PaymentID:           refund.PaymentID,
+		Amount:              formatAmount(refund.Amount),
+		Status:              domain_payment_core.GetRefundStatusDetail(refund.Status),
+		RefundTransactionID: refund.RefundTransactionID,
+	}
+}
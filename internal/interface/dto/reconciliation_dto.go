@@ -0,0 +1,63 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_reconciliation_core"
+)
+
+// DiscrepancyResponse 对账差异DTO
+type DiscrepancyResponse struct {
+	ID            string `json:"id"`
+	Type          string `json:"type"`
+	PaymentID     string `json:"payment_id"`
+	RefundID      string `json:"refund_id,omitempty"` // 仅退款差异有值
+	OrderID       string `json:"order_id"`
+	TransactionID string `json:"transaction_id"`
+	LocalAmount   string `json:"local_amount"`  // 元，十进制字符串
+	RemoteAmount  string `json:"remote_amount"` // 元，十进制字符串
+	Detail        string `json:"detail"`
+}
+
+// StatementReportResponse 对账单对账汇总DTO
+type StatementReportResponse struct {
+	Channel        string                 `json:"channel"`
+	BillDate       string                 `json:"bill_date"` // YYYY-MM-DD
+	RemoteCount    int                    `json:"remote_count"`
+	LocalCount     int                    `json:"local_count"`
+	Matched        int                    `json:"matched"`
+	AmountMismatch int                    `json:"amount_mismatch"`
+	MissingLocal   int                    `json:"missing_local"`
+	MissingRemote  int                    `json:"missing_remote"`
+	Discrepancies  []*DiscrepancyResponse `json:"discrepancies"`
+}
+
+// NewStatementReportResponse 从领域模型创建对账汇总DTO
+func NewStatementReportResponse(report *domain_reconciliation_core.StatementReport) *StatementReportResponse {
+	resp := &StatementReportResponse{
+		Channel:        domain_payment_core.GetPaymentChannelDetail(report.Channel),
+		BillDate:       report.BillDate.Format(time.DateOnly),
+		RemoteCount:    report.RemoteCount,
+		LocalCount:     report.LocalCount,
+		Matched:        report.Matched,
+		AmountMismatch: report.AmountMismatch,
+		MissingLocal:   report.MissingLocal,
+		MissingRemote:  report.MissingRemote,
+		Discrepancies:  make([]*DiscrepancyResponse, 0, len(report.Discrepancies)),
+	}
+	for _, d := range report.Discrepancies {
+		resp.Discrepancies = append(resp.Discrepancies, &DiscrepancyResponse{
+			ID:            d.ID,
+			Type:          string(d.Type),
+			PaymentID:     d.PaymentID,
+			RefundID:      d.RefundID,
+			OrderID:       d.OrderID,
+			TransactionID: d.TransactionID,
+			LocalAmount:   formatAmount(d.LocalAmount),
+			RemoteAmount:  formatAmount(d.RemoteAmount),
+			Detail:        d.Detail,
+		})
+	}
+	return resp
+}
@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/config"
+)
+
+// AdminHandler 运维管理HTTP处理器
+type AdminHandler struct {
+	configs *config.Store
+}
+
+// NewAdminHandler 创建运维管理处理器
+func NewAdminHandler(configs *config.Store) *AdminHandler {
+	return &AdminHandler{configs: configs}
+}
+
+// GetConfig 查询当前生效配置的HTTP处理函数，敏感配置项已脱敏
+func (h *AdminHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "不支持的请求方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(h.configs.Current().Redacted())
+}
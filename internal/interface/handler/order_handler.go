@@ -1,28 +1,40 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
-	"time"
 
 	"github.com/vaynedu/ddd_order_example/internal/application/service"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_idempotency_core"
 	"github.com/vaynedu/ddd_order_example/internal/domain/domain_order_core"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
 	"github.com/vaynedu/ddd_order_example/internal/interface/dto"
-	"gorm.io/gorm"
 )
 
+// IdempotencyKeyHeader 创建订单时用于防重的请求头
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength 幂等键最大长度，与 t_idempotency_key 主键长度保持一致
+const maxIdempotencyKeyLength = 64
+
 // OrderHandler 订单HTTP处理器
 type OrderHandler struct {
-	orderService *service.OrderService
+	orderService       *service.OrderService
+	idempotencyService *service.IdempotencyService
 }
 
 // NewOrderHandler 创建订单处理器
-func NewOrderHandler(service *service.OrderService) *OrderHandler {
-	return &OrderHandler{orderService: service}
+func NewOrderHandler(service *service.OrderService, idempotencyService *service.IdempotencyService) *OrderHandler {
+	return &OrderHandler{
+		orderService:       service,
+		idempotencyService: idempotencyService,
+	}
 }
 
 // CreateOrder 创建订单的HTTP处理函数
+// 携带 Idempotency-Key 请求头时，同一个键的重复请求返回首次创建的结果
 func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 	// 1. 解析请求体
 	var req dto.CreateOrderRequest
@@ -33,23 +45,78 @@ func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 2. 转换为领域模型（通过DTO）
-	items := req.ToDomain()
+	items, err := req.ToDomain()
+	if err != nil {
+		http.Error(w, "无效的请求参数: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	createOrder := func(ctx context.Context) (string, error) {
+		return h.orderService.CreateOrder(ctx, req.CustomerID, items, req.CouponCodes)
+	}
 
 	// 3. 调用应用服务
-	orderID, err := h.orderService.CreateOrder(r.Context(), req.CustomerID, items)
+	key := r.Header.Get(IdempotencyKeyHeader)
+	if key == "" {
+		orderID, err := createOrder(r.Context())
+		if err != nil {
+			http.Error(w, "创建订单失败: "+err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		body, err := createOrderResponse(orderID)
+		if err != nil {
+			http.Error(w, "序列化响应失败: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSONBody(w, http.StatusCreated, body)
+		return
+	}
+
+	if len(key) > maxIdempotencyKeyLength {
+		http.Error(w, "Idempotency-Key 长度不能超过64", http.StatusBadRequest)
+		return
+	}
+
+	requestHash, err := dto.HashRequest(&req)
+	if err != nil {
+		http.Error(w, "无效的请求格式: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	record, replayed, err := h.idempotencyService.Execute(r.Context(), key, requestHash, createOrder, createOrderResponse)
 	if err != nil {
-		http.Error(w, "创建订单失败: "+err.Error(), http.StatusUnprocessableEntity)
+		switch {
+		case errors.Is(err, domain_idempotency_core.ErrIdempotencyKeyConflict):
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		case errors.Is(err, domain_idempotency_core.ErrIdempotencyKeyInProgress), errors.Is(err, domain_idempotency_core.ErrIdempotencyLeaseLost):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "创建订单失败: "+err.Error(), http.StatusUnprocessableEntity)
+		}
 		return
 	}
 
-	// 4. 返回成功响应
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(map[string]string{
+	// 4. 返回成功响应，重放请求返回首次的响应体
+	if replayed {
+		w.Header().Set("Idempotent-Replayed", "true")
+	}
+	writeJSONBody(w, http.StatusCreated, []byte(record.Response))
+}
+
+// createOrderResponse 创建订单的响应体，幂等重放时原样返回
+func createOrderResponse(orderID string) ([]byte, error) {
+	return json.Marshal(map[string]string{
 		"order_id": orderID,
 	})
 }
 
+// writeJSONBody 写出已序列化好的JSON响应
+func writeJSONBody(w http.ResponseWriter, status int, body []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
 // GetOrder 获取订单的HTTP处理函数
 func (h *OrderHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
 	// 1. 从body中获取订单id
@@ -65,7 +132,7 @@ func (h *OrderHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
 	// 2. 调用应用服务
 	order, err := h.orderService.GetOrder(r.Context(), request.OrderID)
 	if err != nil {
-		if err.Error() == "订单不存在" {
+		if errors.Is(err, domain_order_core.ErrOrderNotFound) {
 			http.Error(w, "订单不存在", http.StatusNotFound)
 		} else {
 			http.Error(w, "获取订单失败: "+err.Error(), http.StatusInternalServerError)
@@ -80,6 +147,39 @@ func (h *OrderHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// ListOrders 订单列表查询的HTTP处理函数，按创建时间倒序游标分页
+func (h *OrderHandler) ListOrders(w http.ResponseWriter, r *http.Request) {
+	// 1. 解析查询条件
+	var req dto.ListOrdersRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "无效的请求格式: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	query, err := req.ToDomain()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := query.Normalize(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// 2. 调用应用服务
+	page, err := h.orderService.ListOrders(r.Context(), query)
+	if err != nil {
+		http.Error(w, "查询订单列表失败: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// 3. 转换为响应DTO并返回
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(dto.NewListOrdersResponse(page))
+}
+
 // PayOrder 处理订单支付请求
 func (h *OrderHandler) PayOrder(w http.ResponseWriter, r *http.Request) {
 	// 1. 解析请求体获取订单ID
@@ -99,7 +199,11 @@ func (h *OrderHandler) PayOrder(w http.ResponseWriter, r *http.Request) {
 
 	// 2. 调用应用服务执行支付
 	if err := h.orderService.PayOrder(r.Context(), req.OrderID); err != nil {
-		http.Error(w, "支付失败: "+err.Error(), http.StatusInternalServerError)
+		if errors.Is(err, domain_payment_core.ErrExchangeRateNotFound) {
+			http.Error(w, "支付失败: "+err.Error(), http.StatusUnprocessableEntity)
+		} else {
+			http.Error(w, "支付失败: "+err.Error(), http.StatusInternalServerError)
+		}
 		return
 	}
 
@@ -120,12 +224,16 @@ func (h *OrderHandler) UpdateOrder(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "无效的请求格式: "+err.Error(), http.StatusBadRequest)
 		return
 	}
+	if req.Version == nil {
+		http.Error(w, "订单版本号不能为空", http.StatusBadRequest)
+		return
+	}
 
 	// 2. 验证订单是否存在
 	// todo 关于error，和返回值统一处理
 	existingOrder, err := h.orderService.GetOrder(r.Context(), req.OrderID)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
+		if errors.Is(err, domain_order_core.ErrOrderNotFound) {
 			http.Error(w, "订单不存在", http.StatusNotFound)
 		} else {
 			http.Error(w, "查询订单失败: "+err.Error(), http.StatusInternalServerError)
@@ -133,44 +241,83 @@ func (h *OrderHandler) UpdateOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 3. 合并更新数据（保留原有必要字段并重新计算金额）
-	orderDO := req.ToDomain()
-	if orderDO.CustomerID == "" {
-		orderDO.CustomerID = existingOrder.CustomerID
+	// 客户端基于旧版本修改，直接拒绝，避免覆盖其他操作的更新
+	if existingOrder.CurrentVersion() != *req.Version {
+		writeVersionConflict(w, existingOrder.CurrentVersion())
+		return
 	}
-	orderDO.CreatedAt = existingOrder.CreatedAt
-	orderDO.UpdatedAt = time.Now()
-	// 状态变更
-	if orderDO.Status != domain_order_core.OrderStatusUnknown {
-		orderDO.Status = existingOrder.Status
+
+	// 3. 在已有订单上合并更新数据（保留原有必要字段并重新计算金额）
+	orderDO := existingOrder
+	if req.CustomerID != "" {
+		orderDO.CustomerID = req.CustomerID
 	}
 
-	// 如果更新了订单项，则重新计算总金额
+	// 如果更新了订单项，则重新计算总金额；未更新订单项则保留原金额
 	if len(req.Items) > 0 {
-		// 调用领域层方法计算总金额
-		if err := orderDO.CalculateTotalAmount(); err != nil {
-			http.Error(w, "计算订单金额失败: "+err.Error(), http.StatusBadRequest)
+		update, err := req.ToDomain(existingOrder.OrderCurrency())
+		if err != nil {
+			http.Error(w, "无效的请求参数: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		// 调用领域层方法替换商品并计算总金额，已使用优惠券或已预占库存的订单不能修改商品
+		if err := orderDO.ReplaceItems(update.Items); err != nil {
+			if errors.Is(err, domain_order_core.ErrDiscountedItems) || errors.Is(err, domain_order_core.ErrReservedItems) {
+				http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			} else {
+				http.Error(w, "计算订单金额失败: "+err.Error(), http.StatusBadRequest)
+			}
+			return
+		}
+	}
+
+	// 客户端只能取消未支付的订单；支付、发货、完成由支付通知和后台流程推进，已支付订单通过退款取消
+	if req.Status != "" && domain_order_core.OrderStatus(req.Status) != existingOrder.Status {
+		if domain_order_core.OrderStatus(req.Status) != domain_order_core.OrderStatusCancelled {
+			http.Error(w, "订单状态只能更新为已取消", http.StatusBadRequest)
+			return
+		}
+		if existingOrder.Status != domain_order_core.OrderStatusCreated && existingOrder.Status != domain_order_core.OrderStatusPending {
+			http.Error(w, "只能取消未支付的订单，已支付订单请申请退款", http.StatusUnprocessableEntity)
+			return
+		}
+		if err := orderDO.Cancel(); err != nil {
+			http.Error(w, "更新订单状态失败: "+err.Error(), http.StatusUnprocessableEntity)
 			return
 		}
-	} else {
-		// 未更新订单项，保留原金额
-		orderDO.TotalAmount = existingOrder.TotalAmount
 	}
 
 	// 4. 调用应用服务
 	if err := h.orderService.UpdateOrder(r.Context(), orderDO); err != nil {
-		if errors.Is(err, gorm.ErrDuplicatedKey) {
-			http.Error(w, "订单已被其他操作更新，请刷新后重试", http.StatusConflict)
+		if errors.Is(err, domain_order_core.ErrConcurrentModification) {
+			// 读取后被其他操作更新，重新查询返回最新版本号
+			current, findErr := h.orderService.GetOrder(r.Context(), req.OrderID)
+			if findErr != nil {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
+			writeVersionConflict(w, current.CurrentVersion())
 		} else {
 			http.Error(w, "更新订单失败: "+err.Error(), http.StatusInternalServerError)
 		}
 		return
 	}
 
-	// 5. 返回成功响应
+	// 5. 返回成功响应，带上新版本号供下次更新使用
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{
+	json.NewEncoder(w).Encode(map[string]any{
 		"message": "订单更新成功",
+		"version": orderDO.CurrentVersion(),
+	})
+}
+
+// writeVersionConflict 订单版本冲突，返回409和当前版本号，客户端刷新后基于当前版本重新提交
+func writeVersionConflict(w http.ResponseWriter, currentVersion int64) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	json.NewEncoder(w).Encode(map[string]any{
+		"message":         domain_order_core.ErrConcurrentModification.Error(),
+		"current_version": currentVersion,
 	})
 }
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/smartwalle/alipay/v3"
+	"github.com/vaynedu/ddd_order_example/internal/application/service"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/payment"
+	"github.com/vaynedu/ddd_order_example/internal/interface/dto"
+)
+
+// PaymentHandler 支付HTTP处理器
+type PaymentHandler struct {
+	refundService *service.RefundService
+	notifyService *service.PaymentNotifyService
+}
+
+// NewPaymentHandler 创建支付处理器
+func NewPaymentHandler(refundService *service.RefundService, notifyService *service.PaymentNotifyService) *PaymentHandler {
+	return &PaymentHandler{refundService: refundService, notifyService: notifyService}
+}
+
+// Refund 订单退款的HTTP处理函数，支持全额和部分退款
+func (h *PaymentHandler) Refund(w http.ResponseWriter, r *http.Request) {
+	// 1. 解析请求体
+	var req dto.RefundRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "无效的请求格式: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.OrderID == "" {
+		http.Error(w, "订单ID不能为空", http.StatusBadRequest)
+		return
+	}
+	amount, err := req.AmountInCent()
+	if err != nil {
+		http.Error(w, "无效的请求参数: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if amount < 0 {
+		http.Error(w, domain_payment_core.ErrInvalidRefundAmount.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// 2. 调用应用服务
+	refund, err := h.refundService.RefundOrder(r.Context(), req.OrderID, amount, req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain_payment_core.ErrPaymentNotFound):
+			http.Error(w, "支付单不存在", http.StatusNotFound)
+		case errors.Is(err, domain_payment_core.ErrRefundAmountExceeded),
+			errors.Is(err, domain_payment_core.ErrInvalidRefundAmount),
+			errors.Is(err, domain_payment_core.ErrPaymentNotRefundable):
+			http.Error(w, "退款失败: "+err.Error(), http.StatusUnprocessableEntity)
+		case errors.Is(err, service.ErrRefundResultUnknown):
+			// 渠道可能已受理退款，客户端不应重新发起
+			http.Error(w, err.Error(), http.StatusAccepted)
+		default:
+			http.Error(w, "退款失败: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// 3. 返回成功响应
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(dto.NewRefundResponse(refund))
+}
+
+// AlipayNotify 支付宝异步通知的HTTP处理函数
+// 只有应答 "success" 支付宝才会停止重试，处理失败时返回非200让支付宝稍后重新通知
+func (h *PaymentHandler) AlipayNotify(w http.ResponseWriter, r *http.Request) {
+	// 1. 解析表单
+	if r.Method != http.MethodPost {
+		http.Error(w, "不支持的请求方法", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "无效的通知格式: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// 2. 调用应用服务
+	if err := h.notifyService.HandleNotification(r.Context(), r.PostForm); err != nil {
+		switch {
+		case errors.Is(err, payment.ErrInvalidNotification),
+			errors.Is(err, payment.ErrUnknownTradeStatus):
+			http.Error(w, "通知验签失败: "+err.Error(), http.StatusBadRequest)
+		case errors.Is(err, domain_payment_core.ErrPaymentNotFound):
+			http.Error(w, "支付单不存在", http.StatusNotFound)
+		case errors.Is(err, domain_payment_core.ErrPaymentAmountMismatch):
+			http.Error(w, "处理通知失败: "+err.Error(), http.StatusUnprocessableEntity)
+		default:
+			http.Error(w, "处理通知失败: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// 3. 应答支付宝
+	alipay.ACKNotification(w)
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/vaynedu/ddd_order_example/internal/application/service"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_payment_core"
+	"github.com/vaynedu/ddd_order_example/internal/interface/dto"
+)
+
+// ReconciliationHandler 对账HTTP处理器
+type ReconciliationHandler struct {
+	statementService *service.StatementReconcileService
+}
+
+// NewReconciliationHandler 创建对账处理器
+func NewReconciliationHandler(statementService *service.StatementReconcileService) *ReconciliationHandler {
+	return &ReconciliationHandler{statementService: statementService}
+}
+
+// ReconcileStatement 上传渠道日对账单并对账的HTTP处理函数
+// 查询参数 channel 为支付渠道编号，date 为账单日(YYYY-MM-DD)，请求体为对账单原始CSV内容
+func (h *ReconciliationHandler) ReconcileStatement(w http.ResponseWriter, r *http.Request) {
+	// 1. 解析请求参数
+	if r.Method != http.MethodPost {
+		http.Error(w, "不支持的请求方法", http.StatusMethodNotAllowed)
+		return
+	}
+	channel, err := strconv.Atoi(r.URL.Query().Get("channel"))
+	if err != nil {
+		http.Error(w, "无效的支付渠道", http.StatusBadRequest)
+		return
+	}
+	billDate, err := time.ParseInLocation(time.DateOnly, r.URL.Query().Get("date"), time.Local)
+	if err != nil {
+		http.Error(w, "无效的账单日期: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// 2. 调用应用服务
+	report, err := h.statementService.ReconcileStatement(r.Context(), domain_payment_core.PaymentChannel(channel), billDate, r.Body)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrStatementParserNotFound):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "对账失败: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// 3. 返回对账汇总
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(dto.NewStatementReportResponse(report))
+}
@@ -0,0 +1,76 @@
+// Package middleware HTTP中间件
+package middleware
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RateLimiter 令牌桶限流，所有请求共享一个令牌桶，限流参数可在运行时调整
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // 每秒补充的令牌数，0 表示不限流
+	burst  float64 // 令牌桶容量
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+// NewRateLimiter 创建限流器，rate 为每秒允许的请求数，0 表示不限流；burst 为允许的突发请求数
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	l := &RateLimiter{now: time.Now}
+	l.SetLimit(rate, burst)
+	return l
+}
+
+// SetLimit 调整限流参数，已积累的令牌不超过新的容量
+func (l *RateLimiter) SetLimit(rate float64, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// 创建时令牌桶是满的；之后调整时按旧参数结算已积累的令牌
+	first := l.last.IsZero()
+	l.refill()
+	l.rate = rate
+	l.burst = float64(burst)
+	l.tokens = min(l.tokens, l.burst)
+	if first {
+		l.tokens = l.burst
+	}
+}
+
+// Allow 取一个令牌，令牌不足时返回 false
+func (l *RateLimiter) Allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.rate <= 0 {
+		return true
+	}
+	l.refill()
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// refill 按距上次补充的时间补充令牌，调用方需持有锁
+func (l *RateLimiter) refill() {
+	now := l.now()
+	if !l.last.IsZero() {
+		l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	}
+	l.last = now
+}
+
+// Middleware 超过限流的请求返回 429
+func (l *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !l.Allow() {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "请求过于频繁，请稍后重试", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
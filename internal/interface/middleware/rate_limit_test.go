@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock 手动推进的时钟
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func newTestLimiter(rate float64, burst int) (*RateLimiter, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l := &RateLimiter{now: clock.Now}
+	l.SetLimit(rate, burst)
+	return l, clock
+}
+
+func TestRateLimiter_Allow(t *testing.T) {
+	l, clock := newTestLimiter(2, 3)
+
+	// 初始可以突发 burst 个请求
+	for i := 0; i < 3; i++ {
+		assert.True(t, l.Allow(), i)
+	}
+	assert.False(t, l.Allow())
+
+	// 每秒补充 rate 个令牌，不超过容量
+	clock.now = clock.now.Add(500 * time.Millisecond)
+	assert.True(t, l.Allow())
+	assert.False(t, l.Allow())
+	clock.now = clock.now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		assert.True(t, l.Allow(), i)
+	}
+	assert.False(t, l.Allow())
+}
+
+func TestRateLimiter_SetLimit(t *testing.T) {
+	l, clock := newTestLimiter(0, 0)
+	// 0 表示不限流
+	for i := 0; i < 100; i++ {
+		assert.True(t, l.Allow())
+	}
+
+	// 开启限流时从空桶开始补充，不会放过一整桶的突发请求
+	l.SetLimit(1, 5)
+	assert.False(t, l.Allow())
+	clock.now = clock.now.Add(2 * time.Second)
+	assert.True(t, l.Allow())
+	assert.True(t, l.Allow())
+	assert.False(t, l.Allow())
+
+	// 调小容量时已积累的令牌随之减少
+	clock.now = clock.now.Add(time.Minute)
+	l.SetLimit(1, 1)
+	assert.True(t, l.Allow())
+	assert.False(t, l.Allow())
+
+	l.SetLimit(0, 0)
+	assert.True(t, l.Allow())
+}
+
+func TestRateLimiter_Middleware(t *testing.T) {
+	l, _ := newTestLimiter(1, 1)
+	handler := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/orders/get", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/orders/get", nil))
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenAuth(t *testing.T) {
+	token := ""
+	handler := TokenAuth(func() string { return token }, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func(authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/admin/config", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// 未配置令牌时关闭接口
+	assert.Equal(t, http.StatusForbidden, serve("Bearer ").Code)
+
+	token = "admin-token"
+	assert.Equal(t, http.StatusUnauthorized, serve("").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve("Bearer wrong").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve("admin-token").Code)
+	rec := serve("Bearer admin-token")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// 令牌修改后立即生效
+	token = "rotated"
+	rec = serve("Bearer admin-token")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "Bearer", rec.Header().Get("WWW-Authenticate"))
+}
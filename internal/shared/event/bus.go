@@ -10,6 +10,11 @@ type Event interface {
 	Name() string
 }
 
+// 事件分发接口，应用服务在聚合保存成功后分发领域事件
+type Dispatcher interface {
+	Dispatch(ctx context.Context, events ...Event) error
+}
+
 // 事件处理器接口
 type Handler interface {
 	Handle(ctx context.Context, event Event) error
@@ -56,3 +61,13 @@ func (b *EventBus) Publish(ctx context.Context, event Event) error {
 
 	return nil
 }
+
+// Dispatch 依次发布事件，实现 Dispatcher
+func (b *EventBus) Dispatch(ctx context.Context, events ...Event) error {
+	for _, e := range events {
+		if err := b.Publish(ctx, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -5,74 +5,223 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 	"time"
 
-	"github.com/spf13/viper"
+	"github.com/vaynedu/ddd_order_example/internal/application/saga"
+	"github.com/vaynedu/ddd_order_example/internal/application/service"
+	"github.com/vaynedu/ddd_order_example/internal/domain/domain_order_core"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/config"
 	"github.com/vaynedu/ddd_order_example/internal/infrastructure/di"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/scheduler"
+	"github.com/vaynedu/ddd_order_example/internal/interface/middleware"
+	"github.com/vaynedu/ddd_order_example/internal/shared/event"
 	"github.com/vaynedu/ddd_order_example/pkg/database"
+	"gorm.io/gorm"
 )
 
-func initConfig() error {
-	viper.SetConfigName("config_prod")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath("config")
-	viper.AutomaticEnv()
-	return viper.ReadInConfig()
+// newOrderTimeoutJob 定时取消超时未支付订单
+// 执行间隔启动时确定，超时时间和批量大小每次执行时读取当前配置
+func newOrderTimeoutJob(configs *config.Store, timeoutService *service.OrderTimeoutService) scheduler.Job {
+	return scheduler.Job{
+		Name:     "order_timeout_cancel",
+		Interval: configs.Current().Order.TimeoutCheckInterval,
+		Run: func(ctx context.Context) error {
+			cfg := configs.Current().Order
+			cancelled, err := timeoutService.CancelOverdueOrders(ctx, time.Now().Add(-cfg.PaymentTimeout), cfg.TimeoutBatchSize)
+			if cancelled > 0 {
+				log.Printf("已取消%d个超时未支付订单", cancelled)
+			}
+			return err
+		},
+	}
+}
+
+// newPaymentReconcileJob 定时查询渠道，修正长时间未确定结果的支付单
+func newPaymentReconcileJob(configs *config.Store, reconcileService *service.PaymentReconcileService) scheduler.Job {
+	return scheduler.Job{
+		Name:     "payment_reconcile",
+		Interval: configs.Current().Reconciliation.PaymentInterval,
+		Run: func(ctx context.Context) error {
+			cfg := configs.Current().Reconciliation
+			result, err := reconcileService.ReconcilePayments(ctx, time.Now().Add(-cfg.PaymentMinAge), cfg.BatchSize)
+			if result.Paid > 0 || result.Failed > 0 || result.Discrepancies > 0 {
+				log.Printf("支付对账完成: 查询%d笔, 支付成功%d笔, 支付失败%d笔, 差异%d条",
+					result.Checked, result.Paid, result.Failed, result.Discrepancies)
+			}
+			return err
+		},
+	}
+}
+
+// newSagaRecoveryJob 定时恢复进程崩溃或补偿失败后遗留的saga实例
+func newSagaRecoveryJob(configs *config.Store, recovery *saga.Recovery) scheduler.Job {
+	return scheduler.Job{
+		Name:     "saga_recovery",
+		Interval: configs.Current().Saga.RecoveryInterval,
+		Run: func(ctx context.Context) error {
+			cfg := configs.Current().Saga
+			finished, err := recovery.RecoverStale(ctx, time.Now().Add(-cfg.RecoveryMinAge), cfg.RecoveryBatchSize)
+			if finished > 0 {
+				log.Printf("已恢复%d个未结束的saga实例", finished)
+			}
+			return err
+		},
+	}
+}
+
+// initApp 按 storage.driver 配置选择存储实现并初始化应用，memory 不连接数据库
+func initApp(ctx context.Context, configs *config.Store, bus *event.EventBus) (*di.App, error) {
+	cfg := configs.Current()
+	switch cfg.Storage.Driver {
+	case config.StorageMemory:
+		slog.Warn("使用内存存储，进程退出后数据丢失")
+		return di.InitializeMemoryApp(configs, bus)
+	case config.StorageDatabase:
+		db, err := openDatabase(ctx, cfg.Database)
+		if err != nil {
+			return nil, err
+		}
+		// SQLite 用于本地开发，启动时自动执行迁移；MySQL 需通过 migrate 子命令迁移
+		if cfg.Database.Driver == database.DriverSQLite {
+			if err := migrateUp(ctx, db, cfg.Database.Driver); err != nil {
+				return nil, err
+			}
+		}
+		if !cfg.Alipay.Enabled {
+			slog.Warn("未开启支付宝，使用模拟支付渠道，支付通知不验签")
+			return di.InitializeMockPaymentApp(configs, db, bus)
+		}
+		return di.InitializeApp(configs, db, bus)
+	default:
+		return nil, fmt.Errorf("不支持的存储驱动: %s", cfg.Storage.Driver)
+	}
+}
+
+// setupLogging 按 logging 配置设置默认日志，标准库 log 的输出也经由该日志以 Info 级别输出
+// 警告和错误需使用 slog.Warn、slog.Error 输出，日志级别调高后仍能看到
+// 返回的日志级别可在运行时调整，日志格式修改后需重启生效
+func setupLogging(cfg config.LoggingConfig) *slog.LevelVar {
+	level := new(slog.LevelVar)
+	level.Set(cfg.SlogLevel())
+	opts := &slog.HandlerOptions{Level: level}
+	var h slog.Handler = slog.NewTextHandler(os.Stderr, opts)
+	if cfg.Format == "json" {
+		h = slog.NewJSONHandler(os.Stderr, opts)
+	}
+	slog.SetDefault(slog.New(h))
+	return level
+}
+
+// openDatabase 按 database.driver 配置连接数据库
+func openDatabase(ctx context.Context, cfg config.DatabaseConfig) (*gorm.DB, error) {
+	if cfg.Driver == database.DriverSQLite {
+		if err := os.MkdirAll(filepath.Dir(cfg.Path), 0o755); err != nil {
+			return nil, fmt.Errorf("创建数据库目录失败: %w", err)
+		}
+	}
+	db, err := database.Open(ctx, cfg.Driver, cfg.DSN())
+	if err != nil {
+		return nil, fmt.Errorf("连接数据库失败: %w", err)
+	}
+	return db, nil
 }
 
 func main() {
 	// 解析命令行参数
-	flag.String("config", "config/config_prod.yaml", "配置文件路径")
+	configPath := flag.String("config", "config/config.yaml", "配置文件路径")
+	profile := flag.String("profile", "", "运行环境(dev/test/prod)，不指定时取 APP_PROFILE 环境变量或配置文件中的 profile")
 	flag.Parse()
 
 	// 初始化配置
-	if err := initConfig(); err != nil {
+	configs, err := config.NewStore(*configPath, *profile)
+	if err != nil {
 		log.Fatalf("初始化配置失败: %v", err)
 	}
+	cfg := configs.Current()
 
 	ctx := context.Background()
 
-	// 从配置文件读取数据库连接信息
-	dsn := fmt.Sprintf(
-		"%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		viper.GetString("database.username"),
-		viper.GetString("database.password"),
-		viper.GetString("database.host"),
-		viper.GetInt("database.port"),
-		viper.GetString("database.name"),
-	)
-
-	// 初始化数据库连接
-	db, err := database.InitMySQL(ctx, dsn)
-	if err != nil {
-		log.Fatalf("连接数据库失败: %v", err)
+	// migrate 子命令只执行数据库迁移，不启动服务
+	if flag.Arg(0) == "migrate" {
+		if err := runMigrate(ctx, cfg.Database, flag.Args()[1:]); err != nil {
+			log.Fatalf("数据库迁移失败: %v", err)
+		}
+		return
 	}
 
-	// // 通过Wire依赖注入初始化处理器
-	// orderHandler, err := di.InitializeOrderHandler(db)
-	// if err != nil {
-	// 	log.Fatalf("依赖注入初始化失败: %v", err)
-	// }
+	logLevel := setupLogging(cfg.Logging)
+
+	// 领域事件发布到进程内事件总线
+	eventBus := event.NewEventBus()
 
-	orderHandler, err := di.InitializeTestOrderHandler(db)
+	// 通过Wire依赖注入初始化应用
+	app, err := initApp(ctx, configs, eventBus)
 	if err != nil {
-		log.Fatalf("mock依赖注入初始化失败: %v", err)
+		log.Fatalf("依赖注入初始化失败: %v", err)
 	}
 
-	// 注册路由
+	// 订单取消后释放占用的优惠券
+	eventBus.RegisterHandler(domain_order_core.EventNameOrderCancelled, app.CouponReleaseHandler)
+
+	// 订单支付后确认库存预占，取消后释放
+	eventBus.RegisterHandler(domain_order_core.EventNameOrderPaid, app.InventoryHandler)
+	eventBus.RegisterHandler(domain_order_core.EventNameOrderCancelled, app.InventoryHandler)
+
+	// 启动发件箱投递器，补偿投递事务提交后未能立即分发的事件
+	relayCtx, stopRelay := context.WithCancel(ctx)
+	relayDone := make(chan struct{})
+	go func() {
+		defer close(relayDone)
+		app.OutboxRelay.Run(relayCtx)
+	}()
+
+	// 启动定时任务，多实例部署时同一任务只在一个实例上执行
+	app.Scheduler.Register(newOrderTimeoutJob(configs, app.OrderTimeoutService))
+	app.Scheduler.Register(newPaymentReconcileJob(configs, app.PaymentReconcileService))
+	app.Scheduler.Register(newSagaRecoveryJob(configs, app.SagaRecovery))
+	schedulerCtx, stopScheduler := context.WithCancel(ctx)
+	schedulerDone := make(chan struct{})
+	go func() {
+		defer close(schedulerDone)
+		app.Scheduler.Run(schedulerCtx)
+	}()
+
+	// 注册路由，业务接口共享一个限流器，支付通知和管理接口不限流
+	api := http.NewServeMux()
+	api.HandleFunc("/api/orders/create", app.OrderHandler.CreateOrder)
+	api.HandleFunc("/api/orders/get", app.OrderHandler.GetOrder)
+	api.HandleFunc("/api/orders/list", app.OrderHandler.ListOrders)
+	api.HandleFunc("/api/orders/pay", app.OrderHandler.PayOrder)
+	api.HandleFunc("/api/orders/update", app.OrderHandler.UpdateOrder)
+	api.HandleFunc("/api/payments/refund", app.PaymentHandler.Refund)
+	api.HandleFunc("/api/reconciliation/statement", app.ReconciliationHandler.ReconcileStatement)
+	rateLimiter := middleware.NewRateLimiter(cfg.RateLimit.RequestsPerSecond, cfg.RateLimit.Burst)
+
 	mux := http.NewServeMux()
-	mux.HandleFunc("/api/orders/create", orderHandler.CreateOrder)
-	mux.HandleFunc("/api/orders/list", orderHandler.GetOrder)
-	mux.HandleFunc("/api/orders/pay", orderHandler.PayOrder)
-	mux.HandleFunc("/api/orders/update", orderHandler.UpdateOrder)
+	mux.Handle("/api/", rateLimiter.Middleware(api))
+	// 支付宝异步通知不限流，被限流的通知要等支付宝重试才能确认支付结果
+	mux.HandleFunc("/api/payments/notify/alipay", app.PaymentHandler.AlipayNotify)
+	// 管理接口不限流，需携带 admin.token 配置的访问令牌，未配置令牌时关闭
+	mux.Handle("/admin/config", middleware.TokenAuth(func() string {
+		return configs.Current().Admin.Token
+	}, http.HandlerFunc(app.AdminHandler.GetConfig)))
+
+	// 日志级别、限流参数随配置文件修改生效，其余可热更新的配置由使用方每次读取或自行订阅
+	configs.Subscribe(func(cfg *config.Config) {
+		logLevel.Set(cfg.Logging.SlogLevel())
+		rateLimiter.SetLimit(cfg.RateLimit.RequestsPerSecond, cfg.RateLimit.Burst)
+	})
+	configs.Watch()
 
 	// 创建HTTP服务器
 	server := &http.Server{
-		Addr:    viper.GetString("server.address"),
+		Addr:    cfg.Server.Address,
 		Handler: mux,
 	}
 
@@ -98,5 +247,13 @@ func main() {
 		log.Fatalf("服务器关闭失败: %v", err)
 	}
 
+	// 停止定时任务并释放租约，其他实例可立即接管
+	stopScheduler()
+	<-schedulerDone
+
+	// 停止投递，未投递的消息留在发件箱中，下次启动继续投递
+	stopRelay()
+	<-relayDone
+
 	log.Println("服务器已关闭")
 }
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/config"
+	"github.com/vaynedu/ddd_order_example/internal/infrastructure/persistence/migration"
+	"gorm.io/gorm"
+)
+
+// migrateUsage migrate 子命令用法
+const migrateUsage = `用法: migrate <命令>
+  up        执行全部未执行的迁移
+  down [n]  回滚最近执行的 n 个迁移，默认 1 个
+  status    查看各迁移版本的状态
+  baseline <version>
+            把不超过 version 的迁移记录为已执行但不执行脚本，用于接入按旧版建表脚本建好的数据库`
+
+// runMigrate 执行 migrate 子命令，数据库连接信息与服务相同，取自 database 配置
+func runMigrate(ctx context.Context, cfg config.DatabaseConfig, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("缺少迁移命令\n%s", migrateUsage)
+	}
+
+	db, err := openDatabase(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	migrator, err := migration.New(db, cfg.Driver)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		executed, err := migrator.Up(ctx)
+		for _, m := range executed {
+			log.Printf("已执行迁移 %d_%s", m.Version, m.Name)
+		}
+		if err == nil && len(executed) == 0 {
+			log.Println("数据库已是最新版本")
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps <= 0 {
+				return fmt.Errorf("回滚数量无效: %s", args[1])
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			log.Printf("已回滚迁移 %d_%s", m.Version, m.Name)
+		}
+		return err
+	case "baseline":
+		if len(args) < 2 {
+			return fmt.Errorf("缺少基线版本号\n%s", migrateUsage)
+		}
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || version <= 0 {
+			return fmt.Errorf("基线版本号无效: %s", args[1])
+		}
+		recorded, err := migrator.Baseline(ctx, version)
+		for _, m := range recorded {
+			log.Printf("已记录迁移 %d_%s，未执行脚本", m.Version, m.Name)
+		}
+		return err
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATE\tAPPLIED_AT")
+		for _, status := range statuses {
+			appliedAt := "-"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", status.Version, status.Name, status.State, appliedAt)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("未知的迁移命令: %s\n%s", args[0], migrateUsage)
+	}
+}
+
+// migrateUp 执行全部未执行的迁移
+func migrateUp(ctx context.Context, db *gorm.DB, driver string) error {
+	migrator, err := migration.New(db, driver)
+	if err != nil {
+		return err
+	}
+	executed, err := migrator.Up(ctx)
+	if err != nil {
+		return fmt.Errorf("数据库迁移失败: %w", err)
+	}
+	for _, m := range executed {
+		log.Printf("已执行迁移 %d_%s", m.Version, m.Name)
+	}
+	return nil
+}
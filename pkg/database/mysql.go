@@ -2,11 +2,30 @@ package database
 
 import (
 	"context"
+	"fmt"
 
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
+// 支持的数据库驱动
+const (
+	DriverMySQL  = "mysql"
+	DriverSQLite = "sqlite"
+)
+
+// Open 按驱动类型打开数据库，dsn 的格式由驱动决定
+func Open(ctx context.Context, driver, dsn string) (*gorm.DB, error) {
+	switch driver {
+	case DriverMySQL:
+		return InitMySQL(ctx, dsn)
+	case DriverSQLite:
+		return InitSQLite(ctx, dsn)
+	default:
+		return nil, fmt.Errorf("不支持的数据库驱动: %s", driver)
+	}
+}
+
 // MySQLConfig MySQL配置
 type MySQLConfig struct {
 	Username string
@@ -18,7 +37,8 @@ type MySQLConfig struct {
 }
 
 func InitMySQL(ctx context.Context, dsn string) (*gorm.DB, error) {
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	// TranslateError 将唯一键冲突等方言错误转换为 gorm.ErrDuplicatedKey
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{TranslateError: true})
 	if err != nil {
 		return nil, err
 	}
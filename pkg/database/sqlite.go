@@ -0,0 +1,21 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// SQLiteDSN 根据数据库文件路径生成连接串
+// 等待写锁最多5秒；WAL 模式下读写互不阻塞；事务开始即获取写锁，避免两个读事务同时升级为写事务时死锁
+func SQLiteDSN(path string) string {
+	return fmt.Sprintf("file:%s?_busy_timeout=5000&_journal_mode=WAL&_txlock=immediate", path)
+}
+
+// InitSQLite 打开SQLite数据库，dsn 可由 SQLiteDSN 生成
+func InitSQLite(ctx context.Context, dsn string) (*gorm.DB, error) {
+	// TranslateError 将唯一约束冲突转换为 gorm.ErrDuplicatedKey，与MySQL行为一致
+	return gorm.Open(sqlite.Open(dsn), &gorm.Config{TranslateError: true})
+}
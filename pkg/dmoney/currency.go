@@ -0,0 +1,61 @@
+package dmoney
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Currency ISO 4217 币种代码
+type Currency string
+
+const (
+	CNY Currency = "CNY" // 人民币
+	USD Currency = "USD" // 美元
+	EUR Currency = "EUR" // 欧元
+	GBP Currency = "GBP" // 英镑
+	HKD Currency = "HKD" // 港币
+	JPY Currency = "JPY" // 日元，无辅币
+	KRW Currency = "KRW" // 韩元，无辅币
+	KWD Currency = "KWD" // 科威特第纳尔，辅币为千分之一
+	BHD Currency = "BHD" // 巴林第纳尔，辅币为千分之一
+)
+
+// DefaultCurrency 未指定币种时使用人民币
+const DefaultCurrency = CNY
+
+// currencyExponents 各币种最小单位的小数位数
+var currencyExponents = map[Currency]int{
+	CNY: 2,
+	USD: 2,
+	EUR: 2,
+	GBP: 2,
+	HKD: 2,
+	JPY: 0,
+	KRW: 0,
+	KWD: 3,
+	BHD: 3,
+}
+
+// IsValid 是否为支持的币种
+func (c Currency) IsValid() bool {
+	_, ok := currencyExponents[c]
+	return ok
+}
+
+// Exponent 币种最小单位的小数位数，如人民币为2(分)，日元为0
+// 不支持的币种按2位处理，调用方应先通过 IsValid 校验
+func (c Currency) Exponent() int {
+	if exp, ok := currencyExponents[c]; ok {
+		return exp
+	}
+	return 2
+}
+
+// ParseCurrency 解析币种代码，忽略大小写，不支持的币种返回 ErrUnknownCurrency
+func ParseCurrency(s string) (Currency, error) {
+	c := Currency(strings.ToUpper(strings.TrimSpace(s)))
+	if !c.IsValid() {
+		return "", fmt.Errorf("%w: %q", ErrUnknownCurrency, s)
+	}
+	return c, nil
+}
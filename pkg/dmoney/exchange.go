@@ -0,0 +1,97 @@
+package dmoney
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+var ErrInvalidExchangeRate = errors.New("汇率无效")
+
+// ExchangeRate 汇率，表示 1 单位 From 币种可兑换 Rate 单位 To 币种
+// 汇率以十进制字符串精确保存，换算时使用有理数计算，只在最后一步舍入到目标币种最小单位
+type ExchangeRate struct {
+	from Currency
+	to   Currency
+	text string
+	rate *big.Rat
+}
+
+// ParseExchangeRate 解析十进制汇率字符串(如 "7.1234")，汇率必须为正数
+func ParseExchangeRate(from, to Currency, s string) (ExchangeRate, error) {
+	if !from.IsValid() {
+		return ExchangeRate{}, fmt.Errorf("%w: %s", ErrUnknownCurrency, from)
+	}
+	if !to.IsValid() {
+		return ExchangeRate{}, fmt.Errorf("%w: %s", ErrUnknownCurrency, to)
+	}
+
+	// big.Rat 还接受分数和科学计数法，这里只允许普通十进制小数
+	intPart, fracPart, hasPoint := strings.Cut(s, ".")
+	if intPart == "" || (hasPoint && fracPart == "") || !isDigits(intPart) || !isDigits(fracPart) {
+		return ExchangeRate{}, fmt.Errorf("%w: %q", ErrInvalidExchangeRate, s)
+	}
+	rate, ok := new(big.Rat).SetString(s)
+	if !ok || rate.Sign() <= 0 {
+		return ExchangeRate{}, fmt.Errorf("%w: %q", ErrInvalidExchangeRate, s)
+	}
+	return ExchangeRate{from: from, to: to, text: s, rate: rate}, nil
+}
+
+// From 源币种
+func (r ExchangeRate) From() Currency {
+	return r.from
+}
+
+// To 目标币种
+func (r ExchangeRate) To() Currency {
+	return r.to
+}
+
+// String 汇率的十进制字符串，与解析时的输入一致
+func (r ExchangeRate) String() string {
+	return r.text
+}
+
+// Convert 将 From 币种的金额换算为 To 币种，按目标币种最小单位四舍五入(0.5 远离零)
+func (r ExchangeRate) Convert(m Money) (Money, error) {
+	if r.rate == nil {
+		return Money{}, ErrInvalidExchangeRate
+	}
+	if m.currency != r.from {
+		return Money{}, fmt.Errorf("%w: %s, %s", ErrCurrencyMismatch, m.currency, r.from)
+	}
+
+	// 目标最小单位 = 源最小单位 × 汇率 × 10^(目标精度-源精度)
+	num := new(big.Int).Mul(big.NewInt(m.amount), r.rate.Num())
+	num.Mul(num, pow10(r.to.Exponent()))
+	den := new(big.Int).Mul(r.rate.Denom(), pow10(r.from.Exponent()))
+
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Sign() != 0 {
+		// 余数的两倍不小于除数时向远离零的方向进一
+		twice := new(big.Int).Abs(rem)
+		twice.Lsh(twice, 1)
+		if twice.Cmp(den) >= 0 {
+			quo.Add(quo, big.NewInt(int64(num.Sign())))
+		}
+	}
+	if !quo.IsInt64() {
+		return Money{}, ErrAmountOverflow
+	}
+	return Money{amount: quo.Int64(), currency: r.to}, nil
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -1,8 +1,13 @@
 package dmoney
 
-import "strconv"
+import (
+	"math"
+	"strconv"
+)
 
 // ConvertStringFloat64ToCent 将string的float数字 * 100， 返回int64
+//
+// Deprecated: 浮点运算会截断精度(如 "19.99" 得到 1998)，使用 Parse 解析为 Money
 func ConvertStringFloat64ToCent(s string) (int64, error) {
 	// 1. 转换为float64
 	f, err := strconv.ParseFloat(s, 64)
@@ -18,16 +23,34 @@ func ConvertStringFloat64ToCent(s string) (int64, error) {
 }
 
 // ConvertCentToStringFloat64 将int64的分数字转换为float64的元数字
+//
+// Deprecated: 使用 Money.String
 func ConvertCentToStringFloat64(cents int64) string {
 	return strconv.FormatFloat(float64(cents) / 100, 'f', 2, 64)
 }
 
 // ConvertCentToFloat64 将int64的分数字转换为float64的元数字
+//
+// Deprecated: 使用 Money.String 输出十进制字符串
 func ConvertCentToFloat64(cents int64) float64 {
 	return float64(cents) / 100
 }
 
 // ConvertFloat64ToCent 将float64的元数字转换为int64的分数字
+//
+// Deprecated: 浮点运算会截断精度(如 19.99 得到 1998)，使用 Parse 解析为 Money
 func ConvertFloat64ToCent(f float64) int64 {
 	return int64(f * 100)
 }
+
+// ConvertStringYuanToCent 将string的元金额转换为分，按分四舍五入
+//
+// Deprecated: 使用 Parse 精确解析，超出精度时报错而不是舍入
+// 用于解析支付渠道回传的金额(如 "0.29")，避免浮点误差截断少算一分钱
+func ConvertStringYuanToCent(s string) (int64, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	return int64(math.Round(f * 100)), nil
+}
@@ -85,4 +85,19 @@ func TestConvertFloat64ToCent(t *testing.T) {
 			convey.So(result, convey.ShouldEqual, expected)
 		})
 	})
-}
\ No newline at end of file
+}
+
+func TestConvertStringYuanToCent(t *testing.T) {
+	convey.Convey("Test ConvertStringYuanToCent function", t, func() {
+		convey.Convey("When input has float precision error", func() {
+			result, err := ConvertStringYuanToCent("0.29")
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(result, convey.ShouldEqual, int64(29))
+		})
+
+		convey.Convey("When input is invalid float string", func() {
+			_, err := ConvertStringYuanToCent("invalid")
+			convey.So(err, convey.ShouldNotBeNil)
+		})
+	})
+}
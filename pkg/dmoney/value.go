@@ -0,0 +1,295 @@
+package dmoney
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+var (
+	ErrUnknownCurrency  = errors.New("不支持的币种")
+	ErrCurrencyMismatch = errors.New("币种不一致")
+	ErrAmountOverflow   = errors.New("金额溢出")
+	ErrInvalidAmount    = errors.New("金额格式错误")
+	ErrInvalidRatios    = errors.New("分摊比例无效")
+)
+
+// Money 金额值对象，以币种最小单位(如人民币的分)的整数表示，避免浮点误差
+// 不可变，所有运算返回新值；不同币种之间不能直接运算
+type Money struct {
+	amount   int64
+	currency Currency
+}
+
+// New 以最小单位金额创建 Money
+func New(amount int64, currency Currency) Money {
+	return Money{amount: amount, currency: currency}
+}
+
+// Zero 指定币种的零金额
+func Zero(currency Currency) Money {
+	return Money{currency: currency}
+}
+
+// Parse 解析十进制金额字符串(如 "19.99"、"-0.5")，按字符串逐位解析，结果精确
+// 小数位数超过币种最小单位时返回 ErrInvalidAmount，不做舍入
+func Parse(s string, currency Currency) (Money, error) {
+	if !currency.IsValid() {
+		return Money{}, fmt.Errorf("%w: %s", ErrUnknownCurrency, currency)
+	}
+
+	raw := s
+	negative := false
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		negative = s[0] == '-'
+		s = s[1:]
+	}
+	intPart, fracPart, hasPoint := strings.Cut(s, ".")
+	if intPart == "" || (hasPoint && fracPart == "") {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, raw)
+	}
+	exp := currency.Exponent()
+	if len(fracPart) > exp {
+		return Money{}, fmt.Errorf("%w: %q 超过%s的最小单位精度", ErrInvalidAmount, raw, currency)
+	}
+
+	// 小数部分右侧补零到币种精度后与整数部分拼接，逐位累加并检查溢出
+	digits := intPart + fracPart + strings.Repeat("0", exp-len(fracPart))
+	var amount int64
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, raw)
+		}
+		d := int64(c - '0')
+		if amount > (math.MaxInt64-d)/10 {
+			return Money{}, fmt.Errorf("%w: %q", ErrAmountOverflow, raw)
+		}
+		amount = amount*10 + d
+	}
+	if negative {
+		amount = -amount
+	}
+	return Money{amount: amount, currency: currency}, nil
+}
+
+// Amount 最小单位金额
+func (m Money) Amount() int64 {
+	return m.amount
+}
+
+// Currency 币种
+func (m Money) Currency() Currency {
+	return m.currency
+}
+
+// String 按币种精度格式化为十进制字符串，如 1999 分格式化为 "19.99"
+func (m Money) String() string {
+	exp := m.currency.Exponent()
+	// 先转为无符号数，避免 math.MinInt64 取反溢出
+	abs := uint64(m.amount)
+	if m.amount < 0 {
+		abs = -abs
+	}
+	digits := fmt.Sprintf("%0*d", exp+1, abs)
+
+	var b strings.Builder
+	if m.amount < 0 {
+		b.WriteByte('-')
+	}
+	b.WriteString(digits[:len(digits)-exp])
+	if exp > 0 {
+		b.WriteByte('.')
+		b.WriteString(digits[len(digits)-exp:])
+	}
+	return b.String()
+}
+
+// IsZero 是否为零
+func (m Money) IsZero() bool {
+	return m.amount == 0
+}
+
+// IsNegative 是否为负数
+func (m Money) IsNegative() bool {
+	return m.amount < 0
+}
+
+// IsPositive 是否为正数
+func (m Money) IsPositive() bool {
+	return m.amount > 0
+}
+
+// Equal 金额和币种都相同
+func (m Money) Equal(other Money) bool {
+	return m == other
+}
+
+// Cmp 比较大小，m 小于、等于、大于 other 时分别返回 -1、0、1
+func (m Money) Cmp(other Money) (int, error) {
+	if err := m.assertSameCurrency(other); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.amount < other.amount:
+		return -1, nil
+	case m.amount > other.amount:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+// Add 加法，币种不一致或溢出时返回错误
+func (m Money) Add(other Money) (Money, error) {
+	if err := m.assertSameCurrency(other); err != nil {
+		return Money{}, err
+	}
+	if (other.amount > 0 && m.amount > math.MaxInt64-other.amount) ||
+		(other.amount < 0 && m.amount < math.MinInt64-other.amount) {
+		return Money{}, ErrAmountOverflow
+	}
+	return Money{amount: m.amount + other.amount, currency: m.currency}, nil
+}
+
+// Sub 减法，币种不一致或溢出时返回错误
+func (m Money) Sub(other Money) (Money, error) {
+	if err := m.assertSameCurrency(other); err != nil {
+		return Money{}, err
+	}
+	if (other.amount < 0 && m.amount > math.MaxInt64+other.amount) ||
+		(other.amount > 0 && m.amount < math.MinInt64+other.amount) {
+		return Money{}, ErrAmountOverflow
+	}
+	return Money{amount: m.amount - other.amount, currency: m.currency}, nil
+}
+
+// Mul 乘以整数(如商品数量)，溢出时返回错误
+func (m Money) Mul(n int64) (Money, error) {
+	if m.amount == 0 || n == 0 {
+		return Money{currency: m.currency}, nil
+	}
+	product := m.amount * n
+	if product/n != m.amount || (n == -1 && m.amount == math.MinInt64) {
+		return Money{}, ErrAmountOverflow
+	}
+	return Money{amount: product, currency: m.currency}, nil
+}
+
+// Neg 取相反数
+func (m Money) Neg() (Money, error) {
+	if m.amount == math.MinInt64 {
+		return Money{}, ErrAmountOverflow
+	}
+	return Money{amount: -m.amount, currency: m.currency}, nil
+}
+
+// Allocate 按比例分摊金额，各份之和严格等于原金额
+// 按比例向下取整后，剩余的最小单位从第一份开始逐份补1，如 100 按 1:1:1 分摊为 34、33、33
+func (m Money) Allocate(ratios ...int64) ([]Money, error) {
+	if len(ratios) == 0 {
+		return nil, ErrInvalidRatios
+	}
+	var total int64
+	for _, r := range ratios {
+		if r < 0 || total > math.MaxInt64-r {
+			return nil, ErrInvalidRatios
+		}
+		total += r
+	}
+	if total == 0 {
+		return nil, ErrInvalidRatios
+	}
+
+	// 金额乘以比例可能超出 int64，使用大整数计算
+	amount := big.NewInt(m.amount)
+	negative := m.amount < 0
+	if negative {
+		amount.Neg(amount)
+	}
+	bigTotal := big.NewInt(total)
+	shares := make([]int64, len(ratios))
+	remainder := new(big.Int).Set(amount)
+	for i, r := range ratios {
+		share := new(big.Int).Mul(amount, big.NewInt(r))
+		share.Quo(share, bigTotal)
+		shares[i] = share.Int64()
+		remainder.Sub(remainder, share)
+	}
+	// 余数小于份数，逐份补1；比例为0的份不参与
+	left := remainder.Int64()
+	for i := 0; left > 0; i = (i + 1) % len(shares) {
+		if ratios[i] == 0 {
+			continue
+		}
+		shares[i]++
+		left--
+	}
+
+	result := make([]Money, len(shares))
+	for i, share := range shares {
+		if negative {
+			share = -share
+		}
+		result[i] = Money{amount: share, currency: m.currency}
+	}
+	return result, nil
+}
+
+// Split 平均拆分为 n 份，不能整除的最小单位从第一份开始补齐
+func (m Money) Split(n int) ([]Money, error) {
+	if n <= 0 {
+		return nil, ErrInvalidRatios
+	}
+	ratios := make([]int64, n)
+	for i := range ratios {
+		ratios[i] = 1
+	}
+	return m.Allocate(ratios...)
+}
+
+// Sum 计算同币种金额之和，列表为空时返回该币种的零金额
+func Sum(currency Currency, items ...Money) (Money, error) {
+	total := Zero(currency)
+	for _, item := range items {
+		var err error
+		if total, err = total.Add(item); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
+
+func (m Money) assertSameCurrency(other Money) error {
+	if m.currency != other.currency {
+		return fmt.Errorf("%w: %s, %s", ErrCurrencyMismatch, m.currency, other.currency)
+	}
+	return nil
+}
+
+// moneyJSON JSON序列化格式，金额为十进制字符串，避免客户端按浮点数解析丢失精度
+type moneyJSON struct {
+	Amount   string   `json:"amount"`
+	Currency Currency `json:"currency"`
+}
+
+// MarshalJSON 序列化为 {"amount":"19.99","currency":"CNY"}
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.String(), Currency: m.currency})
+}
+
+// UnmarshalJSON 从 {"amount":"19.99","currency":"CNY"} 反序列化，金额精度不能超过币种最小单位
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var v moneyJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	parsed, err := Parse(v.Amount, v.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
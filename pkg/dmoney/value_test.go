@@ -0,0 +1,242 @@
+package dmoney
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestParse(t *testing.T) {
+	convey.Convey("Test Parse function", t, func() {
+		convey.Convey("When input cannot be represented exactly as float", func() {
+			m, err := Parse("19.99", CNY)
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(m.Amount(), convey.ShouldEqual, int64(1999))
+		})
+
+		convey.Convey("When input has fewer decimals than currency exponent", func() {
+			m, err := Parse("-0.5", CNY)
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(m.Amount(), convey.ShouldEqual, int64(-50))
+
+			m, err = Parse("12", KWD)
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(m.Amount(), convey.ShouldEqual, int64(12000))
+		})
+
+		convey.Convey("When input has more decimals than currency exponent", func() {
+			_, err := Parse("10.123", CNY)
+			convey.So(errors.Is(err, ErrInvalidAmount), convey.ShouldBeTrue)
+
+			_, err = Parse("1.5", JPY)
+			convey.So(errors.Is(err, ErrInvalidAmount), convey.ShouldBeTrue)
+		})
+
+		convey.Convey("When input is malformed", func() {
+			for _, input := range []string{"", "-", ".5", "1.", "1e3", "1,000.00", "abc"} {
+				_, err := Parse(input, CNY)
+				convey.So(errors.Is(err, ErrInvalidAmount), convey.ShouldBeTrue)
+			}
+		})
+
+		convey.Convey("When input overflows int64", func() {
+			_, err := Parse("92233720368547758.08", CNY)
+			convey.So(errors.Is(err, ErrAmountOverflow), convey.ShouldBeTrue)
+		})
+
+		convey.Convey("When currency is unknown", func() {
+			_, err := Parse("1.00", Currency("XXX"))
+			convey.So(errors.Is(err, ErrUnknownCurrency), convey.ShouldBeTrue)
+		})
+	})
+}
+
+func TestMoney_String(t *testing.T) {
+	convey.Convey("Test Money.String function", t, func() {
+		convey.So(New(1999, CNY).String(), convey.ShouldEqual, "19.99")
+		convey.So(New(5, CNY).String(), convey.ShouldEqual, "0.05")
+		convey.So(New(-5, CNY).String(), convey.ShouldEqual, "-0.05")
+		convey.So(New(1500, JPY).String(), convey.ShouldEqual, "1500")
+		convey.So(New(1234, KWD).String(), convey.ShouldEqual, "1.234")
+		convey.So(New(math.MinInt64, CNY).String(), convey.ShouldEqual, "-92233720368547758.08")
+	})
+}
+
+func TestMoney_Arithmetic(t *testing.T) {
+	convey.Convey("Test Money arithmetic", t, func() {
+		convey.Convey("When currencies are the same", func() {
+			sum, err := New(1999, CNY).Add(New(1, CNY))
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(sum, convey.ShouldResemble, New(2000, CNY))
+
+			diff, err := New(1000, CNY).Sub(New(1999, CNY))
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(diff, convey.ShouldResemble, New(-999, CNY))
+
+			product, err := New(1999, CNY).Mul(3)
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(product, convey.ShouldResemble, New(5997, CNY))
+
+			cmp, err := New(1, CNY).Cmp(New(2, CNY))
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(cmp, convey.ShouldEqual, -1)
+		})
+
+		convey.Convey("When currencies differ", func() {
+			_, err := New(100, CNY).Add(New(100, USD))
+			convey.So(errors.Is(err, ErrCurrencyMismatch), convey.ShouldBeTrue)
+
+			_, err = New(100, CNY).Cmp(New(100, USD))
+			convey.So(errors.Is(err, ErrCurrencyMismatch), convey.ShouldBeTrue)
+		})
+
+		convey.Convey("When result overflows", func() {
+			_, err := New(math.MaxInt64, CNY).Add(New(1, CNY))
+			convey.So(errors.Is(err, ErrAmountOverflow), convey.ShouldBeTrue)
+
+			_, err = New(math.MinInt64, CNY).Sub(New(1, CNY))
+			convey.So(errors.Is(err, ErrAmountOverflow), convey.ShouldBeTrue)
+
+			_, err = New(math.MaxInt64/2+1, CNY).Mul(2)
+			convey.So(errors.Is(err, ErrAmountOverflow), convey.ShouldBeTrue)
+
+			_, err = New(math.MinInt64, CNY).Mul(-1)
+			convey.So(errors.Is(err, ErrAmountOverflow), convey.ShouldBeTrue)
+
+			_, err = New(math.MinInt64, CNY).Neg()
+			convey.So(errors.Is(err, ErrAmountOverflow), convey.ShouldBeTrue)
+		})
+	})
+}
+
+func TestMoney_Allocate(t *testing.T) {
+	convey.Convey("Test Money.Allocate function", t, func() {
+		convey.Convey("When amount cannot be divided evenly", func() {
+			parts, err := New(100, CNY).Split(3)
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(parts, convey.ShouldResemble, []Money{New(34, CNY), New(33, CNY), New(33, CNY)})
+		})
+
+		convey.Convey("When allocating by ratios", func() {
+			parts, err := New(1001, CNY).Allocate(70, 20, 10)
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(parts, convey.ShouldResemble, []Money{New(701, CNY), New(200, CNY), New(100, CNY)})
+		})
+
+		convey.Convey("When amount is negative or a ratio is zero", func() {
+			parts, err := New(-5, CNY).Allocate(0, 1, 1)
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(parts, convey.ShouldResemble, []Money{New(0, CNY), New(-3, CNY), New(-2, CNY)})
+		})
+
+		convey.Convey("When product of amount and ratio exceeds int64", func() {
+			parts, err := New(math.MaxInt64, CNY).Allocate(math.MaxInt64-1, 1)
+			convey.So(err, convey.ShouldBeNil)
+			sum, err := Sum(CNY, parts...)
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(sum, convey.ShouldResemble, New(math.MaxInt64, CNY))
+		})
+
+		convey.Convey("When ratios are invalid", func() {
+			_, err := New(100, CNY).Allocate()
+			convey.So(errors.Is(err, ErrInvalidRatios), convey.ShouldBeTrue)
+
+			_, err = New(100, CNY).Allocate(0, 0)
+			convey.So(errors.Is(err, ErrInvalidRatios), convey.ShouldBeTrue)
+
+			_, err = New(100, CNY).Allocate(1, -1)
+			convey.So(errors.Is(err, ErrInvalidRatios), convey.ShouldBeTrue)
+		})
+	})
+}
+
+func TestMoney_JSON(t *testing.T) {
+	convey.Convey("Test Money JSON marshalling", t, func() {
+		convey.Convey("When marshalling", func() {
+			body, err := json.Marshal(New(1999, CNY))
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(string(body), convey.ShouldEqual, `{"amount":"19.99","currency":"CNY"}`)
+		})
+
+		convey.Convey("When unmarshalling", func() {
+			var m Money
+			err := json.Unmarshal([]byte(`{"amount":"0.29","currency":"CNY"}`), &m)
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(m, convey.ShouldResemble, New(29, CNY))
+		})
+
+		convey.Convey("When unmarshalling an amount beyond currency precision", func() {
+			var m Money
+			err := json.Unmarshal([]byte(`{"amount":"1.5","currency":"JPY"}`), &m)
+			convey.So(errors.Is(err, ErrInvalidAmount), convey.ShouldBeTrue)
+		})
+	})
+}
+
+func TestExchangeRate_Convert(t *testing.T) {
+	convey.Convey("Test ExchangeRate.Convert function", t, func() {
+		convey.Convey("When converting between currencies with the same exponent", func() {
+			rate, err := ParseExchangeRate(USD, CNY, "7.1234")
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(rate.String(), convey.ShouldEqual, "7.1234")
+
+			// 19.99 * 7.1234 = 142.396766，四舍五入为 142.40
+			converted, err := rate.Convert(New(1999, USD))
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(converted, convey.ShouldResemble, New(14240, CNY))
+		})
+
+		convey.Convey("When exponents differ", func() {
+			rate, err := ParseExchangeRate(JPY, CNY, "0.0483")
+			convey.So(err, convey.ShouldBeNil)
+			// 1500 * 0.0483 = 72.45
+			converted, err := rate.Convert(New(1500, JPY))
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(converted, convey.ShouldResemble, New(7245, CNY))
+
+			rate, err = ParseExchangeRate(CNY, JPY, "20.705")
+			convey.So(err, convey.ShouldBeNil)
+			// 0.10 * 20.705 = 2.0705，四舍五入为 2
+			converted, err = rate.Convert(New(10, CNY))
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(converted, convey.ShouldResemble, New(2, JPY))
+		})
+
+		convey.Convey("When rounding a half unit", func() {
+			rate, err := ParseExchangeRate(USD, CNY, "0.5")
+			convey.So(err, convey.ShouldBeNil)
+			converted, err := rate.Convert(New(1, USD))
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(converted, convey.ShouldResemble, New(1, CNY))
+
+			converted, err = rate.Convert(New(-1, USD))
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(converted, convey.ShouldResemble, New(-1, CNY))
+		})
+
+		convey.Convey("When source currency does not match", func() {
+			rate, _ := ParseExchangeRate(USD, CNY, "7.1")
+			_, err := rate.Convert(New(100, EUR))
+			convey.So(errors.Is(err, ErrCurrencyMismatch), convey.ShouldBeTrue)
+		})
+
+		convey.Convey("When result overflows", func() {
+			rate, _ := ParseExchangeRate(USD, CNY, "7.1")
+			_, err := rate.Convert(New(math.MaxInt64, USD))
+			convey.So(errors.Is(err, ErrAmountOverflow), convey.ShouldBeTrue)
+		})
+
+		convey.Convey("When rate is invalid", func() {
+			for _, input := range []string{"", "0", "0.000", "-7.1", "7.", ".5", "1/3", "1e3", "abc"} {
+				_, err := ParseExchangeRate(USD, CNY, input)
+				convey.So(errors.Is(err, ErrInvalidExchangeRate), convey.ShouldBeTrue)
+			}
+
+			_, err := ParseExchangeRate(USD, Currency("XXX"), "1")
+			convey.So(errors.Is(err, ErrUnknownCurrency), convey.ShouldBeTrue)
+		})
+	})
+}